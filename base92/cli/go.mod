@@ -1,10 +1,16 @@
 module github.com/presbrey/pkg/base92/cli
 
-go 1.21
+go 1.24.1
 
-require github.com/spf13/cobra v1.8.0
+require (
+	github.com/presbrey/pkg v0.0.0
+	github.com/spf13/cobra v1.8.0
+)
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 )
+
+// The CLI wraps the streaming API of the base92 package in this repository
+replace github.com/presbrey/pkg => ../..
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+// Integrity checksums appended to encoded output, one per line after the data
+const (
+	checksumCRC32  = "crc32"
+	checksumSHA256 = "sha256"
+)
+
+// trailerWindow is the number of bytes held back while decoding so trailing
+// checksum lines can be removed before they reach the decoder
+const trailerWindow = 256
+
+// checksums hashes data for the selected integrity checks
+type checksums struct {
+	names  []string
+	hashes map[string]hash.Hash
+}
+
+// newChecksums creates hashes for the selected integrity checks
+func newChecksums(useCRC32, useSHA256 bool) *checksums {
+	c := &checksums{hashes: make(map[string]hash.Hash)}
+	if useCRC32 {
+		c.names = append(c.names, checksumCRC32)
+		c.hashes[checksumCRC32] = crc32.NewIEEE()
+	}
+	if useSHA256 {
+		c.names = append(c.names, checksumSHA256)
+		c.hashes[checksumSHA256] = sha256.New()
+	}
+	return c
+}
+
+// writer returns a writer that feeds every selected hash
+func (c *checksums) writer() io.Writer {
+	writers := make([]io.Writer, 0, len(c.names))
+	for _, name := range c.names {
+		writers = append(writers, c.hashes[name])
+	}
+	return io.MultiWriter(writers...)
+}
+
+// sum returns the hex digest of a selected hash
+func (c *checksums) sum(name string) string {
+	return hex.EncodeToString(c.hashes[name].Sum(nil))
+}
+
+// trailer returns the checksum lines appended after the encoded data
+func (c *checksums) trailer() string {
+	var b strings.Builder
+	for _, name := range c.names {
+		fmt.Fprintf(&b, "%s:%s\n", name, c.sum(name))
+	}
+	return b.String()
+}
+
+// verify compares the hashes of the decoded data with the checksums found in
+// the input. Checksums that were found are always verified; required ones must
+// be present.
+func (c *checksums) verify(found map[string]string, required []string) error {
+	for _, name := range required {
+		if _, ok := found[name]; !ok {
+			return fmt.Errorf("integrity check failed: no %s checksum in input, which may be truncated", name)
+		}
+	}
+
+	for _, name := range []string{checksumCRC32, checksumSHA256} {
+		expected, ok := found[name]
+		if !ok {
+			continue
+		}
+		if actual := c.sum(name); !strings.EqualFold(expected, actual) {
+			return fmt.Errorf("integrity check failed: %s mismatch (expected %s, got %s), output is corrupt", name, expected, actual)
+		}
+	}
+	return nil
+}
+
+// trailerReader passes its input through, except for trailing checksum lines,
+// which it collects once the input ends
+type trailerReader struct {
+	r        io.Reader
+	buf      []byte
+	held     []byte
+	out      []byte
+	released bool
+	eof      bool
+	found    map[string]string
+}
+
+// newTrailerReader creates a trailerReader
+func newTrailerReader(r io.Reader) *trailerReader {
+	return &trailerReader{r: r, buf: make([]byte, 32*1024), found: make(map[string]string)}
+}
+
+// Read implements io.Reader, holding back the last trailerWindow bytes until
+// the end of the input
+func (t *trailerReader) Read(p []byte) (int, error) {
+	for len(t.out) == 0 {
+		if t.eof {
+			return 0, io.EOF
+		}
+
+		n, err := t.r.Read(t.buf)
+		t.held = append(t.held, t.buf[:n]...)
+
+		switch {
+		case err == io.EOF:
+			t.eof = true
+			t.out = t.stripTrailer()
+			t.held = nil
+		case err != nil:
+			return 0, err
+		case len(t.held) > trailerWindow:
+			release := len(t.held) - trailerWindow
+			t.out = append([]byte(nil), t.held[:release]...)
+			t.held = append(t.held[:0], t.held[release:]...)
+			t.released = true
+		}
+	}
+
+	n := copy(p, t.out)
+	t.out = t.out[n:]
+	return n, nil
+}
+
+// stripTrailer removes checksum lines from the end of the held input, records
+// them and returns the rest
+func (t *trailerReader) stripTrailer() []byte {
+	data := bytes.TrimRight(t.held, " \t\r\n")
+	for {
+		start := bytes.LastIndexByte(data, '\n') + 1
+		if start == 0 && t.released {
+			// The line began before the held bytes, so it is data
+			break
+		}
+
+		name, sum, ok := strings.Cut(strings.TrimSpace(string(data[start:])), ":")
+		if !ok || (name != checksumCRC32 && name != checksumSHA256) {
+			break
+		}
+		if _, seen := t.found[name]; !seen {
+			t.found[name] = sum
+		}
+
+		data = bytes.TrimRight(data[:start], " \t\r\n")
+	}
+	return data
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTrailerRoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("integrity "), 100)
+
+	sums := newChecksums(true, true)
+	sums.writer().Write(data)
+	input := strings.Repeat("A", 1000) + "\n" + sums.trailer()
+
+	trailer := newTrailerReader(iotest.HalfReader(strings.NewReader(input)))
+	body, err := io.ReadAll(trailer)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(body) != strings.Repeat("A", 1000) {
+		t.Errorf("trailer lines were not removed: %q", body[len(body)-20:])
+	}
+
+	decoded := newChecksums(true, true)
+	decoded.writer().Write(data)
+	if err := decoded.verify(trailer.found, []string{checksumCRC32, checksumSHA256}); err != nil {
+		t.Errorf("verify failed: %v", err)
+	}
+
+	corrupted := newChecksums(true, true)
+	corrupted.writer().Write(data[1:])
+	if err := corrupted.verify(trailer.found, nil); err == nil {
+		t.Errorf("verify of corrupted data succeeded")
+	}
+}
+
+func TestTrailerMissing(t *testing.T) {
+	trailer := newTrailerReader(strings.NewReader("ABCD\n"))
+	body, _ := io.ReadAll(trailer)
+	if string(body) != "ABCD" {
+		t.Errorf("body = %q, want %q", body, "ABCD")
+	}
+
+	sums := newChecksums(true, false)
+	if err := sums.verify(trailer.found, nil); err != nil {
+		t.Errorf("verify without required checksums failed: %v", err)
+	}
+	if err := sums.verify(trailer.found, []string{checksumCRC32}); err == nil {
+		t.Errorf("verify succeeded without a required checksum")
+	}
+}
@@ -0,0 +1,395 @@
+package base92
+
+import (
+	"hash/crc32"
+	"strconv"
+)
+
+// denseCharset holds the 92 printable ASCII characters other than space, '"'
+// and '\', so dense output can be embedded in quoted strings unescaped
+const denseCharset = "!#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+
+// Padding characters for WithPadding
+const (
+	StdPadding rune = '.' // URL-safe and outside StdEncoding's alphabet
+	NoPadding  rune = -1  // No padding
+)
+
+// Encoding is a Base92 encoding scheme, defined by its alphabet and how bits
+// are grouped into characters. Encodings are safe for concurrent use.
+type Encoding struct {
+	alphabet  string
+	decodeMap [256]int16
+	dense     bool
+	padChar   rune
+	checksum  bool
+}
+
+// StdEncoding is the URL-safe encoding used by Encode and Decode. It stores 6
+// bits per character, so its output is 4/3 the size of its input.
+var StdEncoding = newEncoding(charset[:64], false)
+
+// DenseEncoding is a true base-92 encoding that stores 13 bits in every pair of
+// characters, so its output is 16/13 the size of its input. Its alphabet is
+// not URL-safe.
+var DenseEncoding = newEncoding(denseCharset, true)
+
+// NewEncoding returns an encoding defined by the given alphabet, which must
+// hold 64 or 92 unique ASCII characters other than whitespace. A 64 character
+// alphabet stores 6 bits per character, like StdEncoding, and a 92 character
+// alphabet 13 bits per pair of characters, like DenseEncoding. The resulting
+// encoding is unpadded and has no checksum. NewEncoding panics if the alphabet
+// is invalid.
+func NewEncoding(alphabet string) *Encoding {
+	if len(alphabet) != 64 && len(alphabet) != 92 {
+		panic("base92: encoding alphabet must be 64 or 92 characters long, not " + strconv.Itoa(len(alphabet)))
+	}
+
+	seen := make(map[byte]bool, len(alphabet))
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if c >= 0x80 || isWhitespace(c) {
+			panic("base92: encoding alphabet contains invalid character " + strconv.QuoteRune(rune(c)))
+		}
+		if seen[c] {
+			panic("base92: encoding alphabet contains duplicate character " + strconv.QuoteRune(rune(c)))
+		}
+		seen[c] = true
+	}
+
+	return newEncoding(alphabet, len(alphabet) == 92)
+}
+
+// newEncoding creates an encoding. Sparse encodings use 64 characters and dense
+// encodings at least 91, as 91*91 is the smallest square above 2^13.
+func newEncoding(alphabet string, dense bool) *Encoding {
+	e := &Encoding{alphabet: alphabet, dense: dense, padChar: NoPadding}
+	for i := range e.decodeMap {
+		e.decodeMap[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.decodeMap[alphabet[i]] = int16(i)
+	}
+	return e
+}
+
+// WithPadding creates a new encoding identical to e except padded with the
+// given character, or unpadded if padding is NoPadding. Padded output is a
+// whole number of blocks: 4 characters for 64 character alphabets and 16 for
+// 92 character alphabets. The padding character must be printable ASCII and
+// not in the alphabet.
+func (e Encoding) WithPadding(padding rune) *Encoding {
+	if padding != NoPadding {
+		if padding <= ' ' || padding >= 0x7F {
+			panic("base92: invalid padding character " + strconv.QuoteRune(padding))
+		}
+		if e.decodeMap[padding] >= 0 {
+			panic("base92: padding character " + strconv.QuoteRune(padding) + " is in the alphabet")
+		}
+	}
+	e.padChar = padding
+	return &e
+}
+
+// WithChecksum creates a new encoding identical to e except that a check
+// character, derived from the CRC-32 of the data, follows the encoded data.
+// Decoding fails with ErrChecksum when it doesn't match, catching most
+// corruption and truncation.
+func (e Encoding) WithChecksum() *Encoding {
+	e.checksum = true
+	return &e
+}
+
+// blockSize returns the number of characters padded output is a multiple of
+func (e *Encoding) blockSize() int {
+	if e.dense {
+		return 16
+	}
+	return 4
+}
+
+// checkChar returns the check character for data with the given CRC-32
+func (e *Encoding) checkChar(crc uint32) byte {
+	return e.alphabet[crc%uint32(len(e.alphabet))]
+}
+
+// EncodedLen returns the length in characters of the encoding of n bytes,
+// including any check character and padding
+func (e *Encoding) EncodedLen(n int) int {
+	if n == 0 {
+		return 0
+	}
+
+	chars := e.dataLen(n)
+	if e.checksum {
+		chars++
+	}
+	if e.padChar != NoPadding {
+		block := e.blockSize()
+		chars = (chars + block - 1) / block * block
+	}
+	return chars
+}
+
+// dataLen returns the number of data characters in the encoding of n bytes
+func (e *Encoding) dataLen(n int) int {
+	bits := 8 * n
+	if !e.dense {
+		return (bits + 5) / 6
+	}
+
+	chars := bits / 13 * 2
+	switch remaining := bits % 13; {
+	case remaining == 0:
+	case remaining <= 6:
+		chars++
+	default:
+		chars += 2
+	}
+	return chars
+}
+
+// DecodedLen returns the maximum length in bytes of the data decoded from n characters
+func (e *Encoding) DecodedLen(n int) int {
+	if !e.dense {
+		return n * 6 / 8
+	}
+	return (n/2*13 + n%2*6) / 8
+}
+
+// Encode encodes src, writing EncodedLen(len(src)) characters to dst
+func (e *Encoding) Encode(dst, src []byte) {
+	var enc bitEncoder
+	out := enc.write(e, dst[:0], src)
+	enc.finish(e, out)
+}
+
+// EncodeToString returns the encoding of src
+func (e *Encoding) EncodeToString(src []byte) string {
+	dst := make([]byte, e.EncodedLen(len(src)))
+	e.Encode(dst, src)
+	return string(dst)
+}
+
+// Decode decodes src into dst, returning the number of bytes written. dst must
+// hold DecodedLen(len(src)) bytes. Whitespace in src is ignored. It returns
+// ErrInvalidChar for characters outside the alphabet, ErrInvalidLength for
+// input that can't be the encoding of any data, such as truncated input, and
+// ErrChecksum when the check character doesn't match.
+func (e *Encoding) Decode(dst, src []byte) (int, error) {
+	var dec bitDecoder
+	out := dst[:0]
+	var err error
+	for _, c := range src {
+		if out, err = dec.write(e, out, c); err != nil {
+			return len(out), err
+		}
+	}
+	out, err = dec.finish(e, out)
+	return len(out), err
+}
+
+// DecodeString returns the data represented by the encoded string s
+func (e *Encoding) DecodeString(s string) ([]byte, error) {
+	dst := make([]byte, e.DecodedLen(len(s)))
+	n, err := e.Decode(dst, []byte(s))
+	return dst[:n], err
+}
+
+// bitEncoder accumulates input bits and emits characters as groups fill up
+type bitEncoder struct {
+	bits  uint64
+	nbits uint
+	crc   uint32
+	bytes int
+}
+
+// write adds bytes and appends any completed characters to out
+func (b *bitEncoder) write(e *Encoding, out []byte, p []byte) []byte {
+	if e.checksum {
+		b.crc = crc32.Update(b.crc, crc32.IEEETable, p)
+	}
+	b.bytes += len(p)
+
+	for _, c := range p {
+		b.bits = b.bits<<8 | uint64(c)
+		b.nbits += 8
+
+		if !e.dense {
+			for b.nbits >= 6 {
+				b.nbits -= 6
+				out = append(out, e.alphabet[(b.bits>>b.nbits)&0x3F])
+			}
+		} else {
+			radix := uint64(len(e.alphabet))
+			for b.nbits >= 13 {
+				b.nbits -= 13
+				v := (b.bits >> b.nbits) & 0x1FFF
+				out = append(out, e.alphabet[v/radix], e.alphabet[v%radix])
+			}
+		}
+
+		b.bits &= 1<<b.nbits - 1
+	}
+	return out
+}
+
+// finish pads the remaining bits with zeros and appends their characters to
+// out, followed by any check character and padding. Dense encodings need only
+// one character when 6 or fewer bits remain.
+func (b *bitEncoder) finish(e *Encoding, out []byte) []byte {
+	if b.bytes == 0 {
+		return out
+	}
+
+	if b.nbits > 0 {
+		if !e.dense || b.nbits <= 6 {
+			out = append(out, e.alphabet[b.bits<<(6-b.nbits)])
+		} else {
+			radix := uint64(len(e.alphabet))
+			v := b.bits << (13 - b.nbits)
+			out = append(out, e.alphabet[v/radix], e.alphabet[v%radix])
+		}
+	}
+
+	chars := e.dataLen(b.bytes)
+	if e.checksum {
+		out = append(out, e.checkChar(b.crc))
+		chars++
+	}
+	for ; chars < e.EncodedLen(b.bytes); chars++ {
+		out = append(out, byte(e.padChar))
+	}
+
+	*b = bitEncoder{}
+	return out
+}
+
+// bitDecoder accumulates character values and emits bytes as they fill up
+type bitDecoder struct {
+	bits    uint64
+	nbits   uint
+	pending int16
+	chars   int
+	bytes   int
+	crc     uint32
+	held    int16
+	holding bool
+	pads    int
+}
+
+// isWhitespace reports whether c is a whitespace character ignored by decoders
+func isWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// write adds a character and appends any completed bytes to out
+func (d *bitDecoder) write(e *Encoding, out []byte, c byte) ([]byte, error) {
+	// Ignore whitespace characters
+	if isWhitespace(c) {
+		return out, nil
+	}
+
+	// Padding may only be followed by more padding
+	if e.padChar != NoPadding && c == byte(e.padChar) {
+		d.pads++
+		return out, nil
+	}
+	v := e.decodeMap[c]
+	if v < 0 {
+		return out, ErrInvalidChar
+	}
+	if d.pads > 0 {
+		return out, ErrInvalidLength
+	}
+
+	// The last character is the check character, so hold each character back
+	// until the next one arrives
+	if e.checksum {
+		held, holding := d.held, d.holding
+		d.held, d.holding = v, true
+		if !holding {
+			return out, nil
+		}
+		v = held
+	}
+
+	return d.decode(e, out, v)
+}
+
+// decode adds the value of a data character and appends any completed bytes to out
+func (d *bitDecoder) decode(e *Encoding, out []byte, v int16) ([]byte, error) {
+	d.chars++
+
+	switch {
+	case !e.dense:
+		d.bits = d.bits<<6 | uint64(v)
+		d.nbits += 6
+	case d.chars%2 == 1:
+		// Wait for the second character of the pair
+		d.pending = v
+		return out, nil
+	default:
+		group := uint64(d.pending)*uint64(len(e.alphabet)) + uint64(v)
+		if group > 0x1FFF {
+			return out, ErrInvalidChar
+		}
+		d.bits = d.bits<<13 | group
+		d.nbits += 13
+	}
+
+	return d.flush(e, out), nil
+}
+
+// flush appends every completed byte to out
+func (d *bitDecoder) flush(e *Encoding, out []byte) []byte {
+	start := len(out)
+	for d.nbits >= 8 {
+		d.nbits -= 8
+		out = append(out, byte(d.bits>>d.nbits))
+		d.bytes++
+	}
+	d.bits &= 1<<d.nbits - 1
+
+	if e.checksum {
+		d.crc = crc32.Update(d.crc, crc32.IEEETable, out[start:])
+	}
+	return out
+}
+
+// finish decodes a trailing unpaired character and checks that the input had
+// the length of a valid encoding and a matching check character
+func (d *bitDecoder) finish(e *Encoding, out []byte) ([]byte, error) {
+	total := d.chars + d.pads
+	if d.holding {
+		total++
+	}
+	if total == 0 {
+		return out, nil
+	}
+	if e.checksum && !d.holding {
+		return out, ErrInvalidLength
+	}
+
+	if e.dense && d.chars%2 == 1 {
+		// A lone trailing character holds 6 bits, but truncated input is
+		// reported as such rather than by the value of its last character
+		if e.dataLen(d.bytes+int(d.nbits+6)/8) != d.chars {
+			return out, ErrInvalidLength
+		}
+		if d.pending > 0x3F {
+			return out, ErrInvalidChar
+		}
+		d.bits = d.bits<<6 | uint64(d.pending)
+		d.nbits += 6
+		out = d.flush(e, out)
+	}
+
+	if e.dataLen(d.bytes) != d.chars || e.EncodedLen(d.bytes) != total {
+		return out, ErrInvalidLength
+	}
+	if e.checksum && e.decodeMap[e.checkChar(d.crc)] != d.held {
+		return out, ErrChecksum
+	}
+	return out, nil
+}
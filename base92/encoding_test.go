@@ -0,0 +1,220 @@
+package base92
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncodingRoundtripAllSizes(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding, DenseEncoding} {
+		for size := 0; size <= 64; size++ {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(rand.Reader, data); err != nil {
+				t.Fatalf("Failed to generate random data: %v", err)
+			}
+
+			encoded := enc.EncodeToString(data)
+			if len(encoded) != enc.EncodedLen(size) {
+				t.Errorf("len(encoded) = %d, EncodedLen(%d) = %d", len(encoded), size, enc.EncodedLen(size))
+			}
+			if enc.DecodedLen(len(encoded)) < size {
+				t.Errorf("DecodedLen(%d) = %d, want at least %d", len(encoded), enc.DecodedLen(len(encoded)), size)
+			}
+
+			decoded, err := enc.DecodeString(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode %d bytes: %v", size, err)
+			}
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("round trip of %d bytes failed", size)
+			}
+		}
+	}
+}
+
+func TestDenseEncodingUsesFullAlphabet(t *testing.T) {
+	if len(denseCharset) != 92 {
+		t.Fatalf("dense alphabet has %d characters, want 92", len(denseCharset))
+	}
+
+	data := make([]byte, 13000)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		t.Fatalf("Failed to generate random data: %v", err)
+	}
+
+	encoded := DenseEncoding.EncodeToString(data)
+	if len(encoded) != 16000 {
+		t.Errorf("encoded 13000 bytes into %d characters, want 16000", len(encoded))
+	}
+	if strings.ContainsAny(encoded, " \"\\") {
+		t.Errorf("dense encoding contains space, quote or backslash")
+	}
+}
+
+func TestEncodingInvalidLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		enc     *Encoding
+		encoded string
+	}{
+		{"Std single character", StdEncoding, "A"},
+		{"Std truncated", StdEncoding, Encode([]byte("Hello"))[:5]},
+		{"Dense single character", DenseEncoding, "A"},
+		{"Dense truncated", DenseEncoding, DenseEncoding.EncodeToString([]byte("Hello, World!"))[:11]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.enc.DecodeString(tt.encoded)
+			if err != ErrInvalidLength {
+				t.Errorf("DecodeString(%q) error = %v, want %v", tt.encoded, err, ErrInvalidLength)
+			}
+		})
+	}
+}
+
+func TestDenseEncodingInvalidInput(t *testing.T) {
+	// "~~" is 91*92+91, beyond the 13 bits a pair holds
+	if _, err := DenseEncoding.DecodeString("~~"); err != ErrInvalidChar {
+		t.Errorf("error = %v, want %v", err, ErrInvalidChar)
+	}
+	if _, err := DenseEncoding.DecodeString("AB CD\"EF"); err != ErrInvalidChar {
+		t.Errorf("error = %v, want %v", err, ErrInvalidChar)
+	}
+}
+
+func TestDenseEncodingStream(t *testing.T) {
+	data := make([]byte, streamBufferSize*2+5)
+	if _, err := io.ReadFull(rand.Reader, data); err != nil {
+		t.Fatalf("Failed to generate random data: %v", err)
+	}
+
+	var encoded bytes.Buffer
+	enc := DenseEncoding.NewEncoder(&encoded)
+	if _, err := enc.Write(data); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if encoded.String() != DenseEncoding.EncodeToString(data) {
+		t.Errorf("stream encoding differs from EncodeToString")
+	}
+
+	decoded, err := io.ReadAll(DenseEncoding.NewDecoder(&encoded))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Errorf("stream round trip failed")
+	}
+
+	_, err = io.ReadAll(DenseEncoding.NewDecoder(strings.NewReader("A")))
+	if err != ErrInvalidLength {
+		t.Errorf("truncated stream error = %v, want %v", err, ErrInvalidLength)
+	}
+}
+
+func TestNewEncoding(t *testing.T) {
+	// A filename-safe alphabet that avoids '-', which can be mistaken for a flag
+	alphabet := "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+_"
+	enc := NewEncoding(alphabet)
+
+	data := []byte("custom alphabets round trip")
+	encoded := enc.EncodeToString(data)
+	for i := 0; i < len(encoded); i++ {
+		if !strings.ContainsRune(alphabet, rune(encoded[i])) {
+			t.Errorf("character %q is not in the alphabet", encoded[i])
+		}
+	}
+	decoded, err := enc.DecodeString(encoded)
+	if err != nil || !bytes.Equal(decoded, data) {
+		t.Errorf("DecodeString() = %q, %v, want %q", decoded, err, data)
+	}
+
+	dense := NewEncoding(denseCharset)
+	if dense.EncodeToString(data) != DenseEncoding.EncodeToString(data) {
+		t.Errorf("92 character alphabet should produce the dense encoding")
+	}
+}
+
+func TestNewEncodingPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+	}{
+		{"Too short", "0123456789"},
+		{"Duplicate", strings.Repeat("A", 64)},
+		{"Whitespace", " " + charset[1:64]},
+		{"Non-ASCII", "\xff" + charset[1:64]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewEncoding(%q) did not panic", tt.alphabet)
+				}
+			}()
+			NewEncoding(tt.alphabet)
+		})
+	}
+}
+
+func TestWithPadding(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding.WithPadding(StdPadding), NewEncoding(denseCharset[:91] + "\\").WithPadding('~')} {
+		block := enc.blockSize()
+		for size := 0; size <= 40; size++ {
+			data := bytes.Repeat([]byte{byte(size)}, size)
+			encoded := enc.EncodeToString(data)
+			if len(encoded)%block != 0 {
+				t.Errorf("encoding of %d bytes has length %d, not a multiple of %d", size, len(encoded), block)
+			}
+
+			decoded, err := enc.DecodeString(encoded)
+			if err != nil || !bytes.Equal(decoded, data) {
+				t.Errorf("round trip of %d bytes failed: %v", size, err)
+			}
+		}
+	}
+
+	enc := StdEncoding.WithPadding(StdPadding)
+	if _, err := enc.DecodeString("QQ"); err != ErrInvalidLength {
+		t.Errorf("missing padding error = %v, want %v", err, ErrInvalidLength)
+	}
+	if _, err := enc.DecodeString("QQ.A"); err != ErrInvalidLength {
+		t.Errorf("data after padding error = %v, want %v", err, ErrInvalidLength)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithPadding with a character in the alphabet did not panic")
+		}
+	}()
+	StdEncoding.WithPadding('A')
+}
+
+func TestWithChecksum(t *testing.T) {
+	for _, enc := range []*Encoding{StdEncoding.WithChecksum(), DenseEncoding.WithChecksum().WithPadding('"')} {
+		data := []byte("Hello, World!")
+		encoded := enc.EncodeToString(data)
+		if len(encoded) != enc.EncodedLen(len(data)) {
+			t.Errorf("len(encoded) = %d, EncodedLen = %d", len(encoded), enc.EncodedLen(len(data)))
+		}
+
+		decoded, err := enc.DecodeString(encoded)
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Errorf("DecodeString() = %q, %v, want %q", decoded, err, data)
+		}
+
+		// Changing the first data character must be caught by the check character
+		corrupted := []byte(encoded)
+		corrupted[0] = enc.alphabet[(enc.decodeMap[corrupted[0]]+1)%64]
+		if _, err := enc.DecodeString(string(corrupted)); err != ErrChecksum {
+			t.Errorf("corrupted input error = %v, want %v", err, ErrChecksum)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package base92
+
+import (
+	"io"
+)
+
+// streamBufferSize is the number of bytes buffered by streaming encoders and decoders
+const streamBufferSize = 4096
+
+// encoder is a streaming Base92 encoder
+type encoder struct {
+	enc  *Encoding
+	w    io.Writer
+	err  error
+	bits bitEncoder
+	out  []byte
+}
+
+// NewEncoder returns a stream encoder using StdEncoding. Data written to it is
+// encoded and written to w. Close must be called to flush any partially
+// written bits.
+func NewEncoder(w io.Writer) io.WriteCloser {
+	return StdEncoding.NewEncoder(w)
+}
+
+// NewEncoder returns a stream encoder using this encoding. Data written to it
+// is encoded and written to w. Close must be called to flush any partially
+// written bits.
+func (e *Encoding) NewEncoder(w io.Writer) io.WriteCloser {
+	return &encoder{enc: e, w: w, out: make([]byte, 0, streamBufferSize)}
+}
+
+// Write encodes p and writes the encoded characters to the underlying writer
+func (e *encoder) Write(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+
+	// Encode in chunks small enough for their output to fit in the buffer
+	for written := 0; written < len(p); {
+		chunk := p[written:]
+		if len(chunk) > streamBufferSize/4 {
+			chunk = chunk[:streamBufferSize/4]
+		}
+		e.out = e.bits.write(e.enc, e.out, chunk)
+		written += len(chunk)
+
+		if len(e.out) >= streamBufferSize/2 {
+			if err := e.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Close writes the remaining bits and buffered characters. It does not close
+// the underlying writer.
+func (e *encoder) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.out = e.bits.finish(e.enc, e.out)
+	return e.flush()
+}
+
+// flush writes the buffered characters to the underlying writer
+func (e *encoder) flush() error {
+	if len(e.out) == 0 {
+		return nil
+	}
+	_, e.err = e.w.Write(e.out)
+	e.out = e.out[:0]
+	return e.err
+}
+
+// decoder is a streaming Base92 decoder
+type decoder struct {
+	enc  *Encoding
+	r    io.Reader
+	err  error
+	bits bitDecoder
+	in   []byte
+	buf  []byte
+	out  []byte
+}
+
+// NewDecoder returns a stream decoder that reads StdEncoding data from r.
+// Whitespace in the input is ignored, as it is by Decode.
+func NewDecoder(r io.Reader) io.Reader {
+	return StdEncoding.NewDecoder(r)
+}
+
+// NewDecoder returns a stream decoder that reads data in this encoding from r.
+// Whitespace in the input is ignored, as it is by Decode.
+func (e *Encoding) NewDecoder(r io.Reader) io.Reader {
+	return &decoder{enc: e, r: r, in: make([]byte, streamBufferSize), buf: make([]byte, 0, streamBufferSize)}
+}
+
+// Read decodes data from the underlying reader into p
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		d.out = d.buf[:0]
+		n, err := d.r.Read(d.in)
+		for _, c := range d.in[:n] {
+			if d.out, d.err = d.bits.write(d.enc, d.out, c); d.err != nil {
+				break
+			}
+		}
+		if d.err == nil && err == io.EOF {
+			// Check the input ended where a valid encoding can
+			d.out, d.err = d.bits.finish(d.enc, d.out)
+		}
+		if d.err == nil {
+			d.err = err
+		}
+	}
+
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
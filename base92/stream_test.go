@@ -0,0 +1,63 @@
+package base92
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamMatchesEncode(t *testing.T) {
+	sizes := []int{0, 1, 2, 3, 4, 5, 100, streamBufferSize - 1, streamBufferSize, streamBufferSize*3 + 7}
+
+	for _, size := range sizes {
+		data := make([]byte, size)
+		if _, err := io.ReadFull(rand.Reader, data); err != nil {
+			t.Fatalf("Failed to generate random data: %v", err)
+		}
+
+		// Write one byte at a time to exercise the buffering
+		var encoded bytes.Buffer
+		enc := NewEncoder(&encoded)
+		for i := range data {
+			if _, err := enc.Write(data[i : i+1]); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		if encoded.String() != Encode(data) {
+			t.Errorf("stream encoding of %d bytes differs from Encode", size)
+		}
+
+		decoded, err := io.ReadAll(NewDecoder(iotest.OneByteReader(&encoded)))
+		if err != nil {
+			t.Fatalf("Failed to decode: %v", err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("stream round trip of %d bytes failed", size)
+		}
+	}
+}
+
+func TestDecoderIgnoresWhitespace(t *testing.T) {
+	data := []byte("Test data with spaces and newlines")
+	decoded, err := io.ReadAll(NewDecoder(strings.NewReader(insertMixedWhitespace(Encode(data)) + "\n")))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Errorf("got %q, want %q", decoded, data)
+	}
+}
+
+func TestDecoderInvalidChar(t *testing.T) {
+	_, err := io.ReadAll(NewDecoder(strings.NewReader("ABC#DEF")))
+	if err != ErrInvalidChar {
+		t.Errorf("error = %v, want %v", err, ErrInvalidChar)
+	}
+}
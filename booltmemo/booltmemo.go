@@ -3,7 +3,9 @@
 package booltmemo
 
 import (
+	"container/list"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,24 +15,48 @@ type CacheEntry struct {
 	ExpiresAt time.Time
 }
 
-// Memoizer stores the memoized function and its cache.
-type Memoizer[T any] struct {
-	fn           func(T) bool
-	cache        map[any]CacheEntry
+// Memoizer stores the memoized function and its cache, keyed by the function's
+// argument type so keys are checked at compile time and stored without boxing.
+type Memoizer[K comparable] struct {
+	fn           func(K) (bool, error)
+	cache        map[K]CacheEntry
+	inflight     map[K]*call
 	mutex        sync.RWMutex
 	trueTTL      time.Duration
 	falseTTL     time.Duration
+	staleTTL     time.Duration
 	cleanupTimer *time.Timer
+
+	maxEntries int
+	lru        *list.List          // Cached keys, most recently used first
+	elements   map[K]*list.Element // Each cached key's element in lru
+	lruMutex   sync.Mutex          // Serializes moves in lru under the read lock
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	evictions  atomic.Uint64
 }
 
 // New creates a new Memoizer for the given boolean function with specified TTLs.
-// - fn: The function to memoize that takes any value and returns a boolean
+// - fn: The function to memoize that takes a comparable key and returns a boolean
 // - trueTTL: How long to cache 'true' results
 // - falseTTL: How long to cache 'false' results
-func New[T any](fn func(T) bool, trueTTL, falseTTL time.Duration) *Memoizer[T] {
-	m := &Memoizer[T]{
+func New[K comparable](fn func(K) bool, trueTTL, falseTTL time.Duration) *Memoizer[K] {
+	return NewWithError(func(key K) (bool, error) {
+		return fn(key), nil
+	}, trueTTL, falseTTL)
+}
+
+// NewWithError creates a new Memoizer for a boolean function that can fail.
+// Failed computations are not cached: callers get the last cached result for
+// the key, even if expired, or false if there is none, so transient failures
+// don't flip results.
+func NewWithError[K comparable](fn func(K) (bool, error), trueTTL, falseTTL time.Duration) *Memoizer[K] {
+	m := &Memoizer[K]{
 		fn:       fn,
-		cache:    make(map[any]CacheEntry),
+		cache:    make(map[K]CacheEntry),
+		inflight: make(map[K]*call),
+		lru:      list.New(),
+		elements: make(map[K]*list.Element),
 		trueTTL:  trueTTL,
 		falseTTL: falseTTL,
 	}
@@ -42,7 +68,7 @@ func New[T any](fn func(T) bool, trueTTL, falseTTL time.Duration) *Memoizer[T] {
 }
 
 // startCleanupTimer starts a timer to periodically clean up expired cache entries.
-func (m *Memoizer[T]) startCleanupTimer() {
+func (m *Memoizer[K]) startCleanupTimer() {
 	// Find the minimum TTL to determine cleanup frequency
 	minTTL := m.trueTTL
 	if m.falseTTL < minTTL {
@@ -66,39 +92,85 @@ func (m *Memoizer[T]) startCleanupTimer() {
 	})
 }
 
-// cleanup removes expired entries from the cache.
-func (m *Memoizer[T]) cleanup() {
+// cleanup removes entries from the cache that have expired and can no longer
+// be served stale.
+func (m *Memoizer[K]) cleanup() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	now := time.Now()
 	for key, entry := range m.cache {
-		if now.After(entry.ExpiresAt) {
-			delete(m.cache, key)
+		if now.After(entry.ExpiresAt.Add(m.staleTTL)) {
+			m.remove(key)
 		}
 	}
 }
 
+// StaleWhileRevalidate makes Get serve expired results for up to maxStale past
+// their expiry while they are recomputed in the background, so callers don't
+// wait for the computation. Zero, the default, disables serving stale results.
+func (m *Memoizer[K]) StaleWhileRevalidate(maxStale time.Duration) *Memoizer[K] {
+	m.mutex.Lock()
+	m.staleTTL = maxStale
+	m.mutex.Unlock()
+	return m
+}
+
+// lookup classifies a cache entry as fresh, stale or missing. The caller must
+// hold a lock.
+func (m *Memoizer[K]) lookup(entry CacheEntry, found bool, now time.Time) (fresh, stale bool) {
+	if !found {
+		return false, false
+	}
+	if now.Before(entry.ExpiresAt) {
+		return true, false
+	}
+	return false, m.staleTTL > 0 && now.Before(entry.ExpiresAt.Add(m.staleTTL))
+}
+
 // Get retrieves the cached result for the given key, or computes and caches it.
-func (m *Memoizer[T]) Get(key T) bool {
+func (m *Memoizer[K]) Get(key K) bool {
 	// Try to get from cache first
+	now := time.Now()
 	m.mutex.RLock()
 	entry, found := m.cache[key]
+	fresh, stale := m.lookup(entry, found, now)
+	if fresh || stale {
+		m.touch(key)
+	}
 	m.mutex.RUnlock()
 
 	// If found and not expired, return the cached value
-	if found && time.Now().Before(entry.ExpiresAt) {
+	if fresh {
+		m.hits.Add(1)
+		return entry.Value
+	}
+
+	// Serve a stale value while it is recomputed
+	if stale {
+		m.hits.Add(1)
+		m.revalidate(key)
 		return entry.Value
 	}
 
 	// Otherwise, compute the result
+	m.misses.Add(1)
 	return m.compute(key)
 }
 
+// call is an in-flight computation of a key, shared by every caller that
+// misses the cache while it runs
+type call struct {
+	done  chan struct{}
+	value bool
+	err   error
+	ok    bool // false if the function panicked
+}
+
 // compute calls the underlying function and caches the result with appropriate TTL.
-// It handles concurrent calls safely.
-func (m *Memoizer[T]) compute(key T) bool {
-	// Acquire full lock for computation and cache update
+// Concurrent calls for the same key share a single computation, while other keys
+// are computed in parallel.
+func (m *Memoizer[K]) compute(key K) bool {
 	m.mutex.Lock()
 
 	// Double-check: Another goroutine might have computed this while we waited for the lock
@@ -108,9 +180,76 @@ func (m *Memoizer[T]) compute(key T) bool {
 		return entry.Value // Return the value computed by the other goroutine
 	}
 
-	// If still not found or expired, proceed with computation
-	result := m.fn(key)
+	// Wait for a computation already in progress
+	if c, ok := m.inflight[key]; ok {
+		m.mutex.Unlock()
+		<-c.done
+		if !c.ok {
+			return m.compute(key)
+		}
+		return c.value
+	}
+
+	c := m.start(key)
+	m.mutex.Unlock()
+
+	m.run(key, c)
+	return c.value
+}
+
+// revalidate recomputes a key in the background, unless it is already being
+// computed
+func (m *Memoizer[K]) revalidate(key K) {
+	m.mutex.Lock()
+	if _, ok := m.inflight[key]; ok {
+		m.mutex.Unlock()
+		return
+	}
+	c := m.start(key)
+	m.mutex.Unlock()
+
+	go func() {
+		// A panic leaves the stale entry to be recomputed by a later call
+		defer func() { recover() }()
+		m.run(key, c)
+	}()
+}
+
+// start registers a computation of a key in progress. The caller must hold
+// the write lock.
+func (m *Memoizer[K]) start(key K) *call {
+	c := &call{done: make(chan struct{})}
+	m.inflight[key] = c
+	return c
+}
 
+// run calls the underlying function for a computation started with start,
+// caches a successful result and releases the callers waiting for it
+func (m *Memoizer[K]) run(key K, c *call) {
+	defer func() {
+		m.mutex.Lock()
+		// Only cache the result if the key wasn't invalidated meanwhile
+		if m.inflight[key] == c {
+			delete(m.inflight, key)
+			if c.ok && c.err == nil {
+				m.store(key, c.value)
+			}
+		}
+		// Failures fall back to the last cached result
+		if c.ok && c.err != nil {
+			c.value = m.cache[key].Value
+		}
+		m.mutex.Unlock()
+		close(c.done)
+	}()
+
+	c.value, c.err = m.fn(key)
+	c.ok = true
+}
+
+// store caches a result with the TTL for its value. The caller must hold the
+// write lock.
+func (m *Memoizer[K]) store(key K, result bool) {
 	// Determine TTL based on result
 	ttl := m.falseTTL
 	if result {
@@ -118,33 +257,93 @@ func (m *Memoizer[T]) compute(key T) bool {
 	}
 
 	// Cache the result
-	expiresAt := time.Now().Add(ttl)
+	now := time.Now()
 	m.cache[key] = CacheEntry{
 		Value:     result,
-		ExpiresAt: expiresAt,
+		ExpiresAt: now.Add(ttl),
+	}
+	if element, ok := m.elements[key]; ok {
+		m.lru.MoveToFront(element)
+	} else {
+		m.elements[key] = m.lru.PushFront(key)
 	}
 
-	m.mutex.Unlock()
+	// Evict the least recently used entries beyond the limit
+	m.evictLocked()
+}
+
+// GetMany retrieves the results for several keys at once, returning them in input order.
+// Cached keys are resolved under a single read lock and each distinct miss is computed
+// once per call, sharing computations already in progress for the same key.
+func (m *Memoizer[K]) GetMany(keys []K) []bool {
+	results := make([]bool, len(keys))
+	var misses []int
+
+	// Resolve cached keys first
+	var stale []K
+	now := time.Now()
+	m.mutex.RLock()
+	for i, key := range keys {
+		entry, found := m.cache[key]
+		fresh, isStale := m.lookup(entry, found, now)
+		switch {
+		case fresh:
+			results[i] = entry.Value
+			m.touch(key)
+		case isStale:
+			results[i] = entry.Value
+			m.touch(key)
+			stale = append(stale, key)
+		default:
+			misses = append(misses, i)
+		}
+	}
+	m.mutex.RUnlock()
+
+	m.hits.Add(uint64(len(keys) - len(misses)))
+	m.misses.Add(uint64(len(misses)))
+
+	// Recompute stale keys in the background
+	for _, key := range stale {
+		m.revalidate(key)
+	}
+
+	// Compute the misses, reusing results for duplicate keys
+	computed := make(map[K]bool, len(misses))
+	for _, i := range misses {
+		key := keys[i]
+		result, ok := computed[key]
+		if !ok {
+			result = m.compute(key)
+			computed[key] = result
+		}
+		results[i] = result
+	}
 
-	return result
+	return results
 }
 
-// Invalidate removes a specific key from the cache.
-func (m *Memoizer[T]) Invalidate(key T) {
+// Invalidate removes a specific key from the cache. The result of a computation
+// of the key already in progress is returned to its callers but not cached.
+func (m *Memoizer[K]) Invalidate(key K) {
 	m.mutex.Lock()
-	delete(m.cache, key)
+	m.remove(key)
+	delete(m.inflight, key)
 	m.mutex.Unlock()
 }
 
 // Clear removes all entries from the cache.
-func (m *Memoizer[T]) Clear() {
+func (m *Memoizer[K]) Clear() {
 	m.mutex.Lock()
-	m.cache = make(map[any]CacheEntry)
+	m.cache = make(map[K]CacheEntry)
+	m.inflight = make(map[K]*call)
+	m.lru = list.New()
+	m.elements = make(map[K]*list.Element)
 	m.mutex.Unlock()
 }
 
 // Stop halts the cleanup timer.
-func (m *Memoizer[T]) Stop() {
+func (m *Memoizer[K]) Stop() {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	if m.cleanupTimer != nil {
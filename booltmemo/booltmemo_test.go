@@ -1,7 +1,9 @@
 package booltmemo
 
 import (
+	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -311,3 +313,280 @@ func TestConcurrency(t *testing.T) {
 		t.Errorf("Expected fewer function calls with caching, got %d", callCount)
 	}
 }
+
+// TestGetMany verifies bulk lookups with a mix of cached and uncached keys
+func TestGetMany(t *testing.T) {
+	calls := make(map[int]int)
+	var counterMutex sync.Mutex
+
+	isEven := func(num int) bool {
+		counterMutex.Lock()
+		calls[num]++
+		counterMutex.Unlock()
+		return num%2 == 0
+	}
+
+	memo := New(isEven, time.Minute, time.Minute)
+	defer memo.Stop()
+
+	// Warm the cache with a couple of keys
+	memo.Get(2)
+	memo.Get(3)
+
+	keys := []int{1, 2, 3, 4, 4, 5}
+	results := memo.GetMany(keys)
+	expected := []bool{false, true, false, true, true, false}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i := range expected {
+		if results[i] != expected[i] {
+			t.Errorf("Expected %v for key %d, got %v", expected[i], keys[i], results[i])
+		}
+	}
+
+	// Cached keys are not recomputed and duplicate misses are computed once
+	for _, key := range keys {
+		if calls[key] != 1 {
+			t.Errorf("Expected 1 call for key %d, got %d", key, calls[key])
+		}
+	}
+
+	// A second bulk lookup is served entirely from the cache
+	memo.GetMany(keys)
+	for _, key := range keys {
+		if calls[key] != 1 {
+			t.Errorf("Expected key %d to stay cached, got %d calls", key, calls[key])
+		}
+	}
+
+	if results := memo.GetMany(nil); len(results) != 0 {
+		t.Errorf("Expected no results for no keys, got %v", results)
+	}
+}
+
+// TestTypedKeys verifies memoizers keyed by non-interface types
+func TestTypedKeys(t *testing.T) {
+	type permission struct {
+		user   string
+		action string
+	}
+
+	calls := 0
+	allowed := New(func(p permission) bool {
+		calls++
+		return p.user == "admin" || p.action == "read"
+	}, time.Minute, time.Minute)
+	defer allowed.Stop()
+
+	if !allowed.Get(permission{"alice", "read"}) {
+		t.Error("Expected alice to be allowed to read")
+	}
+	if allowed.Get(permission{"alice", "write"}) {
+		t.Error("Expected alice not to be allowed to write")
+	}
+	if !allowed.Get(permission{"alice", "read"}) || calls != 2 {
+		t.Errorf("Expected equal struct keys to share a cache entry, got %d calls", calls)
+	}
+
+	allowed.Invalidate(permission{"alice", "read"})
+	allowed.Get(permission{"alice", "read"})
+	if calls != 3 {
+		t.Errorf("Expected invalidated key to be recomputed, got %d calls", calls)
+	}
+}
+
+// TestConcurrentComputationsShared verifies concurrent misses for a key share one computation
+func TestConcurrentComputationsShared(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	memo := New(func(key string) bool {
+		calls.Add(1)
+		if key == "slow" {
+			<-release
+		}
+		return true
+	}, time.Minute, time.Minute)
+	defer memo.Stop()
+
+	const goroutines = 10
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !memo.Get("slow") {
+				t.Error("Expected true for slow key")
+			}
+		}()
+	}
+
+	// Other keys are computed while the slow key is in progress
+	done := make(chan bool)
+	go func() { done <- memo.Get("fast") }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected other keys not to wait for the slow computation")
+	}
+
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected 2 computations (slow and fast), got %d", n)
+	}
+}
+
+// TestInvalidateDuringComputation verifies an invalidated in-flight result isn't cached
+func TestInvalidateDuringComputation(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	memo := New(func(key int) bool {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return true
+	}, time.Minute, time.Minute)
+	defer memo.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		memo.Get(1)
+		close(done)
+	}()
+
+	<-started
+	memo.Invalidate(1)
+	close(release)
+	<-done
+
+	memo.Get(1)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected the invalidated result to be recomputed, got %d calls", n)
+	}
+}
+
+// TestNewWithError verifies failed computations fall back to the last result
+func TestNewWithError(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+
+	memo := NewWithError(func(key string) (bool, error) {
+		calls.Add(1)
+		if fail.Load() {
+			return false, errors.New("backend unavailable")
+		}
+		return true, nil
+	}, 20*time.Millisecond, 20*time.Millisecond)
+	defer memo.Stop()
+
+	// With nothing cached, a failure returns false and isn't cached
+	fail.Store(true)
+	if memo.Get("user") {
+		t.Error("Expected false for a failed computation with no cached result")
+	}
+	fail.Store(false)
+	if !memo.Get("user") {
+		t.Error("Expected true once the computation succeeds")
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected the failure not to be cached, got %d calls", n)
+	}
+
+	// Once the entry expires, a failure keeps the previous result
+	time.Sleep(30 * time.Millisecond)
+	fail.Store(true)
+	if !memo.Get("user") {
+		t.Error("Expected the expired result to be kept when recomputing fails")
+	}
+	if results := memo.GetMany([]string{"user", "other"}); !results[0] || results[1] {
+		t.Errorf("Expected [true false] from GetMany, got %v", results)
+	}
+}
+
+// TestStaleWhileRevalidate verifies expired results are served while recomputed
+func TestStaleWhileRevalidate(t *testing.T) {
+	var value atomic.Bool
+	var calls atomic.Int32
+	release := make(chan struct{}, 1)
+
+	memo := New(func(key int) bool {
+		if calls.Add(1) > 1 {
+			<-release
+		}
+		return value.Load()
+	}, 20*time.Millisecond, 20*time.Millisecond).StaleWhileRevalidate(time.Minute)
+	defer memo.Stop()
+
+	value.Store(true)
+	if !memo.Get(1) {
+		t.Fatal("Expected true on first computation")
+	}
+
+	// The expired value is returned without waiting for the recomputation
+	time.Sleep(30 * time.Millisecond)
+	value.Store(false)
+	for i := 0; i < 3; i++ {
+		if !memo.Get(1) {
+			t.Error("Expected the stale value while revalidating")
+		}
+	}
+	release <- struct{}{}
+
+	// The background recomputation replaces the stale value
+	deadline := time.Now().Add(time.Second)
+	for memo.Get(1) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the revalidated value to be cached")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected a single background recomputation, got %d calls", n)
+	}
+}
+
+// TestMaxEntries verifies least recently used entries are evicted beyond the limit
+func TestMaxEntries(t *testing.T) {
+	calls := make(map[int]int)
+	memo := New(func(key int) bool {
+		calls[key]++
+		return key%2 == 0
+	}, time.Minute, time.Minute).MaxEntries(2)
+	defer memo.Stop()
+
+	memo.Get(1)
+	time.Sleep(time.Millisecond)
+	memo.Get(2)
+	time.Sleep(time.Millisecond)
+	memo.Get(1) // 2 is now the least recently used
+	time.Sleep(time.Millisecond)
+	memo.Get(3) // Evicts 2
+
+	memo.Get(1)
+	memo.Get(3)
+	if calls[1] != 1 || calls[3] != 1 {
+		t.Errorf("Expected recently used keys to stay cached, got calls %v", calls)
+	}
+	memo.Get(2)
+	if calls[2] != 2 {
+		t.Errorf("Expected the least recently used key to be evicted, got %d calls", calls[2])
+	}
+
+	stats := memo.Stats()
+	expected := Stats{Hits: 3, Misses: 4, Evictions: 2, Size: 2}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+
+	// Lowering the limit evicts immediately
+	memo.MaxEntries(1)
+	if stats := memo.Stats(); stats.Size != 1 || stats.Evictions != 3 {
+		t.Errorf("Expected 1 entry after lowering the limit, got %+v", stats)
+	}
+}
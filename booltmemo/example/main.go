@@ -9,14 +9,10 @@ import (
 
 // A sample function that we want to memoize
 // It simulates a function that might be expensive to compute
-func isEven(val interface{}) bool {
+func isEven(num int) bool {
 	// Simulate expensive computation
 	time.Sleep(100 * time.Millisecond)
 
-	num, ok := val.(int)
-	if !ok {
-		return false
-	}
 	return num%2 == 0
 }
 
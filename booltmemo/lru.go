@@ -0,0 +1,66 @@
+package booltmemo
+
+// Stats reports cache activity since the memoizer was created
+type Stats struct {
+	Hits      uint64 // Lookups served from the cache, including stale results
+	Misses    uint64 // Lookups that waited for a computation
+	Evictions uint64 // Entries evicted to stay within MaxEntries
+	Size      int    // Entries currently cached
+}
+
+// MaxEntries limits the number of cached results. When a new result exceeds
+// the limit, the least recently used entry is evicted. Zero or less, the
+// default, means unlimited.
+func (m *Memoizer[K]) MaxEntries(n int) *Memoizer[K] {
+	m.mutex.Lock()
+	m.maxEntries = n
+	m.evictLocked()
+	m.mutex.Unlock()
+	return m
+}
+
+// Stats returns the memoizer's hit, miss and eviction counts and its size
+func (m *Memoizer[K]) Stats() Stats {
+	m.mutex.RLock()
+	size := len(m.cache)
+	m.mutex.RUnlock()
+
+	return Stats{
+		Hits:      m.hits.Load(),
+		Misses:    m.misses.Load(),
+		Evictions: m.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// touch records that a key was used. The caller must hold a lock.
+func (m *Memoizer[K]) touch(key K) {
+	if element, ok := m.elements[key]; ok {
+		m.lruMutex.Lock()
+		m.lru.MoveToFront(element)
+		m.lruMutex.Unlock()
+	}
+}
+
+// remove deletes a key from the cache. The caller must hold the write lock.
+func (m *Memoizer[K]) remove(key K) {
+	delete(m.cache, key)
+	if element, ok := m.elements[key]; ok {
+		m.lru.Remove(element)
+		delete(m.elements, key)
+	}
+}
+
+// evictLocked evicts the least recently used entries until the cache is within
+// its limit. The caller must hold the write lock.
+func (m *Memoizer[K]) evictLocked() {
+	for m.maxEntries > 0 && len(m.cache) > m.maxEntries {
+		oldest := m.lru.Back()
+		if oldest == nil {
+			return
+		}
+
+		m.remove(oldest.Value.(K))
+		m.evictions.Add(1)
+	}
+}
@@ -22,18 +22,38 @@ import (
 	"github.com/presbrey/pkg/echovalidator"
 )
 
+// Option configures the Echo context created by HandlerFunc and MiddlewareFunc
+type Option func(*echoContext)
+
+// WithValidator sets the validator used by Context.Validate. When no validator
+// is provided the echovalidator singleton is used.
+func WithValidator(v echo.Validator) Option {
+	return func(c *echoContext) {
+		c.validator = v
+	}
+}
+
+// newEchoContext creates an Echo context for the request with the given options applied
+func newEchoContext(w http.ResponseWriter, r *http.Request, opts []Option) *echoContext {
+	c := &echoContext{
+		request:        r,
+		responseWriter: w,
+		response:       &echo.Response{Writer: w},
+		params:         make(map[string]string),
+		store:          make(map[string]interface{}),
+		binder:         &echo.DefaultBinder{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
 // HandlerFunc converts an Echo handler function to a http.HandlerFunc that can be used with Gorilla Mux
-func HandlerFunc(echoHandler echo.HandlerFunc) http.HandlerFunc {
+func HandlerFunc(echoHandler echo.HandlerFunc, opts ...Option) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Create a new Echo context
-		echoCtx := &echoContext{
-			request:        r,
-			responseWriter: w,
-			response:       &echo.Response{Writer: w},
-			params:         make(map[string]string),
-			store:          make(map[string]interface{}),
-			binder:         &echo.DefaultBinder{},
-		}
+		echoCtx := newEchoContext(w, r, opts)
 
 		// Extract path parameters from Gorilla context and add them to our echo context
 		vars := mux.Vars(r)
@@ -63,18 +83,11 @@ func HandlerFunc(echoHandler echo.HandlerFunc) http.HandlerFunc {
 }
 
 // MiddlewareFunc converts an Echo middleware function to a Gorilla middleware function
-func MiddlewareFunc(m echo.MiddlewareFunc) mux.MiddlewareFunc {
+func MiddlewareFunc(m echo.MiddlewareFunc, opts ...Option) mux.MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Create a new Echo context
-			c := &echoContext{
-				request:        r,
-				responseWriter: w,
-				response:       &echo.Response{Writer: w},
-				params:         make(map[string]string),
-				store:          make(map[string]interface{}),
-				binder:         &echo.DefaultBinder{},
-			}
+			c := newEchoContext(w, r, opts)
 
 			// Extract path parameters from Gorilla mux
 			vars := mux.Vars(r)
@@ -129,6 +142,7 @@ type echoContext struct {
 	binder         echo.Binder
 	renderer       echo.Renderer
 	logger         echo.Logger
+	validator      echo.Validator
 }
 
 // Request returns the http.Request object
@@ -548,9 +562,12 @@ func (c *echoContext) XMLBlob(code int, b []byte) error {
 	return err
 }
 
-// Validate validates provided value using the echovalidator package
+// Validate validates provided value using the configured validator, falling
+// back to the echovalidator package singleton
 func (c *echoContext) Validate(i interface{}) error {
-	// Use the singleton validator from echovalidator package
+	if c.validator != nil {
+		return c.validator.Validate(i)
+	}
 	return echovalidator.Default().Validate(i)
 }
 
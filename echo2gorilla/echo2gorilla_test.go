@@ -317,3 +317,75 @@ func TestCompleteIntegration(t *testing.T) {
 		assert.Equal(t, "This is a protected resource", result["message"])
 	})
 }
+
+// bannedNameValidator rejects users named "forbidden"
+type bannedNameValidator struct {
+	calls int
+}
+
+func (v *bannedNameValidator) Validate(i interface{}) error {
+	v.calls++
+	if u, ok := i.(*User); ok && u.Name == "forbidden" {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "name is not allowed")
+	}
+	return nil
+}
+
+// Echo handler that binds and validates JSON
+func echoValidateHandler(c echo.Context) error {
+	user := new(User)
+	if err := c.Bind(user); err != nil {
+		return err
+	}
+	if err := c.Validate(user); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+func TestWithValidator(t *testing.T) {
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	// The default validator has no rule against the name
+	rec := post(HandlerFunc(echoValidateHandler), `{"id": 1, "name": "forbidden"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The injected validator is used instead of the default
+	validator := &bannedNameValidator{}
+	handler := HandlerFunc(echoValidateHandler, WithValidator(validator))
+
+	rec = post(handler, `{"id": 1, "name": "forbidden"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "name is not allowed")
+
+	rec = post(handler, `{"id": 2, "name": "allowed"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 2, validator.calls)
+
+	// Middleware contexts use the injected validator too
+	r := mux.NewRouter()
+	r.Use(MiddlewareFunc(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := c.Validate(&User{Name: c.Request().Header.Get("X-Name")}); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}, WithValidator(validator)))
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Name", "forbidden")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 3, validator.calls)
+}
@@ -0,0 +1,122 @@
+package echoflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// Bind populates the struct pointed to by dst from the flags for the request.
+// Fields are matched by a `flag:"path.to.key"` tag using the same dot notation as
+// the getters, and a `default:"value"` tag supplies the value of missing flags.
+// Defaults are JSON, except for string, time.Duration and time.Time fields, which
+// take the plain text. Times are RFC 3339 strings. Struct fields tagged with a path bind their own fields relative to
+// it; untagged fields are left alone. Like the WithDefault getters, flags that
+// can't be loaded fall back to their defaults.
+func (s *SDK) Bind(c echo.Context, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+	return s.bindStruct(c, v.Elem(), "")
+}
+
+// Bind populates the struct pointed to by dst from the flags. See SDK.Bind.
+func (fs *FlagSet) Bind(dst interface{}) error {
+	return fs.sdk.Bind(fs.c, dst)
+}
+
+func (s *SDK) bindStruct(c echo.Context, v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup("flag")
+		if !ok || key == "" || key == "-" || !field.IsExported() {
+			continue
+		}
+		path := prefix + key
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+			if err := s.bindStruct(c, fv, path+"."); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := s.getValue(c, path)
+		if err != nil {
+			def, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			if err := setDefault(fv, def); err != nil {
+				return fmt.Errorf("default for field %s: %w", field.Name, err)
+			}
+			continue
+		}
+
+		if err := setValue(fv, value); err != nil {
+			return fmt.Errorf("flag %s for field %s: %w", path, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// setValue assigns a flag value to a field, converting it through JSON
+func setValue(fv reflect.Value, value interface{}) error {
+	if fv.Kind() == reflect.String {
+		// Match GetString, which formats non-string values
+		fv.SetString(fmt.Sprintf("%v", value))
+		return nil
+	}
+	if fv.Type() == durationType {
+		switch d := value.(type) {
+		case string:
+			parsed, err := time.ParseDuration(d)
+			if err != nil {
+				return err
+			}
+			fv.SetInt(int64(parsed))
+			return nil
+		}
+	}
+	if fv.Type() == timeType {
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected an RFC 3339 time, got %T", value)
+		}
+		parsed, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return err
+		}
+		fv.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, fv.Addr().Interface())
+}
+
+// setDefault assigns the text of a default tag to a field
+func setDefault(fv reflect.Value, def string) error {
+	switch {
+	case fv.Kind() == reflect.String:
+		fv.SetString(def)
+		return nil
+	case fv.Type() == durationType, fv.Type() == timeType:
+		return setValue(fv, def)
+	}
+	return json.Unmarshal([]byte(def), fv.Addr().Interface())
+}
@@ -0,0 +1,126 @@
+package echoflags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBind(t *testing.T) {
+	server := mockServer(t)
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{FlagsBase: server.URL})
+
+	type Limits struct {
+		MaxUsers int    `flag:"maxUsers"`
+		Storage  string `flag:"maxStorage"`
+		Missing  int    `flag:"missing" default:"7"`
+	}
+	type AppConfig struct {
+		Feature1   bool                   `flag:"feature1"`
+		MaxItems   int                    `flag:"maxItems"`
+		Discount   float64                `flag:"discount"`
+		Regions    []string               `flag:"allowedRegions"`
+		Version    string                 `flag:"metadata.version"`
+		Retention  int                    `flag:"metadata.dataRetentionDays"`
+		Limits     Limits                 `flag:"limits"`
+		Security   map[string]interface{} `flag:"security"`
+		Theme      string                 `flag:"theme" default:"light"`
+		Timeout    time.Duration          `flag:"timeout" default:"1m30s"`
+		Tags       []string               `flag:"tags" default:"[\"a\", \"b\"]"`
+		Untagged   string
+		Unresolved map[string]string `flag:"unresolved"`
+	}
+
+	t.Run("binds values and defaults", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.Set("user", "admin@example.com")
+
+		cfg := AppConfig{Untagged: "kept"}
+		require.NoError(t, sdk.Bind(c, &cfg))
+
+		assert.True(t, cfg.Feature1)
+		assert.Equal(t, 500, cfg.MaxItems)
+		assert.Equal(t, 0.3, cfg.Discount)
+		assert.Equal(t, []string{"us-east-1", "us-west-2", "eu-west-1", "ap-southeast-1"}, cfg.Regions)
+		assert.Equal(t, "2.0.0", cfg.Version)
+		assert.Equal(t, 365, cfg.Retention)
+		assert.Equal(t, Limits{MaxUsers: 1000, Storage: "1TB", Missing: 7}, cfg.Limits)
+		assert.Equal(t, true, cfg.Security["mfaRequired"])
+		assert.Equal(t, "light", cfg.Theme)
+		assert.Equal(t, 90*time.Second, cfg.Timeout)
+		assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+		assert.Equal(t, "kept", cfg.Untagged)
+		assert.Nil(t, cfg.Unresolved)
+	})
+
+	t.Run("fluent API", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var cfg struct {
+			MaxItems int `flag:"maxItems"`
+		}
+		require.NoError(t, sdk.WithContext(c).Bind(&cfg))
+		assert.Equal(t, 100, cfg.MaxItems)
+	})
+
+	t.Run("reports conversion errors", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var cfg struct {
+			Regions int `flag:"allowedRegions"`
+		}
+		err := sdk.Bind(c, &cfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "allowedRegions")
+
+		var bad struct {
+			Count int `flag:"count" default:"many"`
+		}
+		assert.Error(t, sdk.Bind(c, &bad))
+	})
+
+	t.Run("binds RFC 3339 times", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"hosts/tenant1.json": {Data: []byte(`{"*": {"launch": {"at": "2025-03-01T09:30:00-05:00"}, "bad": 1}}`)},
+		}
+		sdk := NewWithConfig(Config{FlagsFS: fsys, FlagsBase: "hosts"})
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var cfg struct {
+			LaunchAt time.Time `flag:"launch.at"`
+			SunsetAt time.Time `flag:"sunset" default:"2030-01-01T00:00:00Z"`
+		}
+		require.NoError(t, sdk.Bind(c, &cfg))
+		assert.True(t, cfg.LaunchAt.Equal(time.Date(2025, 3, 1, 14, 30, 0, 0, time.UTC)))
+		assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), cfg.SunsetAt)
+
+		var bad struct {
+			At time.Time `flag:"bad"`
+		}
+		err := sdk.Bind(c, &bad)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "RFC 3339")
+	})
+
+	t.Run("requires a struct pointer", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var cfg struct{}
+		assert.Error(t, sdk.Bind(c, cfg))
+		assert.Error(t, sdk.Bind(c, (*struct{})(nil)))
+	})
+}
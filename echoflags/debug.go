@@ -0,0 +1,112 @@
+package echoflags
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Flag sources reported by DebugHandler, in increasing order of precedence.
+// In single file mode the static file is reported as the host layer.
+const (
+	SourceBase     = "base"
+	SourceHost     = "host"
+	SourceBaseRule = "base-rule"
+	SourceHostRule = "host-rule"
+	SourceBaseUser = "base-user"
+	SourceHostUser = "host-user"
+)
+
+// DebugFlag describes the effective value of a single flag and where it came from
+type DebugFlag struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Chain  []string    `json:"chain"`
+}
+
+// DebugReport describes how the flags for a request were resolved
+type DebugReport struct {
+	Host  string               `json:"host"`
+	User  string               `json:"user"`
+	URLs  map[string]string    `json:"urls"`
+	Flags map[string]DebugFlag `json:"flags"`
+}
+
+// Debug resolves every flag visible to the request's user and reports its
+// final value together with the layers that defined it.
+func (s *SDK) Debug(c echo.Context) (*DebugReport, error) {
+	merged, layers, err := s.resolveConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	user := s.config.GetUserFunc(c)
+	report := &DebugReport{
+		Host:  ContextHost(c),
+		User:  user,
+		URLs:  make(map[string]string),
+		Flags: make(map[string]DebugFlag),
+	}
+
+	for _, l := range layers {
+		report.URLs[l.name] = l.url
+	}
+
+	// Wildcard values are overridden by rules and rules by user values
+	// regardless of layer, so walk the sections in that order.
+	chains := make(map[string][]string)
+	for _, l := range layers {
+		for key := range l.config["*"] {
+			chains[key] = append(chains[key], l.name)
+		}
+	}
+	for _, l := range layers {
+		for key := range l.config[RulesKey] {
+			if _, ok := evaluateRules(merged, key, user); ok {
+				chains[key] = append(chains[key], l.ruleName)
+			}
+		}
+	}
+	if user != "" && user != "*" {
+		for _, l := range layers {
+			for key := range l.config[user] {
+				chains[key] = append(chains[key], l.userName)
+			}
+		}
+	}
+
+	for key, chain := range chains {
+		value, err := lookupValueInConfig(merged, key, user)
+		if err != nil {
+			continue
+		}
+		report.Flags[key] = DebugFlag{
+			Value:  value,
+			Source: chain[len(chain)-1],
+			Chain:  chain,
+		}
+	}
+
+	return report, nil
+}
+
+// DebugHandler returns a handler that responds with the Debug report for the
+// request, after checking Config.DebugAuthorizer
+func (s *SDK) DebugHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if s.config.DebugAuthorizer != nil {
+			if err := s.config.DebugAuthorizer(c); err != nil {
+				if he, ok := err.(*echo.HTTPError); ok {
+					return he
+				}
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+		}
+
+		report, err := s.Debug(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+		}
+		return c.JSON(http.StatusOK, report)
+	}
+}
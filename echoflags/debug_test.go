@@ -0,0 +1,147 @@
+package echoflags
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugHandler(t *testing.T) {
+	server := mockServer(t)
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{
+		FlagsBase:    server.URL,
+		BaseHost:     "baseForMerge",
+		DisableCache: true,
+	})
+
+	debug := func(t *testing.T, host, user string) DebugReport {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/debug", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		if user != "" {
+			c.Set("user", user)
+		}
+
+		require.NoError(t, sdk.DebugHandler()(c))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var report DebugReport
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+		return report
+	}
+
+	t.Run("reports provenance of wildcard values", func(t *testing.T) {
+		report := debug(t, "tenant1", "")
+
+		assert.Equal(t, "tenant1", report.Host)
+		assert.Equal(t, server.URL+"/baseForMerge.json", report.URLs[SourceBase])
+		assert.Equal(t, server.URL+"/tenant1.json", report.URLs[SourceHost])
+
+		// base-only
+		require.Contains(t, report.Flags, "fallbackKey")
+		assert.Equal(t, true, report.Flags["fallbackKey"].Value)
+		assert.Equal(t, SourceBase, report.Flags["fallbackKey"].Source)
+		assert.Equal(t, []string{SourceBase}, report.Flags["fallbackKey"].Chain)
+
+		// host-only
+		require.Contains(t, report.Flags, "feature3")
+		assert.Equal(t, SourceHost, report.Flags["feature3"].Source)
+		assert.Equal(t, []string{SourceHost}, report.Flags["feature3"].Chain)
+
+		// overridden by host
+		require.Contains(t, report.Flags, "feature1")
+		assert.Equal(t, true, report.Flags["feature1"].Value)
+		assert.Equal(t, SourceHost, report.Flags["feature1"].Source)
+		assert.Equal(t, []string{SourceBase, SourceHost}, report.Flags["feature1"].Chain)
+
+		// nested maps report the merged value
+		metadata, ok := report.Flags["metadata"].Value.(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "base", metadata["source"])
+		assert.Equal(t, "1.0.0", metadata["version"])
+	})
+
+	t.Run("reports provenance of user overrides", func(t *testing.T) {
+		report := debug(t, "tenant1", "user@example.com")
+
+		assert.Equal(t, "user@example.com", report.User)
+
+		require.Contains(t, report.Flags, "maxItems")
+		assert.Equal(t, float64(150), report.Flags["maxItems"].Value)
+		assert.Equal(t, SourceHostUser, report.Flags["maxItems"].Source)
+		assert.Equal(t, []string{SourceHost, SourceBaseUser, SourceHostUser}, report.Flags["maxItems"].Chain)
+
+		require.Contains(t, report.Flags, "feature2")
+		assert.Equal(t, true, report.Flags["feature2"].Value)
+		assert.Equal(t, SourceHostUser, report.Flags["feature2"].Source)
+	})
+
+	t.Run("base only when host is missing", func(t *testing.T) {
+		report := debug(t, "nonexistent", "base-user@example.com")
+
+		assert.NotContains(t, report.URLs, SourceHost)
+		require.Contains(t, report.Flags, "fromBase")
+		assert.Equal(t, SourceBaseUser, report.Flags["fromBase"].Source)
+		assert.NotContains(t, report.Flags, "feature3")
+	})
+
+	t.Run("gated by the authorizer", func(t *testing.T) {
+		sdk := NewWithConfig(Config{
+			FlagsBase:    server.URL,
+			DisableCache: true,
+			DebugAuthorizer: func(c echo.Context) error {
+				switch c.Request().Header.Get("X-Role") {
+				case "support":
+					return nil
+				case "":
+					return echo.ErrUnauthorized
+				}
+				return errors.New("not support staff")
+			},
+		})
+		serve := func(role string) (*httptest.ResponseRecorder, error) {
+			req := httptest.NewRequest(http.MethodGet, "http://tenant1/debug", nil)
+			if role != "" {
+				req.Header.Set("X-Role", role)
+			}
+			rec := httptest.NewRecorder()
+			return rec, sdk.DebugHandler()(e.NewContext(req, rec))
+		}
+
+		rec, err := serve("support")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var he *echo.HTTPError
+		_, err = serve("")
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusUnauthorized, he.Code)
+
+		_, err = serve("sales")
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusForbidden, he.Code)
+	})
+
+	t.Run("errors when nothing can be loaded", func(t *testing.T) {
+		sdk := NewWithConfig(Config{
+			FlagsBase:    server.URL,
+			DisableCache: true,
+		})
+		req := httptest.NewRequest(http.MethodGet, "http://nonexistent/debug", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		err := sdk.DebugHandler()(c)
+		var he *echo.HTTPError
+		require.ErrorAs(t, err, &he)
+		assert.Equal(t, http.StatusServiceUnavailable, he.Code)
+	})
+}
@@ -0,0 +1,63 @@
+package echoflags
+
+import "strings"
+
+// Evaluation describes a single flag evaluation reported to Config.OnEvaluate
+type Evaluation struct {
+	Host  string
+	User  string
+	Key   string
+	Value interface{}
+
+	// Source is the layer the value was resolved from: SourceBase, SourceHost,
+	// SourceBaseRule, SourceHostRule, SourceBaseUser or SourceHostUser.
+	// It is empty when the flag could not be resolved.
+	Source string
+
+	// Err is the error returned to the caller, if any
+	Err error
+}
+
+// evaluation describes the result of looking up a key for Config.OnEvaluate
+func (r *resolvedFlags) evaluation(key string, value interface{}, err error) Evaluation {
+	e := Evaluation{
+		Host:  r.host,
+		User:  r.user,
+		Key:   key,
+		Value: value,
+		Err:   err,
+	}
+	if err == nil {
+		e.Source = r.source(key)
+	}
+	return e
+}
+
+// source returns the layer that supplied the root of a resolved key, following
+// the precedence of lookupValueInConfig: user values, then rules, then wildcards
+func (r *resolvedFlags) source(key string) string {
+	root, _, _ := strings.Cut(key, ".")
+
+	if r.user != "" {
+		for i := len(r.layers) - 1; i >= 0; i-- {
+			if _, ok := r.layers[i].config[r.user][root]; ok {
+				return r.layers[i].userName
+			}
+		}
+	}
+
+	if _, ok := evaluateRules(r.config, root, r.user); ok {
+		for i := len(r.layers) - 1; i >= 0; i-- {
+			if _, ok := r.layers[i].config[RulesKey][root]; ok {
+				return r.layers[i].ruleName
+			}
+		}
+	}
+
+	for i := len(r.layers) - 1; i >= 0; i-- {
+		if _, ok := r.layers[i].config["*"][root]; ok {
+			return r.layers[i].name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package echoflags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnEvaluate(t *testing.T) {
+	server := mockServer(t)
+	defer server.Close()
+
+	var evaluations []Evaluation
+	sdk := NewWithConfig(Config{
+		FlagsBase: server.URL,
+		BaseHost:  "baseForMerge",
+		OnEvaluate: func(c echo.Context, e Evaluation) {
+			evaluations = append(evaluations, e)
+		},
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.Set("user", "base-user@example.com")
+
+	tests := []struct {
+		key    string
+		value  interface{}
+		source string
+	}{
+		{"fallbackKey", true, SourceBase},
+		{"feature1", true, SourceHost},
+		{"fromBase", true, SourceBaseUser},
+		{"metadata.version", "1.0.0", SourceHost},
+	}
+	for _, tt := range tests {
+		evaluations = nil
+		value, err := sdk.getValue(c, tt.key)
+		require.NoError(t, err)
+		require.Len(t, evaluations, 1, tt.key)
+		assert.Equal(t, Evaluation{
+			Host:   "tenant1",
+			User:   "base-user@example.com",
+			Key:    tt.key,
+			Value:  tt.value,
+			Source: tt.source,
+		}, evaluations[0], tt.key)
+		assert.Equal(t, tt.value, value)
+	}
+
+	t.Run("user values from the host", func(t *testing.T) {
+		c.Set("user", "user@example.com")
+		evaluations = nil
+		assert.Equal(t, 150, sdk.GetIntWithDefault(c, "maxItems", 0))
+		require.Len(t, evaluations, 1)
+		assert.Equal(t, SourceHostUser, evaluations[0].Source)
+	})
+
+	t.Run("reports missing flags", func(t *testing.T) {
+		evaluations = nil
+		assert.False(t, sdk.IsEnabled(c, "missing"))
+		require.Len(t, evaluations, 1)
+		assert.Error(t, evaluations[0].Err)
+		assert.Empty(t, evaluations[0].Source)
+	})
+
+	t.Run("called for every evaluation with middleware", func(t *testing.T) {
+		evaluations = nil
+		require.NoError(t, sdk.Middleware()(func(c echo.Context) error {
+			flags := FromContext(c)
+			flags.IsEnabled("feature1")
+			flags.IsEnabled("feature1")
+			return nil
+		})(c))
+		require.Len(t, evaluations, 2)
+		assert.Equal(t, SourceHost, evaluations[1].Source)
+	})
+}
@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -33,6 +37,11 @@ type Config struct {
 	// Only used when FlagsURL is empty
 	FlagsBase string
 
+	// FlagsFS serves the configuration files from a file system instead of HTTP.
+	// FlagsURL and FlagsBase are then paths within it. Without FlagsFS, a FlagsURL
+	// or FlagsBase that is a local path or file:// URL is read from disk.
+	FlagsFS fs.FS
+
 	// DisableCache disables caching when set to true
 	DisableCache bool
 
@@ -42,6 +51,10 @@ type Config struct {
 	// ErrorTTL is the time-to-live for cached errors (404s, network errors, etc.)
 	ErrorTTL time.Duration
 
+	// RefreshAhead refreshes a cached configuration in the background when it is
+	// used within this duration of expiring, so requests don't wait on the fetch
+	RefreshAhead time.Duration
+
 	// HTTPClient allows custom HTTP client configuration
 	HTTPClient *http.Client
 
@@ -61,6 +74,15 @@ type Config struct {
 
 	// GetUserFunc allows custom logic to extract user from context
 	GetUserFunc func(c echo.Context) string
+
+	// DebugAuthorizer gates DebugHandler. A nil error allows the request; other
+	// errors are returned as is when they are *echo.HTTPError, or as 403 Forbidden.
+	// When nil, DebugHandler is not gated and must be mounted behind authentication.
+	DebugAuthorizer func(c echo.Context) error
+
+	// OnEvaluate is called on every flag evaluation, for exposure logging and
+	// debugging. It runs synchronously, so it should not block.
+	OnEvaluate func(c echo.Context, e Evaluation)
 }
 
 // HostConfig represents the structure of a host's JSON configuration
@@ -70,6 +92,8 @@ type HostConfig map[string]map[string]interface{}
 type SDK struct {
 	config Config
 	cache  *cache
+	fsys   fs.FS  // file system serving the configuration files, if not HTTP
+	fsRoot string // directory of fsys, against which configuration paths are resolved
 }
 
 // cache represents an in-memory cache
@@ -79,9 +103,10 @@ type cache struct {
 }
 
 type cacheEntry struct {
-	data      HostConfig
-	err       error
-	expiresAt time.Time
+	data       HostConfig
+	err        error
+	expiresAt  time.Time
+	refreshing atomic.Bool
 }
 
 // NewWithConfig creates a new SDK instance with multi-tenant support based on request host
@@ -127,12 +152,35 @@ func NewWithConfig(config Config) *SDK {
 		}
 	}
 
-	return &SDK{
+	sdk := &SDK{
 		config: config,
 		cache: &cache{
 			entries: make(map[string]*cacheEntry),
 		},
 	}
+
+	switch {
+	case config.FlagsFS != nil:
+		sdk.fsys = config.FlagsFS
+	case config.FlagsURL != "":
+		if isLocalPath(config.FlagsURL) {
+			sdk.fsRoot = filepath.Dir(strings.TrimPrefix(config.FlagsURL, "file://"))
+			sdk.fsys = os.DirFS(sdk.fsRoot)
+		}
+	case isLocalPath(config.FlagsBase):
+		sdk.fsRoot = strings.TrimPrefix(config.FlagsBase, "file://")
+		sdk.fsys = os.DirFS(sdk.fsRoot)
+	}
+
+	return sdk
+}
+
+// isLocalPath reports whether a FlagsURL or FlagsBase refers to the local disk
+func isLocalPath(location string) bool {
+	if location == "" {
+		return false
+	}
+	return strings.HasPrefix(location, "file://") || !strings.Contains(location, "://")
 }
 
 // New creates a new SDK instance that uses a single static configuration file
@@ -142,8 +190,52 @@ func New(flagsURL string) *SDK {
 	})
 }
 
-// fetchHostConfig fetches the host configuration from HTTP
+// fetchHostConfig fetches the host configuration from HTTP or the file system
 func (s *SDK) fetchHostConfig(ctx context.Context, url string) (HostConfig, error) {
+	var body []byte
+	var err error
+	if s.fsys != nil {
+		body, err = s.readHostConfig(url)
+	} else {
+		body, err = s.requestHostConfig(ctx, url)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var config HostConfig
+	if err := json.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("unmarshaling config: %w", err)
+	}
+
+	return config, nil
+}
+
+// readHostConfig reads a configuration file from the SDK's file system. Paths
+// outside the FlagsBase directory are rejected.
+func (s *SDK) readHostConfig(location string) ([]byte, error) {
+	name := strings.TrimPrefix(location, "file://")
+	if s.fsRoot != "" {
+		rel, err := filepath.Rel(filepath.Clean(s.fsRoot), filepath.Clean(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid config path: %s", location)
+		}
+		name = filepath.ToSlash(rel)
+	}
+	name = strings.TrimPrefix(name, "/")
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("invalid config path: %s", location)
+	}
+
+	body, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	return body, nil
+}
+
+// requestHostConfig fetches a configuration file over HTTP
+func (s *SDK) requestHostConfig(ctx context.Context, url string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -164,12 +256,7 @@ func (s *SDK) fetchHostConfig(ctx context.Context, url string) (HostConfig, erro
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
-	var config HostConfig
-	if err := json.Unmarshal(body, &config); err != nil {
-		return nil, fmt.Errorf("unmarshaling config: %w", err)
-	}
-
-	return config, nil
+	return body, nil
 }
 
 // getHostConfig gets the host configuration with caching support
@@ -188,6 +275,7 @@ func (s *SDK) getHostConfig(c echo.Context, host string) (HostConfig, error) {
 			if entry.err != nil {
 				return nil, entry.err
 			}
+			s.maybeRefreshAhead(flagsURL, entry)
 			return entry.data, nil
 		}
 	}
@@ -224,47 +312,95 @@ func (s *SDK) getValue(c echo.Context, key string) (interface{}, error) {
 		return nil, fmt.Errorf("key cannot be empty")
 	}
 
+	// Use the flags resolved by Middleware when present
+	r := s.resolvedFromContext(c)
+	if r == nil {
+		r = s.resolve(c)
+	}
+
+	value, err := r.lookup(key)
+	if s.config.OnEvaluate != nil {
+		s.config.OnEvaluate(c, r.evaluation(key, value, err))
+	}
+	return value, err
+}
+
+// resolveConfig loads the configuration layers for the request's host and merges
+// them, base first.
+func (s *SDK) resolveConfig(c echo.Context) (HostConfig, []configLayer, error) {
+	layers, err := s.loadLayers(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var merged HostConfig
+	for _, l := range layers {
+		merged = mergeHostConfig(merged, l.config)
+	}
+	return merged, layers, nil
+}
+
+// configLayer is a single loaded configuration file taking part in a merge
+type configLayer struct {
+	name     string
+	ruleName string
+	userName string
+	url      string
+	config   HostConfig
+}
+
+// loadLayers loads the configuration files used for the request, base first.
+func (s *SDK) loadLayers(c echo.Context) ([]configLayer, error) {
 	host := ContextHost(c)
 
 	if s.config.FlagsURL != "" {
-		// Single file mode
-		config, err := s.getHostConfig(c, host) // host is ignored here
+		config, err := s.getHostConfig(c, host)
 		if err != nil {
 			return nil, err
 		}
-		return lookupValueInConfig(config, key, s.config.GetUserFunc(c))
+		return []configLayer{{
+			name:     SourceHost,
+			ruleName: SourceHostRule,
+			userName: SourceHostUser,
+			url:      s.config.FlagsURL,
+			config:   config,
+		}}, nil
 	}
 
-	// Multi-host mode
-	var baseConfig HostConfig
+	var layers []configLayer
 	if s.config.BaseHost != "" {
-		baseConfig, _ = s.getHostConfig(c, s.config.BaseHost)
-	}
-
-	if host == "" {
-		if baseConfig == nil {
-			return nil, fmt.Errorf("no flag configuration could be loaded")
+		if baseConfig, err := s.getHostConfig(c, s.config.BaseHost); err == nil {
+			layers = append(layers, configLayer{
+				name:     SourceBase,
+				ruleName: SourceBaseRule,
+				userName: SourceBaseUser,
+				url:      s.config.GetFlagsURL(c, s.config.BaseHost),
+				config:   baseConfig,
+			})
 		}
-		return lookupValueInConfig(baseConfig, key, s.config.GetUserFunc(c))
 	}
 
-	if host == s.config.BaseHost {
-		if baseConfig == nil {
-			return nil, fmt.Errorf("no flag configuration could be loaded for host: %s", host)
+	if host != "" && host != s.config.BaseHost {
+		hostConfig, err := s.getHostConfig(c, host)
+		if err != nil {
+			if len(layers) == 0 {
+				return nil, err
+			}
+			return layers, nil
 		}
-		return lookupValueInConfig(baseConfig, key, s.config.GetUserFunc(c))
+		layers = append(layers, configLayer{
+			name:     SourceHost,
+			ruleName: SourceHostRule,
+			userName: SourceHostUser,
+			url:      s.config.GetFlagsURL(c, host),
+			config:   hostConfig,
+		})
 	}
 
-	hostConfig, err := s.getHostConfig(c, host)
-	if err != nil {
-		if baseConfig != nil {
-			return lookupValueInConfig(baseConfig, key, s.config.GetUserFunc(c))
-		}
-		return nil, err
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("no flag configuration could be loaded")
 	}
-
-	finalConfig := mergeHostConfig(baseConfig, hostConfig)
-	return lookupValueInConfig(finalConfig, key, s.config.GetUserFunc(c))
+	return layers, nil
 }
 
 // GetFlagKeys retrieves all flag keys for the current context
@@ -344,6 +480,10 @@ func lookupValueInConfig(config HostConfig, key, user string) (interface{}, erro
 		}
 	}
 
+	if v, ok := evaluateRules(config, rootKey, user); ok {
+		value = v
+	}
+
 	if user != "" {
 		if userConfig, exists := config[user]; exists {
 			if v, ok := userConfig[rootKey]; ok {
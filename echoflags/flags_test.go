@@ -3,11 +3,14 @@ package echoflags
 import (
 	"context"
 	"encoding/json"
+	"io/fs"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -1242,4 +1245,70 @@ func TestGetFlagKeys(t *testing.T) {
 		require.NoError(t, err)
 		assert.ElementsMatch(t, []string{"fallbackKey", "feature1", "allowedRegions", "metadata", "feature2", "feature3", "maxItems", "fromBase", "betaFeatures", "premiumFeatures", "maxDataPoints", "apiRateLimit", "discount", "apiVersion", "experimentVariant", "limits", "notifications", "security"}, keys)
 	})
-}
\ No newline at end of file
+}
+
+func TestFileSources(t *testing.T) {
+	e := echo.New()
+	newContext := func(host, user string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		if user != "" {
+			c.Set("user", user)
+		}
+		return c
+	}
+
+	t.Run("local directory", func(t *testing.T) {
+		sdk := NewWithConfig(Config{
+			FlagsBase: "examples/hosts",
+			BaseHost:  "fallback-host",
+		})
+
+		maxItems, err := sdk.GetInt(newContext("tenant1", "user@example.com"), "maxItems")
+		require.NoError(t, err)
+		assert.Equal(t, 150, maxItems)
+
+		// Missing hosts fall back to the base host
+		assert.True(t, sdk.IsEnabled(newContext("nonexistent", ""), "fallbackHost"))
+	})
+
+	t.Run("local file URL", func(t *testing.T) {
+		dir, err := filepath.Abs("examples")
+		require.NoError(t, err)
+		sdk := New("file://" + filepath.Join(dir, "flags.json"))
+
+		assert.True(t, sdk.IsEnabled(newContext("anyhost", ""), "enableNewFeature"))
+	})
+
+	t.Run("bare relative file", func(t *testing.T) {
+		t.Chdir("examples")
+		sdk := New("flags.json")
+
+		assert.True(t, sdk.IsEnabled(newContext("anyhost", ""), "enableNewFeature"))
+	})
+
+	t.Run("fs.FS", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"hosts/tenant1.json": {Data: []byte(`{"*": {"feature1": true}}`)},
+		}
+		sdk := NewWithConfig(Config{FlagsFS: fsys, FlagsBase: "hosts"})
+
+		assert.True(t, sdk.IsEnabled(newContext("tenant1", ""), "feature1"))
+
+		_, err := sdk.GetBool(newContext("tenant2", ""), "feature1")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("rejects paths outside the directory", func(t *testing.T) {
+		sdk := NewWithConfig(Config{
+			FlagsBase: "examples/hosts",
+			GetFlagsURL: func(c echo.Context, host string) string {
+				return "examples/hosts/../flags.json"
+			},
+		})
+
+		_, err := sdk.GetBool(newContext("tenant1", ""), "enableNewFeature")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid config path")
+	})
+}
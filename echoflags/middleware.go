@@ -0,0 +1,86 @@
+package echoflags
+
+import (
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// flagsContextKey is the echo.Context key holding the flags resolved by Middleware
+const flagsContextKey = "echoflags.flags"
+
+// resolvedFlags is the merged configuration for a single request and user
+type resolvedFlags struct {
+	sdk    *SDK
+	host   string
+	config HostConfig
+	layers []configLayer
+	user   string
+	err    error
+	values sync.Map // key -> lookupResult
+}
+
+type lookupResult struct {
+	value interface{}
+	err   error
+}
+
+// lookup resolves a key against the request's configuration, remembering the result
+func (r *resolvedFlags) lookup(key string) (interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if v, ok := r.values.Load(key); ok {
+		res := v.(lookupResult)
+		return res.value, res.err
+	}
+
+	value, err := lookupValueInConfig(r.config, key, r.user)
+	r.values.Store(key, lookupResult{value: value, err: err})
+	return value, err
+}
+
+// Middleware returns an echo.MiddlewareFunc that resolves the merged host and user
+// flags once per request and stores them in the context. Getters called with that
+// context, and the FlagSet returned by FromContext, read from the stored flags
+// instead of the cache. It should run after the middleware that sets the user.
+func (s *SDK) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set(flagsContextKey, s.resolve(c))
+			return next(c)
+		}
+	}
+}
+
+// resolve loads and merges the flags for the request's host and user
+func (s *SDK) resolve(c echo.Context) *resolvedFlags {
+	config, layers, err := s.resolveConfig(c)
+	return &resolvedFlags{
+		sdk:    s,
+		host:   ContextHost(c),
+		config: config,
+		layers: layers,
+		user:   s.config.GetUserFunc(c),
+		err:    err,
+	}
+}
+
+// FromContext returns a FlagSet for the flags stored in the context by Middleware,
+// or nil if Middleware has not run for the request.
+func FromContext(c echo.Context) *FlagSet {
+	r, ok := c.Get(flagsContextKey).(*resolvedFlags)
+	if !ok {
+		return nil
+	}
+	return r.sdk.WithContext(c)
+}
+
+// resolvedFromContext returns the flags stored in the context by this SDK's Middleware
+func (s *SDK) resolvedFromContext(c echo.Context) *resolvedFlags {
+	r, ok := c.Get(flagsContextKey).(*resolvedFlags)
+	if !ok || r.sdk != s {
+		return nil
+	}
+	return r
+}
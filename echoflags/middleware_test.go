@@ -0,0 +1,80 @@
+package echoflags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.ServeFile(w, r, "examples/hosts/tenant1.json")
+	}))
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{
+		FlagsBase:    server.URL,
+		DisableCache: true,
+	})
+
+	serve := func(t *testing.T, user string, handler echo.HandlerFunc) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		if user != "" {
+			c.Set("user", user)
+		}
+		require.NoError(t, sdk.Middleware()(handler)(c))
+	}
+
+	t.Run("resolves flags once per request", func(t *testing.T) {
+		fetches.Store(0)
+		serve(t, "user@example.com", func(c echo.Context) error {
+			flags := FromContext(c)
+			require.NotNil(t, flags)
+
+			assert.Equal(t, 150, flags.GetIntWithDefault("maxItems", 0))
+			assert.Equal(t, "1.0.0", flags.GetStringWithDefault("metadata.version", ""))
+			assert.True(t, flags.IsEnabled("feature2"))
+			assert.Equal(t, 0.15, sdk.GetFloat64WithDefault(c, "discount", 0))
+			assert.Equal(t, 150, flags.GetIntWithDefault("maxItems", 0))
+
+			_, err := flags.GetString("missing")
+			assert.Error(t, err)
+			return nil
+		})
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("wildcard values without a user", func(t *testing.T) {
+		serve(t, "", func(c echo.Context) error {
+			assert.Equal(t, 100, FromContext(c).GetIntWithDefault("maxItems", 0))
+			return nil
+		})
+	})
+
+	t.Run("reports load errors from getters", func(t *testing.T) {
+		sdk := NewWithConfig(Config{FlagsBase: "http://127.0.0.1:0", DisableCache: true})
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		err := sdk.Middleware()(func(c echo.Context) error {
+			_, err := FromContext(c).GetBool("feature1")
+			assert.Error(t, err)
+			return nil
+		})(c)
+		require.NoError(t, err)
+	})
+
+	t.Run("nil without middleware", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		assert.Nil(t, FromContext(e.NewContext(req, httptest.NewRecorder())))
+	})
+}
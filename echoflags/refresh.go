@@ -0,0 +1,75 @@
+package echoflags
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maybeRefreshAhead starts a background fetch of a cached configuration that is
+// about to expire. Only one refresh runs per entry, and a failed refresh leaves
+// the cached data in place until it expires.
+func (s *SDK) maybeRefreshAhead(flagsURL string, entry *cacheEntry) {
+	if s.config.RefreshAhead <= 0 || time.Until(entry.expiresAt) > s.config.RefreshAhead {
+		return
+	}
+	if !entry.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		config, err := s.fetchHostConfig(context.Background(), flagsURL)
+		if err != nil {
+			return
+		}
+
+		s.cache.mu.Lock()
+		defer s.cache.mu.Unlock()
+		// Don't resurrect an entry that was invalidated while fetching
+		if s.cache.entries[flagsURL] != entry {
+			return
+		}
+		s.cache.entries[flagsURL] = &cacheEntry{
+			data:      config,
+			expiresAt: time.Now().Add(s.config.CacheTTL),
+		}
+	}()
+}
+
+// InvalidateRequest is the optional body accepted by InvalidateHandler
+type InvalidateRequest struct {
+	// Hosts are host names whose configuration should be refetched
+	Hosts []string `json:"hosts"`
+
+	// URLs are configuration URLs (cache keys) that should be refetched
+	URLs []string `json:"urls"`
+}
+
+// InvalidateHandler returns a handler for invalidation webhooks. It clears the
+// cached configuration of the hosts and URLs in the request body, or the whole
+// cache when the body names none, so the next request fetches fresh flags.
+func (s *SDK) InvalidateHandler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req InvalidateRequest
+		if c.Request().ContentLength != 0 {
+			if err := c.Bind(&req); err != nil {
+				return err
+			}
+		}
+
+		if len(req.Hosts) == 0 && len(req.URLs) == 0 {
+			s.ClearCache()
+			return c.NoContent(http.StatusNoContent)
+		}
+
+		for _, host := range req.Hosts {
+			s.ClearCacheKey(s.config.GetFlagsURL(c, host))
+		}
+		for _, url := range req.URLs {
+			s.ClearCacheKey(url)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,96 @@
+package echoflags
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// versionServer serves {"*": {"version": N}} for every host, where N is the
+// number of fetches so far
+func versionServer(fetches *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"*": {"version": %d}}`, fetches.Add(1))
+	}))
+}
+
+func TestRefreshAhead(t *testing.T) {
+	var fetches atomic.Int32
+	server := versionServer(&fetches)
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{
+		FlagsBase:    server.URL,
+		CacheTTL:     time.Second,
+		RefreshAhead: 900 * time.Millisecond,
+	})
+	version := func() int {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		return sdk.GetIntWithDefault(e.NewContext(req, httptest.NewRecorder()), "version", 0)
+	}
+
+	assert.Equal(t, 1, version())
+
+	// Still fresh enough, so no refresh
+	assert.Equal(t, 1, version())
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// Within RefreshAhead of expiring: served from cache and refreshed in the background
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, 1, version())
+	require.Eventually(t, func() bool { return version() == 2 }, 500*time.Millisecond, 10*time.Millisecond)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestInvalidateHandler(t *testing.T) {
+	var fetches atomic.Int32
+	server := versionServer(&fetches)
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{FlagsBase: server.URL})
+	version := func(host string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://"+host+"/", nil)
+		return sdk.GetIntWithDefault(e.NewContext(req, httptest.NewRecorder()), "version", 0)
+	}
+	invalidate := func(body string) {
+		req := httptest.NewRequest(http.MethodPost, "/flags/invalidate", strings.NewReader(body))
+		if body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		rec := httptest.NewRecorder()
+		require.NoError(t, sdk.InvalidateHandler()(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+
+	assert.Equal(t, 1, version("tenant1"))
+	assert.Equal(t, 2, version("tenant2"))
+	assert.Equal(t, 1, version("tenant1"))
+
+	t.Run("invalidates named hosts", func(t *testing.T) {
+		invalidate(`{"hosts": ["tenant1"]}`)
+		assert.Equal(t, 3, version("tenant1"))
+		assert.Equal(t, 2, version("tenant2"))
+	})
+
+	t.Run("invalidates URLs", func(t *testing.T) {
+		invalidate(`{"urls": ["` + server.URL + `/tenant2.json"]}`)
+		assert.Equal(t, 4, version("tenant2"))
+		assert.Equal(t, 3, version("tenant1"))
+	})
+
+	t.Run("invalidates everything without a body", func(t *testing.T) {
+		invalidate("")
+		assert.Equal(t, 5, version("tenant1"))
+		assert.Equal(t, 6, version("tenant2"))
+	})
+}
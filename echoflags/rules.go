@@ -0,0 +1,104 @@
+package echoflags
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"slices"
+	"time"
+)
+
+// RulesKey is the HostConfig section holding targeting rules, keyed by flag.
+// Rules are evaluated after wildcard values and before user-specific values.
+const RulesKey = "$rules"
+
+// Rule targets a flag value at a subset of users. A flag in the RulesKey section
+// holds either a single rule or a list of rules, and the first matching rule wins.
+type Rule struct {
+	// Value is the flag value for matching users (default: true)
+	Value interface{} `json:"value"`
+
+	// Percentage rolls the value out to this percentage (0-100) of users, bucketed
+	// by a stable hash of the flag key and user. Anonymous users never match.
+	Percentage *float64 `json:"percentage,omitempty"`
+
+	// Allow lists users who always match, regardless of Percentage
+	Allow []string `json:"allow,omitempty"`
+
+	// Deny lists users who never match
+	Deny []string `json:"deny,omitempty"`
+
+	// Start and End bound the window in which the rule matches (RFC 3339)
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Matches reports whether the rule applies to the user for the flag key at the given time
+func (r *Rule) Matches(key, user string, now time.Time) bool {
+	if slices.Contains(r.Deny, user) {
+		return false
+	}
+	if r.Start != nil && now.Before(*r.Start) {
+		return false
+	}
+	if r.End != nil && !now.Before(*r.End) {
+		return false
+	}
+	if user != "" && slices.Contains(r.Allow, user) {
+		return true
+	}
+	if r.Percentage != nil {
+		return user != "" && rolloutBucket(key, user) < *r.Percentage
+	}
+	return len(r.Allow) == 0
+}
+
+// rolloutBucket places a user in [0, 100) for the flag key, so each flag rolls
+// out to a different, but stable, subset of users
+func rolloutBucket(key, user string) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(user))
+	return float64(h.Sum32()%10000) / 100
+}
+
+// parseRules decodes a single rule or a list of rules
+func parseRules(raw interface{}) []Rule {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+
+	if _, ok := raw.([]interface{}); ok {
+		var rules []Rule
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil
+		}
+		return rules
+	}
+
+	var rule Rule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil
+	}
+	return []Rule{rule}
+}
+
+// evaluateRules returns the value of the first rule for the key matching the user
+func evaluateRules(config HostConfig, key, user string) (interface{}, bool) {
+	raw, ok := config[RulesKey][key]
+	if !ok {
+		return nil, false
+	}
+
+	now := time.Now()
+	for _, rule := range parseRules(raw) {
+		if rule.Matches(key, user, now) {
+			if rule.Value == nil {
+				return true, true
+			}
+			return rule.Value, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,116 @@
+package echoflags
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	config := HostConfig{
+		"*": {
+			"newCheckout": false,
+			"theme":       "light",
+			"promo":       false,
+			"beta":        false,
+		},
+		RulesKey: {
+			"newCheckout": map[string]interface{}{
+				"percentage": 25,
+				"allow":      []string{"qa@example.com"},
+				"deny":       []string{"vip@example.com"},
+			},
+			"theme": []interface{}{
+				map[string]interface{}{"allow": []string{"designer@example.com"}, "value": "dark"},
+				map[string]interface{}{"percentage": 100, "value": "blue"},
+			},
+			"promo": map[string]interface{}{"start": past, "end": future},
+			"beta":  map[string]interface{}{"start": future},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(config)
+	}))
+	defer server.Close()
+
+	e := echo.New()
+	sdk := NewWithConfig(Config{FlagsURL: server.URL})
+
+	ctx := func(user string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "http://tenant1/", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		if user != "" {
+			c.Set("user", user)
+		}
+		return c
+	}
+
+	t.Run("percentage rollout is stable and proportional", func(t *testing.T) {
+		enabled := 0
+		for i := 0; i < 1000; i++ {
+			user := fmt.Sprintf("user%d@example.com", i)
+			first := sdk.IsEnabled(ctx(user), "newCheckout")
+			assert.Equal(t, first, sdk.IsEnabled(ctx(user), "newCheckout"))
+			assert.Equal(t, rolloutBucket("newCheckout", user) < 25, first)
+			if first {
+				enabled++
+			}
+		}
+		assert.InDelta(t, 250, enabled, 50)
+	})
+
+	t.Run("anonymous users are not rolled out", func(t *testing.T) {
+		assert.False(t, sdk.IsEnabled(ctx(""), "newCheckout"))
+	})
+
+	t.Run("allow and deny lists", func(t *testing.T) {
+		assert.True(t, sdk.IsEnabled(ctx("qa@example.com"), "newCheckout"))
+		assert.False(t, sdk.IsEnabled(ctx("vip@example.com"), "newCheckout"))
+	})
+
+	t.Run("user values override rules", func(t *testing.T) {
+		// Rules take effect before user-specific values
+		config := HostConfig{
+			"*":                    {"flag": false},
+			RulesKey:               {"flag": map[string]interface{}{"percentage": 100}},
+			"override@example.com": {"flag": false},
+		}
+		value, err := lookupValueInConfig(config, "flag", "override@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, false, value)
+
+		value, err = lookupValueInConfig(config, "flag", "other@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, true, value)
+	})
+
+	t.Run("first matching rule wins", func(t *testing.T) {
+		assert.Equal(t, "dark", sdk.GetStringWithDefault(ctx("designer@example.com"), "theme", ""))
+		assert.Equal(t, "blue", sdk.GetStringWithDefault(ctx("someone@example.com"), "theme", ""))
+		assert.Equal(t, "light", sdk.GetStringWithDefault(ctx(""), "theme", ""))
+	})
+
+	t.Run("date windows", func(t *testing.T) {
+		assert.True(t, sdk.IsEnabled(ctx("someone@example.com"), "promo"))
+		assert.False(t, sdk.IsEnabled(ctx("someone@example.com"), "beta"))
+	})
+
+	t.Run("debug reports rule provenance", func(t *testing.T) {
+		report, err := sdk.Debug(ctx("qa@example.com"))
+		require.NoError(t, err)
+		assert.Equal(t, SourceHostRule, report.Flags["newCheckout"].Source)
+		assert.Equal(t, []string{SourceHost, SourceHostRule}, report.Flags["newCheckout"].Chain)
+		assert.Equal(t, true, report.Flags["newCheckout"].Value)
+	})
+}
@@ -0,0 +1,82 @@
+package echofly
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// DrainConfig holds configuration for draining a machine before shutdown
+type DrainConfig struct {
+	// Signals start draining when received (default: SIGINT and SIGTERM)
+	Signals []os.Signal
+	// Replay answers requests pinned to this machine with "Fly-Replay: elsewhere=true"
+	// while draining, so their sessions move to other machines
+	Replay bool
+	// OnDrain is called once when draining starts
+	OnDrain func()
+}
+
+// Drainer tracks whether a machine is draining before shutdown. Once draining,
+// the sticky sessions middleware stops pinning sessions to the machine.
+type Drainer struct {
+	config   DrainConfig
+	draining atomic.Bool
+	once     sync.Once
+	stopOnce sync.Once
+	done     chan struct{}
+	signals  chan os.Signal
+}
+
+// Drain returns a Drainer that starts draining when the process receives one of
+// the configured signals. The signals no longer terminate the process, so the
+// application must shut down once Done is closed.
+func Drain(config DrainConfig) *Drainer {
+	if len(config.Signals) == 0 {
+		config.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	d := &Drainer{
+		config:  config,
+		done:    make(chan struct{}),
+		signals: make(chan os.Signal, 1),
+	}
+	signal.Notify(d.signals, config.Signals...)
+	go func() {
+		if _, ok := <-d.signals; ok {
+			d.Start()
+		}
+	}()
+	return d
+}
+
+// Start begins draining, as if one of the configured signals was received
+func (d *Drainer) Start() {
+	d.once.Do(func() {
+		d.draining.Store(true)
+		if d.config.OnDrain != nil {
+			d.config.OnDrain()
+		}
+		close(d.done)
+	})
+}
+
+// Stop stops listening for signals. It doesn't stop draining once started.
+func (d *Drainer) Stop() {
+	d.stopOnce.Do(func() {
+		signal.Stop(d.signals)
+		close(d.signals)
+	})
+}
+
+// Draining reports whether the machine is draining
+func (d *Drainer) Draining() bool {
+	return d.draining.Load()
+}
+
+// Done returns a channel that is closed when draining starts
+func (d *Drainer) Done() <-chan struct{} {
+	return d.done
+}
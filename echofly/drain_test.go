@@ -0,0 +1,116 @@
+package echofly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDrainingEcho(t *testing.T, config DrainConfig) (*echo.Echo, *Drainer) {
+	t.Helper()
+
+	drainer := Drain(config)
+	t.Cleanup(drainer.Stop)
+
+	e := echo.New()
+	e.Use(StickySessionsWithConfig(StickySessionsConfig{Drainer: drainer}))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+	return e, drainer
+}
+
+func TestDrainer_Start(t *testing.T) {
+	calls := 0
+	drainer := Drain(DrainConfig{OnDrain: func() { calls++ }})
+	defer drainer.Stop()
+
+	assert.False(t, drainer.Draining())
+	select {
+	case <-drainer.Done():
+		t.Fatal("Done closed before draining")
+	default:
+	}
+
+	drainer.Start()
+	drainer.Start()
+
+	assert.True(t, drainer.Draining())
+	assert.Equal(t, 1, calls)
+	<-drainer.Done()
+}
+
+func TestDrain_NoNewCookies(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+	e, drainer := newDrainingEcho(t, DrainConfig{})
+	drainer.Start()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Set-Cookie"))
+}
+
+func TestDrain_PinnedSessionServedWithoutReplay(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+	e, drainer := newDrainingEcho(t, DrainConfig{})
+	drainer.Start()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "machine-1"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(FlyReplayHeader))
+}
+
+func TestDrain_ReplayPinnedSessions(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+	e, drainer := newDrainingEcho(t, DrainConfig{Replay: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "machine-1"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	drainer.Start()
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "machine-1"})
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	assert.Equal(t, "elsewhere=true", rec.Header().Get(FlyReplayHeader))
+}
+
+func TestStickySessions_ReleasedSessionMoves(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-2")
+
+	e := echo.New()
+	e.Use(StickySessions())
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	// A session replayed by its draining machine is reassigned here
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: "machine-1"})
+	req.Header.Set(FlyReplaySrcHeader, "instance=machine-1;region=iad;t=1700000000")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(FlyReplayHeader))
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, "machine-2", cookies[0].Value)
+}
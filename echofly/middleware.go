@@ -2,8 +2,12 @@
 package echofly
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -26,6 +30,12 @@ type StickySessionsConfig struct {
 	MaxAge time.Duration
 	// Skipper defines a function to skip middleware
 	Skipper func(c echo.Context) bool
+	// Secret signs the cookie with HMAC-SHA256 when set, so clients can't pin
+	// themselves to arbitrary machines. Invalid cookies are dropped and reissued.
+	Secret []byte
+	// Drainer stops new cookies being issued while the machine drains before
+	// shutdown, and optionally migrates pinned sessions to other machines
+	Drainer *Drainer
 }
 
 // DefaultStickySessionsConfig returns the default configuration
@@ -62,14 +72,30 @@ func StickySessionsWithConfig(config StickySessionsConfig) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			// Get the cookie from the request
-			cookie, err := c.Cookie(config.CookieName)
+			// Get the machine ID from the request's cookie
+			cookieMachineID := ""
+			if cookie, err := c.Cookie(config.CookieName); err == nil {
+				cookieMachineID, _ = config.machineID(cookie.Value)
+			}
+
+			// A session replayed here by the machine it is pinned to was released
+			// by that machine while draining, so it moves to this machine
+			if cookieMachineID != "" && cookieMachineID != machineID && replaySource(c) == cookieMachineID {
+				cookieMachineID = ""
+			}
+
+			draining := config.Drainer != nil && config.Drainer.Draining()
+
+			if cookieMachineID == "" {
+				// Don't pin new sessions to a machine that is shutting down
+				if draining {
+					return next(c)
+				}
 
-			if err != nil || cookie.Value == "" {
-				// No cookie found, set it with current machine ID
+				// No valid cookie found, set it with current machine ID
 				newCookie := &http.Cookie{
 					Name:     config.CookieName,
-					Value:    machineID,
+					Value:    config.cookieValue(machineID),
 					MaxAge:   int(config.MaxAge.Seconds()),
 					Path:     "/",
 					HttpOnly: true,
@@ -80,18 +106,66 @@ func StickySessionsWithConfig(config StickySessionsConfig) echo.MiddlewareFunc {
 			}
 
 			// Cookie exists, check if it matches current machine ID
-			if cookie.Value != machineID {
+			if cookieMachineID != machineID {
 				// Cookie has different machine ID, replay to that instance
-				c.Response().Header().Set(FlyReplayHeader, "instance="+cookie.Value)
+				c.Response().Header().Set(FlyReplayHeader, "instance="+cookieMachineID)
 				return c.NoContent(http.StatusTemporaryRedirect)
 			}
 
-			// Cookie matches current machine, continue normally
+			// Cookie matches current machine, so migrate the session elsewhere
+			// if draining, or continue normally
+			if draining && config.Drainer.config.Replay {
+				c.Response().Header().Set(FlyReplayHeader, "elsewhere=true")
+				return c.NoContent(http.StatusTemporaryRedirect)
+			}
 			return next(c)
 		}
 	}
 }
 
+// replaySource returns the ID of the machine that replayed the request, if any
+func replaySource(c echo.Context) string {
+	for _, field := range strings.Split(c.Request().Header.Get(FlyReplaySrcHeader), ";") {
+		if instance, ok := strings.CutPrefix(strings.TrimSpace(field), "instance="); ok {
+			return instance
+		}
+	}
+	return ""
+}
+
+// cookieValue returns the cookie value pinning a session to a machine, signed
+// as "<machine ID>.<signature>" when a secret is configured
+func (config StickySessionsConfig) cookieValue(machineID string) string {
+	if len(config.Secret) == 0 {
+		return machineID
+	}
+	return machineID + "." + config.sign(machineID)
+}
+
+// machineID returns the machine ID in a cookie value and whether it is valid.
+// Signed values with a missing or wrong signature are invalid.
+func (config StickySessionsConfig) machineID(value string) (string, bool) {
+	if len(config.Secret) == 0 {
+		return value, value != ""
+	}
+
+	machineID, signature, ok := strings.Cut(value, ".")
+	if !ok || machineID == "" {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(config.sign(machineID))) {
+		return "", false
+	}
+	return machineID, true
+}
+
+// sign returns the base64url-encoded HMAC-SHA256 of a machine ID
+func (config StickySessionsConfig) sign(machineID string) string {
+	mac := hmac.New(sha256.New, config.Secret)
+	mac.Write([]byte(machineID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
 // StickySessions returns a middleware function with default configuration
 func StickySessions() echo.MiddlewareFunc {
 	return StickySessionsWithConfig(DefaultStickySessionsConfig())
@@ -320,3 +320,63 @@ func TestStickySessionsIntegration(t *testing.T) {
 	assert.Equal(t, http.StatusTemporaryRedirect, rec4.Code)
 	assert.Equal(t, "instance=different-machine-id", rec4.Header().Get("Fly-Replay"))
 }
+
+func TestStickySessionsWithConfig_Signed(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+
+	config := StickySessionsConfig{Secret: []byte("secret")}
+	e := echo.New()
+	e.Use(StickySessionsWithConfig(config))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	// First request gets a signed cookie
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, strings.HasPrefix(cookies[0].Value, "machine-1."))
+
+	// A validly signed cookie for another machine is replayed
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: CookieName, Value: config.cookieValue("machine-2")})
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	assert.Equal(t, "instance=machine-2", rec.Header().Get(FlyReplayHeader))
+}
+
+func TestStickySessionsWithConfig_ForgedCookie(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+
+	config := StickySessionsConfig{Secret: []byte("secret")}
+	e := echo.New()
+	e.Use(StickySessionsWithConfig(config))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, "test")
+	})
+
+	forged := []string{
+		"machine-2",
+		"machine-2.",
+		"machine-2." + StickySessionsConfig{Secret: []byte("other")}.sign("machine-2"),
+		"." + config.sign(""),
+	}
+	for _, value := range forged {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: CookieName, Value: value})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		// Forged cookies are dropped and reissued for this machine
+		assert.Equal(t, http.StatusOK, rec.Code, value)
+		assert.Empty(t, rec.Header().Get(FlyReplayHeader), value)
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 1, value)
+		assert.Equal(t, config.cookieValue("machine-1"), cookies[0].Value, value)
+	}
+}
@@ -0,0 +1,88 @@
+package echofly
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FlyReplaySrcHeader is the header Fly.io adds to requests it has replayed
+const FlyReplaySrcHeader = "Fly-Replay-Src"
+
+// PrimaryRegionConfig holds configuration for the primary region middleware
+type PrimaryRegionConfig struct {
+	// PrimaryRegion is the region that handles writes (default: $PRIMARY_REGION)
+	PrimaryRegion string
+	// IsWrite reports whether a request must be handled in the primary region
+	// (default: any method other than GET, HEAD and OPTIONS)
+	IsWrite func(c echo.Context) bool
+	// Skipper defines a function to skip middleware
+	Skipper func(c echo.Context) bool
+}
+
+// DefaultPrimaryRegionConfig returns the default configuration
+func DefaultPrimaryRegionConfig() PrimaryRegionConfig {
+	return PrimaryRegionConfig{
+		PrimaryRegion: os.Getenv("PRIMARY_REGION"),
+		IsWrite:       IsWriteMethod,
+		Skipper:       nil,
+	}
+}
+
+// IsWriteMethod reports whether the request method may modify data
+func IsWriteMethod(c echo.Context) bool {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// PrimaryRegionWithConfig returns a middleware function that replays writes
+// handled outside the primary region to it, with custom configuration
+func PrimaryRegionWithConfig(config PrimaryRegionConfig) echo.MiddlewareFunc {
+	// Set defaults
+	if config.PrimaryRegion == "" {
+		config.PrimaryRegion = os.Getenv("PRIMARY_REGION")
+	}
+	if config.IsWrite == nil {
+		config.IsWrite = IsWriteMethod
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// Skip middleware if skipper function returns true
+			if config.Skipper != nil && config.Skipper(c) {
+				return next(c)
+			}
+
+			// Get the current region from environment
+			region := os.Getenv("FLY_REGION")
+
+			// If not running on Fly.io or there is no primary region, skip the middleware
+			if region == "" || config.PrimaryRegion == "" || region == config.PrimaryRegion {
+				return next(c)
+			}
+
+			// Don't replay a request that was already replayed, to avoid loops
+			// when the primary region has no machines available
+			if c.Request().Header.Get(FlyReplaySrcHeader) != "" {
+				return next(c)
+			}
+
+			if config.IsWrite(c) {
+				c.Response().Header().Set(FlyReplayHeader, "region="+config.PrimaryRegion)
+				return c.NoContent(http.StatusTemporaryRedirect)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// PrimaryRegion returns a middleware function that replays writes to
+// $PRIMARY_REGION with default configuration
+func PrimaryRegion() echo.MiddlewareFunc {
+	return PrimaryRegionWithConfig(DefaultPrimaryRegionConfig())
+}
@@ -0,0 +1,131 @@
+package echofly
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPrimaryRegionEcho(mw echo.MiddlewareFunc) *echo.Echo {
+	e := echo.New()
+	e.Use(mw)
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "handled")
+	}
+	e.GET("/", handler)
+	e.POST("/", handler)
+	return e
+}
+
+func TestPrimaryRegion_ReplaysWrites(t *testing.T) {
+	t.Setenv("FLY_REGION", "lhr")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegion())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	assert.Equal(t, "region=iad", rec.Header().Get(FlyReplayHeader))
+}
+
+func TestPrimaryRegion_ServesReads(t *testing.T) {
+	t.Setenv("FLY_REGION", "lhr")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegion())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "handled", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(FlyReplayHeader))
+}
+
+func TestPrimaryRegion_InPrimaryRegion(t *testing.T) {
+	t.Setenv("FLY_REGION", "iad")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegion())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(FlyReplayHeader))
+}
+
+func TestPrimaryRegion_NotOnFly(t *testing.T) {
+	t.Setenv("FLY_REGION", "")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegion())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPrimaryRegion_AlreadyReplayed(t *testing.T) {
+	t.Setenv("FLY_REGION", "lhr")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegion())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(FlyReplaySrcHeader, "instance=abc;region=lhr;t=1")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(FlyReplayHeader))
+}
+
+func TestPrimaryRegionWithConfig_CustomIsWrite(t *testing.T) {
+	t.Setenv("FLY_REGION", "lhr")
+
+	e := newPrimaryRegionEcho(PrimaryRegionWithConfig(PrimaryRegionConfig{
+		PrimaryRegion: "ord",
+		IsWrite: func(c echo.Context) bool {
+			return c.QueryParam("write") == "1"
+		},
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/?write=1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, rec.Code)
+	assert.Equal(t, "region=ord", rec.Header().Get(FlyReplayHeader))
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestPrimaryRegionWithConfig_Skipper(t *testing.T) {
+	t.Setenv("FLY_REGION", "lhr")
+	t.Setenv("PRIMARY_REGION", "iad")
+
+	e := newPrimaryRegionEcho(PrimaryRegionWithConfig(PrimaryRegionConfig{
+		Skipper: func(c echo.Context) bool { return true },
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,76 @@
+package echofly
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Status is the machine report returned by StatusHandler
+type Status struct {
+	MachineID      string       `json:"machine_id"`
+	Region         string       `json:"region"`
+	AppName        string       `json:"app_name,omitempty"`
+	MemoryMB       int          `json:"memory_mb"`
+	HeapAllocBytes uint64       `json:"heap_alloc_bytes"`
+	Goroutines     int          `json:"goroutines"`
+	Sticky         StickyStatus `json:"sticky"`
+}
+
+// StickyStatus reports the request's sticky session cookie
+type StickyStatus struct {
+	// CookieName is the name of the sticky session cookie
+	CookieName string `json:"cookie_name"`
+	// MachineID is the machine ID in the cookie, if any
+	MachineID string `json:"machine_id,omitempty"`
+	// Present reports whether the request has the cookie
+	Present bool `json:"present"`
+	// Valid reports whether the cookie's signature is valid, or the cookie
+	// is present when cookies aren't signed
+	Valid bool `json:"valid"`
+	// Matches reports whether the cookie pins the request to this machine
+	Matches bool `json:"matches"`
+}
+
+// StatusHandler returns a handler reporting the machine's ID, region, memory
+// and goroutines, and the request's sticky session cookie, as JSON
+func StatusHandler() echo.HandlerFunc {
+	return StatusHandlerWithConfig(DefaultStickySessionsConfig())
+}
+
+// StatusHandlerWithConfig returns a status handler reporting the sticky
+// session cookie of the given sticky sessions configuration
+func StatusHandlerWithConfig(config StickySessionsConfig) echo.HandlerFunc {
+	if config.CookieName == "" {
+		config.CookieName = CookieName
+	}
+
+	return func(c echo.Context) error {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		// Fly.io sets the machine's allocated memory in megabytes
+		memoryMB, _ := strconv.Atoi(os.Getenv("FLY_VM_MEMORY_MB"))
+
+		status := Status{
+			MachineID:      os.Getenv("FLY_MACHINE_ID"),
+			Region:         os.Getenv("FLY_REGION"),
+			AppName:        os.Getenv("FLY_APP_NAME"),
+			MemoryMB:       memoryMB,
+			HeapAllocBytes: mem.HeapAlloc,
+			Goroutines:     runtime.NumGoroutine(),
+			Sticky:         StickyStatus{CookieName: config.CookieName},
+		}
+
+		if cookie, err := c.Cookie(config.CookieName); err == nil && cookie.Value != "" {
+			status.Sticky.Present = true
+			status.Sticky.MachineID, status.Sticky.Valid = config.machineID(cookie.Value)
+			status.Sticky.Matches = status.Sticky.Valid && status.Sticky.MachineID == status.MachineID
+		}
+
+		return c.JSON(http.StatusOK, status)
+	}
+}
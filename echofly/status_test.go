@@ -0,0 +1,87 @@
+package echofly
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getStatus(t *testing.T, handler echo.HandlerFunc, cookie *http.Cookie) Status {
+	t.Helper()
+
+	e := echo.New()
+	e.GET("/fly/status", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/fly/status", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status Status
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return status
+}
+
+func TestStatusHandler(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+	t.Setenv("FLY_REGION", "iad")
+	t.Setenv("FLY_APP_NAME", "my-app")
+	t.Setenv("FLY_VM_MEMORY_MB", "512")
+
+	status := getStatus(t, StatusHandler(), &http.Cookie{Name: CookieName, Value: "machine-1"})
+
+	assert.Equal(t, "machine-1", status.MachineID)
+	assert.Equal(t, "iad", status.Region)
+	assert.Equal(t, "my-app", status.AppName)
+	assert.Equal(t, 512, status.MemoryMB)
+	assert.Positive(t, status.Goroutines)
+	assert.Positive(t, status.HeapAllocBytes)
+	assert.Equal(t, StickyStatus{CookieName: CookieName, MachineID: "machine-1", Present: true, Valid: true, Matches: true}, status.Sticky)
+}
+
+func TestStatusHandler_NoCookie(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+
+	status := getStatus(t, StatusHandler(), nil)
+
+	assert.False(t, status.Sticky.Present)
+	assert.False(t, status.Sticky.Matches)
+	assert.Empty(t, status.Sticky.MachineID)
+}
+
+func TestStatusHandlerWithConfig_OtherMachine(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+
+	handler := StatusHandlerWithConfig(StickySessionsConfig{CookieName: "custom"})
+	status := getStatus(t, handler, &http.Cookie{Name: "custom", Value: "machine-2"})
+
+	assert.Equal(t, "custom", status.Sticky.CookieName)
+	assert.Equal(t, "machine-2", status.Sticky.MachineID)
+	assert.True(t, status.Sticky.Present)
+	assert.False(t, status.Sticky.Matches)
+}
+
+func TestStatusHandlerWithConfig_Signed(t *testing.T) {
+	t.Setenv("FLY_MACHINE_ID", "machine-1")
+
+	config := StickySessionsConfig{Secret: []byte("secret")}
+	handler := StatusHandlerWithConfig(config)
+
+	status := getStatus(t, handler, &http.Cookie{Name: CookieName, Value: config.cookieValue("machine-1")})
+	assert.Equal(t, "machine-1", status.Sticky.MachineID)
+	assert.True(t, status.Sticky.Valid)
+	assert.True(t, status.Sticky.Matches)
+
+	status = getStatus(t, handler, &http.Cookie{Name: CookieName, Value: "machine-1"})
+	assert.True(t, status.Sticky.Present)
+	assert.False(t, status.Sticky.Valid)
+	assert.False(t, status.Sticky.Matches)
+}
@@ -0,0 +1,145 @@
+package echogoog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DirectoryGroupsScope is the OAuth2 scope DirectoryGroups needs
+const DirectoryGroupsScope = "https://www.googleapis.com/auth/admin.directory.group.readonly"
+
+// directoryGroupsURL is the Admin SDK Directory API endpoint listing groups
+const directoryGroupsURL = "https://admin.googleapis.com/admin/directory/v1/groups"
+
+// GroupsFunc returns the email addresses of the groups a user belongs to
+type GroupsFunc func(ctx context.Context, user *UserInfo) ([]string, error)
+
+// groupsEntry is a user's groups cached by the middleware
+type groupsEntry struct {
+	groups  []string
+	expires time.Time
+}
+
+// DirectoryGroups returns a GroupsFunc that looks up the groups a user is a
+// direct member of with the Admin SDK Directory API. The client must be
+// authorized for DirectoryGroupsScope, typically by a service account with
+// domain-wide delegation impersonating a Workspace administrator.
+func DirectoryGroups(client *http.Client) GroupsFunc {
+	return func(ctx context.Context, user *UserInfo) ([]string, error) {
+		var groups []string
+		pageToken := ""
+		for {
+			query := url.Values{"userKey": {user.Email}}
+			if pageToken != "" {
+				query.Set("pageToken", pageToken)
+			}
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, directoryGroupsURL+"?"+query.Encode(), nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list groups: %w", err)
+			}
+
+			var page struct {
+				Groups []struct {
+					Email string `json:"email"`
+				} `json:"groups"`
+				NextPageToken string `json:"nextPageToken"`
+			}
+			if resp.StatusCode != http.StatusOK {
+				resp.Body.Close()
+				return nil, fmt.Errorf("failed to list groups: %s", resp.Status)
+			}
+			err = json.NewDecoder(resp.Body).Decode(&page)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode groups: %w", err)
+			}
+
+			for _, group := range page.Groups {
+				groups = append(groups, group.Email)
+			}
+			if page.NextPageToken == "" {
+				return groups, nil
+			}
+			pageToken = page.NextPageToken
+		}
+	}
+}
+
+// ProtectWithGroups returns an Echo middleware that requires authentication
+// and membership in at least one of the given groups. Users in none of them
+// receive 403 Forbidden. It panics if Config.Groups is not set.
+func (m *Middleware) ProtectWithGroups(groups ...string) echo.MiddlewareFunc {
+	if m.config.Groups == nil {
+		panic("echogoog: ProtectWithGroups requires Config.Groups")
+	}
+
+	protect := m.Protect()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return protect(func(c echo.Context) error {
+			user, err := GetUser(c)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+			}
+
+			member, err := m.userGroups(c.Request().Context(), user)
+			if err != nil {
+				c.Logger().Errorf("failed to resolve groups for %s: %v", user.Email, err)
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve group membership")
+			}
+			if !inAnyGroup(member, groups) {
+				return echo.NewHTTPError(http.StatusForbidden, "Not a member of a required group")
+			}
+			return next(c)
+		})
+	}
+}
+
+// userGroups returns the user's groups, resolving them at most once per GroupsCacheTTL
+func (m *Middleware) userGroups(ctx context.Context, user *UserInfo) ([]string, error) {
+	m.groupsMu.Lock()
+	entry, ok := m.groupsCache[user.Sub]
+	m.groupsMu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.groups, nil
+	}
+
+	groups, err := m.config.Groups(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	m.groupsMu.Lock()
+	defer m.groupsMu.Unlock()
+	now := time.Now()
+	for sub, entry := range m.groupsCache {
+		if now.After(entry.expires) {
+			delete(m.groupsCache, sub)
+		}
+	}
+	m.groupsCache[user.Sub] = groupsEntry{groups: groups, expires: now.Add(m.config.GroupsCacheTTL)}
+	return groups, nil
+}
+
+// inAnyGroup reports whether any of the member groups is one of the required groups
+func inAnyGroup(member, required []string) bool {
+	for _, group := range member {
+		for _, want := range required {
+			if strings.EqualFold(group, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -8,7 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/labstack/echo/v4"
@@ -57,6 +60,27 @@ type Config struct {
 	// SessionMaxAge is the max age of the session cookie in seconds (default: 86400 = 24 hours)
 	SessionMaxAge int
 
+	// SessionStore keeps sessions, including ID and refresh tokens, server-side
+	// When set, the session cookie holds only an opaque session ID
+	// Default: nil (user info is stored in the session cookie itself)
+	SessionStore SessionStore
+
+	// OfflineAccess requests a refresh token and refreshes the access token before
+	// it expires, extending the session so active users aren't sent back through
+	// Google login every SessionMaxAge. Requires SessionStore.
+	OfflineAccess bool
+
+	// RefreshLeeway is how long before expiry the access token is refreshed (default: 5 minutes)
+	RefreshLeeway time.Duration
+
+	// Groups resolves the Google Groups a user belongs to, for ProtectWithGroups
+	// Use DirectoryGroups for the Admin SDK Directory API, or a custom function
+	// mapping users to groups from any other source
+	Groups GroupsFunc
+
+	// GroupsCacheTTL is how long a user's resolved groups are cached (default: 5 minutes)
+	GroupsCacheTTL time.Duration
+
 	// CookieSecure sets the Secure flag on cookies (should be true in production)
 	CookieSecure bool
 
@@ -80,6 +104,17 @@ type Config struct {
 
 	// SuccessRedirect is the URL to redirect to after successful authentication
 	SuccessRedirect string
+
+	// LogoutRedirect is the URL to redirect to after logout (default: "/")
+	LogoutRedirect string
+
+	// RevokeOnLogout revokes the user's Google tokens at logout, so the app's
+	// access must be granted again at the next login. Requires SessionStore.
+	RevokeOnLogout bool
+
+	// GoogleLogout also signs the user out of their Google account at logout,
+	// redirecting through Google's logout page and back to LogoutRedirect
+	GoogleLogout bool
 }
 
 // UserInfo represents the authenticated user's information
@@ -100,11 +135,21 @@ type Middleware struct {
 	oauth2Config *oauth2.Config
 	verifier     *oidc.IDTokenVerifier
 	provider     *oidc.Provider
+
+	groupsMu    sync.Mutex
+	groupsCache map[string]groupsEntry
 }
 
+// Google endpoints used at logout
+const (
+	googleRevokeURL = "https://oauth2.googleapis.com/revoke"
+	googleLogoutURL = "https://accounts.google.com/Logout"
+)
+
 const (
-	contextKeyUser = "google_openid_user"
-	stateKey       = "google_openid_state"
+	contextKeyUser    = "google_openid_user"
+	contextKeySession = "google_openid_session"
+	stateKey          = "google_openid_state"
 )
 
 // New creates a new Google OpenID middleware with the given configuration
@@ -121,6 +166,12 @@ func New(config *Config) (*Middleware, error) {
 	if config.RedirectURL != "" && config.RedirectPath != "" {
 		return nil, errors.New("cannot specify both RedirectURL and RedirectPath")
 	}
+	if config.OfflineAccess && config.SessionStore == nil {
+		return nil, errors.New("OfflineAccess requires a SessionStore")
+	}
+	if config.RevokeOnLogout && config.SessionStore == nil {
+		return nil, errors.New("RevokeOnLogout requires a SessionStore")
+	}
 
 	// Normalize RedirectPath to ensure it starts with exactly one leading "/"
 	if config.RedirectPath != "" {
@@ -135,6 +186,12 @@ func New(config *Config) (*Middleware, error) {
 	if config.SessionMaxAge == 0 {
 		config.SessionMaxAge = 86400 // 24 hours
 	}
+	if config.RefreshLeeway == 0 {
+		config.RefreshLeeway = 5 * time.Minute
+	}
+	if config.GroupsCacheTTL == 0 {
+		config.GroupsCacheTTL = 5 * time.Minute
+	}
 	if config.CookieSameSite == 0 {
 		config.CookieSameSite = http.SameSiteLaxMode
 	}
@@ -147,6 +204,9 @@ func New(config *Config) (*Middleware, error) {
 	if config.LogoutPath == "" {
 		config.LogoutPath = "/auth/google/logout"
 	}
+	if config.LogoutRedirect == "" {
+		config.LogoutRedirect = "/"
+	}
 	if len(config.Scopes) == 0 {
 		config.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
 	}
@@ -184,6 +244,7 @@ func New(config *Config) (*Middleware, error) {
 		oauth2Config: oauth2Config,
 		verifier:     verifier,
 		provider:     provider,
+		groupsCache:  make(map[string]groupsEntry),
 	}, nil
 }
 
@@ -198,16 +259,20 @@ func (m *Middleware) RegisterRoutes(e *echo.Echo) {
 func (m *Middleware) Protect() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := m.getUserFromSession(c)
-			if err != nil || user == nil {
+			session, err := m.getSession(c)
+			if err == nil && m.needsRefresh(session) {
+				err = m.refreshSession(c, session)
+			}
+			if err != nil || session == nil {
 				if m.config.UnauthorizedHandler != nil {
 					return m.config.UnauthorizedHandler(c)
 				}
 				return c.Redirect(http.StatusTemporaryRedirect, m.config.LoginPath)
 			}
 
-			// Store user in context
-			c.Set(contextKeyUser, user)
+			// Store user and session in context
+			c.Set(contextKeyUser, &session.User)
+			c.Set(contextKeySession, session)
 			return next(c)
 		}
 	}
@@ -226,6 +291,25 @@ func GetUser(c echo.Context) (*UserInfo, error) {
 	return userInfo, nil
 }
 
+// GetToken retrieves the authenticated user's OAuth2 token from the request
+// context, for calling Google APIs on their behalf. It requires a SessionStore,
+// and the token is kept live by OfflineAccess.
+func GetToken(c echo.Context) (*oauth2.Token, error) {
+	session, ok := c.Get(contextKeySession).(*Session)
+	if !ok || session == nil {
+		return nil, errors.New("session not found in context")
+	}
+	if session.AccessToken == "" {
+		return nil, errors.New("no access token in session (SessionStore is required)")
+	}
+
+	token := session.Token()
+	if !token.Valid() {
+		return nil, errors.New("access token has expired")
+	}
+	return token, nil
+}
+
 // handleLogin initiates the OAuth2 flow
 func (m *Middleware) handleLogin(c echo.Context) error {
 	state, err := generateRandomState()
@@ -243,7 +327,13 @@ func (m *Middleware) handleLogin(c echo.Context) error {
 	}
 
 	// Build authorization URL with hd parameter if hosted domains are specified
-	authURL := oauth2Cfg.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	// Force the consent prompt for offline access, as Google only issues a
+	// refresh token when the user grants consent
+	var opts []oauth2.AuthCodeOption
+	if m.config.OfflineAccess {
+		opts = append(opts, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	}
+	authURL := oauth2Cfg.AuthCodeURL(state, opts...)
 
 	// Add hosted domain hint if only one domain is allowed
 	if len(m.config.AllowedHostedDomains) == 1 {
@@ -309,14 +399,16 @@ func (m *Middleware) handleCallback(c echo.Context) error {
 	}
 
 	// Store user in session
-	userJSON, err := json.Marshal(userInfo)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to serialize user info")
+	session := &Session{
+		User:         userInfo,
+		IDToken:      rawIDToken,
+		AccessToken:  oauth2Token.AccessToken,
+		RefreshToken: oauth2Token.RefreshToken,
+		Expiry:       oauth2Token.Expiry,
+	}
+	if err := m.saveSession(c, session); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to save session")
 	}
-
-	m.setSessionCookie(c, m.config.SessionCookieName,
-		base64.StdEncoding.EncodeToString(userJSON),
-		m.config.SessionMaxAge)
 
 	// Redirect to success page
 	redirectURL := m.config.SuccessRedirect
@@ -327,10 +419,77 @@ func (m *Middleware) handleCallback(c echo.Context) error {
 	return c.Redirect(http.StatusTemporaryRedirect, redirectURL)
 }
 
-// handleLogout clears the session
+// handleLogout clears the session, optionally revoking the user's tokens and
+// signing them out of Google
 func (m *Middleware) handleLogout(c echo.Context) error {
+	ctx := c.Request().Context()
+	if m.config.SessionStore != nil {
+		if cookie, err := c.Cookie(m.config.SessionCookieName); err == nil {
+			if m.config.RevokeOnLogout {
+				if session, err := m.config.SessionStore.Get(ctx, cookie.Value); err == nil {
+					if err := revokeToken(ctx, session); err != nil {
+						c.Logger().Errorf("failed to revoke token: %v", err)
+					}
+				}
+			}
+			if err := m.config.SessionStore.Delete(ctx, cookie.Value); err != nil {
+				c.Logger().Errorf("failed to delete session: %v", err)
+			}
+		}
+	}
 	m.clearCookie(c, m.config.SessionCookieName)
-	return c.Redirect(http.StatusTemporaryRedirect, "/")
+
+	if m.config.GoogleLogout {
+		return c.Redirect(http.StatusTemporaryRedirect, m.googleLogoutURL(c))
+	}
+	return c.Redirect(http.StatusTemporaryRedirect, m.config.LogoutRedirect)
+}
+
+// revokeToken revokes the session's refresh token, which also revokes its
+// access tokens, or its access token if there is no refresh token
+func revokeToken(ctx context.Context, session *Session) error {
+	token := session.RefreshToken
+	if token == "" {
+		token = session.AccessToken
+	}
+	if token == "" {
+		return nil
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, googleRevokeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revocation endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+// googleLogoutURL returns Google's logout URL, continuing to LogoutRedirect.
+// Google only follows continue URLs through its App Engine logout, so the
+// redirect is chained through it.
+func (m *Middleware) googleLogoutURL(c echo.Context) string {
+	redirectURL := m.config.LogoutRedirect
+	if strings.HasPrefix(redirectURL, "/") {
+		host := m.getHost(c)
+		if len(m.config.AllowedRedirectHosts) > 0 && !m.isHostAllowed(host) {
+			return googleLogoutURL
+		}
+		redirectURL = fmt.Sprintf("%s://%s%s", m.getScheme(c), host, redirectURL)
+	}
+
+	appEngineLogout := "https://appengine.google.com/_ah/logout?continue=" + url.QueryEscape(redirectURL)
+	return googleLogoutURL + "?continue=" + url.QueryEscape(appEngineLogout)
 }
 
 // isHostedDomainAllowed checks if the hosted domain is in the allowed list
@@ -347,13 +506,17 @@ func (m *Middleware) isHostedDomainAllowed(domain string) bool {
 	return false
 }
 
-// getUserFromSession retrieves user info from the session cookie
-func (m *Middleware) getUserFromSession(c echo.Context) (*UserInfo, error) {
+// getSession retrieves the session, which holds only user info without a SessionStore
+func (m *Middleware) getSession(c echo.Context) (*Session, error) {
 	cookie, err := c.Cookie(m.config.SessionCookieName)
 	if err != nil {
 		return nil, err
 	}
 
+	if m.config.SessionStore != nil {
+		return m.config.SessionStore.Get(c.Request().Context(), cookie.Value)
+	}
+
 	userJSON, err := base64.StdEncoding.DecodeString(cookie.Value)
 	if err != nil {
 		return nil, err
@@ -364,7 +527,94 @@ func (m *Middleware) getUserFromSession(c echo.Context) (*UserInfo, error) {
 		return nil, err
 	}
 
-	return &userInfo, nil
+	return &Session{User: userInfo}, nil
+}
+
+// needsRefresh reports whether the session's access token should be refreshed
+func (m *Middleware) needsRefresh(session *Session) bool {
+	if !m.config.OfflineAccess || session.RefreshToken == "" || session.Expiry.IsZero() {
+		return false
+	}
+	return time.Until(session.Expiry) < m.config.RefreshLeeway
+}
+
+// refreshSession exchanges the refresh token for new tokens, re-checks the
+// user's claims and saves the session for another SessionMaxAge
+func (m *Middleware) refreshSession(c echo.Context, session *Session) error {
+	ctx := c.Request().Context()
+
+	// Refreshing doesn't use the redirect URL, so the shared config is safe here
+	expired := &oauth2.Token{RefreshToken: session.RefreshToken, Expiry: time.Now()}
+	token, err := m.oauth2Config.TokenSource(ctx, expired).Token()
+	if err != nil {
+		return fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	session.AccessToken = token.AccessToken
+	session.Expiry = token.Expiry
+	if token.RefreshToken != "" {
+		session.RefreshToken = token.RefreshToken
+	}
+
+	if rawIDToken, ok := token.Extra("id_token").(string); ok {
+		idToken, err := m.verifier.Verify(ctx, rawIDToken)
+		if err != nil {
+			return fmt.Errorf("failed to verify refreshed ID token: %w", err)
+		}
+		var userInfo UserInfo
+		if err := idToken.Claims(&userInfo); err != nil {
+			return fmt.Errorf("failed to parse refreshed claims: %w", err)
+		}
+		if len(m.config.AllowedHostedDomains) > 0 && !m.isHostedDomainAllowed(userInfo.HostedDomain) {
+			return fmt.Errorf("domain %q is not allowed", userInfo.HostedDomain)
+		}
+		session.User = userInfo
+		session.IDToken = rawIDToken
+	}
+
+	cookie, err := c.Cookie(m.config.SessionCookieName)
+	if err != nil {
+		return err
+	}
+	return m.storeSession(c, cookie.Value, session)
+}
+
+// saveSession stores the session and sets the session cookie. Without a
+// SessionStore only the user info is kept, in the cookie itself.
+func (m *Middleware) saveSession(c echo.Context, session *Session) error {
+	if m.config.SessionStore == nil {
+		userJSON, err := json.Marshal(session.User)
+		if err != nil {
+			return err
+		}
+		m.setSessionCookie(c, m.config.SessionCookieName,
+			base64.StdEncoding.EncodeToString(userJSON),
+			m.config.SessionMaxAge)
+		return nil
+	}
+
+	// Replace any previous session rather than reusing its ID
+	if cookie, err := c.Cookie(m.config.SessionCookieName); err == nil {
+		_ = m.config.SessionStore.Delete(c.Request().Context(), cookie.Value)
+	}
+
+	id, err := generateRandomState()
+	if err != nil {
+		return err
+	}
+	return m.storeSession(c, id, session)
+}
+
+// storeSession saves the session in the SessionStore under id and sets the
+// session cookie, both expiring after SessionMaxAge
+func (m *Middleware) storeSession(c echo.Context, id string, session *Session) error {
+	ttl := time.Duration(m.config.SessionMaxAge) * time.Second
+	if err := m.config.SessionStore.Save(c.Request().Context(), id, session, ttl); err != nil {
+		return err
+	}
+
+	m.setSessionCookie(c, m.config.SessionCookieName, id, m.config.SessionMaxAge)
+	return nil
 }
 
 // setSessionCookie sets a session cookie
@@ -0,0 +1,154 @@
+package echogoog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/oauth2"
+)
+
+// ErrSessionNotFound is returned by a SessionStore when a session doesn't exist or has expired
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session holds the state of an authenticated user kept by a SessionStore
+type Session struct {
+	User         UserInfo  `json:"user"`
+	IDToken      string    `json:"id_token,omitempty"`
+	AccessToken  string    `json:"access_token,omitempty"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Token returns the session's OAuth2 token
+func (s *Session) Token() *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  s.AccessToken,
+		TokenType:    "Bearer",
+		RefreshToken: s.RefreshToken,
+		Expiry:       s.Expiry,
+	}
+}
+
+// SessionStore keeps sessions server-side, keyed by the opaque session ID
+// stored in the session cookie
+type SessionStore interface {
+	// Get returns the session with the given ID, or ErrSessionNotFound
+	Get(ctx context.Context, id string) (*Session, error)
+
+	// Save stores a session under the given ID, expiring it after ttl
+	Save(ctx context.Context, id string, session *Session, ttl time.Duration) error
+
+	// Delete removes the session with the given ID, if any
+	Delete(ctx context.Context, id string) error
+}
+
+// memoryEntry is a session held by a MemoryStore
+type memoryEntry struct {
+	session Session
+	expires time.Time
+}
+
+// MemoryStore is a SessionStore that keeps sessions in process memory. Sessions
+// are lost on restart and not shared between instances, so it suits development
+// and single-instance deployments.
+type MemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]memoryEntry
+}
+
+// NewMemoryStore creates an empty in-memory session store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sessions: make(map[string]memoryEntry)}
+}
+
+// Get returns a copy of the session with the given ID
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if time.Now().After(entry.expires) {
+		delete(s.sessions, id)
+		return nil, ErrSessionNotFound
+	}
+
+	session := entry.session
+	return &session, nil
+}
+
+// Save stores a copy of the session, removing any expired sessions
+func (s *MemoryStore) Save(ctx context.Context, id string, session *Session, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.sessions {
+		if now.After(entry.expires) {
+			delete(s.sessions, key)
+		}
+	}
+
+	s.sessions[id] = memoryEntry{session: *session, expires: now.Add(ttl)}
+	return nil
+}
+
+// Delete removes the session with the given ID
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+// RedisStore is a SessionStore that keeps sessions in Redis as JSON, so they are
+// shared between instances and survive restarts
+type RedisStore struct {
+	client redis.UniversalClient
+
+	// Prefix is prepended to session IDs to form Redis keys (default: "echogoog:session:")
+	Prefix string
+}
+
+// NewRedisStore creates a session store using the given Redis client
+func NewRedisStore(client redis.UniversalClient) *RedisStore {
+	return &RedisStore{client: client, Prefix: "echogoog:session:"}
+}
+
+// Get loads the session with the given ID from Redis
+func (s *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	data, err := s.client.Get(ctx, s.Prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save stores the session in Redis with the given expiry
+func (s *RedisStore) Save(ctx context.Context, id string, session *Session, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.Prefix+id, data, ttl).Err()
+}
+
+// Delete removes the session with the given ID from Redis
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	return s.client.Del(ctx, s.Prefix+id).Err()
+}
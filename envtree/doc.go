@@ -70,6 +70,29 @@ All three .env files will be loaded, with variables in myapp/.env
 taking precedence over those in projects/.env, which in turn take
 precedence over those in /.env.
 
+# File Formats
+
+Alongside .env, each directory is searched for .env.yaml, .env.yml and
+.env.toml. Nested keys are joined with "_" and lists with ",". Set
+Config.FileFormat to parse a single file name in a fixed format instead.
+
+# Encrypted Files
+
+.env.enc files encrypted with age or sops are decrypted using the identity from
+Config.AgeIdentity, Config.AgeIdentityFile, ENVTREE_AGE_KEY or
+ENVTREE_AGE_KEY_FILE.
+
+# Interpolation
+
+Values may reference other variables as $VAR, ${VAR}, ${VAR:-default} or
+${VAR-default}. References are expanded after files are merged, resolving to
+the closest definition and then the process environment. Use $$ for a literal $.
+
+# Watching
+
+Loader.Watch reloads the tree when env files change and reports the added,
+changed and removed keys to a callback.
+
 # Configuration Options
 
 The Config struct provides fine-grained control:
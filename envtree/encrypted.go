@@ -0,0 +1,107 @@
+package envtree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Environment variables consulted for age identities when the Config has none
+const (
+	AgeKeyEnv     = "ENVTREE_AGE_KEY"
+	AgeKeyFileEnv = "ENVTREE_AGE_KEY_FILE"
+)
+
+// sopsCommand is the sops executable used to decrypt sops files
+var sopsCommand = "sops"
+
+// sopsMetadata matches the metadata keys sops adds to encrypted dotenv files
+var sopsMetadata = regexp.MustCompile(`(?m)^sops_(version|mac)=`)
+
+// ageIdentities returns the configured age identities, or an empty string if
+// none are available
+func (l *Loader) ageIdentities() (string, error) {
+	if l.config.AgeIdentity != "" {
+		return l.config.AgeIdentity, nil
+	}
+
+	keyFile := l.config.AgeIdentityFile
+	if keyFile == "" {
+		if key := os.Getenv(AgeKeyEnv); key != "" {
+			return key, nil
+		}
+		keyFile = os.Getenv(AgeKeyFileEnv)
+	}
+	if keyFile == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read age identity file: %w", err)
+	}
+	return string(data), nil
+}
+
+// decryptEnvFile decrypts an age or sops encrypted dotenv file
+func (l *Loader) decryptEnvFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := l.ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	if sopsMetadata.Match(data) {
+		return decryptSops(path, keys)
+	}
+
+	armored := bytes.HasPrefix(bytes.TrimSpace(data), []byte(armor.Header))
+	if !armored && !bytes.HasPrefix(data, []byte("age-encryption.org/")) {
+		return nil, fmt.Errorf("%s is not encrypted with age or sops", path)
+	}
+	if keys == "" {
+		return nil, fmt.Errorf("no age identity available to decrypt %s", path)
+	}
+
+	identities, err := age.ParseIdentities(strings.NewReader(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identities: %w", err)
+	}
+
+	var r io.Reader = bytes.NewReader(data)
+	if armored {
+		r = armor.NewReader(r)
+	}
+	decrypted, err := age.Decrypt(r, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w", path, err)
+	}
+	return io.ReadAll(decrypted)
+}
+
+// decryptSops decrypts a sops dotenv file with the sops command, passing along
+// any age identities
+func decryptSops(path, keys string) ([]byte, error) {
+	cmd := exec.Command(sopsCommand, "--decrypt", "--input-type", "dotenv", "--output-type", "dotenv", path)
+	if keys != "" {
+		cmd.Env = append(os.Environ(), "SOPS_AGE_KEY="+keys)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sops failed to decrypt %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
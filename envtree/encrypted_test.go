@@ -0,0 +1,126 @@
+package envtree
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// encryptAge encrypts plaintext to the identity's recipient
+func encryptAge(t *testing.T, identity *age.X25519Identity, plaintext string, armored bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var dst io.WriteCloser = nopWriteCloser{&buf}
+	if armored {
+		dst = armor.NewWriter(&buf)
+	}
+	w, err := age.Encrypt(dst, identity.Recipient())
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if _, err := io.WriteString(w, plaintext); err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	return buf.Bytes()
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestLoadAgeEncrypted(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	dir := t.TempDir()
+	encrypted := encryptAge(t, identity, "ENVTREE_AGE_SECRET=hunter2\nENVTREE_AGE_SHARED=encrypted\n", false)
+	if err := os.WriteFile(filepath.Join(dir, ".env.enc"), encrypted, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	writeFile(t, filepath.Join(dir, ".env"), "ENVTREE_AGE_SHARED=plaintext\n")
+	chdir(t, dir)
+
+	vars, err := New(&Config{AgeIdentity: identity.String()}).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if vars["ENVTREE_AGE_SECRET"] != "hunter2" {
+		t.Errorf("Expected decrypted secret, got %q", vars["ENVTREE_AGE_SECRET"])
+	}
+	// Plaintext .env takes precedence within a directory
+	if vars["ENVTREE_AGE_SHARED"] != "plaintext" {
+		t.Errorf("Expected plaintext value to win, got %q", vars["ENVTREE_AGE_SHARED"])
+	}
+
+	// Without an identity the file cannot be loaded
+	t.Setenv(AgeKeyEnv, "")
+	t.Setenv(AgeKeyFileEnv, "")
+	if _, err := New(nil).Read(); err == nil || !strings.Contains(err.Error(), "no age identity") {
+		t.Errorf("Expected missing identity error, got %v", err)
+	}
+}
+
+func TestLoadArmoredAgeWithKeyFile(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("Failed to generate identity: %v", err)
+	}
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "key.txt")
+	writeFile(t, keyFile, "# created: test\n"+identity.String()+"\n")
+	writeFile(t, filepath.Join(dir, ".env.enc"), string(encryptAge(t, identity, "ENVTREE_AGE_ARMORED=yes\n", true)))
+	chdir(t, dir)
+	t.Setenv(AgeKeyEnv, "")
+	t.Setenv(AgeKeyFileEnv, keyFile)
+
+	vars, err := New(nil).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if vars["ENVTREE_AGE_ARMORED"] != "yes" {
+		t.Errorf("Expected decrypted value, got %q", vars["ENVTREE_AGE_ARMORED"])
+	}
+}
+
+func TestLoadSopsEncrypted(t *testing.T) {
+	// Stand in for sops with a script that checks the key and prints plaintext
+	bin := t.TempDir()
+	script := "#!/bin/sh\n[ \"$SOPS_AGE_KEY\" = test-key ] || { echo missing key >&2; exit 1; }\necho ENVTREE_SOPS_SECRET=decrypted\n"
+	if err := os.WriteFile(filepath.Join(bin, "sops"), []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	originalCommand := sopsCommand
+	sopsCommand = filepath.Join(bin, "sops")
+	t.Cleanup(func() { sopsCommand = originalCommand })
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env.enc"), "ENVTREE_SOPS_SECRET=ENC[AES256_GCM,data:abc,type:str]\nsops_version=3.8.1\n")
+	chdir(t, dir)
+
+	vars, err := New(&Config{AgeIdentity: "test-key"}).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if vars["ENVTREE_SOPS_SECRET"] != "decrypted" {
+		t.Errorf("Expected decrypted value, got %q", vars["ENVTREE_SOPS_SECRET"])
+	}
+
+	if _, err := New(&Config{AgeIdentity: "wrong-key"}).Read(); err == nil || !strings.Contains(err.Error(), "missing key") {
+		t.Errorf("Expected sops error, got %v", err)
+	}
+}
@@ -7,14 +7,25 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-
-	"github.com/joho/godotenv"
 )
 
 // Config holds the configuration for the environment loader
 type Config struct {
 	// EnvFileName is the name of the env file to search for (default: ".env")
 	EnvFileName string
+
+	// FileFormat sets how env files are parsed. The default, FormatAuto, detects
+	// the format by extension and also finds EnvFileName with .yaml, .yml and
+	// .toml extensions in each directory.
+	FileFormat FileFormat
+
+	// AgeIdentity holds the age identities (AGE-SECRET-KEY-...) used to decrypt
+	// .env.enc files. When empty, AgeIdentityFile and then the ENVTREE_AGE_KEY
+	// and ENVTREE_AGE_KEY_FILE environment variables are used.
+	AgeIdentity string
+
+	// AgeIdentityFile is the path of a file containing age identities
+	AgeIdentityFile string
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -34,25 +45,69 @@ func New(config *Config) *Loader {
 	if config == nil {
 		config = DefaultConfig()
 	}
+	if config.EnvFileName == "" {
+		config.EnvFileName = DefaultConfig().EnvFileName
+	}
 	return &Loader{config: config}
 }
 
-// Load searches for environment files and loads them
+// Load searches for environment files and loads them. Variables that are
+// already set in the environment are not overridden.
 func (l *Loader) Load() error {
+	vars, err := l.Read()
+	if err != nil {
+		return err
+	}
+
+	for key, value := range vars {
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// Read searches for environment files and returns their merged variables
+// without modifying the environment. Files closer to the current directory
+// take precedence. References such as $VAR and ${VAR} in values are expanded
+// against the merged variables and then the process environment.
+func (l *Loader) Read() (map[string]string, error) {
+	_, layers, err := l.readLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := interpolate(layers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate env files: %w", err)
+	}
+
+	return vars, nil
+}
+
+// readLayers reads each environment file, returning the paths and their
+// variables with closer files first
+func (l *Loader) readLayers() ([]string, []map[string]string, error) {
 	// Get environment file paths
 	envFiles, err := l.getEnvFilePaths()
 	if err != nil {
-		return fmt.Errorf("failed to get env file paths: %w", err)
+		return nil, nil, fmt.Errorf("failed to get env file paths: %w", err)
 	}
 
-	// Load environment files if any were found
-	if len(envFiles) > 0 {
-		if err := godotenv.Load(envFiles...); err != nil {
-			return fmt.Errorf("failed to load env files: %w", err)
+	layers := make([]map[string]string, 0, len(envFiles))
+	for _, path := range envFiles {
+		fileVars, err := l.readEnvFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load env files: %w", err)
 		}
+		layers = append(layers, fileVars)
 	}
 
-	return nil
+	return envFiles, layers, nil
 }
 
 // MustLoad loads environment files and panics on error
@@ -64,8 +119,28 @@ func (l *Loader) MustLoad() {
 
 // getEnvFilePaths searches for .env files from the current directory up to the root
 func (l *Loader) getEnvFilePaths() ([]string, error) {
+	dirs, err := searchDirs()
+	if err != nil {
+		return nil, err
+	}
+
 	var envFiles []string
+	for _, dir := range dirs {
+		// Check for each candidate env file in the directory
+		for _, name := range l.candidateNames() {
+			envPath := filepath.Join(dir, name)
+			if info, err := os.Stat(envPath); err == nil && !info.IsDir() {
+				// If it exists, add it to the list
+				envFiles = append(envFiles, envPath)
+			}
+		}
+	}
+
+	return envFiles, nil
+}
 
+// searchDirs returns the current directory and each of its parents up to the root
+func searchDirs() ([]string, error) {
 	// Get the current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -73,16 +148,8 @@ func (l *Loader) getEnvFilePaths() ([]string, error) {
 	}
 
 	// Start from the current directory and move up
+	dirs := []string{cwd}
 	for {
-		// Construct the path to the env file in the current directory
-		envPath := filepath.Join(cwd, l.config.EnvFileName)
-
-		// Check if the file exists
-		if _, err := os.Stat(envPath); err == nil {
-			// If it exists, add it to the list
-			envFiles = append(envFiles, envPath)
-		}
-
 		// Move to the parent directory
 		parent := filepath.Dir(cwd)
 
@@ -93,9 +160,10 @@ func (l *Loader) getEnvFilePaths() ([]string, error) {
 
 		// Update current working directory to the parent
 		cwd = parent
+		dirs = append(dirs, cwd)
 	}
 
-	return envFiles, nil
+	return dirs, nil
 }
 
 // GetEnvFilePaths returns all environment file paths without loading them
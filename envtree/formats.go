@@ -0,0 +1,178 @@
+package envtree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileFormat identifies how an environment file is parsed
+type FileFormat string
+
+// Supported environment file formats
+const (
+	// FormatAuto detects the format from the file extension and also searches
+	// for YAML and TOML variants of EnvFileName (e.g. .env.yaml, .env.toml)
+	FormatAuto FileFormat = ""
+	// FormatDotenv parses KEY=value files
+	FormatDotenv FileFormat = "dotenv"
+	// FormatYAML parses YAML mappings
+	FormatYAML FileFormat = "yaml"
+	// FormatTOML parses TOML tables
+	FormatTOML FileFormat = "toml"
+	// FormatEncrypted decrypts age or sops encrypted dotenv files
+	FormatEncrypted FileFormat = "encrypted"
+)
+
+// autoExtensions are appended to EnvFileName when searching with FormatAuto,
+// in order of precedence within a directory
+var autoExtensions = []string{"", ".enc", ".yaml", ".yml", ".toml"}
+
+// detectFormat returns the format for a file based on its extension
+func detectFormat(path string) FileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	case ".enc":
+		return FormatEncrypted
+	default:
+		return FormatDotenv
+	}
+}
+
+// candidateNames returns the file names to look for in each directory
+func (l *Loader) candidateNames() []string {
+	if l.config.FileFormat != FormatAuto {
+		return []string{l.config.EnvFileName}
+	}
+	names := make([]string, 0, len(autoExtensions))
+	for _, ext := range autoExtensions {
+		names = append(names, l.config.EnvFileName+ext)
+	}
+	return names
+}
+
+// readEnvFile parses a single environment file into a map of variables
+func (l *Loader) readEnvFile(path string) (map[string]string, error) {
+	format := l.config.FileFormat
+	if format == FormatAuto {
+		format = detectFormat(path)
+	}
+
+	switch format {
+	case FormatDotenv:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseDotenv(data)
+	case FormatEncrypted:
+		data, err := l.decryptEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseDotenv(data)
+	case FormatYAML, FormatTOML:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var values map[string]interface{}
+		if format == FormatYAML {
+			err = yaml.Unmarshal(data, &values)
+		} else {
+			err = toml.Unmarshal(data, &values)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		vars := make(map[string]string)
+		flattenValues("", values, vars)
+		return vars, nil
+	default:
+		return nil, fmt.Errorf("unsupported file format %q", format)
+	}
+}
+
+// Masks substituted for "$" and "\$" while recovering unexpanded dotenv values
+const (
+	dollarMark        = "\x00"
+	escapedDollarMark = "\x01"
+)
+
+// parseDotenv parses dotenv data into values for interpolate, in which
+// literal dollar signs are written as "$$". godotenv only expands references to
+// variables defined earlier in the same file, so the file is parsed a second
+// time with dollar signs masked to recover the references it expanded.
+// Single-quoted values come through both parses unchanged and stay literal.
+func parseDotenv(data []byte) (map[string]string, error) {
+	parsed, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var masked map[string]string
+	if !strings.ContainsAny(string(data), dollarMark+escapedDollarMark) {
+		masker := strings.NewReplacer(`\$`, escapedDollarMark, "$", dollarMark)
+		masked, err = godotenv.Unmarshal(masker.Replace(string(data)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unmask := strings.NewReplacer(dollarMark, "$", escapedDollarMark, `\$`)
+	toTemplate := strings.NewReplacer(dollarMark, "$", escapedDollarMark, "$$")
+	vars := make(map[string]string, len(parsed))
+	for key, value := range parsed {
+		if raw, ok := masked[key]; ok && unmask.Replace(raw) != value {
+			vars[key] = toTemplate.Replace(raw)
+		} else {
+			vars[key] = strings.ReplaceAll(value, "$", "$$")
+		}
+	}
+	return vars, nil
+}
+
+// flattenValues converts structured values into environment variables. Nested
+// keys are joined with "_" and lists are joined with ",".
+func flattenValues(prefix string, values map[string]interface{}, vars map[string]string) {
+	for key, value := range values {
+		name := key
+		if prefix != "" {
+			name = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flattenValues(name, v, vars)
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, scalarString(item))
+			}
+			vars[name] = strings.Join(items, ",")
+		default:
+			vars[name] = scalarString(v)
+		}
+	}
+}
+
+// scalarString formats a scalar value the way it would appear in a .env file
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
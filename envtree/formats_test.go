@@ -0,0 +1,119 @@
+package envtree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFile writes a test file, failing the test on error
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create %s: %v", path, err)
+	}
+}
+
+// chdir changes to dir for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(originalWd) })
+}
+
+func TestLoadYAMLAndTOML(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "app")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	writeFile(t, filepath.Join(root, ".env.yaml"), `
+ENVTREE_FMT_SHARED: from-root-yaml
+ENVTREE_FMT_ROOT: 42
+ENVTREE_FMT_DB:
+  HOST: db.internal
+  PORT: 5432
+ENVTREE_FMT_HOSTS:
+  - a.example.com
+  - b.example.com
+`)
+	writeFile(t, filepath.Join(child, ".env.toml"), `
+ENVTREE_FMT_SHARED = "from-child-toml"
+ENVTREE_FMT_TOML = true
+ENVTREE_FMT_DOTENV = "from-child-toml"
+`)
+	writeFile(t, filepath.Join(child, ".env"), "ENVTREE_FMT_DOTENV=from-child-dotenv\n")
+	chdir(t, child)
+
+	vars, err := New(nil).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"ENVTREE_FMT_SHARED":  "from-child-toml",   // Closer files take precedence
+		"ENVTREE_FMT_DOTENV":  "from-child-dotenv", // .env wins within a directory
+		"ENVTREE_FMT_ROOT":    "42",
+		"ENVTREE_FMT_DB_HOST": "db.internal",
+		"ENVTREE_FMT_DB_PORT": "5432",
+		"ENVTREE_FMT_HOSTS":   "a.example.com,b.example.com",
+		"ENVTREE_FMT_TOML":    "true",
+	}
+	for key, want := range expected {
+		if got := vars[key]; got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+
+	// Load populates the environment without overriding existing variables
+	t.Setenv("ENVTREE_FMT_ROOT", "preset")
+	for key := range expected {
+		if key != "ENVTREE_FMT_ROOT" {
+			os.Unsetenv(key)
+			defer os.Unsetenv(key)
+		}
+	}
+	if err := New(nil).Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := os.Getenv("ENVTREE_FMT_DB_HOST"); got != "db.internal" {
+		t.Errorf("Expected ENVTREE_FMT_DB_HOST to be loaded, got %q", got)
+	}
+	if got := os.Getenv("ENVTREE_FMT_ROOT"); got != "preset" {
+		t.Errorf("Expected existing ENVTREE_FMT_ROOT to be kept, got %q", got)
+	}
+}
+
+func TestExplicitFileFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "secrets"), "ENVTREE_FMT_SECRET: hunter2\n")
+	writeFile(t, filepath.Join(dir, "secrets.toml"), "ENVTREE_FMT_SECRET = \"ignored\"\n")
+	chdir(t, dir)
+
+	loader := New(&Config{EnvFileName: "secrets", FileFormat: FormatYAML})
+	paths, err := loader.GetEnvFilePaths()
+	if err != nil {
+		t.Fatalf("GetEnvFilePaths failed: %v", err)
+	}
+	if len(paths) != 1 || filepath.Base(paths[0]) != "secrets" {
+		t.Fatalf("Expected only the secrets file, got %v", paths)
+	}
+
+	vars, err := loader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if vars["ENVTREE_FMT_SECRET"] != "hunter2" {
+		t.Errorf("Expected ENVTREE_FMT_SECRET=hunter2, got %q", vars["ENVTREE_FMT_SECRET"])
+	}
+
+	// Invalid content reports the file
+	writeFile(t, filepath.Join(dir, "secrets"), "not: [valid\n")
+	if _, err := loader.Read(); err == nil {
+		t.Error("Expected an error for invalid YAML")
+	}
+}
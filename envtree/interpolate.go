@@ -0,0 +1,193 @@
+package envtree
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// interpolate expands variable references in layers of environment values.
+// Layers are ordered from highest to lowest precedence. A reference resolves to
+// the highest-precedence definition of the variable, falling back to the
+// process environment. A variable that references itself, as in
+// PATH=$HOME/bin:$PATH, sees the definition it overrides.
+func interpolate(layers []map[string]string) (map[string]string, error) {
+	r := &resolver{
+		layers:   layers,
+		resolved: make(map[layerVar]string),
+		active:   make(map[layerVar]bool),
+	}
+
+	vars := make(map[string]string)
+	for _, layer := range layers {
+		for name := range layer {
+			if _, done := vars[name]; done {
+				continue
+			}
+			value, _, err := r.resolve(name, 0)
+			if err != nil {
+				return nil, err
+			}
+			vars[name] = value
+		}
+	}
+
+	return vars, nil
+}
+
+// layerVar identifies a variable definition within a layer
+type layerVar struct {
+	name  string
+	layer int
+}
+
+// resolver expands variables across layers, caching each definition
+type resolver struct {
+	layers   []map[string]string
+	resolved map[layerVar]string
+	active   map[layerVar]bool
+}
+
+// resolve returns the expanded value of the first definition of name found at
+// or after the given layer
+func (r *resolver) resolve(name string, from int) (string, bool, error) {
+	for i := from; i < len(r.layers); i++ {
+		template, ok := r.layers[i][name]
+		if !ok {
+			continue
+		}
+
+		key := layerVar{name: name, layer: i}
+		if value, done := r.resolved[key]; done {
+			return value, true, nil
+		}
+		if r.active[key] {
+			return "", false, fmt.Errorf("cyclic reference to %s", name)
+		}
+
+		r.active[key] = true
+		value, err := expand(template, func(ref string) (string, bool, error) {
+			if ref == name {
+				return r.resolve(ref, i+1)
+			}
+			return r.resolve(ref, 0)
+		})
+		delete(r.active, key)
+		if err != nil {
+			return "", false, err
+		}
+
+		r.resolved[key] = value
+		return value, true, nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	return value, ok, nil
+}
+
+// expand replaces $NAME, ${NAME}, ${NAME:-default} and ${NAME-default} in s
+// using lookup. "$$" produces a literal "$". Malformed references are kept as
+// written.
+func expand(s string, lookup func(name string) (string, bool, error)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				b.WriteByte('$')
+				continue
+			}
+			value, err := expandBraced(s[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = end
+		case isNameStart(next):
+			j := i + 2
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			value, _, err := lookup(s[i+1 : j])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+// expandBraced expands the contents of a ${...} reference
+func expandBraced(expr string, lookup func(name string) (string, bool, error)) (string, error) {
+	n := 0
+	for n < len(expr) && isNameChar(expr[n]) {
+		n++
+	}
+	if n == 0 || !isNameStart(expr[0]) {
+		return "${" + expr + "}", nil
+	}
+	name, op := expr[:n], expr[n:]
+
+	value, found, err := lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case op == "":
+		return value, nil
+	case strings.HasPrefix(op, ":-"):
+		if found && value != "" {
+			return value, nil
+		}
+		return expand(op[2:], lookup)
+	case strings.HasPrefix(op, "-"):
+		if found {
+			return value, nil
+		}
+		return expand(op[1:], lookup)
+	default:
+		return "${" + expr + "}", nil
+	}
+}
+
+// closingBrace returns the index of the brace closing a ${ whose contents
+// start at from, or -1 if it is unterminated
+func closingBrace(s string, from int) int {
+	depth := 1
+	for i := from; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isNameStart reports whether c can start a variable name
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// isNameChar reports whether c can appear in a variable name
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,103 @@
+package envtree
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolationAcrossTree(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "app")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	t.Setenv("ENVTREE_INTERP_USER", "alice")
+	writeFile(t, filepath.Join(root, ".env"), strings.Join([]string{
+		"ENVTREE_INTERP_HOST=root.example.com",
+		"ENVTREE_INTERP_URL=https://${ENVTREE_INTERP_HOST}:$ENVTREE_INTERP_PORT/",
+		"ENVTREE_INTERP_PATH=/usr/bin",
+		"",
+	}, "\n"))
+	writeFile(t, filepath.Join(child, ".env"), strings.Join([]string{
+		"ENVTREE_INTERP_HOST=child.example.com",
+		"ENVTREE_INTERP_PORT=8080",
+		"ENVTREE_INTERP_PATH=/opt/bin:$ENVTREE_INTERP_PATH",
+		"ENVTREE_INTERP_HOME=/home/${ENVTREE_INTERP_USER}",
+		"ENVTREE_INTERP_LEVEL=${ENVTREE_INTERP_UNSET:-info}",
+		"ENVTREE_INTERP_QUOTED='$ENVTREE_INTERP_HOST'",
+		`ENVTREE_INTERP_ESCAPED="\$ENVTREE_INTERP_HOST"`,
+		"",
+	}, "\n"))
+	writeFile(t, filepath.Join(child, ".env.yaml"), `
+ENVTREE_INTERP_YAML: ${ENVTREE_INTERP_HOST}/$$literal
+`)
+	chdir(t, child)
+
+	vars, err := New(nil).Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"ENVTREE_INTERP_URL":     "https://child.example.com:8080/", // Closer definitions are used
+		"ENVTREE_INTERP_PATH":    "/opt/bin:/usr/bin",               // Self-references see the overridden value
+		"ENVTREE_INTERP_HOME":    "/home/alice",                     // Falls back to the environment
+		"ENVTREE_INTERP_LEVEL":   "info",
+		"ENVTREE_INTERP_QUOTED":  "$ENVTREE_INTERP_HOST",
+		"ENVTREE_INTERP_ESCAPED": "$ENVTREE_INTERP_HOST",
+		"ENVTREE_INTERP_YAML":    "child.example.com/$literal",
+	}
+	for key, want := range expected {
+		if got := vars[key]; got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+}
+
+func TestInterpolationCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "ENVTREE_CYCLE_A=$ENVTREE_CYCLE_B\nENVTREE_CYCLE_B=${ENVTREE_CYCLE_A}\n")
+	chdir(t, dir)
+
+	if _, err := New(nil).Read(); err == nil || !strings.Contains(err.Error(), "cyclic reference") {
+		t.Errorf("Expected cyclic reference error, got %v", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	lookup := func(name string) (string, bool, error) {
+		switch name {
+		case "SET":
+			return "value", true, nil
+		case "EMPTY":
+			return "", true, nil
+		}
+		return "", false, nil
+	}
+
+	tests := map[string]string{
+		"plain":                 "plain",
+		"$SET and ${SET}":       "value and value",
+		"${EMPTY:-default}":     "default",
+		"${EMPTY-default}":      "",
+		"${UNSET-${SET}}":       "value",
+		"$$SET costs $5":        "$SET costs $5",
+		"${unterminated":        "${unterminated",
+		"${1BAD}":               "${1BAD}",
+		"$UNSET/trailing$":      "/trailing$",
+		"${SET}suffix$SET.tail": "valuesuffixvalue.tail",
+	}
+	for input, want := range tests {
+		got, err := expand(input, lookup)
+		if err != nil {
+			t.Errorf("expand(%q) failed: %v", input, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("expand(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package envtree
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PlanFile describes what a single environment file contributes to a load
+type PlanFile struct {
+	// Path is the absolute path of the file
+	Path string
+
+	// Set lists the keys Load would set from this file
+	Set []string
+
+	// Overrides lists the keys this file provides in place of farther files
+	Overrides []string
+
+	// Shadowed lists the keys this file defines that closer files replace
+	Shadowed []string
+
+	// Kept lists the keys this file provides that are already set in the
+	// environment, so Load would leave them unchanged
+	Kept []string
+}
+
+// Plan describes what Load would do without modifying the environment
+type Plan struct {
+	// Files lists the environment files in the order they are applied, from
+	// the farthest to the closest
+	Files []PlanFile
+
+	// Vars holds the merged and interpolated variables from every file
+	Vars map[string]string
+}
+
+// Plan reports which files would be loaded, in what order, and which keys each
+// would set or override, without modifying the environment
+func (l *Loader) Plan() (*Plan, error) {
+	paths, layers, err := l.readLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := interpolate(layers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to interpolate env files: %w", err)
+	}
+
+	plan := &Plan{Vars: vars}
+	for i := len(layers) - 1; i >= 0; i-- {
+		file := PlanFile{Path: paths[i]}
+		for key := range layers[i] {
+			if definedIn(layers[:i], key) {
+				file.Shadowed = append(file.Shadowed, key)
+				continue
+			}
+			if definedIn(layers[i+1:], key) {
+				file.Overrides = append(file.Overrides, key)
+			}
+			if _, exists := os.LookupEnv(key); exists {
+				file.Kept = append(file.Kept, key)
+			} else {
+				file.Set = append(file.Set, key)
+			}
+		}
+		sort.Strings(file.Set)
+		sort.Strings(file.Overrides)
+		sort.Strings(file.Shadowed)
+		sort.Strings(file.Kept)
+		plan.Files = append(plan.Files, file)
+	}
+
+	return plan, nil
+}
+
+// definedIn reports whether any of the layers defines key
+func definedIn(layers []map[string]string, key string) bool {
+	for _, layer := range layers {
+		if _, ok := layer[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the plan as a human-readable report
+func (p *Plan) String() string {
+	if len(p.Files) == 0 {
+		return "no environment files found\n"
+	}
+
+	var b strings.Builder
+	for i, file := range p.Files {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, file.Path)
+		writePlanKeys(&b, "set", file.Set)
+		writePlanKeys(&b, "overrides", file.Overrides)
+		writePlanKeys(&b, "shadowed", file.Shadowed)
+		writePlanKeys(&b, "kept from environment", file.Kept)
+	}
+	return b.String()
+}
+
+// writePlanKeys writes a labelled list of keys if it is not empty
+func writePlanKeys(b *strings.Builder, label string, keys []string) {
+	if len(keys) > 0 {
+		fmt.Fprintf(b, "   %s: %s\n", label, strings.Join(keys, ", "))
+	}
+}
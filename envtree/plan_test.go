@@ -0,0 +1,63 @@
+package envtree
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "app")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	writeFile(t, filepath.Join(root, ".env"), "ENVTREE_PLAN_A=root\nENVTREE_PLAN_B=root\nENVTREE_PLAN_ENV=root\n")
+	writeFile(t, filepath.Join(child, ".env"), "ENVTREE_PLAN_B=child\nENVTREE_PLAN_C=${ENVTREE_PLAN_A}-child\n")
+	chdir(t, child)
+	t.Setenv("ENVTREE_PLAN_ENV", "preset")
+
+	plan, err := New(nil).Plan()
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	if len(plan.Files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(plan.Files))
+	}
+
+	// Files are listed farthest first
+	rootFile, childFile := plan.Files[0], plan.Files[1]
+	if !strings.HasSuffix(rootFile.Path, filepath.Join(filepath.Base(root), ".env")) || !strings.HasSuffix(childFile.Path, filepath.Join("app", ".env")) {
+		t.Errorf("Unexpected file order: %s, %s", rootFile.Path, childFile.Path)
+	}
+
+	expectKeys := func(label string, got, want []string) {
+		t.Helper()
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s %v, got %v", label, want, got)
+		}
+	}
+	expectKeys("root set", rootFile.Set, []string{"ENVTREE_PLAN_A"})
+	expectKeys("root shadowed", rootFile.Shadowed, []string{"ENVTREE_PLAN_B"})
+	expectKeys("root kept", rootFile.Kept, []string{"ENVTREE_PLAN_ENV"})
+	expectKeys("child set", childFile.Set, []string{"ENVTREE_PLAN_B", "ENVTREE_PLAN_C"})
+	expectKeys("child overrides", childFile.Overrides, []string{"ENVTREE_PLAN_B"})
+
+	if plan.Vars["ENVTREE_PLAN_C"] != "root-child" {
+		t.Errorf("Expected interpolated ENVTREE_PLAN_C, got %q", plan.Vars["ENVTREE_PLAN_C"])
+	}
+
+	// Planning never modifies the environment
+	if _, exists := os.LookupEnv("ENVTREE_PLAN_A"); exists {
+		t.Error("Plan should not set environment variables")
+	}
+
+	report := plan.String()
+	if !strings.Contains(report, "overrides: ENVTREE_PLAN_B") || !strings.Contains(report, "kept from environment: ENVTREE_PLAN_ENV") {
+		t.Errorf("Unexpected report:\n%s", report)
+	}
+}
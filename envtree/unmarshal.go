@@ -0,0 +1,177 @@
+package envtree
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Unmarshal populates the struct pointed to by v from the process environment.
+//
+// Fields are bound with `env:"NAME"` tags. Add ",required" to fail when the
+// variable is unset, and an `envDefault:"value"` tag to use a value when it is
+// unset. Supported field types are strings, booleans, integers, floats,
+// time.Duration, encoding.TextUnmarshaler implementations, pointers to these
+// and slices of them given as comma-separated values. Nested structs without an
+// env tag are populated recursively.
+func Unmarshal(v interface{}) error {
+	return unmarshal(v, os.LookupEnv)
+}
+
+// Unmarshal populates the struct pointed to by v from the environment files
+// merged with the process environment, without modifying the environment.
+// As with Load, variables already set in the environment take precedence.
+func (l *Loader) Unmarshal(v interface{}) error {
+	vars, err := l.Read()
+	if err != nil {
+		return err
+	}
+	return unmarshal(v, func(key string) (string, bool) {
+		if value, exists := os.LookupEnv(key); exists {
+			return value, true
+		}
+		value, exists := vars[key]
+		return value, exists
+	})
+}
+
+// unmarshal populates v using lookup to find variables
+func unmarshal(v interface{}, lookup func(string) (string, bool)) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("envtree: Unmarshal requires a non-nil pointer to a struct")
+	}
+
+	var missing []string
+	if err := unmarshalStruct(rv.Elem(), lookup, &missing); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("envtree: required variables not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// unmarshalStruct populates the tagged fields of a struct value
+func unmarshalStruct(rv reflect.Value, lookup func(string) (string, bool), missing *[]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		value := rv.Field(i)
+
+		tag, tagged := field.Tag.Lookup("env")
+		if !tagged {
+			if field.Type.Kind() == reflect.Struct && !isTextUnmarshaler(value) {
+				if err := unmarshalStruct(value, lookup, missing); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		raw, exists := lookup(name)
+		if !exists {
+			raw, exists = field.Tag.Lookup("envDefault")
+		}
+		if !exists {
+			if options == "required" {
+				*missing = append(*missing, name)
+			}
+			continue
+		}
+
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("envtree: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// durationType is the reflect type of time.Duration
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// isTextUnmarshaler reports whether a pointer to the value implements encoding.TextUnmarshaler
+func isTextUnmarshaler(value reflect.Value) bool {
+	_, ok := value.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setValue parses raw into the value according to its type
+func setValue(value reflect.Value, raw string) error {
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		return setValue(value.Elem(), raw)
+	}
+
+	if isTextUnmarshaler(value) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		if raw != "" {
+			items = strings.Split(raw, ",")
+		}
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package envtree
+
+import (
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDatabaseConfig struct {
+	Host string `env:"ENVTREE_UM_DB_HOST" envDefault:"localhost"`
+	Port int    `env:"ENVTREE_UM_DB_PORT" envDefault:"5432"`
+}
+
+type testConfig struct {
+	Name     string        `env:"ENVTREE_UM_NAME,required"`
+	Debug    bool          `env:"ENVTREE_UM_DEBUG"`
+	Timeout  time.Duration `env:"ENVTREE_UM_TIMEOUT" envDefault:"5s"`
+	Ratio    float64       `env:"ENVTREE_UM_RATIO"`
+	Workers  *uint         `env:"ENVTREE_UM_WORKERS"`
+	Hosts    []string      `env:"ENVTREE_UM_HOSTS"`
+	Ports    []int         `env:"ENVTREE_UM_PORTS"`
+	Addr     netip.Addr    `env:"ENVTREE_UM_ADDR"`
+	Database testDatabaseConfig
+	Ignored  string
+	internal string `env:"ENVTREE_UM_NAME"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Setenv("ENVTREE_UM_NAME", "api")
+	t.Setenv("ENVTREE_UM_DEBUG", "true")
+	t.Setenv("ENVTREE_UM_RATIO", "0.25")
+	t.Setenv("ENVTREE_UM_WORKERS", "8")
+	t.Setenv("ENVTREE_UM_HOSTS", "a.example.com, b.example.com")
+	t.Setenv("ENVTREE_UM_PORTS", "80,443")
+	t.Setenv("ENVTREE_UM_ADDR", "10.0.0.1")
+	t.Setenv("ENVTREE_UM_DB_HOST", "db.internal")
+
+	var cfg testConfig
+	if err := Unmarshal(&cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if cfg.Name != "api" || !cfg.Debug || cfg.Ratio != 0.25 {
+		t.Errorf("Unexpected scalar fields: %+v", cfg)
+	}
+	if cfg.Timeout != 5*time.Second {
+		t.Errorf("Expected default timeout, got %v", cfg.Timeout)
+	}
+	if cfg.Workers == nil || *cfg.Workers != 8 {
+		t.Errorf("Expected 8 workers, got %v", cfg.Workers)
+	}
+	if !reflect.DeepEqual(cfg.Hosts, []string{"a.example.com", "b.example.com"}) || !reflect.DeepEqual(cfg.Ports, []int{80, 443}) {
+		t.Errorf("Unexpected slices: %v %v", cfg.Hosts, cfg.Ports)
+	}
+	if cfg.Addr.String() != "10.0.0.1" {
+		t.Errorf("Expected parsed address, got %v", cfg.Addr)
+	}
+	if cfg.Database.Host != "db.internal" || cfg.Database.Port != 5432 {
+		t.Errorf("Unexpected nested struct: %+v", cfg.Database)
+	}
+	if cfg.internal != "" {
+		t.Error("Unexported fields should be ignored")
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	var cfg testConfig
+	if err := Unmarshal(cfg); err == nil {
+		t.Error("Expected error for non-pointer")
+	}
+
+	t.Setenv("ENVTREE_UM_NAME", "")
+	if err := Unmarshal(&cfg); err != nil {
+		t.Errorf("Empty values satisfy required: %v", err)
+	}
+
+	t.Setenv("ENVTREE_UM_DEBUG", "maybe")
+	if err := Unmarshal(&cfg); err == nil || !strings.Contains(err.Error(), "ENVTREE_UM_DEBUG") {
+		t.Errorf("Expected parse error naming the variable, got %v", err)
+	}
+
+	var required struct {
+		A string `env:"ENVTREE_UM_MISSING_A,required"`
+		B string `env:"ENVTREE_UM_MISSING_B,required"`
+	}
+	if err := Unmarshal(&required); err == nil || !strings.Contains(err.Error(), "ENVTREE_UM_MISSING_A, ENVTREE_UM_MISSING_B") {
+		t.Errorf("Expected missing variables error, got %v", err)
+	}
+}
+
+func TestLoaderUnmarshal(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".env"), "ENVTREE_UM_FILE_NAME=from-file\nENVTREE_UM_FILE_PORT=8080\n")
+	chdir(t, dir)
+	t.Setenv("ENVTREE_UM_FILE_PORT", "9090")
+
+	var cfg struct {
+		Name string `env:"ENVTREE_UM_FILE_NAME,required"`
+		Port int    `env:"ENVTREE_UM_FILE_PORT"`
+	}
+	if err := New(nil).Unmarshal(&cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if cfg.Name != "from-file" || cfg.Port != 9090 {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
@@ -0,0 +1,161 @@
+package envtree
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits for file events to settle before reloading
+var watchDebounce = 100 * time.Millisecond
+
+// Changes describes the variables that changed between two loads of the env tree
+type Changes struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// IsEmpty reports whether no variables changed
+func (c Changes) IsEmpty() bool {
+	return len(c.Added) == 0 && len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// diffVars compares two sets of variables and returns the sorted keys that changed
+func diffVars(before, after map[string]string) Changes {
+	var changes Changes
+	for key, value := range after {
+		previous, existed := before[key]
+		if !existed {
+			changes.Added = append(changes.Added, key)
+		} else if previous != value {
+			changes.Changed = append(changes.Changed, key)
+		}
+	}
+	for key := range before {
+		if _, exists := after[key]; !exists {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Changed)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+// Watch loads the environment files and reloads them whenever a file in the
+// tree is created, modified or removed, calling onChange with the keys that
+// changed. Variables loaded by Watch are kept up to date in the environment;
+// variables that were already set to a different value are left alone. Watch
+// blocks until ctx is done and then returns ctx.Err().
+func (l *Loader) Watch(ctx context.Context, onChange func(Changes)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Directories are watched so that new files and atomic replacements are seen
+	dirs, err := searchDirs()
+	if err != nil {
+		return err
+	}
+	for i, dir := range dirs {
+		// Parent directories may not be watchable; only the current one is required
+		if err := watcher.Add(dir); err != nil && i == 0 {
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, name := range l.candidateNames() {
+		names[name] = true
+	}
+
+	current, err := l.Read()
+	if err != nil {
+		return err
+	}
+
+	// Track the variables Watch is responsible for
+	owned := make(map[string]bool)
+	for key, value := range current {
+		if existing, exists := os.LookupEnv(key); exists && existing != value {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		owned[key] = true
+	}
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if names[filepath.Base(event.Name)] {
+				reload = time.After(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Warning: error watching environment files: %v", err)
+
+		case <-reload:
+			reload = nil
+			next, err := l.Read()
+			if err != nil {
+				log.Printf("Warning: failed to reload environment files: %v", err)
+				continue
+			}
+
+			changes := diffVars(current, next)
+			if changes.IsEmpty() {
+				continue
+			}
+			applyChanges(next, changes, owned)
+			current = next
+
+			if onChange != nil {
+				onChange(changes)
+			}
+		}
+	}
+}
+
+// applyChanges updates the environment for the changed variables that are
+// owned by the watcher or not yet set
+func applyChanges(vars map[string]string, changes Changes, owned map[string]bool) {
+	for _, keys := range [][]string{changes.Added, changes.Changed} {
+		for _, key := range keys {
+			if _, exists := os.LookupEnv(key); exists && !owned[key] {
+				continue
+			}
+			if err := os.Setenv(key, vars[key]); err != nil {
+				log.Printf("Warning: failed to set %s: %v", key, err)
+				continue
+			}
+			owned[key] = true
+		}
+	}
+	for _, key := range changes.Removed {
+		if owned[key] {
+			os.Unsetenv(key)
+			delete(owned, key)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package envtree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffVars(t *testing.T) {
+	changes := diffVars(
+		map[string]string{"A": "1", "B": "2", "C": "3"},
+		map[string]string{"A": "1", "B": "20", "D": "4"},
+	)
+
+	expected := Changes{Added: []string{"D"}, Changed: []string{"B"}, Removed: []string{"C"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, changes)
+	}
+	if !(Changes{}).IsEmpty() || changes.IsEmpty() {
+		t.Error("IsEmpty returned the wrong result")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, ".env")
+	writeFile(t, envFile, "ENVTREE_WATCH_TOKEN=first\nENVTREE_WATCH_OLD=gone-soon\n")
+	chdir(t, dir)
+
+	// Variables set outside the loader are never overridden
+	t.Setenv("ENVTREE_WATCH_PINNED", "from-env")
+	for _, key := range []string{"ENVTREE_WATCH_TOKEN", "ENVTREE_WATCH_OLD", "ENVTREE_WATCH_NEW"} {
+		key := key
+		t.Cleanup(func() { os.Unsetenv(key) })
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changesCh := make(chan Changes, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- New(nil).Watch(ctx, func(c Changes) { changesCh <- c })
+	}()
+
+	// Wait for the initial load
+	deadline := time.Now().Add(2 * time.Second)
+	for os.Getenv("ENVTREE_WATCH_TOKEN") != "first" {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for initial load")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	writeFile(t, envFile, "ENVTREE_WATCH_TOKEN=second\nENVTREE_WATCH_NEW=hello\nENVTREE_WATCH_PINNED=from-file\n")
+
+	select {
+	case changes := <-changesCh:
+		expected := Changes{
+			Added:   []string{"ENVTREE_WATCH_NEW", "ENVTREE_WATCH_PINNED"},
+			Changed: []string{"ENVTREE_WATCH_TOKEN"},
+			Removed: []string{"ENVTREE_WATCH_OLD"},
+		}
+		if !reflect.DeepEqual(changes, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for change callback")
+	}
+
+	expectedEnv := map[string]string{
+		"ENVTREE_WATCH_TOKEN":  "second",
+		"ENVTREE_WATCH_NEW":    "hello",
+		"ENVTREE_WATCH_PINNED": "from-env",
+	}
+	for key, want := range expectedEnv {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+	if _, exists := os.LookupEnv("ENVTREE_WATCH_OLD"); exists {
+		t.Error("Expected ENVTREE_WATCH_OLD to be unset")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Watch did not return after cancel")
+	}
+}
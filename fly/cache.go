@@ -0,0 +1,145 @@
+package fly
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cachedMachines is a machine list stored in the cache
+type cachedMachines struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Machines  []Machine `json:"machines"`
+}
+
+var (
+	machineCacheTTL time.Duration
+	machineCacheDir string
+	machineCache    = make(map[string]cachedMachines)
+	machineCacheMu  sync.Mutex
+
+	// Global counter for machine list cache hits
+	cacheHitCount int32
+)
+
+// SetMachineCache caches GetMachineList results for ttl. When dir is not empty
+// results are also stored there, so separate processes can share them. A zero
+// ttl disables the cache.
+func SetMachineCache(ttl time.Duration, dir string) {
+	machineCacheMu.Lock()
+	defer machineCacheMu.Unlock()
+	machineCacheTTL = ttl
+	machineCacheDir = dir
+}
+
+// ClearMachineCache removes every cached machine list from memory. Files in the
+// cache directory are left to expire.
+func ClearMachineCache() {
+	machineCacheMu.Lock()
+	defer machineCacheMu.Unlock()
+	machineCache = make(map[string]cachedMachines)
+}
+
+// GetCacheHitCount returns the number of GetMachineList calls served from the cache
+func GetCacheHitCount() int32 {
+	return atomic.LoadInt32(&cacheHitCount)
+}
+
+// cachedMachineList returns a fresh cached machine list for an app
+func cachedMachineList(appName string) ([]Machine, bool) {
+	machineCacheMu.Lock()
+	defer machineCacheMu.Unlock()
+
+	if machineCacheTTL <= 0 {
+		return nil, false
+	}
+
+	entry, ok := machineCache[appName]
+	if !ok && machineCacheDir != "" {
+		entry, ok = readMachineCacheFile(appName)
+	}
+	if !ok || time.Since(entry.FetchedAt) > machineCacheTTL {
+		return nil, false
+	}
+
+	machineCache[appName] = entry
+	atomic.AddInt32(&cacheHitCount, 1)
+	return append([]Machine(nil), entry.Machines...), true
+}
+
+// storeMachineList caches a machine list for an app
+func storeMachineList(appName string, machines []Machine) {
+	machineCacheMu.Lock()
+	defer machineCacheMu.Unlock()
+
+	if machineCacheTTL <= 0 {
+		return
+	}
+
+	entry := cachedMachines{FetchedAt: time.Now(), Machines: append([]Machine(nil), machines...)}
+	machineCache[appName] = entry
+	if machineCacheDir != "" {
+		writeMachineCacheFile(appName, entry)
+	}
+}
+
+// invalidateMachineList drops the cached machine list for an app after it changes
+func invalidateMachineList(appName string) {
+	machineCacheMu.Lock()
+	defer machineCacheMu.Unlock()
+
+	delete(machineCache, appName)
+	if machineCacheDir != "" {
+		os.Remove(machineCacheFile(appName))
+	}
+}
+
+// machineCacheFile returns the path of an app's cache file
+func machineCacheFile(appName string) string {
+	return filepath.Join(machineCacheDir, url.PathEscape(appName)+".json")
+}
+
+// readMachineCacheFile reads an app's cache file, reporting whether it was usable
+func readMachineCacheFile(appName string) (cachedMachines, bool) {
+	var entry cachedMachines
+	data, err := os.ReadFile(machineCacheFile(appName))
+	if err != nil {
+		return entry, false
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, false
+	}
+	return entry, true
+}
+
+// writeMachineCacheFile stores an app's cache file. Failures only cost a cache miss later.
+func writeMachineCacheFile(appName string, entry cachedMachines) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(machineCacheDir, 0700); err != nil {
+		return
+	}
+
+	// Write to a temporary file first so concurrent readers never see partial data
+	tmp, err := os.CreateTemp(machineCacheDir, ".machines-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), machineCacheFile(appName)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,63 @@
+package fly
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMachineCache(t *testing.T) {
+	dir := t.TempDir()
+	SetMachineCache(time.Minute, dir)
+	t.Cleanup(func() {
+		SetMachineCache(0, "")
+		ClearMachineCache()
+	})
+
+	storeMachineList("us-east-1-portal", []Machine{{ID: "m1", Region: "iad"}})
+
+	// Served from memory without calling flyctl
+	hits, calls := GetCacheHitCount(), GetFlyctlCallCount()
+	machines, err := GetMachineList("us-east-1-portal")
+	require.NoError(t, err)
+	require.Len(t, machines, 1)
+	assert.Equal(t, "m1", machines[0].ID)
+	assert.Equal(t, hits+1, GetCacheHitCount())
+	assert.Equal(t, calls, GetFlyctlCallCount())
+
+	// Served from disk once memory is cleared, as in a new process
+	ClearMachineCache()
+	_, ok := cachedMachineList("us-east-1-portal")
+	assert.True(t, ok)
+
+	// Changing a machine drops its app from the cache
+	invalidateMachineList("us-east-1-portal")
+	_, ok = cachedMachineList("us-east-1-portal")
+	assert.False(t, ok)
+}
+
+func TestMachineCacheExpiry(t *testing.T) {
+	dir := t.TempDir()
+	SetMachineCache(time.Minute, dir)
+	t.Cleanup(func() {
+		SetMachineCache(0, "")
+		ClearMachineCache()
+	})
+
+	stale, err := json.Marshal(cachedMachines{FetchedAt: time.Now().Add(-time.Hour), Machines: []Machine{{ID: "old"}}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(machineCacheFile("eu-west-1-portal"), stale, 0600))
+
+	_, ok := cachedMachineList("eu-west-1-portal")
+	assert.False(t, ok)
+
+	// A zero TTL disables the cache entirely
+	storeMachineList("eu-west-1-portal", []Machine{{ID: "new"}})
+	SetMachineCache(0, dir)
+	_, ok = cachedMachineList("eu-west-1-portal")
+	assert.False(t, ok)
+}
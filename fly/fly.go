@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"hash/fnv"
+	"log"
 	"os"
 	"os/exec"
 	"strings"
@@ -78,10 +79,6 @@ func getEnvironmentStringSlice(envName string, defaultValues []string) []string
 }
 
 var (
-	usRegions []string
-	euRegions []string
-	appNames  []string
-
 	// Global counter for flyctl calls
 	flyctlCallCount int32
 
@@ -106,13 +103,24 @@ var (
 )
 
 func init() {
-	usRegions = getEnvironmentStringSlice("US_REGIONS", []string{"us-east-1", "us-east-2", "us-east-3", "us-east-4"})
-	euRegions = getEnvironmentStringSlice("EU_REGIONS", []string{"eu-west-1", "eu-west-2", "eu-west-3", "eu-west-4"})
-	appNames = getEnvironmentStringSlice("APP_NAMES", []string{"portal", "websocket"})
+	regionConfig = DefaultRegionConfig()
+	if path := os.Getenv(RegionConfigEnv); path != "" {
+		config, err := LoadRegionConfig(path)
+		if err != nil {
+			log.Printf("Warning: failed to load region config: %v", err)
+			return
+		}
+		regionConfig = config
+	}
 }
 
-// GetMachineList gets the list of machines for a specific app
+// GetMachineList gets the list of machines for a specific app. Results are
+// served from the cache when one is enabled with SetMachineCache.
 func GetMachineList(appName string) ([]Machine, error) {
+	if machines, ok := cachedMachineList(appName); ok {
+		return machines, nil
+	}
+
 	// Increment the global flyctl call counter
 	IncrementFlyctlCallCount()
 
@@ -133,6 +141,7 @@ func GetMachineList(appName string) ([]Machine, error) {
 		return nil, fmt.Errorf("error parsing JSON: %v", err)
 	}
 
+	storeMachineList(appName, machines)
 	return machines, nil
 }
 
@@ -265,17 +274,17 @@ func ColorizedAppPrefix(appName string) string {
 
 // GetUSRegions returns the list of US regions
 func GetUSRegions() []string {
-	return usRegions
+	return GetRegionGroup(GroupUS)
 }
 
 // GetEURegions returns the list of EU regions
 func GetEURegions() []string {
-	return euRegions
+	return GetRegionGroup(GroupEU)
 }
 
 // GetAppNames returns the list of application names
 func GetAppNames() []string {
-	return appNames
+	return GetConfig().AppNames
 }
 
 // GetFlyctlCallCount returns the current count of flyctl calls
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -20,8 +22,27 @@ type LogsFlags struct {
 	follow   bool
 	usOnly   bool
 	euOnly   bool
+	group    string
 	numLines int
 	appName  string
+	level    string
+	region   string
+	instance string
+	compact  bool
+}
+
+// filter returns the structured log filter selected by the flags
+func (f LogsFlags) filter() fly.LogFilter {
+	return fly.LogFilter{
+		Level:    f.level,
+		Region:   f.region,
+		Instance: f.instance,
+	}
+}
+
+// structured reports whether logs must be fetched as JSON
+func (f LogsFlags) structured() bool {
+	return f.compact || !f.filter().IsEmpty()
 }
 
 // LogResult contains the logs and metadata for a machine
@@ -30,15 +51,82 @@ type LogResult struct {
 	MachineID   string
 	MachineName string
 	Logs        string
+	Entries     []fly.LogEntry
 	Error       error
 }
 
 // Command-line flags for list command
 type ListFlags struct {
+	usOnly   bool
+	euOnly   bool
+	group    string
+	quiet    bool
+	appName  string
+	output   string
+	cacheTTL time.Duration
+}
+
+// Command-line flags for the restart, stop and start commands
+type ActionFlags struct {
 	usOnly  bool
 	euOnly  bool
-	quiet   bool
+	group   string
+	appName string
+	confirm bool
+}
+
+// Command-line flags for the scale command
+type ScaleFlags struct {
 	appName string
+	count   int
+	regions string
+	confirm bool
+}
+
+// scaleOperation is a single clone or destroy performed by the scale command
+type scaleOperation struct {
+	Region  string
+	Clone   bool
+	Machine fly.Machine
+}
+
+// Command-line flags for the health command
+type HealthFlags struct {
+	usOnly       bool
+	euOnly       bool
+	group        string
+	appName      string
+	minMachines  int
+	stuckAfter   time.Duration
+	allowStopped bool
+}
+
+// healthColors maps health ratings to ANSI colors
+var healthColors = map[fly.HealthStatus]string{
+	fly.HealthGreen:  "\033[32m",
+	fly.HealthYellow: "\033[33m",
+	fly.HealthRed:    "\033[31m",
+}
+
+// machineTarget is a machine selected for an action
+type machineTarget struct {
+	AppName string
+	Machine fly.Machine
+}
+
+// ActionResult holds the result of an action on a single machine
+type ActionResult struct {
+	AppName     string
+	MachineID   string
+	MachineName string
+	Error       error
+}
+
+// actionPastTense maps machine actions to the verbs used in summaries
+var actionPastTense = map[string]string{
+	fly.ActionRestart: "Restarted",
+	fly.ActionStop:    "Stopped",
+	fly.ActionStart:   "Started",
 }
 
 // MachineResult holds the result of a machine query
@@ -47,6 +135,7 @@ type MachineResult struct {
 	Region       string
 	Output       string
 	MachineCount int
+	Machines     []fly.Machine
 	Error        error
 }
 
@@ -64,6 +153,93 @@ func formatTimestamp(ts int64) string {
 		ts%10)
 }
 
+// cacheTTLEnv names the environment variable holding the default machine list cache TTL
+const cacheTTLEnv = "FLYSU_CACHE_TTL"
+
+// envCacheTTL returns the machine list cache TTL from the environment, or zero
+func envCacheTTL() time.Duration {
+	value := os.Getenv(cacheTTLEnv)
+	if value == "" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q: %v", cacheTTLEnv, value, err)
+		return 0
+	}
+	return ttl
+}
+
+// configureMachineCache enables the machine list cache, shared between runs
+// through the user cache directory
+func configureMachineCache(ttl time.Duration) {
+	dir := ""
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		dir = filepath.Join(cacheDir, "flysu")
+	}
+	fly.SetMachineCache(ttl, dir)
+}
+
+// callStats summarizes the flyctl calls made and the cache hits that avoided them
+func callStats() string {
+	return fmt.Sprintf("Processed %d flyctl calls (%d cache hits).", fly.GetFlyctlCallCount(), fly.GetCacheHitCount())
+}
+
+// shortID truncates a machine ID for display
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// selectGroups returns the region groups selected by the -us, -eu and -g flags.
+// Every group is selected when no flag is given.
+func selectGroups(usOnly, euOnly bool, group string) []fly.RegionGroup {
+	var selected []fly.RegionGroup
+	for _, g := range fly.GetRegionGroups() {
+		switch {
+		case group != "":
+			if strings.EqualFold(g.Name, group) {
+				selected = append(selected, g)
+			}
+		case usOnly || euOnly:
+			if (usOnly && g.Name == fly.GroupUS) || (euOnly && g.Name == fly.GroupEU) {
+				selected = append(selected, g)
+			}
+		default:
+			selected = append(selected, g)
+		}
+	}
+
+	if group != "" && len(selected) == 0 {
+		log.Fatalf("Unknown region group: %s", group)
+	}
+	return selected
+}
+
+// selectRegions returns the regions in the groups selected by the -us, -eu and -g flags
+func selectRegions(usOnly, euOnly bool, group string) []string {
+	config := fly.RegionConfig{Groups: selectGroups(usOnly, euOnly, group)}
+	return config.AllRegions()
+}
+
+// targetAppNames returns the specific app if one is given, otherwise every
+// app type in each region (e.g., "us-east-1-portal", "eu-west-2-websocket")
+func targetAppNames(appName string, regions []string) []string {
+	if appName != "" {
+		return []string{appName}
+	}
+
+	var fullAppNames []string
+	for _, region := range regions {
+		for _, appType := range fly.GetAppNames() {
+			fullAppNames = append(fullAppNames, fly.FullAppName(region, appType))
+		}
+	}
+	return fullAppNames
+}
+
 // padToWidth ensures a string is exactly the specified width by padding or truncating
 func padToWidth(s string, width int) string {
 	if len(s) > width {
@@ -102,8 +278,23 @@ func prefixLogLines(appName, logs string) string {
 	return result.String()
 }
 
-// processMachineLogs processes logs for all machines of a specific app
-func processMachineLogs(appName string, resultChan chan<- LogResult, wg *sync.WaitGroup, followFlag bool) {
+// renderLogEntries renders structured log entries one per line, either as
+// compact text or as the original JSON
+func renderLogEntries(entries []fly.LogEntry, compact bool) string {
+	var result strings.Builder
+	for _, entry := range entries {
+		if compact {
+			result.WriteString(entry.Compact())
+		} else {
+			result.WriteString(entry.Raw)
+		}
+		result.WriteString("\n")
+	}
+	return result.String()
+}
+
+// processMachineLogs processes buffered logs for all machines of a specific app
+func processMachineLogs(appName string, resultChan chan<- LogResult, wg *sync.WaitGroup, structured bool) {
 	defer wg.Done()
 
 	// Get list of machines for this app
@@ -132,8 +323,21 @@ func processMachineLogs(appName string, resultChan chan<- LogResult, wg *sync.Wa
 			continue
 		}
 
+		// Get structured logs for this machine when filtering or re-rendering
+		if structured {
+			entries, err := fly.GetMachineStructuredLogs(appName, machine.ID)
+			resultChan <- LogResult{
+				AppName:     appName,
+				MachineID:   machine.ID,
+				MachineName: machine.Name,
+				Entries:     entries,
+				Error:       err,
+			}
+			continue
+		}
+
 		// Get logs for this machine
-		logs, err := fly.GetMachineLogs(appName, machine.ID, followFlag)
+		logs, err := fly.GetMachineLogs(appName, machine.ID, false)
 		if err != nil {
 			resultChan <- LogResult{
 				AppName:   appName,
@@ -150,37 +354,69 @@ func processMachineLogs(appName string, resultChan chan<- LogResult, wg *sync.Wa
 			MachineName: machine.Name,
 			Logs:        logs,
 		}
-
-		// When following, only process one machine per app to avoid flooding the terminal
-		if followFlag {
-			break
-		}
 	}
 }
 
-// getMachineDetails gets the machine details for a specific app
-func getMachineDetails(appName string) (string, int, error) {
-	// Increment the global flyctl call counter
-	fly.IncrementFlyctlCallCount()
+// followLogs follows the logs of every started machine of the given apps until interrupted
+func followLogs(appNames []string, logsFlags LogsFlags) {
+	targets := collectMachineTargets(appNames, func(m fly.Machine) bool {
+		return m.State == "started"
+	})
+	if len(targets) == 0 {
+		fmt.Println("No started machines found.")
+		return
+	}
 
-	cmd := exec.Command("flyctl", "machine", "list", "--json", "-a", appName)
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = os.Stderr
+	sources := make([]fly.LogSource, len(targets))
+	for i, target := range targets {
+		sources[i] = fly.LogSource{AppName: target.AppName, MachineID: target.Machine.ID}
+	}
 
-	err := cmd.Run()
-	if err != nil {
-		return "Not found or error", 0, nil
+	fmt.Printf("Following logs from %d machines...\n", len(sources))
+	printHorizontalRule()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stream := fly.NewLogStream(sources)
+	stream.JSON = logsFlags.structured()
+	filter := logsFlags.filter()
+
+	for line := range stream.Start(ctx) {
+		prefix := fly.ColorizedAppPrefix(line.Source.AppName)
+		if line.Err != nil {
+			log.Printf("%s [%s] %v\n", prefix, shortID(line.Source.MachineID), line.Err)
+			continue
+		}
+
+		text := line.Text
+		if stream.JSON {
+			entry, err := fly.ParseLogLine(text)
+			if err != nil {
+				entry.Message = text
+			}
+			if !filter.Match(entry) {
+				continue
+			}
+			text = strings.TrimSuffix(renderLogEntries([]fly.LogEntry{entry}, logsFlags.compact), "\n")
+		}
+		fmt.Printf("%s %s\n", prefix, text)
 	}
 
-	var machines []fly.Machine
-	err = json.Unmarshal(out.Bytes(), &machines)
+	fmt.Println(callStats())
+}
+
+// getMachineDetails gets the machine details for a specific app, returning the
+// rendered text and the machines it describes
+func getMachineDetails(appName string) (string, []fly.Machine, error) {
+	machines, err := fly.GetMachineList(appName)
 	if err != nil {
-		return fmt.Sprintf("Error parsing JSON: %v", err), 0, nil
+		fmt.Fprintln(os.Stderr, err)
+		return "Not found or error", nil, nil
 	}
 
 	if len(machines) == 0 {
-		return "No machines", 0, nil
+		return "No machines", nil, nil
 	}
 
 	// Format the output
@@ -203,7 +439,7 @@ func getMachineDetails(appName string) (string, int, error) {
 		// Format the machine details
 		fmt.Fprintf(&result, "%s [%s] %s in %s • %dCPU/%dMB • %s\n",
 			m.Name,
-			m.ID[:8],
+			shortID(m.ID),
 			m.State,
 			m.Region,
 			m.Config.Guest.CPUs,
@@ -218,7 +454,7 @@ func getMachineDetails(appName string) (string, int, error) {
 		}
 	}
 
-	return result.String(), len(machines), nil
+	return result.String(), machines, nil
 }
 
 // collectMachineData collects data for all machines in parallel
@@ -240,18 +476,19 @@ func collectMachineData(regions []string) (map[string]map[string]MachineResult,
 			go func(r, appType string) {
 				defer wg.Done()
 
-				appName := r + "-" + appType
-				output, count, err := getMachineDetails(appName)
+				appName := fly.FullAppName(r, appType)
+				output, machines, err := getMachineDetails(appName)
 
 				mutex.Lock()
 				results[r][appType] = MachineResult{
 					AppName:      appName,
 					Region:       r,
 					Output:       output,
-					MachineCount: count,
+					MachineCount: len(machines),
+					Machines:     machines,
 					Error:        err,
 				}
-				totalMachines += count
+				totalMachines += len(machines)
 				mutex.Unlock()
 			}(region, appType)
 		}
@@ -383,32 +620,24 @@ func runLogsCommand(args []string) {
 	logsCmd.BoolVar(&logsFlags.follow, "f", false, "Follow logs")
 	logsCmd.BoolVar(&logsFlags.usOnly, "us", false, "Show only US regions")
 	logsCmd.BoolVar(&logsFlags.euOnly, "eu", false, "Show only EU regions")
+	logsCmd.StringVar(&logsFlags.group, "g", "", "Show only regions in this region group")
 	logsCmd.IntVar(&logsFlags.numLines, "n", 100, "Number of lines to show")
 	logsCmd.StringVar(&logsFlags.appName, "a", "", "Specific app name to target")
+	logsCmd.StringVar(&logsFlags.level, "level", "", "Show only log lines with this level (e.g. error)")
+	logsCmd.StringVar(&logsFlags.region, "region", "", "Show only log lines from this region")
+	logsCmd.StringVar(&logsFlags.instance, "instance", "", "Show only log lines from this instance (ID prefix)")
+	logsCmd.BoolVar(&logsFlags.compact, "compact", false, "Render structured log lines as compact text")
 
 	logsCmd.Parse(args)
 
-	// Determine regions based on flags
-	regions := append(fly.GetUSRegions(), fly.GetEURegions()...)
-	if logsFlags.usOnly && !logsFlags.euOnly {
-		regions = fly.GetUSRegions()
-	} else if logsFlags.euOnly && !logsFlags.usOnly {
-		regions = fly.GetEURegions()
-	}
-
-	// Generate app names (e.g., "us-east-1-portal", "eu-west-2-websocket", etc.)
-	var fullAppNames []string
+	// Determine regions and apps based on flags
+	regions := selectRegions(logsFlags.usOnly, logsFlags.euOnly, logsFlags.group)
+	fullAppNames := targetAppNames(logsFlags.appName, regions)
 
-	// If a specific app name is provided, use it directly
-	if logsFlags.appName != "" {
-		fullAppNames = []string{logsFlags.appName}
-	} else {
-		// Otherwise, generate all combinations
-		for _, region := range regions {
-			for _, appName := range fly.GetAppNames() {
-				fullAppNames = append(fullAppNames, region+"-"+appName)
-			}
-		}
+	// Following streams every started machine until interrupted
+	if logsFlags.follow {
+		followLogs(fullAppNames, logsFlags)
+		return
 	}
 
 	// Create a channel for results and a WaitGroup to synchronize goroutines
@@ -422,7 +651,7 @@ func runLogsCommand(args []string) {
 
 	for _, appName := range fullAppNames {
 		wg.Add(1)
-		go processMachineLogs(appName, resultChan, &wg, logsFlags.follow)
+		go processMachineLogs(appName, resultChan, &wg, logsFlags.structured())
 	}
 
 	// Create a separate goroutine to close the channel when all processing is done
@@ -438,7 +667,16 @@ func runLogsCommand(args []string) {
 			continue
 		}
 
-		if !logsFlags.follow && result.Logs != "" {
+		if result.Entries != nil {
+			entries := fly.FilterLogs(result.Entries, logsFlags.filter())
+			if len(entries) > 0 {
+				fmt.Print(prefixLogLines(result.AppName, renderLogEntries(entries, logsFlags.compact)))
+				printHorizontalRule()
+			}
+			continue
+		}
+
+		if result.Logs != "" {
 			// Print logs with proper prefixing
 			output := prefixLogLines(result.AppName, result.Logs)
 			fmt.Print(output)
@@ -446,7 +684,43 @@ func runLogsCommand(args []string) {
 		}
 	}
 
-	fmt.Printf("Processed %d flyctl calls.\n", fly.GetFlyctlCallCount())
+	fmt.Println(callStats())
+}
+
+// listRegions returns the regions queried by the list command
+func listRegions(listFlags ListFlags) []string {
+	return selectRegions(listFlags.usOnly, listFlags.euOnly, listFlags.group)
+}
+
+// collectMachineRecords gathers the machines selected by the list flags as
+// structured records, ordered by app and machine name
+func collectMachineRecords(listFlags ListFlags) []MachineRecord {
+	var records []MachineRecord
+
+	if listFlags.appName != "" {
+		_, machines, _ := getMachineDetails(listFlags.appName)
+		for _, m := range machines {
+			records = append(records, newMachineRecord(listFlags.appName, m))
+		}
+	} else {
+		results, _ := collectMachineData(listRegions(listFlags))
+		for _, appResults := range results {
+			for _, result := range appResults {
+				for _, m := range result.Machines {
+					records = append(records, newMachineRecord(result.AppName, m))
+				}
+			}
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].App != records[j].App {
+			return records[i].App < records[j].App
+		}
+		return records[i].Name < records[j].Name
+	})
+
+	return records
 }
 
 // runListCommand runs the list subcommand
@@ -456,10 +730,26 @@ func runListCommand(args []string) {
 	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
 	listCmd.BoolVar(&listFlags.usOnly, "us", false, "Show only US regions")
 	listCmd.BoolVar(&listFlags.euOnly, "eu", false, "Show only EU regions")
+	listCmd.StringVar(&listFlags.group, "g", "", "Show only regions in this region group")
 	listCmd.BoolVar(&listFlags.quiet, "q", false, "Quiet mode (show only counts)")
 	listCmd.StringVar(&listFlags.appName, "a", "", "Specific app name to target")
+	listCmd.StringVar(&listFlags.output, "o", OutputTable, "Output format ("+outputFormatList()+")")
+	listCmd.DurationVar(&listFlags.cacheTTL, "cache", envCacheTTL(), "Reuse machine lists fetched within this long (e.g. 30s)")
 
 	listCmd.Parse(args)
+	configureMachineCache(listFlags.cacheTTL)
+
+	if !isOutputFormat(listFlags.output) {
+		log.Fatalf("Unknown output format %q (expected %s)", listFlags.output, outputFormatList())
+	}
+
+	// Structured output skips the progress messages so it can be piped
+	if listFlags.output != OutputTable {
+		if err := writeMachineRecords(os.Stdout, listFlags.output, collectMachineRecords(listFlags)); err != nil {
+			log.Fatalf("Error writing output: %v", err)
+		}
+		return
+	}
 
 	// Start collecting data in parallel
 	startTime := time.Now()
@@ -471,7 +761,8 @@ func runListCommand(args []string) {
 		fmt.Printf("Fetching data for app: %s\n", listFlags.appName)
 
 		// Direct call to get machine details for the specific app
-		output, count, err := getMachineDetails(listFlags.appName)
+		output, machines, err := getMachineDetails(listFlags.appName)
+		count := len(machines)
 
 		if err != nil {
 			fmt.Printf("Error fetching data for %s: %v\n", listFlags.appName, err)
@@ -487,25 +778,12 @@ func runListCommand(args []string) {
 			}
 		}
 
-		fmt.Printf("\nProcessed %d flyctl calls.\n", fly.GetFlyctlCallCount())
+		fmt.Printf("\n%s\n", callStats())
 		return
 	}
 
 	// Determine which regions to query for the normal case (no specific app)
-	var regionsToQuery []string
-	if !listFlags.usOnly && !listFlags.euOnly {
-		// Default: query all regions
-		regionsToQuery = append(regionsToQuery, fly.GetUSRegions()...)
-		regionsToQuery = append(regionsToQuery, fly.GetEURegions()...)
-	} else {
-		// Query based on flags
-		if listFlags.usOnly {
-			regionsToQuery = append(regionsToQuery, fly.GetUSRegions()...)
-		}
-		if listFlags.euOnly {
-			regionsToQuery = append(regionsToQuery, fly.GetEURegions()...)
-		}
-	}
+	regionsToQuery := listRegions(listFlags)
 
 	// Collect data for all regions
 	results, totalMachines := collectMachineData(regionsToQuery)
@@ -516,17 +794,354 @@ func runListCommand(args []string) {
 		len(regionsToQuery),
 		time.Since(startTime).Seconds())
 
-	// Display US regions data
-	if listFlags.usOnly || !listFlags.euOnly {
-		displayRegionData(fly.GetUSRegions(), "US", results, listFlags.quiet)
+	// Display data for each selected region group
+	for _, group := range selectGroups(listFlags.usOnly, listFlags.euOnly, listFlags.group) {
+		displayRegionData(group.Regions, group.Name, results, listFlags.quiet)
+	}
+
+	fmt.Printf("\n%s\n", callStats())
+}
+
+// collectMachineTargets lists the machines of each app in parallel and returns
+// those selected by keep
+func collectMachineTargets(appNames []string, keep func(fly.Machine) bool) []machineTarget {
+	var targets []machineTarget
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, appName := range appNames {
+		wg.Add(1)
+		go func(appName string) {
+			defer wg.Done()
+
+			machines, err := fly.GetMachineList(appName)
+			if err != nil {
+				log.Printf("Error listing machines for %s: %v\n", appName, err)
+				return
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, machine := range machines {
+				if keep(machine) {
+					targets = append(targets, machineTarget{AppName: appName, Machine: machine})
+				}
+			}
+		}(appName)
+	}
+
+	wg.Wait()
+
+	// Keep the output stable regardless of which query finished first
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].AppName != targets[j].AppName {
+			return targets[i].AppName < targets[j].AppName
+		}
+		return targets[i].Machine.Name < targets[j].Machine.Name
+	})
+
+	return targets
+}
+
+// confirmAction asks the user to confirm an action on stdin
+func confirmAction(prompt string) bool {
+	fmt.Printf("%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// runMachineActionCommand runs the restart, stop and start subcommands
+func runMachineActionCommand(action string, args []string) {
+	// Parse flags for the action command
+	actionFlags := ActionFlags{}
+	actionCmd := flag.NewFlagSet(action, flag.ExitOnError)
+	actionCmd.BoolVar(&actionFlags.usOnly, "us", false, "Target only US regions")
+	actionCmd.BoolVar(&actionFlags.euOnly, "eu", false, "Target only EU regions")
+	actionCmd.StringVar(&actionFlags.group, "g", "", "Target only regions in this region group")
+	actionCmd.StringVar(&actionFlags.appName, "a", "", "Specific app name to target")
+	actionCmd.BoolVar(&actionFlags.confirm, "confirm", true, "Prompt for confirmation before acting")
+
+	actionCmd.Parse(args)
+
+	startTime := time.Now()
+	regions := selectRegions(actionFlags.usOnly, actionFlags.euOnly, actionFlags.group)
+	fullAppNames := targetAppNames(actionFlags.appName, regions)
+
+	fmt.Printf("Finding machines to %s...\n", action)
+	targets := collectMachineTargets(fullAppNames, func(m fly.Machine) bool {
+		return m.NeedsAction(action)
+	})
+	if len(targets) == 0 {
+		fmt.Printf("No machines to %s.\n", action)
+		return
+	}
+
+	// Show what will be affected
+	for _, target := range targets {
+		fmt.Printf("%s %s [%s] %s in %s\n",
+			fly.ColorizedAppPrefix(target.AppName),
+			target.Machine.Name,
+			shortID(target.Machine.ID),
+			target.Machine.State,
+			target.Machine.Region)
+	}
+	printHorizontalRule()
+
+	if actionFlags.confirm && !confirmAction(fmt.Sprintf("%s %d machines?", strings.ToUpper(action[:1])+action[1:], len(targets))) {
+		fmt.Println("Aborted.")
+		return
+	}
+
+	// Run the action on every machine in parallel
+	resultChan := make(chan ActionResult, len(targets))
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target machineTarget) {
+			defer wg.Done()
+			resultChan <- ActionResult{
+				AppName:     target.AppName,
+				MachineID:   target.Machine.ID,
+				MachineName: target.Machine.Name,
+				Error:       fly.RunMachineAction(target.AppName, target.Machine.ID, action),
+			}
+		}(target)
 	}
 
-	// Display EU regions data
-	if listFlags.euOnly || !listFlags.usOnly {
-		displayRegionData(fly.GetEURegions(), "EU", results, listFlags.quiet)
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// Report results as they come in
+	failed := 0
+	for result := range resultChan {
+		status := "ok"
+		if result.Error != nil {
+			status = fmt.Sprintf("ERROR: %v", result.Error)
+			failed++
+		}
+		fmt.Printf("%s %s [%s] %s\n", fly.ColorizedAppPrefix(result.AppName), result.MachineName, shortID(result.MachineID), status)
 	}
 
-	fmt.Printf("\nProcessed %d flyctl calls.\n", fly.GetFlyctlCallCount())
+	printHorizontalRule()
+	fmt.Printf("%s %d of %d machines (%d failed) in %.2f seconds.\n",
+		actionPastTense[action],
+		len(targets)-failed,
+		len(targets),
+		failed,
+		time.Since(startTime).Seconds())
+	fmt.Println(callStats())
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runScaleCommand runs the scale subcommand
+func runScaleCommand(args []string) {
+	// Parse flags for the scale command
+	scaleFlags := ScaleFlags{}
+	scaleCmd := flag.NewFlagSet("scale", flag.ExitOnError)
+	scaleCmd.StringVar(&scaleFlags.appName, "a", "", "App name to scale (required)")
+	scaleCmd.IntVar(&scaleFlags.count, "count", -1, "Target number of machines per region (required)")
+	scaleCmd.StringVar(&scaleFlags.regions, "region", "", "Comma-separated regions to scale (default: regions with machines)")
+	scaleCmd.BoolVar(&scaleFlags.confirm, "confirm", true, "Prompt for confirmation before acting")
+
+	scaleCmd.Parse(args)
+
+	if scaleFlags.appName == "" || scaleFlags.count < 0 {
+		fmt.Println("Usage: flysu scale -a <app> -count <N> [-region <regions>]")
+		os.Exit(1)
+	}
+
+	var regions []string
+	for _, region := range strings.Split(scaleFlags.regions, ",") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+
+	startTime := time.Now()
+	machines, err := fly.GetMachineList(scaleFlags.appName)
+	if err != nil {
+		log.Fatalf("Error listing machines for %s: %v", scaleFlags.appName, err)
+	}
+
+	// Show the plan and collect the operations it needs
+	var operations []scaleOperation
+	prefix := fly.ColorizedAppPrefix(scaleFlags.appName)
+	for _, plan := range fly.PlanScale(machines, regions, scaleFlags.count) {
+		switch {
+		case plan.Current < plan.Target && plan.CloneFrom == nil:
+			fmt.Printf("%s %s: %d -> %d, no machine to clone from\n", prefix, plan.Region, plan.Current, plan.Target)
+		case plan.IsNoop():
+			fmt.Printf("%s %s: %d machines, nothing to do\n", prefix, plan.Region, plan.Current)
+		case plan.Clones > 0:
+			fmt.Printf("%s %s: %d -> %d, cloning %s [%s] %d times\n", prefix, plan.Region, plan.Current, plan.Target,
+				plan.CloneFrom.Name, shortID(plan.CloneFrom.ID), plan.Clones)
+			for i := 0; i < plan.Clones; i++ {
+				operations = append(operations, scaleOperation{Region: plan.Region, Clone: true, Machine: *plan.CloneFrom})
+			}
+		default:
+			fmt.Printf("%s %s: %d -> %d, destroying %d machines\n", prefix, plan.Region, plan.Current, plan.Target, len(plan.Destroy))
+			for _, m := range plan.Destroy {
+				fmt.Printf("    %s [%s] %s\n", m.Name, shortID(m.ID), m.State)
+				operations = append(operations, scaleOperation{Region: plan.Region, Machine: m})
+			}
+		}
+	}
+	printHorizontalRule()
+
+	if len(operations) == 0 {
+		fmt.Println("Nothing to scale.")
+		return
+	}
+
+	if scaleFlags.confirm && !confirmAction(fmt.Sprintf("Run %d operations on %s?", len(operations), scaleFlags.appName)) {
+		fmt.Println("Aborted.")
+		return
+	}
+
+	// Run every clone and destroy in parallel
+	type scaleResult struct {
+		scaleOperation
+		Error error
+	}
+	resultChan := make(chan scaleResult, len(operations))
+	var wg sync.WaitGroup
+	for _, op := range operations {
+		wg.Add(1)
+		go func(op scaleOperation) {
+			defer wg.Done()
+			var err error
+			if op.Clone {
+				err = fly.CloneMachine(scaleFlags.appName, op.Machine.ID, op.Region)
+			} else {
+				err = fly.DestroyMachine(scaleFlags.appName, op.Machine.ID)
+			}
+			resultChan <- scaleResult{scaleOperation: op, Error: err}
+		}(op)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	// Report results as they come in
+	cloned, destroyed, failed := 0, 0, 0
+	for result := range resultChan {
+		verb := "destroy"
+		if result.Clone {
+			verb = "clone"
+		}
+
+		status := "ok"
+		switch {
+		case result.Error != nil:
+			status = fmt.Sprintf("ERROR: %v", result.Error)
+			failed++
+		case result.Clone:
+			cloned++
+		default:
+			destroyed++
+		}
+		fmt.Printf("%s %s %s [%s] %s\n", prefix, result.Region, verb, shortID(result.Machine.ID), status)
+	}
+
+	printHorizontalRule()
+	fmt.Printf("Cloned %d and destroyed %d machines (%d failed) in %.2f seconds.\n",
+		cloned,
+		destroyed,
+		failed,
+		time.Since(startTime).Seconds())
+	fmt.Println(callStats())
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// colorizeHealth renders text in the color of a health rating
+func colorizeHealth(status fly.HealthStatus, text string) string {
+	return healthColors[status] + text + "\033[0m"
+}
+
+// runHealthCommand runs the health subcommand, exiting 1 if anything is yellow
+// and 2 if anything is red
+func runHealthCommand(args []string) {
+	// Parse flags for the health command
+	defaults := fly.DefaultHealthThresholds()
+	healthFlags := HealthFlags{}
+	healthCmd := flag.NewFlagSet("health", flag.ExitOnError)
+	healthCmd.BoolVar(&healthFlags.usOnly, "us", false, "Check only US regions")
+	healthCmd.BoolVar(&healthFlags.euOnly, "eu", false, "Check only EU regions")
+	healthCmd.StringVar(&healthFlags.group, "g", "", "Check only regions in this region group")
+	healthCmd.StringVar(&healthFlags.appName, "a", "", "Specific app name to check")
+	healthCmd.IntVar(&healthFlags.minMachines, "min", defaults.MinMachines, "Started machines each app should run")
+	healthCmd.DurationVar(&healthFlags.stuckAfter, "stuck", defaults.StuckAfter, "How long a machine may stay in a transitional state")
+	healthCmd.BoolVar(&healthFlags.allowStopped, "allow-stopped", defaults.AllowStopped, "Treat stopped machines as healthy")
+
+	healthCmd.Parse(args)
+
+	thresholds := fly.HealthThresholds{
+		MinMachines:  healthFlags.minMachines,
+		StuckAfter:   healthFlags.stuckAfter,
+		AllowStopped: healthFlags.allowStopped,
+	}
+	regions := selectRegions(healthFlags.usOnly, healthFlags.euOnly, healthFlags.group)
+	fullAppNames := targetAppNames(healthFlags.appName, regions)
+
+	// Evaluate every app in parallel
+	now := time.Now()
+	results := make([]fly.AppHealth, len(fullAppNames))
+	var wg sync.WaitGroup
+	for i, appName := range fullAppNames {
+		wg.Add(1)
+		go func(i int, appName string) {
+			defer wg.Done()
+			machines, err := fly.GetMachineList(appName)
+			if err != nil {
+				results[i] = fly.AppHealth{AppName: appName, Status: fly.HealthRed, Reason: "error listing machines"}
+				return
+			}
+			results[i] = fly.EvaluateAppHealth(appName, machines, thresholds, now)
+		}(i, appName)
+	}
+	wg.Wait()
+
+	// Print one line per app, followed by any unhealthy machines
+	counts := make(map[fly.HealthStatus]int)
+	worst := fly.HealthGreen
+	for _, result := range results {
+		counts[result.Status]++
+		if result.Status > worst {
+			worst = result.Status
+		}
+
+		line := fmt.Sprintf("%-7s %-30s %d started", strings.ToUpper(result.Status.String()), result.AppName, result.Started)
+		if result.Reason != "" {
+			line += " • " + result.Reason
+		}
+		fmt.Println(colorizeHealth(result.Status, line))
+
+		for _, machine := range result.Machines {
+			if machine.Status != fly.HealthGreen {
+				fmt.Println(colorizeHealth(machine.Status, fmt.Sprintf("        %s [%s] %s in %s",
+					machine.Machine.Name, shortID(machine.Machine.ID), machine.Reason, machine.Machine.Region)))
+			}
+		}
+	}
+
+	printHorizontalRule()
+	fmt.Printf("%s, %s, %s\n",
+		colorizeHealth(fly.HealthGreen, fmt.Sprintf("%d green", counts[fly.HealthGreen])),
+		colorizeHealth(fly.HealthYellow, fmt.Sprintf("%d yellow", counts[fly.HealthYellow])),
+		colorizeHealth(fly.HealthRed, fmt.Sprintf("%d red", counts[fly.HealthRed])))
+
+	os.Exit(int(worst))
 }
 
 func main() {
@@ -536,9 +1151,17 @@ func main() {
 		fmt.Println("Commands:")
 		fmt.Println("  list    List all fly machines across regions")
 		fmt.Println("  logs    Show logs from fly machines across regions")
+		fmt.Println("  restart Restart fly machines across regions")
+		fmt.Println("  stop    Stop fly machines across regions")
+		fmt.Println("  start   Start fly machines across regions")
+		fmt.Println("  scale   Clone or destroy machines to reach a count per region")
+		fmt.Println("  health  Rate machines and apps green, yellow or red")
 		os.Exit(1)
 	}
 
+	// Cache machine lists when FLYSU_CACHE_TTL is set
+	configureMachineCache(envCacheTTL())
+
 	// Get the subcommand
 	command := os.Args[1]
 
@@ -551,21 +1174,54 @@ func main() {
 		runListCommand(args)
 	case "logs":
 		runLogsCommand(args)
+	case fly.ActionRestart, fly.ActionStop, fly.ActionStart:
+		runMachineActionCommand(command, args)
+	case "scale":
+		runScaleCommand(args)
+	case "health":
+		runHealthCommand(args)
 	case "help":
 		fmt.Println("Usage: flysu <command> [options]")
 		fmt.Println("Commands:")
 		fmt.Println("  list    List all fly machines across regions")
 		fmt.Println("    -us   Show only US regions")
 		fmt.Println("    -eu   Show only EU regions")
+		fmt.Println("    -g    Show only regions in a region group")
 		fmt.Println("    -q    Quiet mode (show only counts)")
 		fmt.Println("    -a    Specific app name to target")
+		fmt.Println("    -o    Output format: table (default), json or csv")
+		fmt.Println("    -cache D     Reuse machine lists fetched within D (default: $FLYSU_CACHE_TTL)")
 		fmt.Println("")
 		fmt.Println("  logs    Show logs from fly machines across regions")
 		fmt.Println("    -f    Follow logs (tail)")
 		fmt.Println("    -us   Show only US regions")
 		fmt.Println("    -eu   Show only EU regions")
+		fmt.Println("    -g    Show only regions in a region group")
 		fmt.Println("    -n N  Number of lines to show (default: 100)")
 		fmt.Println("    -a    Specific app name to target")
+		fmt.Println("    -level L     Show only JSON log lines with level L (e.g. error)")
+		fmt.Println("    -region R    Show only JSON log lines from region R")
+		fmt.Println("    -instance I  Show only JSON log lines from instance I")
+		fmt.Println("    -compact     Re-render JSON log lines as compact text")
+		fmt.Println("")
+		fmt.Println("  restart, stop, start    Change the state of fly machines across regions")
+		fmt.Println("    -us   Target only US regions")
+		fmt.Println("    -eu   Target only EU regions")
+		fmt.Println("    -g    Target only regions in a region group")
+		fmt.Println("    -a    Specific app name to target")
+		fmt.Println("    -confirm=false  Skip the confirmation prompt")
+		fmt.Println("")
+		fmt.Println("  scale   Clone or destroy machines to reach a count per region")
+		fmt.Println("    -a          App name to scale (required)")
+		fmt.Println("    -count N    Target number of machines per region (required)")
+		fmt.Println("    -region R   Comma-separated regions (default: regions with machines)")
+		fmt.Println("    -confirm=false  Skip the confirmation prompt")
+		fmt.Println("")
+		fmt.Println("  health  Rate machines and apps green, yellow or red (exit 0, 1 or 2)")
+		fmt.Println("    -us, -eu, -g, -a  Select apps as for list")
+		fmt.Println("    -min N          Started machines each app should run (default: 1)")
+		fmt.Println("    -stuck D        Time a machine may stay starting/stopping/replacing (default: 10m)")
+		fmt.Println("    -allow-stopped  Treat stopped machines as healthy")
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		fmt.Println("Run 'flysu help' for usage information")
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/presbrey/pkg/fly"
+)
+
+// Output formats supported by the list command
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputCSV   = "csv"
+)
+
+// isOutputFormat reports whether format is a supported output format
+func isOutputFormat(format string) bool {
+	switch format {
+	case OutputTable, OutputJSON, OutputCSV:
+		return true
+	}
+	return false
+}
+
+// MachineRecord is a flat, structured view of a machine for JSON and CSV output
+type MachineRecord struct {
+	App         string    `json:"app"`
+	Name        string    `json:"name"`
+	ID          string    `json:"id"`
+	State       string    `json:"state"`
+	Region      string    `json:"region"`
+	CPUs        int       `json:"cpus"`
+	MemoryMB    int       `json:"memory_mb"`
+	Image       string    `json:"image"`
+	EventType   string    `json:"event_type,omitempty"`
+	EventStatus string    `json:"event_status,omitempty"`
+	Created     time.Time `json:"created_at"`
+	Updated     time.Time `json:"updated_at"`
+}
+
+// machineRecordHeader lists the CSV columns in the order written by writeMachineCSV
+var machineRecordHeader = []string{
+	"app", "name", "id", "state", "region", "cpus", "memory_mb", "image",
+	"event_type", "event_status", "created_at", "updated_at",
+}
+
+// newMachineRecord builds a record for a machine belonging to an app
+func newMachineRecord(appName string, m fly.Machine) MachineRecord {
+	image := m.ImageRef.Repository
+	if m.ImageRef.Tag != "" {
+		image += ":" + m.ImageRef.Tag
+	}
+
+	record := MachineRecord{
+		App:      appName,
+		Name:     m.Name,
+		ID:       m.ID,
+		State:    m.State,
+		Region:   m.Region,
+		CPUs:     m.Config.Guest.CPUs,
+		MemoryMB: m.Config.Guest.MemoryMB,
+		Image:    image,
+		Created:  m.Created,
+		Updated:  m.Updated,
+	}
+	if len(m.Events) > 0 {
+		record.EventType = m.Events[0].Type
+		record.EventStatus = m.Events[0].Status
+	}
+	return record
+}
+
+// writeMachineRecords writes records in the given structured format
+func writeMachineRecords(w io.Writer, format string, records []MachineRecord) error {
+	switch format {
+	case OutputJSON:
+		return writeMachineJSON(w, records)
+	case OutputCSV:
+		return writeMachineCSV(w, records)
+	default:
+		return fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// writeMachineJSON writes records as an indented JSON array
+func writeMachineJSON(w io.Writer, records []MachineRecord) error {
+	if records == nil {
+		records = []MachineRecord{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// writeMachineCSV writes records as CSV with a header row
+func writeMachineCSV(w io.Writer, records []MachineRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(machineRecordHeader); err != nil {
+		return err
+	}
+	for _, r := range records {
+		row := []string{
+			r.App,
+			r.Name,
+			r.ID,
+			r.State,
+			r.Region,
+			strconv.Itoa(r.CPUs),
+			strconv.Itoa(r.MemoryMB),
+			r.Image,
+			r.EventType,
+			r.EventStatus,
+			formatRecordTime(r.Created),
+			formatRecordTime(r.Updated),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatRecordTime formats a time for CSV output, leaving zero times empty
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// outputFormatList returns the supported formats for usage messages
+func outputFormatList() string {
+	return strings.Join([]string{OutputJSON, OutputCSV, OutputTable}, "|")
+}
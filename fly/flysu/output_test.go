@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/fly"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecords() []MachineRecord {
+	machine := fly.Machine{
+		ID:       "e784079b449483",
+		Name:     "quiet-sun-123",
+		State:    "started",
+		Region:   "iad",
+		ImageRef: fly.ImageRef{Repository: "acme/portal", Tag: "deployment-01H"},
+		Created:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Config:   fly.Config{Guest: fly.Guest{CPUs: 2, MemoryMB: 512}},
+		Events:   []fly.Event{{Type: "start", Status: "started"}},
+	}
+	return []MachineRecord{newMachineRecord("us-east-1-portal", machine)}
+}
+
+func TestWriteMachineJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeMachineRecords(&buf, OutputJSON, testRecords()))
+
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "us-east-1-portal", decoded[0]["app"])
+	assert.Equal(t, "acme/portal:deployment-01H", decoded[0]["image"])
+	assert.Equal(t, float64(512), decoded[0]["memory_mb"])
+
+	// An empty inventory is still a JSON array
+	buf.Reset()
+	require.NoError(t, writeMachineRecords(&buf, OutputJSON, nil))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteMachineCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeMachineRecords(&buf, OutputCSV, testRecords()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, strings.Join(machineRecordHeader, ","), lines[0])
+	assert.Equal(t, "us-east-1-portal,quiet-sun-123,e784079b449483,started,iad,2,512,acme/portal:deployment-01H,start,started,2024-01-01T00:00:00Z,", lines[1])
+}
+
+func TestOutputFormats(t *testing.T) {
+	assert.True(t, isOutputFormat(OutputTable))
+	assert.False(t, isOutputFormat("xml"))
+	assert.Error(t, writeMachineRecords(&bytes.Buffer{}, OutputTable, nil))
+}
@@ -0,0 +1,141 @@
+package fly
+
+import (
+	"fmt"
+	"time"
+)
+
+// HealthStatus is a red/yellow/green health rating
+type HealthStatus int
+
+// Health ratings, from best to worst
+const (
+	HealthGreen HealthStatus = iota
+	HealthYellow
+	HealthRed
+)
+
+// String returns the rating's name
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthGreen:
+		return "green"
+	case HealthYellow:
+		return "yellow"
+	default:
+		return "red"
+	}
+}
+
+// HealthThresholds configures how machines and apps are rated
+type HealthThresholds struct {
+	// MinMachines is the number of started machines each app should run.
+	// Apps with none are red and apps with fewer are yellow.
+	MinMachines int
+
+	// StuckAfter is how long a machine may stay in a transitional state, such
+	// as starting or replacing, before it is rated red instead of yellow
+	StuckAfter time.Duration
+
+	// AllowStopped rates stopped and suspended machines green, for apps that
+	// scale to zero
+	AllowStopped bool
+}
+
+// DefaultHealthThresholds returns thresholds suitable for always-on apps
+func DefaultHealthThresholds() HealthThresholds {
+	return HealthThresholds{
+		MinMachines: 1,
+		StuckAfter:  10 * time.Minute,
+	}
+}
+
+// MachineHealth is the rating of a single machine
+type MachineHealth struct {
+	Machine Machine
+	Status  HealthStatus
+	Reason  string
+}
+
+// AppHealth is the rating of an app and its machines. The app's status is the
+// worst of its machines and its own machine count check.
+type AppHealth struct {
+	AppName  string
+	Status   HealthStatus
+	Reason   string
+	Started  int
+	Machines []MachineHealth
+}
+
+// LastEventTime returns the time of the machine's most recent event, or the
+// zero time if it has none
+func (m Machine) LastEventTime() time.Time {
+	var latest int64
+	for _, event := range m.Events {
+		if event.Timestamp > latest {
+			latest = event.Timestamp
+		}
+	}
+	if latest == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(latest)
+}
+
+// EvaluateMachineHealth rates a single machine
+func EvaluateMachineHealth(m Machine, thresholds HealthThresholds, now time.Time) MachineHealth {
+	health := MachineHealth{Machine: m, Status: HealthGreen}
+
+	switch m.State {
+	case "started":
+	case "stopped", "suspended":
+		if !thresholds.AllowStopped {
+			health.Status = HealthYellow
+			health.Reason = m.State
+		}
+	case "failed", "destroyed":
+		health.Status = HealthRed
+		health.Reason = m.State
+	default:
+		health.Status = HealthYellow
+		health.Reason = m.State
+		if last := m.LastEventTime(); !last.IsZero() {
+			age := now.Sub(last).Truncate(time.Second)
+			health.Reason = fmt.Sprintf("%s for %s", m.State, age)
+			if thresholds.StuckAfter > 0 && age > thresholds.StuckAfter {
+				health.Status = HealthRed
+			}
+		}
+	}
+
+	return health
+}
+
+// EvaluateAppHealth rates an app from its machines
+func EvaluateAppHealth(appName string, machines []Machine, thresholds HealthThresholds, now time.Time) AppHealth {
+	health := AppHealth{AppName: appName, Status: HealthGreen}
+
+	for _, m := range machines {
+		machineHealth := EvaluateMachineHealth(m, thresholds, now)
+		if m.State == "started" {
+			health.Started++
+		}
+		if machineHealth.Status > health.Status {
+			health.Status = machineHealth.Status
+		}
+		health.Machines = append(health.Machines, machineHealth)
+	}
+
+	if thresholds.MinMachines > 0 && health.Started < thresholds.MinMachines {
+		status := HealthYellow
+		if health.Started == 0 {
+			status = HealthRed
+		}
+		if status > health.Status {
+			health.Status = status
+		}
+		health.Reason = fmt.Sprintf("%d of %d machines started", health.Started, thresholds.MinMachines)
+	}
+
+	return health
+}
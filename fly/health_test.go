@@ -0,0 +1,61 @@
+package fly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateMachineHealth(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	thresholds := DefaultHealthThresholds()
+	eventAt := func(age time.Duration) []Event {
+		return []Event{{Type: "launch", Timestamp: now.Add(-age).UnixMilli()}}
+	}
+
+	assert.Equal(t, HealthGreen, EvaluateMachineHealth(Machine{State: "started"}, thresholds, now).Status)
+	assert.Equal(t, HealthYellow, EvaluateMachineHealth(Machine{State: "stopped"}, thresholds, now).Status)
+	assert.Equal(t, HealthRed, EvaluateMachineHealth(Machine{State: "failed"}, thresholds, now).Status)
+
+	starting := EvaluateMachineHealth(Machine{State: "starting", Events: eventAt(time.Minute)}, thresholds, now)
+	assert.Equal(t, HealthYellow, starting.Status)
+	assert.Equal(t, "starting for 1m0s", starting.Reason)
+
+	stuck := EvaluateMachineHealth(Machine{State: "replacing", Events: eventAt(time.Hour)}, thresholds, now)
+	assert.Equal(t, HealthRed, stuck.Status)
+
+	thresholds.AllowStopped = true
+	assert.Equal(t, HealthGreen, EvaluateMachineHealth(Machine{State: "stopped"}, thresholds, now).Status)
+}
+
+func TestEvaluateAppHealth(t *testing.T) {
+	now := time.Now()
+	thresholds := DefaultHealthThresholds()
+	thresholds.MinMachines = 2
+
+	health := EvaluateAppHealth("app", []Machine{{State: "started"}, {State: "started"}}, thresholds, now)
+	assert.Equal(t, HealthGreen, health.Status)
+	assert.Equal(t, 2, health.Started)
+
+	health = EvaluateAppHealth("app", []Machine{{State: "started"}}, thresholds, now)
+	assert.Equal(t, HealthYellow, health.Status)
+	assert.Equal(t, "1 of 2 machines started", health.Reason)
+
+	health = EvaluateAppHealth("app", nil, thresholds, now)
+	assert.Equal(t, HealthRed, health.Status)
+
+	// Machine problems raise the app's status
+	health = EvaluateAppHealth("app", []Machine{{State: "started"}, {State: "started"}, {State: "failed"}}, thresholds, now)
+	assert.Equal(t, HealthRed, health.Status)
+	require.Len(t, health.Machines, 3)
+	assert.Equal(t, "failed", health.Machines[2].Reason)
+}
+
+func TestLastEventTime(t *testing.T) {
+	assert.True(t, Machine{}.LastEventTime().IsZero())
+	m := Machine{Events: []Event{{Timestamp: 1000}, {Timestamp: 3000}, {Timestamp: 2000}}}
+	assert.Equal(t, int64(3000), m.LastEventTime().UnixMilli())
+	assert.Equal(t, "red", HealthRed.String())
+}
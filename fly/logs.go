@@ -0,0 +1,164 @@
+package fly
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LogEntry is a single structured log line as emitted by `flyctl logs --json`
+type LogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Region    string `json:"region"`
+	Instance  string `json:"instance"`
+	Message   string `json:"message"`
+
+	// Raw is the original line the entry was parsed from
+	Raw string `json:"-"`
+}
+
+// rawLogEntry accepts both the flat flyctl format and the nested NATS format
+// ({"fly":{"app":{"instance":...},"region":...},"log":{"level":...}})
+type rawLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Region    string `json:"region"`
+	Instance  string `json:"instance"`
+	Message   string `json:"message"`
+	Fly       struct {
+		App struct {
+			Instance string `json:"instance"`
+		} `json:"app"`
+		Region string `json:"region"`
+	} `json:"fly"`
+	Log struct {
+		Level string `json:"level"`
+	} `json:"log"`
+}
+
+// ParseLogLine parses a single JSON log line
+func ParseLogLine(line string) (LogEntry, error) {
+	var raw rawLogEntry
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return LogEntry{Raw: line}, fmt.Errorf("error parsing log line: %v", err)
+	}
+
+	entry := LogEntry{
+		Timestamp: raw.Timestamp,
+		Level:     raw.Level,
+		Region:    raw.Region,
+		Instance:  raw.Instance,
+		Message:   raw.Message,
+		Raw:       line,
+	}
+	if entry.Level == "" {
+		entry.Level = raw.Log.Level
+	}
+	if entry.Region == "" {
+		entry.Region = raw.Fly.Region
+	}
+	if entry.Instance == "" {
+		entry.Instance = raw.Fly.App.Instance
+	}
+
+	return entry, nil
+}
+
+// ParseLogs parses newline separated JSON log lines, preserving order.
+// Lines that are not valid JSON are kept with only Message and Raw set.
+func ParseLogs(logs string) []LogEntry {
+	var entries []LogEntry
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		entry, err := ParseLogLine(line)
+		if err != nil {
+			entry.Message = line
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// LogFilter selects log entries by field. Empty fields match anything.
+type LogFilter struct {
+	Level    string
+	Region   string
+	Instance string
+}
+
+// IsEmpty reports whether the filter matches every entry
+func (f LogFilter) IsEmpty() bool {
+	return f.Level == "" && f.Region == "" && f.Instance == ""
+}
+
+// Match reports whether the entry satisfies every non-empty filter field
+func (f LogFilter) Match(entry LogEntry) bool {
+	if f.Level != "" && !strings.EqualFold(f.Level, entry.Level) {
+		return false
+	}
+	if f.Region != "" && !strings.EqualFold(f.Region, entry.Region) {
+		return false
+	}
+	if f.Instance != "" && !strings.HasPrefix(entry.Instance, f.Instance) {
+		return false
+	}
+	return true
+}
+
+// FilterLogs returns the entries matching the filter, preserving order
+func FilterLogs(entries []LogEntry, filter LogFilter) []LogEntry {
+	result := make([]LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// Compact renders the entry as a single line of text
+func (e LogEntry) Compact() string {
+	instance := e.Instance
+	if len(instance) > 8 {
+		instance = instance[:8]
+	}
+
+	var fields []string
+	for _, f := range []string{e.Timestamp, e.Region, instance} {
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	if e.Level != "" {
+		fields = append(fields, "["+e.Level+"]")
+	}
+	fields = append(fields, e.Message)
+
+	return strings.Join(fields, " ")
+}
+
+// GetMachineStructuredLogs gets the buffered logs for a specific machine as structured entries
+func GetMachineStructuredLogs(appName, machineID string) ([]LogEntry, error) {
+	// Increment the global flyctl call counter
+	IncrementFlyctlCallCount()
+
+	cmd := exec.Command("flyctl", "logs", "-a", appName, "--machine", machineID, "--no-tail", "--json")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("error running command: %v - %s", err, stderr.String())
+	}
+
+	return ParseLogs(out.String()), nil
+}
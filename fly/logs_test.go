@@ -0,0 +1,65 @@
+package fly
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testLogs = `{"timestamp":"2024-01-01T00:00:01Z","level":"info","region":"iad","instance":"e784079b449483","message":"starting"}
+{"timestamp":"2024-01-01T00:00:02Z","level":"error","region":"iad","instance":"e784079b449483","message":"first failure"}
+{"timestamp":"2024-01-01T00:00:03Z","level":"warn","region":"ams","instance":"3d8d9e3f6b2c89","message":"slow request"}
+not json at all
+{"timestamp":"2024-01-01T00:00:04Z","fly":{"app":{"instance":"3d8d9e3f6b2c89","name":"app"},"region":"ams"},"log":{"level":"error"},"message":"second failure"}
+`
+
+func TestParseLogs(t *testing.T) {
+	entries := ParseLogs(testLogs)
+	require.Len(t, entries, 5)
+
+	assert.Equal(t, "info", entries[0].Level)
+	assert.Equal(t, "iad", entries[0].Region)
+	assert.Equal(t, "starting", entries[0].Message)
+
+	// Non-JSON lines are kept as plain messages
+	assert.Equal(t, "", entries[3].Level)
+	assert.Equal(t, "not json at all", entries[3].Message)
+
+	// Nested format
+	assert.Equal(t, "error", entries[4].Level)
+	assert.Equal(t, "ams", entries[4].Region)
+	assert.Equal(t, "3d8d9e3f6b2c89", entries[4].Instance)
+}
+
+func TestFilterLogsByLevel(t *testing.T) {
+	entries := FilterLogs(ParseLogs(testLogs), LogFilter{Level: "error"})
+	require.Len(t, entries, 2)
+	assert.Equal(t, "first failure", entries[0].Message)
+	assert.Equal(t, "second failure", entries[1].Message)
+
+	// Level matching is case-insensitive
+	assert.Len(t, FilterLogs(ParseLogs(testLogs), LogFilter{Level: "ERROR"}), 2)
+}
+
+func TestFilterLogsByRegionAndInstance(t *testing.T) {
+	entries := FilterLogs(ParseLogs(testLogs), LogFilter{Region: "ams"})
+	require.Len(t, entries, 2)
+	assert.Equal(t, "slow request", entries[0].Message)
+	assert.Equal(t, "second failure", entries[1].Message)
+
+	entries = FilterLogs(ParseLogs(testLogs), LogFilter{Level: "error", Instance: "e784"})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "first failure", entries[0].Message)
+
+	assert.True(t, LogFilter{}.IsEmpty())
+	assert.Len(t, FilterLogs(ParseLogs(testLogs), LogFilter{}), 5)
+}
+
+func TestLogEntryCompact(t *testing.T) {
+	entries := ParseLogs(testLogs)
+	assert.Equal(t, "2024-01-01T00:00:02Z iad e784079b [error] first failure", entries[1].Compact())
+	assert.Equal(t, "not json at all", entries[3].Compact())
+	assert.True(t, strings.HasPrefix(entries[1].Raw, "{"))
+}
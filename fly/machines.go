@@ -0,0 +1,89 @@
+package fly
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// Machine actions supported by RunMachineAction
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionRestart = "restart"
+)
+
+// IsMachineAction reports whether action is a supported machine action
+func IsMachineAction(action string) bool {
+	switch action {
+	case ActionStart, ActionStop, ActionRestart:
+		return true
+	}
+	return false
+}
+
+// NeedsAction reports whether the machine is not already in the state the action
+// would put it in. Restarts always apply.
+func (m Machine) NeedsAction(action string) bool {
+	switch action {
+	case ActionStart:
+		return m.State != "started"
+	case ActionStop:
+		return m.State != "stopped"
+	}
+	return true
+}
+
+// RunMachineAction starts, stops or restarts a specific machine
+func RunMachineAction(appName, machineID, action string) error {
+	if !IsMachineAction(action) {
+		return fmt.Errorf("unsupported machine action: %s", action)
+	}
+
+	defer invalidateMachineList(appName)
+	return runMachineCommand(action, machineID, "-a", appName)
+}
+
+// runMachineCommand runs `flyctl machine <subcommand> <args...>`
+func runMachineCommand(subcommand string, args ...string) error {
+	// Increment the global flyctl call counter
+	IncrementFlyctlCallCount()
+
+	cmd := exec.Command("flyctl", append([]string{"machine", subcommand}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("error running machine %s: %v - %s", subcommand, err, stderr.String())
+	}
+
+	return nil
+}
+
+// StartMachine starts a specific machine
+func StartMachine(appName, machineID string) error {
+	return RunMachineAction(appName, machineID, ActionStart)
+}
+
+// StopMachine stops a specific machine
+func StopMachine(appName, machineID string) error {
+	return RunMachineAction(appName, machineID, ActionStop)
+}
+
+// RestartMachine restarts a specific machine
+func RestartMachine(appName, machineID string) error {
+	return RunMachineAction(appName, machineID, ActionRestart)
+}
+
+// CloneMachine creates a new machine in a region with the same configuration as an existing machine
+func CloneMachine(appName, machineID, region string) error {
+	defer invalidateMachineList(appName)
+	return runMachineCommand("clone", machineID, "-a", appName, "--region", region)
+}
+
+// DestroyMachine destroys a specific machine, stopping it first if it is running
+func DestroyMachine(appName, machineID string) error {
+	defer invalidateMachineList(appName)
+	return runMachineCommand("destroy", machineID, "-a", appName, "--force")
+}
@@ -0,0 +1,27 @@
+package fly
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineNeedsAction(t *testing.T) {
+	started := Machine{State: "started"}
+	stopped := Machine{State: "stopped"}
+
+	assert.False(t, started.NeedsAction(ActionStart))
+	assert.True(t, started.NeedsAction(ActionStop))
+	assert.True(t, stopped.NeedsAction(ActionStart))
+	assert.False(t, stopped.NeedsAction(ActionStop))
+	assert.True(t, started.NeedsAction(ActionRestart))
+	assert.True(t, stopped.NeedsAction(ActionRestart))
+}
+
+func TestRunMachineActionRejectsUnknownAction(t *testing.T) {
+	before := GetFlyctlCallCount()
+	assert.Error(t, RunMachineAction("app", "machine", "destroy"))
+	assert.Equal(t, before, GetFlyctlCallCount())
+	assert.False(t, IsMachineAction("destroy"))
+	assert.True(t, IsMachineAction(ActionRestart))
+}
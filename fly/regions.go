@@ -0,0 +1,190 @@
+package fly
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the built-in region groups
+const (
+	GroupUS = "us"
+	GroupEU = "eu"
+)
+
+// DefaultAppNameTemplate builds app names such as "us-east-1-portal"
+const DefaultAppNameTemplate = "{region}-{app}"
+
+// RegionConfigEnv names an environment variable holding the path of a YAML or
+// JSON region config file loaded at startup
+const RegionConfigEnv = "FLY_REGION_CONFIG"
+
+// RegionGroup is a named set of regions, such as "us" or "eu"
+type RegionGroup struct {
+	Name    string   `json:"name" yaml:"name"`
+	Regions []string `json:"regions" yaml:"regions"`
+}
+
+// RegionConfig defines the region groups and applications operated on
+type RegionConfig struct {
+	// Groups lists the region groups in display order
+	Groups []RegionGroup `json:"groups" yaml:"groups"`
+
+	// AppNames lists the application types deployed in each region
+	AppNames []string `json:"app_names" yaml:"app_names"`
+
+	// AppNameTemplate builds a full app name from {region} and {app}
+	// placeholders (default: "{region}-{app}")
+	AppNameTemplate string `json:"app_name_template" yaml:"app_name_template"`
+}
+
+// DefaultRegionConfig returns the built-in configuration, overridden by the
+// US_REGIONS, EU_REGIONS, APP_NAMES and APP_NAME_TEMPLATE environment variables
+func DefaultRegionConfig() *RegionConfig {
+	template := os.Getenv("APP_NAME_TEMPLATE")
+	if template == "" {
+		template = DefaultAppNameTemplate
+	}
+
+	return &RegionConfig{
+		Groups: []RegionGroup{
+			{Name: GroupUS, Regions: getEnvironmentStringSlice("US_REGIONS", []string{"us-east-1", "us-east-2", "us-east-3", "us-east-4"})},
+			{Name: GroupEU, Regions: getEnvironmentStringSlice("EU_REGIONS", []string{"eu-west-1", "eu-west-2", "eu-west-3", "eu-west-4"})},
+		},
+		AppNames:        getEnvironmentStringSlice("APP_NAMES", []string{"portal", "websocket"}),
+		AppNameTemplate: template,
+	}
+}
+
+// LoadRegionConfig reads a region config from a YAML or JSON file, chosen by
+// extension. Missing fields keep their default values.
+func LoadRegionConfig(path string) (*RegionConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading region config: %v", err)
+	}
+
+	config := DefaultRegionConfig()
+	loaded := &RegionConfig{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, loaded)
+	default:
+		err = yaml.Unmarshal(data, loaded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing region config: %v", err)
+	}
+
+	if loaded.Groups != nil {
+		config.Groups = loaded.Groups
+	}
+	if loaded.AppNames != nil {
+		config.AppNames = loaded.AppNames
+	}
+	if loaded.AppNameTemplate != "" {
+		config.AppNameTemplate = loaded.AppNameTemplate
+	}
+
+	return config, config.Validate()
+}
+
+// Validate checks that the config is usable
+func (c *RegionConfig) Validate() error {
+	seen := make(map[string]bool)
+	for _, group := range c.Groups {
+		if group.Name == "" {
+			return fmt.Errorf("region group without a name")
+		}
+		if seen[group.Name] {
+			return fmt.Errorf("duplicate region group %q", group.Name)
+		}
+		seen[group.Name] = true
+	}
+	if !strings.Contains(c.AppNameTemplate, "{app}") && !strings.Contains(c.AppNameTemplate, "{region}") {
+		return fmt.Errorf("app name template %q has no {region} or {app} placeholder", c.AppNameTemplate)
+	}
+	return nil
+}
+
+// Group returns the regions in the named group, or nil if there is no such group
+func (c *RegionConfig) Group(name string) []string {
+	for _, group := range c.Groups {
+		if strings.EqualFold(group.Name, name) {
+			return group.Regions
+		}
+	}
+	return nil
+}
+
+// AllRegions returns the regions of every group in order, without duplicates
+func (c *RegionConfig) AllRegions() []string {
+	var regions []string
+	seen := make(map[string]bool)
+	for _, group := range c.Groups {
+		for _, region := range group.Regions {
+			if !seen[region] {
+				seen[region] = true
+				regions = append(regions, region)
+			}
+		}
+	}
+	return regions
+}
+
+// AppName builds the full app name for an application type in a region
+func (c *RegionConfig) AppName(region, appType string) string {
+	template := c.AppNameTemplate
+	if template == "" {
+		template = DefaultAppNameTemplate
+	}
+	return strings.NewReplacer("{region}", region, "{app}", appType).Replace(template)
+}
+
+var (
+	regionConfig   *RegionConfig
+	regionConfigMu sync.RWMutex
+)
+
+// SetConfig replaces the region config used by the package
+func SetConfig(config *RegionConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	regionConfigMu.Lock()
+	regionConfig = config
+	regionConfigMu.Unlock()
+	return nil
+}
+
+// GetConfig returns the region config used by the package
+func GetConfig() *RegionConfig {
+	regionConfigMu.RLock()
+	defer regionConfigMu.RUnlock()
+	return regionConfig
+}
+
+// GetRegionGroup returns the regions in the named group
+func GetRegionGroup(name string) []string {
+	return GetConfig().Group(name)
+}
+
+// GetRegionGroups returns every configured region group
+func GetRegionGroups() []RegionGroup {
+	return GetConfig().Groups
+}
+
+// GetAllRegions returns the regions of every configured group
+func GetAllRegions() []string {
+	return GetConfig().AllRegions()
+}
+
+// FullAppName builds the full app name for an application type in a region
+func FullAppName(region, appType string) string {
+	return GetConfig().AppName(region, appType)
+}
@@ -0,0 +1,71 @@
+package fly
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRegionConfigYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+groups:
+  - name: ap
+    regions: [syd, nrt]
+  - name: us
+    regions: [iad, ord]
+app_names: [api]
+app_name_template: "acme-{app}-{region}"
+`), 0644))
+
+	config, err := LoadRegionConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"syd", "nrt"}, config.Group("ap"))
+	assert.Equal(t, []string{"iad", "ord"}, config.Group("US"))
+	assert.Nil(t, config.Group("eu"))
+	assert.Equal(t, []string{"syd", "nrt", "iad", "ord"}, config.AllRegions())
+	assert.Equal(t, "acme-api-syd", config.AppName("syd", "api"))
+}
+
+func TestLoadRegionConfigJSONKeepsDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"app_names": ["worker"]}`), 0644))
+
+	config, err := LoadRegionConfig(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"worker"}, config.AppNames)
+	assert.Equal(t, DefaultRegionConfig().Groups, config.Groups)
+	assert.Equal(t, "us-east-1-worker", config.AppName("us-east-1", "worker"))
+}
+
+func TestRegionConfigValidate(t *testing.T) {
+	assert.Error(t, (&RegionConfig{Groups: []RegionGroup{{Name: "us"}, {Name: "us"}}, AppNameTemplate: DefaultAppNameTemplate}).Validate())
+	assert.Error(t, (&RegionConfig{Groups: []RegionGroup{{}}, AppNameTemplate: DefaultAppNameTemplate}).Validate())
+	assert.Error(t, (&RegionConfig{AppNameTemplate: "static"}).Validate())
+}
+
+func TestSetConfig(t *testing.T) {
+	original := GetConfig()
+	t.Cleanup(func() { SetConfig(original) })
+
+	require.NoError(t, SetConfig(&RegionConfig{
+		Groups:          []RegionGroup{{Name: "us", Regions: []string{"iad"}}, {Name: "sa", Regions: []string{"gru"}}},
+		AppNames:        []string{"web"},
+		AppNameTemplate: "{app}-{region}",
+	}))
+
+	assert.Equal(t, []string{"iad"}, GetUSRegions())
+	assert.Nil(t, GetEURegions())
+	assert.Equal(t, []string{"gru"}, GetRegionGroup("sa"))
+	assert.Equal(t, []string{"iad", "gru"}, GetAllRegions())
+	assert.Equal(t, []string{"web"}, GetAppNames())
+	assert.Equal(t, "web-gru", FullAppName("gru", "web"))
+
+	assert.Error(t, SetConfig(&RegionConfig{AppNameTemplate: "static"}))
+	assert.Equal(t, "web-gru", FullAppName("gru", "web"))
+}
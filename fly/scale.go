@@ -0,0 +1,87 @@
+package fly
+
+import (
+	"sort"
+)
+
+// ScalePlan describes how to bring a region to a target machine count
+type ScalePlan struct {
+	Region  string
+	Current int
+	Target  int
+
+	// CloneFrom is the machine cloned to add machines, nil when none are added
+	CloneFrom *Machine
+	Clones    int
+
+	// Destroy lists the machines removed to shrink the region
+	Destroy []Machine
+}
+
+// IsNoop reports whether the region is already at its target count
+func (p ScalePlan) IsNoop() bool {
+	return p.Clones == 0 && len(p.Destroy) == 0
+}
+
+// PlanScale works out the clones and destroys needed to run count machines in
+// each region. When no regions are given, every region the app already runs
+// machines in is scaled. New machines are cloned from the most recently
+// updated machine in the region, or in the app if the region is empty.
+// Stopped machines are destroyed first, then the newest.
+func PlanScale(machines []Machine, regions []string, count int) []ScalePlan {
+	byRegion := make(map[string][]Machine)
+	for _, m := range machines {
+		byRegion[m.Region] = append(byRegion[m.Region], m)
+	}
+
+	if len(regions) == 0 {
+		for region := range byRegion {
+			regions = append(regions, region)
+		}
+		sort.Strings(regions)
+	}
+
+	template := latestMachine(machines)
+
+	plans := make([]ScalePlan, 0, len(regions))
+	for _, region := range regions {
+		current := byRegion[region]
+		plan := ScalePlan{Region: region, Current: len(current), Target: count}
+
+		switch {
+		case len(current) < count:
+			plan.CloneFrom = latestMachine(current)
+			if plan.CloneFrom == nil {
+				plan.CloneFrom = template
+			}
+			if plan.CloneFrom != nil {
+				plan.Clones = count - len(current)
+			}
+		case len(current) > count:
+			candidates := append([]Machine(nil), current...)
+			sort.SliceStable(candidates, func(i, j int) bool {
+				iStopped, jStopped := candidates[i].State != "started", candidates[j].State != "started"
+				if iStopped != jStopped {
+					return iStopped
+				}
+				return candidates[i].Created.After(candidates[j].Created)
+			})
+			plan.Destroy = candidates[:len(current)-count]
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans
+}
+
+// latestMachine returns the most recently updated machine, or nil if there are none
+func latestMachine(machines []Machine) *Machine {
+	var latest *Machine
+	for i := range machines {
+		if latest == nil || machines[i].Updated.After(latest.Updated) {
+			latest = &machines[i]
+		}
+	}
+	return latest
+}
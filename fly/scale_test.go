@@ -0,0 +1,59 @@
+package fly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testScaleMachines() []Machine {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []Machine{
+		{ID: "iad-old", Region: "iad", State: "started", Created: base, Updated: base},
+		{ID: "iad-new", Region: "iad", State: "started", Created: base.Add(time.Hour), Updated: base.Add(time.Hour)},
+		{ID: "iad-stopped", Region: "iad", State: "stopped", Created: base, Updated: base},
+		{ID: "ams-1", Region: "ams", State: "started", Created: base, Updated: base.Add(2 * time.Hour)},
+	}
+}
+
+func TestPlanScaleDown(t *testing.T) {
+	plans := PlanScale(testScaleMachines(), []string{"iad"}, 1)
+	require.Len(t, plans, 1)
+
+	// Stopped machines go first, then the newest
+	plan := plans[0]
+	assert.Equal(t, 3, plan.Current)
+	require.Len(t, plan.Destroy, 2)
+	assert.Equal(t, "iad-stopped", plan.Destroy[0].ID)
+	assert.Equal(t, "iad-new", plan.Destroy[1].ID)
+	assert.Zero(t, plan.Clones)
+}
+
+func TestPlanScaleUp(t *testing.T) {
+	plans := PlanScale(testScaleMachines(), []string{"iad", "syd"}, 4)
+	require.Len(t, plans, 2)
+
+	assert.Equal(t, 1, plans[0].Clones)
+	assert.Equal(t, "iad-new", plans[0].CloneFrom.ID)
+
+	// Empty regions clone the app's most recently updated machine
+	assert.Equal(t, 4, plans[1].Clones)
+	assert.Equal(t, "ams-1", plans[1].CloneFrom.ID)
+}
+
+func TestPlanScaleExistingRegions(t *testing.T) {
+	plans := PlanScale(testScaleMachines(), nil, 1)
+	require.Len(t, plans, 2)
+	assert.Equal(t, "ams", plans[0].Region)
+	assert.True(t, plans[0].IsNoop())
+	assert.Equal(t, "iad", plans[1].Region)
+	assert.Len(t, plans[1].Destroy, 2)
+
+	// Nothing to clone from in an app without machines
+	plans = PlanScale(nil, []string{"iad"}, 2)
+	require.Len(t, plans, 1)
+	assert.Nil(t, plans[0].CloneFrom)
+	assert.True(t, plans[0].IsNoop())
+}
@@ -0,0 +1,187 @@
+package fly
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogSource identifies a machine whose logs are followed
+type LogSource struct {
+	AppName   string
+	MachineID string
+}
+
+// LogLine is a single line received from a followed machine. Lines with Err
+// set report a connection failure rather than log output.
+type LogLine struct {
+	Source LogSource
+	Text   string
+	Err    error
+}
+
+// LogStream follows the logs of many machines concurrently and merges them into
+// a single channel. Reading from the channel applies backpressure: when it is
+// full, sources stop reading from flyctl until there is room. Sources whose
+// flyctl process exits are reconnected with exponential backoff.
+type LogStream struct {
+	// Buffer is the capacity of the channel returned by Start
+	Buffer int
+
+	// JSON requests structured log lines (flyctl logs --json)
+	JSON bool
+
+	// RetryDelay is the delay before the first reconnect attempt. It doubles
+	// after each consecutive failure up to MaxRetryDelay.
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+
+	// MaxRetries is the number of consecutive reconnects attempted without
+	// receiving output before a source is abandoned. Zero retries forever.
+	MaxRetries int
+
+	// Command builds the process used to follow a source. It defaults to
+	// `flyctl logs -a <app> --machine <id>`.
+	Command func(ctx context.Context, source LogSource, json bool) *exec.Cmd
+
+	sources []LogSource
+}
+
+// NewLogStream creates a LogStream for the given machines with default settings
+func NewLogStream(sources []LogSource) *LogStream {
+	return &LogStream{
+		Buffer:        256,
+		RetryDelay:    time.Second,
+		MaxRetryDelay: 30 * time.Second,
+		MaxRetries:    10,
+		Command:       flyctlLogsCommand,
+		sources:       sources,
+	}
+}
+
+// flyctlLogsCommand builds the flyctl command that follows a machine's logs
+func flyctlLogsCommand(ctx context.Context, source LogSource, json bool) *exec.Cmd {
+	args := []string{"logs", "-a", source.AppName, "--machine", source.MachineID}
+	if json {
+		args = append(args, "--json")
+	}
+	return exec.CommandContext(ctx, "flyctl", args...)
+}
+
+// Start begins following every source and returns the merged lines. The
+// channel is closed once ctx is done or every source has been abandoned.
+func (s *LogStream) Start(ctx context.Context) <-chan LogLine {
+	lines := make(chan LogLine, s.Buffer)
+
+	var wg sync.WaitGroup
+	for _, source := range s.sources {
+		wg.Add(1)
+		go func(source LogSource) {
+			defer wg.Done()
+			s.follow(ctx, source, lines)
+		}(source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	return lines
+}
+
+// follow tails a single source, reconnecting until ctx is done or the retries
+// are exhausted
+func (s *LogStream) follow(ctx context.Context, source LogSource, lines chan<- LogLine) {
+	delay := s.RetryDelay
+	failures := 0
+
+	for {
+		received, err := s.tail(ctx, source, lines)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Output means the connection was healthy, so start backing off afresh
+		if received {
+			delay = s.RetryDelay
+			failures = 0
+		}
+		failures++
+		if err == nil {
+			err = fmt.Errorf("log stream ended")
+		}
+
+		if s.MaxRetries > 0 && failures > s.MaxRetries {
+			s.send(ctx, lines, LogLine{Source: source, Err: fmt.Errorf("giving up after %d attempts: %w", failures, err)})
+			return
+		}
+		if !s.send(ctx, lines, LogLine{Source: source, Err: fmt.Errorf("reconnecting in %s: %w", delay, err)}) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if s.MaxRetryDelay > 0 && delay > s.MaxRetryDelay {
+			delay = s.MaxRetryDelay
+		}
+	}
+}
+
+// tail runs the follow command once, forwarding its output until it exits.
+// It reports whether any lines were received.
+func (s *LogStream) tail(ctx context.Context, source LogSource, lines chan<- LogLine) (bool, error) {
+	// Increment the global flyctl call counter
+	IncrementFlyctlCallCount()
+
+	cmd := s.Command(ctx, source, s.JSON)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, fmt.Errorf("error creating stdout pipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return false, fmt.Errorf("error starting command: %v", err)
+	}
+
+	received := false
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		received = true
+		if !s.send(ctx, lines, LogLine{Source: source, Text: text}) {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return received, fmt.Errorf("error running command: %v - %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return received, nil
+}
+
+// send delivers a line, blocking until there is room or ctx is done
+func (s *LogStream) send(ctx context.Context, lines chan<- LogLine, line LogLine) bool {
+	select {
+	case lines <- line:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,96 @@
+package fly
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptCommand returns a Command that runs a shell script with the source in $APP and $MACHINE
+func scriptCommand(script string) func(context.Context, LogSource, bool) *exec.Cmd {
+	return func(ctx context.Context, source LogSource, json bool) *exec.Cmd {
+		cmd := exec.CommandContext(ctx, "sh", "-c", script)
+		cmd.Env = append(cmd.Environ(), "APP="+source.AppName, "MACHINE="+source.MachineID)
+		return cmd
+	}
+}
+
+func TestLogStreamMergesAndReconnects(t *testing.T) {
+	stream := NewLogStream([]LogSource{
+		{AppName: "app-a", MachineID: "m1"},
+		{AppName: "app-b", MachineID: "m2"},
+	})
+	stream.RetryDelay = time.Millisecond
+	stream.Command = scriptCommand(`echo "$APP/$MACHINE first"; echo "$APP/$MACHINE second"; exit 1`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	seen := make(map[string]int)
+	reconnects := 0
+	for line := range stream.Start(ctx) {
+		if line.Err != nil {
+			assert.Contains(t, line.Err.Error(), "reconnecting")
+			reconnects++
+		} else {
+			assert.True(t, strings.HasPrefix(line.Text, line.Source.AppName+"/"+line.Source.MachineID))
+			seen[line.Source.AppName]++
+		}
+		if seen["app-a"] >= 4 && seen["app-b"] >= 4 {
+			cancel()
+		}
+	}
+
+	// Each source was reconnected after its process exited
+	assert.GreaterOrEqual(t, seen["app-a"], 4)
+	assert.GreaterOrEqual(t, seen["app-b"], 4)
+	assert.GreaterOrEqual(t, reconnects, 2)
+}
+
+func TestLogStreamGivesUp(t *testing.T) {
+	stream := NewLogStream([]LogSource{{AppName: "app", MachineID: "m1"}})
+	stream.RetryDelay = time.Millisecond
+	stream.MaxRetries = 2
+	stream.Command = scriptCommand(`echo "no such machine" >&2; exit 1`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var errs []error
+	for line := range stream.Start(ctx) {
+		require.Error(t, line.Err)
+		errs = append(errs, line.Err)
+	}
+
+	// Two reconnects, then the source is abandoned and the channel closed
+	require.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Error(), "no such machine")
+	assert.Contains(t, errs[2].Error(), "giving up after 3 attempts")
+	assert.NoError(t, ctx.Err())
+}
+
+func TestLogStreamBackpressure(t *testing.T) {
+	stream := NewLogStream([]LogSource{{AppName: "app", MachineID: "m1"}})
+	stream.Buffer = 1
+	stream.Command = scriptCommand(`i=0; while [ $i -lt 100 ]; do echo "line $i"; i=$((i+1)); done; exec sleep 10`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lines := stream.Start(ctx)
+
+	// Nothing is dropped while the reader is slow
+	for i := 0; i < 100; i++ {
+		line := <-lines
+		require.NoError(t, line.Err)
+		require.Equal(t, "line "+strconv.Itoa(i), line.Text)
+	}
+
+	cancel()
+	for range lines {
+	}
+}
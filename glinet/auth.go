@@ -0,0 +1,138 @@
+package glinet
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/GehirnInc/crypt"
+	_ "github.com/GehirnInc/crypt/md5_crypt"
+	_ "github.com/GehirnInc/crypt/sha256_crypt"
+	_ "github.com/GehirnInc/crypt/sha512_crypt"
+)
+
+// challengeResult represents the router's response to a login challenge
+type challengeResult struct {
+	Salt       string `json:"salt"`
+	Alg        int    `json:"alg"`
+	Nonce      string `json:"nonce"`
+	HashMethod string `json:"hash-method"`
+}
+
+// loginResult represents the router's response to a successful login
+type loginResult struct {
+	SID string `json:"sid"`
+}
+
+// Login authenticates with the router using its challenge/response scheme and
+// returns a client holding the session token. The client keeps the credentials
+// and logs in again whenever the router rejects an expired session, so long
+// batches survive router reboots.
+func Login(routerURL, username, password string) (*Client, error) {
+	c := NewClient(routerURL, "")
+	c.username = username
+	c.password = password
+
+	if _, err := c.relogin(""); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// token returns the current session token
+func (c *Client) token() string {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.AuthToken
+}
+
+// canLogin reports whether the client has credentials to log in again
+func (c *Client) canLogin() bool {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.username != ""
+}
+
+// relogin replaces a stale session token with a new one. If another request
+// already replaced it, the current token is returned without logging in again.
+func (c *Client) relogin(stale string) (string, error) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	if c.AuthToken != stale {
+		return c.AuthToken, nil
+	}
+
+	sid, err := c.login()
+	if err != nil {
+		return "", err
+	}
+	c.AuthToken = sid
+	return sid, nil
+}
+
+// login performs the challenge/response login and returns the new session ID
+func (c *Client) login() (string, error) {
+	var challenge challengeResult
+	if err := c.post(1, "challenge", map[string]interface{}{"username": c.username}, "", &challenge); err != nil {
+		return "", fmt.Errorf("error requesting login challenge: %w", err)
+	}
+
+	// The password is first hashed the way the router stores it in /etc/shadow
+	crypter, err := passwordCrypter(challenge.Alg)
+	if err != nil {
+		return "", err
+	}
+	cipherPassword, err := crypter.Generate([]byte(c.password), []byte(fmt.Sprintf("$%d$%s", challenge.Alg, challenge.Salt)))
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+
+	// Then combined with the username and the one-time nonce
+	var h hash.Hash
+	switch challenge.HashMethod {
+	case "", "md5":
+		h = md5.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", fmt.Errorf("unsupported login hash method: %s", challenge.HashMethod)
+	}
+	fmt.Fprintf(h, "%s:%s:%s", c.username, cipherPassword, challenge.Nonce)
+
+	var result loginResult
+	params := map[string]interface{}{"username": c.username, "hash": hex.EncodeToString(h.Sum(nil))}
+	if err := c.post(1, "login", params, "", &result); err != nil {
+		return "", fmt.Errorf("error logging in: %w", err)
+	}
+	if result.SID == "" {
+		return "", fmt.Errorf("login response did not include a session")
+	}
+
+	// A new session invalidates anything cached under the old one
+	c.clientCacheMu.Lock()
+	c.clientCache = nil
+	c.clientCacheTime = time.Time{}
+	c.clientCacheMu.Unlock()
+
+	return result.SID, nil
+}
+
+// passwordCrypter returns the crypt(3) function identified by a challenge's algorithm
+func passwordCrypter(alg int) (crypt.Crypter, error) {
+	switch alg {
+	case 1:
+		return crypt.MD5.New(), nil
+	case 5:
+		return crypt.SHA256.New(), nil
+	case 6:
+		return crypt.SHA512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported password algorithm: %d", alg)
+}
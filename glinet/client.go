@@ -1,8 +1,6 @@
 package glinet
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"sync"
@@ -18,6 +16,11 @@ type Client struct {
 	clientCache     []ClientInfo
 	clientCacheMu   sync.RWMutex
 	clientCacheTime time.Time
+
+	// Credentials kept by Login to replace expired sessions
+	username string
+	password string
+	authMu   sync.Mutex
 }
 
 // RouterClient creates a new client for connecting to the router
@@ -114,53 +117,9 @@ func (c *Client) GetClients() ([]ClientInfo, error) {
 	}
 	c.clientCacheMu.RUnlock()
 
-	// Create request payload
-	req := Request{
-		JSONRPC: "2.0",
-		ID:      3,
-		Method:  "call",
-		Params:  []interface{}{c.AuthToken, "clients", "get_list", map[string]interface{}{}},
-	}
-
-	// Marshal the request to JSON
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequest(http.MethodPost, c.RouterURL+"/rpc", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json, text/plain, */*")
-
-	// Add cookie
-	cookie := &http.Cookie{
-		Name:  "Admin-Token",
-		Value: c.AuthToken,
-	}
-	httpReq.AddCookie(cookie)
-
-	// Make the request
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Decode response
 	var clientResp ClientListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&clientResp); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := c.call(3, "clients", "get_list", nil, &clientResp.Result); err != nil {
+		return nil, err
 	}
 
 	// Update cache
@@ -247,59 +206,14 @@ func (c *Client) AddStaticBind(name, mac, ip string) error {
 		IP:   ip,
 	}
 
-	// Create request payload
-	req := Request{
-		JSONRPC: "2.0",
-		ID:      4,
-		Method:  "call",
-		Params:  []interface{}{c.AuthToken, "lan", "add_static_bind", bindParams},
-	}
-
-	// Marshal the request to JSON
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequest(http.MethodPost, c.RouterURL+"/rpc", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json, text/plain, */*")
-
-	// Add cookie
-	cookie := &http.Cookie{
-		Name:  "Admin-Token",
-		Value: c.AuthToken,
-	}
-	httpReq.AddCookie(cookie)
-
-	// Make the request
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Decode response
-	var genericResp GenericResponse
-	if err := json.NewDecoder(resp.Body).Decode(&genericResp); err != nil {
-		return fmt.Errorf("error decoding response: %w", err)
-	}
-
-	// The response should be {"id":4,"jsonrpc":"2.0","result":[]}
+	// The result should be an empty array
 	// If result is not an empty array, something went wrong
-	if genericResp.JSONRPC != "2.0" || len(genericResp.Result) != 0 {
-		return fmt.Errorf("unexpected response: %+v", genericResp)
+	var result []interface{}
+	if err := c.call(4, "lan", "add_static_bind", bindParams, &result); err != nil {
+		return err
+	}
+	if len(result) != 0 {
+		return fmt.Errorf("unexpected response: %+v", result)
 	}
 
 	return nil
@@ -307,54 +221,18 @@ func (c *Client) AddStaticBind(name, mac, ip string) error {
 
 // GetStaticBindings retrieves the list of static IP bindings from the router
 func (c *Client) GetStaticBindings() ([]StaticBindInfo, error) {
-	// Create request payload
-	req := Request{
-		JSONRPC: "2.0",
-		ID:      2,
-		Method:  "call",
-		Params:  []interface{}{c.AuthToken, "lan", "get_static_bind_list", map[string]interface{}{}},
-	}
-
-	// Marshal the request to JSON
-	reqBody, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %w", err)
-	}
-
-	// Create HTTP request
-	httpReq, err := http.NewRequest(http.MethodPost, c.RouterURL+"/rpc", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Accept", "application/json, text/plain, */*")
-
-	// Add cookie
-	cookie := &http.Cookie{
-		Name:  "Admin-Token",
-		Value: c.AuthToken,
-	}
-	httpReq.AddCookie(cookie)
-
-	// Make the request
-	resp, err := c.HTTPClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check response status
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Decode response
 	var bindResp StaticBindListResponse
-	if err := json.NewDecoder(resp.Body).Decode(&bindResp); err != nil {
-		return nil, fmt.Errorf("error decoding response: %w", err)
+	if err := c.call(2, "lan", "get_static_bind_list", nil, &bindResp.Result); err != nil {
+		return nil, err
 	}
 
 	return bindResp.Result.StaticBindList, nil
 }
+
+// RemoveStaticBind removes the static IP address reservation for a MAC address
+func (c *Client) RemoveStaticBind(mac string) error {
+	if err := c.call(11, "lan", "remove_static_bind", map[string]interface{}{"mac": mac}, nil); err != nil {
+		return fmt.Errorf("error removing static bind for %s: %w", mac, err)
+	}
+	return nil
+}
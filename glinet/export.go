@@ -0,0 +1,66 @@
+package glinet
+
+import (
+	"sort"
+)
+
+// ExportRecord represents a static IP binding or connected client in an export
+type ExportRecord struct {
+	Name   string `json:"name"`
+	MAC    string `json:"mac"`
+	IP     string `json:"ip"`
+	Online bool   `json:"online"`
+	Static bool   `json:"static"`
+}
+
+// ExportStaticBindings returns every static IP binding on the router followed by
+// the connected clients that have no binding, so the result can be imported on
+// another router
+func (c *Client) ExportStaticBindings() ([]ExportRecord, error) {
+	bindings, err := c.GetStaticBindings()
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := c.GetClients()
+	if err != nil {
+		return nil, err
+	}
+
+	// Index clients by MAC address to look up their online state
+	clientsByMAC := make(map[string]ClientInfo, len(clients))
+	for _, client := range clients {
+		clientsByMAC[client.MAC] = client
+	}
+
+	records := make([]ExportRecord, 0, len(bindings)+len(clients))
+	bound := make(map[string]bool, len(bindings))
+	for _, binding := range bindings {
+		bound[binding.MAC] = true
+		records = append(records, ExportRecord{
+			Name:   binding.Name,
+			MAC:    binding.MAC,
+			IP:     binding.IP,
+			Online: clientsByMAC[binding.MAC].Online,
+			Static: true,
+		})
+	}
+
+	var unbound []ExportRecord
+	for _, client := range clients {
+		if bound[client.MAC] || !client.Online {
+			continue
+		}
+		unbound = append(unbound, ExportRecord{
+			Name:   client.Name,
+			MAC:    client.MAC,
+			IP:     client.IP,
+			Online: true,
+		})
+	}
+	sort.Slice(unbound, func(i, j int) bool {
+		return unbound[i].MAC < unbound[j].MAC
+	})
+
+	return append(records, unbound...), nil
+}
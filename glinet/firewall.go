@@ -0,0 +1,115 @@
+package glinet
+
+import (
+	"fmt"
+)
+
+// PortForward represents a port forwarding rule from the WAN to a LAN device
+type PortForward struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Proto    string `json:"proto"`
+	Src      string `json:"src"`
+	SrcDPort string `json:"src_dport"`
+	Dest     string `json:"dest"`
+	DestIP   string `json:"dest_ip"`
+	DestPort string `json:"dest_port"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// FirewallRule represents a traffic rule that accepts, rejects or drops traffic
+// between firewall zones
+type FirewallRule struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Proto    string `json:"proto"`
+	Src      string `json:"src"`
+	SrcIP    string `json:"src_ip,omitempty"`
+	Dest     string `json:"dest"`
+	DestIP   string `json:"dest_ip,omitempty"`
+	DestPort string `json:"dest_port,omitempty"`
+	Target   string `json:"target"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// GetPortForwards retrieves the list of port forwarding rules from the router
+func (c *Client) GetPortForwards() ([]PortForward, error) {
+	var result struct {
+		Res []PortForward `json:"res"`
+	}
+	if err := c.call(5, "firewall", "get_port_forward_list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Res, nil
+}
+
+// AddPortForward adds a port forwarding rule. Empty Src, Dest and Proto fields
+// default to "wan", "lan" and "tcp udp", and an empty DestPort to SrcDPort.
+// The rule is only active when Enabled is set.
+func (c *Client) AddPortForward(rule PortForward) error {
+	if rule.DestIP == "" || rule.SrcDPort == "" {
+		return fmt.Errorf("port forward requires a destination IP and external port")
+	}
+	if rule.Src == "" {
+		rule.Src = "wan"
+	}
+	if rule.Dest == "" {
+		rule.Dest = "lan"
+	}
+	if rule.Proto == "" {
+		rule.Proto = "tcp udp"
+	}
+	if rule.DestPort == "" {
+		rule.DestPort = rule.SrcDPort
+	}
+	rule.ID = ""
+
+	if err := c.call(6, "firewall", "add_port_forward", rule, nil); err != nil {
+		return fmt.Errorf("error adding port forward %s: %w", rule.Name, err)
+	}
+	return nil
+}
+
+// RemovePortForward removes a port forwarding rule by its ID
+func (c *Client) RemovePortForward(id string) error {
+	if err := c.call(7, "firewall", "remove_port_forward", map[string]interface{}{"id": id}, nil); err != nil {
+		return fmt.Errorf("error removing port forward %s: %w", id, err)
+	}
+	return nil
+}
+
+// GetFirewallRules retrieves the list of firewall traffic rules from the router
+func (c *Client) GetFirewallRules() ([]FirewallRule, error) {
+	var result struct {
+		Res []FirewallRule `json:"res"`
+	}
+	if err := c.call(8, "firewall", "get_rule_list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Res, nil
+}
+
+// AddFirewallRule adds a firewall traffic rule. An empty Target defaults to
+// "ACCEPT". The rule is only active when Enabled is set.
+func (c *Client) AddFirewallRule(rule FirewallRule) error {
+	if rule.Src == "" {
+		return fmt.Errorf("firewall rule requires a source zone")
+	}
+	if rule.Target == "" {
+		rule.Target = "ACCEPT"
+	}
+	rule.ID = ""
+
+	if err := c.call(9, "firewall", "add_rule", rule, nil); err != nil {
+		return fmt.Errorf("error adding firewall rule %s: %w", rule.Name, err)
+	}
+	return nil
+}
+
+// RemoveFirewallRule removes a firewall traffic rule by its ID
+func (c *Client) RemoveFirewallRule(id string) error {
+	if err := c.call(10, "firewall", "remove_rule", map[string]interface{}{"id": id}, nil); err != nil {
+		return fmt.Errorf("error removing firewall rule %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package glinet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Fleet runs operations against several routers concurrently
+type Fleet struct {
+	Clients []*Client
+
+	// Concurrency limits how many routers are contacted at once. Zero or less
+	// contacts every router at once.
+	Concurrency int
+}
+
+// FleetResult represents the outcome of an operation on one router of a fleet
+type FleetResult[T any] struct {
+	RouterURL string
+	Value     T
+	Err       error
+}
+
+// NewFleet creates a fleet from router URLs and their authentication tokens.
+// A single token is used for every router.
+func NewFleet(routerURLs, authTokens []string) (*Fleet, error) {
+	if len(authTokens) != 1 && len(authTokens) != len(routerURLs) {
+		return nil, fmt.Errorf("expected 1 or %d auth tokens, got %d", len(routerURLs), len(authTokens))
+	}
+
+	fleet := &Fleet{}
+	for i, routerURL := range routerURLs {
+		authToken := authTokens[0]
+		if len(authTokens) > 1 {
+			authToken = authTokens[i]
+		}
+		fleet.Clients = append(fleet.Clients, NewClient(routerURL, authToken))
+	}
+	return fleet, nil
+}
+
+// LoginFleet logs in to every router concurrently with the same credentials
+// and creates a fleet from the resulting clients
+func LoginFleet(routerURLs []string, username, password string) (*Fleet, error) {
+	clients := make([]*Client, len(routerURLs))
+	errs := make([]error, len(routerURLs))
+
+	var wg sync.WaitGroup
+	for i, routerURL := range routerURLs {
+		wg.Add(1)
+		go func(i int, routerURL string) {
+			defer wg.Done()
+			clients[i], errs[i] = Login(routerURL, username, password)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", routerURL, errs[i])
+			}
+		}(i, routerURL)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Fleet{Clients: clients}, nil
+}
+
+// fanOut runs fn against every router of the fleet and returns the results in
+// the order of the fleet's clients
+func fanOut[T any](f *Fleet, fn func(c *Client) (T, error)) []FleetResult[T] {
+	results := make([]FleetResult[T], len(f.Clients))
+
+	limit := f.Concurrency
+	if limit <= 0 || limit > len(f.Clients) {
+		limit = len(f.Clients)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, c := range f.Clients {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c *Client) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			value, err := fn(c)
+			results[i] = FleetResult[T]{RouterURL: c.RouterURL, Value: value, Err: err}
+		}(i, c)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Each runs fn against every router of the fleet and returns the per-router errors
+func (f *Fleet) Each(fn func(c *Client) error) []FleetResult[struct{}] {
+	return fanOut(f, func(c *Client) (struct{}, error) {
+		return struct{}{}, fn(c)
+	})
+}
+
+// GetClients retrieves the list of clients from every router
+func (f *Fleet) GetClients() []FleetResult[[]ClientInfo] {
+	return fanOut(f, (*Client).GetClients)
+}
+
+// GetStaticBindings retrieves the list of static IP bindings from every router
+func (f *Fleet) GetStaticBindings() []FleetResult[[]StaticBindInfo] {
+	return fanOut(f, (*Client).GetStaticBindings)
+}
+
+// AddStaticBind adds a static IP address reservation on every router
+func (f *Fleet) AddStaticBind(name, mac, ip string) []FleetResult[struct{}] {
+	return f.Each(func(c *Client) error {
+		return c.AddStaticBind(name, mac, ip)
+	})
+}
+
+// FleetErr joins the errors of a fleet operation, each prefixed with its
+// router's URL, or returns nil if every router succeeded
+func FleetErr[T any](results []FleetResult[T]) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", result.RouterURL, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/presbrey/pkg/glinet"
+)
+
+// exportHeader matches the columns read by importCSV so exports can be imported
+// on another router
+var exportHeader = []string{"USERNAME", "MAC ADDRESS", "IP ADDRESS", "STATUS", "STATIC"}
+
+// exportBindings writes the router's static bindings and connected clients to a
+// CSV or JSON file, or to standard output when the path is "-"
+func exportBindings(exportPath, format string, client *glinet.Client) error {
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(exportPath), ".json") {
+			format = "json"
+		}
+	}
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	log.Printf("Fetching static bindings and clients from router...")
+	records, err := client.ExportStaticBindings()
+	if err != nil {
+		return fmt.Errorf("failed to export static bindings from router: %w", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if exportPath != "-" {
+		file, err := os.Create(exportPath)
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(records)
+	} else {
+		err = writeExportCSV(out, records)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	log.Printf("Exported %d records", len(records))
+	return nil
+}
+
+// writeExportCSV writes export records as CSV
+func writeExportCSV(out io.Writer, records []glinet.ExportRecord) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		status := "DISCONNECTED"
+		if record.Online {
+			status = "CONNECTED"
+		}
+		row := []string{record.Name, record.MAC, record.IP, status, strconv.FormatBool(record.Static)}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/joho/godotenv"
@@ -16,13 +19,23 @@ import (
 )
 
 var (
-	flagRouterURL = flag.String("router-url", "", "Router URL")
-	flagAuthToken = flag.String("auth-token", "", "Router authentication token")
+	flagRouterURL = flag.String("router-url", "", "Router URL, or a comma-separated list of router URLs")
+	flagAuthToken = flag.String("auth-token", "", "Router authentication token, or a comma-separated list with one per router")
+	flagParallel  = flag.Int("parallel", 1, "Number of routers to update at once")
+	flagUsername  = flag.String("username", "", "Router username, used with -password instead of -auth-token (default root)")
+	flagPassword  = flag.String("password", "", "Router password, to log in and renew expired sessions automatically")
 
 	flagImportCSV = flag.String("import-csv", "", "CSV file containing MAC addresses and IP addresses")
 	flagImportARP = flag.String("import-arp", "", "ARP table file from Linux containing IP and MAC addresses")
 	flagClientList = flag.String("client-list", "", "CSV file containing known client hostnames for MAC addresses")
 	flagDryRun    = flag.Bool("dry-run", false, "Parse the input without making changes to the router")
+	flagSync      = flag.Bool("sync", false, "Treat the input as the desired state: update changed bindings and remove bindings not in the input")
+
+	flagImportPeers = flag.String("import-peers", "", "CSV file of WireGuard/OpenVPN server peers to provision (NAME, TYPE, ADDRESS, PASSWORD)")
+	flagPeerConfigs = flag.String("peer-configs", "", "Directory to write the client configs of provisioned peers")
+
+	flagExport       = flag.String("export", "", "Export static bindings and connected clients to a file (- for stdout)")
+	flagExportFormat = flag.String("export-format", "", "Export format: csv or json (default: from the file extension, else csv)")
 )
 
 // loadClientList loads a client list CSV file and returns a map of MAC addresses to hostnames
@@ -118,18 +131,41 @@ func main() {
 	if *flagRouterURL == "" {
 		*flagRouterURL = os.Getenv("GLINET_ROUTER_URL")
 	}
-	if *flagAuthToken == "" || *flagRouterURL == "" {
-		log.Fatal("Router authentication token and URL are required")
+	if *flagUsername == "" {
+		*flagUsername = os.Getenv("GLINET_USERNAME")
+	}
+	if *flagUsername == "" {
+		*flagUsername = "root"
+	}
+	if *flagPassword == "" {
+		*flagPassword = os.Getenv("GLINET_PASSWORD")
+	}
+	if (*flagAuthToken == "" && *flagPassword == "") || *flagRouterURL == "" {
+		log.Fatal("Router URL and either a password or authentication token are required")
 	}
 
-	// Create a new router client
-	client := glinet.NewClient(*flagRouterURL, *flagAuthToken)
+	// Create a client for each router, logging in when a password is
+	// available so expired sessions are renewed
+	routerURLs := splitList(*flagRouterURL)
+	var fleet *glinet.Fleet
+	var err error
+	if *flagPassword != "" {
+		fleet, err = glinet.LoginFleet(routerURLs, *flagUsername, *flagPassword)
+		if err != nil {
+			log.Fatalf("Error logging in to router: %v", err)
+		}
+	} else {
+		fleet, err = glinet.NewFleet(routerURLs, splitList(*flagAuthToken))
+		if err != nil {
+			log.Fatalf("Error creating router clients: %v", err)
+		}
+	}
+	fleet.Concurrency = *flagParallel
 
 	// Load client list if specified
 	var clientList map[string]string
 	if *flagClientList != "" {
 		log.Printf("Loading client list from %s", *flagClientList)
-		var err error
 		clientList, err = loadClientList(*flagClientList)
 		if err != nil {
 			log.Fatalf("Error loading client list: %v", err)
@@ -137,28 +173,116 @@ func main() {
 		log.Printf("Loaded %d client hostnames", len(clientList))
 	}
 
-	switch {
-	case *flagImportCSV != "":
-		// Import static IP reservations from CSV
-		if err := importCSV(*flagImportCSV, client, *flagDryRun, clientList); err != nil {
-			log.Fatalf("Error importing CSV: %v", err)
+	results := fleet.Each(func(client *glinet.Client) error {
+		if len(fleet.Clients) > 1 {
+			log.Printf("Processing router %s", client.RouterURL)
 		}
-	case *flagImportARP != "":
-		// Import static IP reservations from Linux ARP table
-		if err := importARP(*flagImportARP, client, *flagDryRun, clientList); err != nil {
-			log.Fatalf("Error importing ARP table: %v", err)
+
+		switch {
+		case *flagExport != "":
+			// Export static IP reservations and connected clients
+			exportPath := *flagExport
+			if len(fleet.Clients) > 1 {
+				exportPath = routerExportPath(exportPath, client.RouterURL)
+			}
+			if err := exportBindings(exportPath, *flagExportFormat, client); err != nil {
+				return fmt.Errorf("error exporting: %w", err)
+			}
+		case *flagImportCSV != "":
+			// Import static IP reservations from CSV
+			if err := importCSV(*flagImportCSV, client, *flagDryRun, *flagSync, clientList); err != nil {
+				return fmt.Errorf("error importing CSV: %w", err)
+			}
+		case *flagImportARP != "":
+			// Import static IP reservations from Linux ARP table
+			if err := importARP(*flagImportARP, client, *flagDryRun, *flagSync, clientList); err != nil {
+				return fmt.Errorf("error importing ARP table: %w", err)
+			}
+		case *flagImportPeers != "":
+			// Provision VPN server peers
+			if err := importPeers(*flagImportPeers, client, *flagDryRun, *flagPeerConfigs); err != nil {
+				return fmt.Errorf("error importing peers: %w", err)
+			}
 		}
+		return nil
+	})
+	if err := glinet.FleetErr(results); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// routerExportPath adds a router's host to an export file name so exports from
+// several routers don't overwrite each other
+func routerExportPath(exportPath, routerURL string) string {
+	if exportPath == "-" {
+		return exportPath
+	}
+
+	host := routerURL
+	if u, err := url.Parse(routerURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	host = strings.NewReplacer(":", "_", "/", "_").Replace(host)
+
+	ext := filepath.Ext(exportPath)
+	return strings.TrimSuffix(exportPath, ext) + "-" + host + ext
+}
+
+// getExistingBindings fetches the router's static bindings keyed by normalized
+// MAC address. Nothing is fetched when readRouter is false.
+func getExistingBindings(client *glinet.Client, readRouter bool) (map[string]glinet.StaticBindInfo, error) {
+	log.Printf("Fetching existing static bindings from router...")
+	existingBindings := make(map[string]glinet.StaticBindInfo)
+	if !readRouter {
+		return existingBindings, nil
+	}
+
+	bindings, err := client.GetStaticBindings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get static bindings from router: %w", err)
+	}
+	log.Printf("Found %d existing static bindings", len(bindings))
+
+	// Create a map of MAC to binding info for quick lookups
+	for _, binding := range bindings {
+		existingBindings[normalizeMACAddress(binding.MAC)] = binding
+	}
+	return existingBindings, nil
+}
+
 // addStaticBinding is a helper function to add a static IP binding to the router
-// It checks if the binding already exists and skips it if it does
-func addStaticBinding(client *glinet.Client, deviceName, macAddress, ipAddress string, dryRun bool, existingBindings map[string]glinet.StaticBindInfo) error {
+// It checks if the binding already exists and skips it if it does. In sync mode
+// a binding to a different IP is replaced instead.
+func addStaticBinding(client *glinet.Client, deviceName, macAddress, ipAddress string, dryRun, sync bool, existingBindings map[string]glinet.StaticBindInfo) error {
 	// Check if the MAC address already has a static binding
-	if existingBind, exists := existingBindings[macAddress]; exists {
-		log.Printf("SKIPPING: Static IP reservation already exists for MAC %s (%s) with IP %s",
-			macAddress, existingBind.Name, existingBind.IP)
-		return nil
+	if existingBind, exists := existingBindings[normalizeMACAddress(macAddress)]; exists {
+		if !sync || existingBind.IP == ipAddress {
+			log.Printf("SKIPPING: Static IP reservation already exists for MAC %s (%s) with IP %s",
+				macAddress, existingBind.Name, existingBind.IP)
+			return nil
+		}
+
+		if dryRun {
+			log.Printf("DRY RUN: Would remove static IP reservation for %s (%s) to IP %s",
+				existingBind.Name, existingBind.MAC, existingBind.IP)
+		} else {
+			log.Printf("Removing static IP reservation for %s (%s) to IP %s",
+				existingBind.Name, existingBind.MAC, existingBind.IP)
+			if err := client.RemoveStaticBind(existingBind.MAC); err != nil {
+				return fmt.Errorf("error removing static IP reservation for %s: %w", existingBind.Name, err)
+			}
+		}
 	}
 
 	if dryRun {
@@ -180,28 +304,71 @@ func addStaticBinding(client *glinet.Client, deviceName, macAddress, ipAddress s
 	return nil
 }
 
-func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList map[string]string) error {
+// removeStaleBindings removes the router's static bindings for MAC addresses
+// that are not in the desired set
+func removeStaleBindings(client *glinet.Client, existingBindings map[string]glinet.StaticBindInfo, desired map[string]bool, dryRun bool) (removed, failed int) {
+	// Remove in a stable order so runs are easy to compare
+	macs := make([]string, 0, len(existingBindings))
+	for mac := range existingBindings {
+		if !desired[mac] {
+			macs = append(macs, mac)
+		}
+	}
+	sort.Strings(macs)
+
+	for _, mac := range macs {
+		binding := existingBindings[mac]
+		if dryRun {
+			log.Printf("DRY RUN: Would remove static IP reservation for %s (%s) to IP %s",
+				binding.Name, binding.MAC, binding.IP)
+			removed++
+			continue
+		}
+
+		log.Printf("Removing static IP reservation for %s (%s) to IP %s",
+			binding.Name, binding.MAC, binding.IP)
+		if err := client.RemoveStaticBind(binding.MAC); err != nil {
+			log.Printf("error removing static IP reservation for %s: %v", binding.Name, err)
+			failed++
+			continue
+		}
+		removed++
+	}
+
+	return removed, failed
+}
+
+// syncBindings removes stale bindings after an import, unless some input
+// entries failed or could not be matched to a MAC address and their devices
+// can't be told apart from stale ones
+func syncBindings(client *glinet.Client, existingBindings map[string]glinet.StaticBindInfo, desired map[string]bool, dryRun bool, failCount int) {
+	if failCount > 0 {
+		log.Printf("SYNC: Not removing stale static IP reservations because %d entries failed or have no MAC address", failCount)
+		return
+	}
+
+	removed, failed := removeStaleBindings(client, existingBindings, desired, dryRun)
+	if dryRun {
+		log.Printf("SYNC: %d static IP reservations would be removed", removed)
+	} else {
+		log.Printf("SYNC: %d static IP reservations removed, %d failed", removed, failed)
+	}
+}
+
+func importCSV(csvPath string, client *glinet.Client, dryRun, sync bool, clientList map[string]string) error {
 	if dryRun {
 		log.Printf("DRY RUN: Parsing CSV file %s without making changes", csvPath)
 	} else {
 		log.Printf("Importing static IP reservations from %s", csvPath)
 	}
 
-	// Get existing static bindings
-	log.Printf("Fetching existing static bindings from router...")
-	existingBindings := make(map[string]glinet.StaticBindInfo)
-	if !dryRun {
-		bindings, err := client.GetStaticBindings()
-		if err != nil {
-			return fmt.Errorf("failed to get static bindings from router: %w", err)
-		}
-		log.Printf("Found %d existing static bindings", len(bindings))
-
-		// Create a map of MAC to binding info for quick lookups
-		for _, binding := range bindings {
-			existingBindings[binding.MAC] = binding
-		}
+	// Get existing static bindings. Sync mode reads them even in a dry run to
+	// preview removals.
+	existingBindings, err := getExistingBindings(client, !dryRun || sync)
+	if err != nil {
+		return err
 	}
+	desired := make(map[string]bool)
 
 	// Open the CSV file
 	file, err := os.Open(csvPath)
@@ -226,6 +393,8 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 	nameIdx := -1
 	ipIdx := -1
 	statusIdx := -1
+	macIdx := -1
+	staticIdx := -1
 	for i, col := range header {
 		switch col {
 		case "USERNAME":
@@ -234,6 +403,10 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 			ipIdx = i
 		case "STATUS":
 			statusIdx = i
+		case "MAC ADDRESS":
+			macIdx = i
+		case "STATIC":
+			staticIdx = i
 		}
 	}
 
@@ -245,8 +418,9 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 	// Map to store IP to MAC mappings we've seen
 	ipToMac := make(map[string]string)
 
-	// Only fetch clients from router if not in dry-run mode
-	if !dryRun {
+	// Only fetch clients from router if not in dry-run mode and the CSV
+	// doesn't already provide MAC addresses
+	if (!dryRun || sync) && macIdx == -1 {
 		// First, get all current clients to have their MAC addresses
 		log.Printf("Fetching current clients from router...")
 		allClients, err := client.GetClients()
@@ -261,8 +435,9 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 		}
 	}
 
-	// Process each row
-	var successCount, failCount, skippedCount int
+	// Process each row. Rows skipped without a known MAC address are counted
+	// as unmatched, since sync can't tell their reservations from stale ones.
+	var successCount, failCount, skippedCount, unmatchedCount int
 	for {
 		row, err := reader.Read()
 		if err == io.EOF {
@@ -272,10 +447,22 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 			return fmt.Errorf("error reading CSV row: %w", err)
 		}
 
-		// Skip rows that aren't connected if status column exists
-		if statusIdx != -1 && row[statusIdx] != "CONNECTED" {
+		// Skip rows that aren't connected if status column exists, unless
+		// they are static reservations from an export
+		static := staticIdx != -1 && staticIdx < len(row) && row[staticIdx] == "true"
+		if statusIdx != -1 && row[statusIdx] != "CONNECTED" && !static {
 			log.Printf("Skipping device with status: %s", row[statusIdx])
 			skippedCount++
+
+			// The device is still part of the desired state, so sync keeps
+			// its reservation
+			if macIdx != -1 && macIdx < len(row) && strings.TrimSpace(row[macIdx]) != "" {
+				desired[normalizeMACAddress(strings.Trim(row[macIdx], "\""))] = true
+			} else if mac, found := ipToMac[strings.Trim(row[ipIdx], "\"")]; found {
+				desired[normalizeMACAddress(mac)] = true
+			} else {
+				unmatchedCount++
+			}
 			continue
 		}
 
@@ -287,7 +474,10 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 
 		// In dry-run mode, generate a fake MAC address based on the IP
 		var macAddress string
-		if dryRun {
+		if macIdx != -1 && macIdx < len(row) && strings.TrimSpace(row[macIdx]) != "" {
+			// Use the MAC address from the CSV, such as one written by -export
+			macAddress = normalizeMACAddress(strings.Trim(row[macIdx], "\""))
+		} else if dryRun && !sync {
 			// Generate a deterministic MAC address from the IP for testing
 			ipParts := strings.Split(ipAddress, ".")
 			if len(ipParts) != 4 {
@@ -324,7 +514,8 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 		}
 
 		// Add static binding using the MAC address
-		err = addStaticBinding(client, deviceName, macAddress, ipAddress, dryRun, existingBindings)
+		desired[normalizeMACAddress(macAddress)] = true
+		err = addStaticBinding(client, deviceName, macAddress, ipAddress, dryRun, sync, existingBindings)
 		if err != nil {
 			log.Printf("%v", err)
 			failCount++
@@ -340,32 +531,28 @@ func importCSV(csvPath string, client *glinet.Client, dryRun bool, clientList ma
 		log.Printf("Import complete: %d successful, %d failed, %d skipped",
 			successCount, failCount, skippedCount)
 	}
+
+	if sync {
+		syncBindings(client, existingBindings, desired, dryRun, failCount+unmatchedCount)
+	}
 	return nil
 }
 
 // importARP imports static IP reservations from a Linux ARP table file
-func importARP(arpPath string, client *glinet.Client, dryRun bool, clientList map[string]string) error {
+func importARP(arpPath string, client *glinet.Client, dryRun, sync bool, clientList map[string]string) error {
 	if dryRun {
 		log.Printf("DRY RUN: Parsing ARP table file %s without making changes", arpPath)
 	} else {
 		log.Printf("Importing static IP reservations from ARP table %s", arpPath)
 	}
 
-	// Get existing static bindings
-	log.Printf("Fetching existing static bindings from router...")
-	existingBindings := make(map[string]glinet.StaticBindInfo)
-	if !dryRun {
-		bindings, err := client.GetStaticBindings()
-		if err != nil {
-			return fmt.Errorf("failed to get static bindings from router: %w", err)
-		}
-		log.Printf("Found %d existing static bindings", len(bindings))
-
-		// Create a map of MAC to binding info for quick lookups
-		for _, binding := range bindings {
-			existingBindings[binding.MAC] = binding
-		}
+	// Get existing static bindings. Sync mode reads them even in a dry run to
+	// preview removals.
+	existingBindings, err := getExistingBindings(client, !dryRun || sync)
+	if err != nil {
+		return err
 	}
+	desired := make(map[string]bool)
 
 	// Open the ARP file
 	file, err := os.Open(arpPath)
@@ -428,7 +615,8 @@ func importARP(arpPath string, client *glinet.Client, dryRun bool, clientList ma
 			ipAddress, macAddress, interface_)
 
 		// Add static binding
-		err = addStaticBinding(client, deviceName, macAddress, ipAddress, dryRun, existingBindings)
+		desired[normalizeMACAddress(macAddress)] = true
+		err = addStaticBinding(client, deviceName, macAddress, ipAddress, dryRun, sync, existingBindings)
 		if err != nil {
 			log.Printf("%v", err)
 			failCount++
@@ -450,5 +638,9 @@ func importARP(arpPath string, client *glinet.Client, dryRun bool, clientList ma
 			successCount, failCount)
 	}
 
+	if sync {
+		syncBindings(client, existingBindings, desired, dryRun, failCount)
+	}
+
 	return nil
 }
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/presbrey/pkg/glinet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRouter serves the router RPC methods used by the importer
+type fakeRouter struct {
+	bindings []glinet.StaticBindInfo
+	clients  []glinet.ClientInfo
+	added    []string
+	removed  []string
+	mu       sync.Mutex
+}
+
+func (f *fakeRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     int               `json:"id"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) < 4 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	var method string
+	json.Unmarshal(req.Params[2], &method)
+	var params struct {
+		MAC string `json:"mac"`
+	}
+	json.Unmarshal(req.Params[3], &params)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result interface{} = []interface{}{}
+	switch method {
+	case "get_static_bind_list":
+		result = map[string]interface{}{"static_bind_list": f.bindings}
+	case "get_list":
+		result = map[string]interface{}{"clients": f.clients}
+	case "add_static_bind":
+		f.added = append(f.added, params.MAC)
+	case "remove_static_bind":
+		f.removed = append(f.removed, params.MAC)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": req.ID, "jsonrpc": "2.0", "result": result})
+}
+
+// writeCSV writes content to a CSV file in a temporary directory
+func writeCSV(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "input.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestImportCSVSyncKeepsOfflineDevices(t *testing.T) {
+	router := &fakeRouter{bindings: []glinet.StaticBindInfo{
+		{Name: "laptop", MAC: "aa:aa:aa:aa:aa:01", IP: "192.168.8.10"},
+		{Name: "printer", MAC: "aa:aa:aa:aa:aa:02", IP: "192.168.8.11"},
+		{Name: "old", MAC: "aa:aa:aa:aa:aa:03", IP: "192.168.8.12"},
+	}}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// The printer is offline, but still listed and so still desired
+	path := writeCSV(t, "USERNAME,IP ADDRESS,STATUS,MAC ADDRESS\n"+
+		"laptop,192.168.8.10,CONNECTED,AA:AA:AA:AA:AA:01\n"+
+		"printer,192.168.8.11,OFFLINE,AA:AA:AA:AA:AA:02\n")
+	require.NoError(t, importCSV(path, glinet.NewClient(server.URL, "token"), false, true, nil))
+
+	assert.Equal(t, []string{"aa:aa:aa:aa:aa:03"}, router.removed)
+	assert.Empty(t, router.added)
+}
+
+func TestImportCSVSyncOfflineWithoutMAC(t *testing.T) {
+	router := &fakeRouter{
+		bindings: []glinet.StaticBindInfo{
+			{Name: "laptop", MAC: "aa:aa:aa:aa:aa:01", IP: "192.168.8.10"},
+			{Name: "printer", MAC: "aa:aa:aa:aa:aa:02", IP: "192.168.8.11"},
+		},
+		clients: []glinet.ClientInfo{{IP: "192.168.8.10", MAC: "aa:aa:aa:aa:aa:01"}},
+	}
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// The offline printer can't be matched to a MAC address, so nothing is
+	// removed rather than risking its reservation
+	path := writeCSV(t, "USERNAME,IP ADDRESS,STATUS\n"+
+		"laptop,192.168.8.10,CONNECTED\n"+
+		"printer,192.168.8.11,OFFLINE\n")
+	require.NoError(t, importCSV(path, glinet.NewClient(server.URL, "token"), false, true, nil))
+
+	assert.Empty(t, router.removed)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/presbrey/pkg/glinet"
+)
+
+// VPN peer types accepted in the TYPE column of a peers CSV file
+const (
+	peerTypeWireGuard = "wireguard"
+	peerTypeOpenVPN   = "openvpn"
+)
+
+// importPeers provisions VPN server peers from a CSV file with NAME, TYPE,
+// ADDRESS and PASSWORD columns. Only NAME is required; TYPE defaults to
+// wireguard and PASSWORD is required for openvpn users. When configDir is set,
+// each new peer's client config is written there.
+func importPeers(csvPath string, client *glinet.Client, dryRun bool, configDir string) error {
+	if dryRun {
+		log.Printf("DRY RUN: Parsing peers file %s without making changes", csvPath)
+	} else {
+		log.Printf("Provisioning VPN peers from %s", csvPath)
+	}
+
+	// Get existing peers so they are skipped
+	existingWireGuard := make(map[string]bool)
+	existingOpenVPN := make(map[string]bool)
+	if !dryRun {
+		log.Printf("Fetching existing VPN peers from router...")
+		peers, err := client.GetWireGuardPeers()
+		if err != nil {
+			return fmt.Errorf("failed to get WireGuard peers from router: %w", err)
+		}
+		for _, peer := range peers {
+			existingWireGuard[peer.Name] = true
+		}
+
+		users, err := client.GetOpenVPNUsers()
+		if err != nil {
+			return fmt.Errorf("failed to get OpenVPN users from router: %w", err)
+		}
+		for _, user := range users {
+			existingOpenVPN[user.Username] = true
+		}
+		log.Printf("Found %d WireGuard peers and %d OpenVPN users", len(peers), len(users))
+	}
+
+	if configDir != "" && !dryRun {
+		if err := os.MkdirAll(configDir, 0700); err != nil {
+			return fmt.Errorf("failed to create peer config directory: %w", err)
+		}
+	}
+
+	// Open the CSV file
+	file, err := os.Open(csvPath)
+	if err != nil {
+		return fmt.Errorf("failed to open peers file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // Allow variable number of fields per record
+
+	// Read the header row
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read peers header: %w", err)
+	}
+
+	// Find the indices of the columns we need
+	nameIdx := -1
+	typeIdx := -1
+	addressIdx := -1
+	passwordIdx := -1
+	for i, col := range header {
+		switch col {
+		case "NAME":
+			nameIdx = i
+		case "TYPE":
+			typeIdx = i
+		case "ADDRESS":
+			addressIdx = i
+		case "PASSWORD":
+			passwordIdx = i
+		}
+	}
+	if nameIdx == -1 {
+		return fmt.Errorf("peers CSV file missing required column: NAME")
+	}
+
+	column := func(row []string, idx int) string {
+		if idx == -1 || idx >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[idx])
+	}
+
+	// Process each row
+	var successCount, failCount, skippedCount int
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading peers row: %w", err)
+		}
+
+		name := column(row, nameIdx)
+		peerType := strings.ToLower(column(row, typeIdx))
+		if peerType == "" {
+			peerType = peerTypeWireGuard
+		}
+		if name == "" {
+			log.Printf("Warning: Skipping peer without a name")
+			failCount++
+			continue
+		}
+
+		switch peerType {
+		case peerTypeWireGuard:
+			if existingWireGuard[name] {
+				log.Printf("SKIPPING: WireGuard peer %s already exists", name)
+				skippedCount++
+				continue
+			}
+			err = addWireGuardPeer(client, name, column(row, addressIdx), dryRun, configDir)
+		case peerTypeOpenVPN:
+			if existingOpenVPN[name] {
+				log.Printf("SKIPPING: OpenVPN user %s already exists", name)
+				skippedCount++
+				continue
+			}
+			err = addOpenVPNUser(client, name, column(row, passwordIdx), dryRun, configDir)
+		default:
+			err = fmt.Errorf("unsupported peer type %q for %s", peerType, name)
+		}
+
+		if err != nil {
+			log.Printf("%v", err)
+			failCount++
+		} else {
+			successCount++
+		}
+	}
+
+	if dryRun {
+		log.Printf("DRY RUN complete: %d would succeed, %d would fail, %d skipped",
+			successCount, failCount, skippedCount)
+	} else {
+		log.Printf("Peer provisioning complete: %d successful, %d failed, %d skipped",
+			successCount, failCount, skippedCount)
+	}
+	return nil
+}
+
+// addWireGuardPeer creates a WireGuard peer and writes its client config
+func addWireGuardPeer(client *glinet.Client, name, address string, dryRun bool, configDir string) error {
+	if dryRun {
+		log.Printf("DRY RUN: Would add WireGuard peer %s", name)
+		return nil
+	}
+
+	log.Printf("Adding WireGuard peer %s", name)
+	if err := client.AddWireGuardPeer(name, address); err != nil {
+		return err
+	}
+	if configDir == "" {
+		return nil
+	}
+
+	// The router assigns the peer ID, so look the new peer up by name
+	peer, err := client.GetWireGuardPeerByName(name)
+	if err != nil {
+		return err
+	}
+	config, err := client.GetWireGuardPeerConfig(peer.PeerID)
+	if err != nil {
+		return err
+	}
+	return writePeerConfig(configDir, name+".conf", config)
+}
+
+// addOpenVPNUser creates an OpenVPN user and writes the client config
+func addOpenVPNUser(client *glinet.Client, name, password string, dryRun bool, configDir string) error {
+	if password == "" {
+		return fmt.Errorf("OpenVPN user %s requires a PASSWORD", name)
+	}
+	if dryRun {
+		log.Printf("DRY RUN: Would add OpenVPN user %s", name)
+		return nil
+	}
+
+	log.Printf("Adding OpenVPN user %s", name)
+	if err := client.AddOpenVPNUser(name, password); err != nil {
+		return err
+	}
+	if configDir == "" {
+		return nil
+	}
+
+	config, err := client.GetOpenVPNConfig()
+	if err != nil {
+		return err
+	}
+	return writePeerConfig(configDir, name+".ovpn", config)
+}
+
+// writePeerConfig writes a peer's client config, which contains keys, readable only by the owner
+func writePeerConfig(configDir, fileName, config string) error {
+	path := filepath.Join(configDir, filepath.Base(fileName))
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		return fmt.Errorf("failed to write peer config: %w", err)
+	}
+	log.Printf("Wrote peer config to %s", path)
+	return nil
+}
@@ -0,0 +1,144 @@
+package glinet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errAccessDenied is the error code the router returns for an invalid or expired session
+const errAccessDenied = -32000
+
+// RPCError represents an error returned by the router API
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("router error %d: %s", e.Code, e.Message)
+}
+
+// rpcResponse represents a response from the router API before its result is decoded
+type rpcResponse struct {
+	ID      int             `json:"id"`
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// isSessionError reports whether err means the router rejected the session token
+func isSessionError(err error) bool {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Code == errAccessDenied
+	}
+	var statusErr *statusError
+	return errors.As(err, &statusErr) && statusErr.code == http.StatusUnauthorized
+}
+
+// statusError reports an unexpected HTTP status from the router
+type statusError struct {
+	code int
+}
+
+// Error implements the error interface
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// call invokes a method of a router API module and decodes its result into
+// result, which may be nil to discard it. When the client was created by Login
+// and the router rejects the session, it logs in again and retries once.
+func (c *Client) call(id int, module, method string, params interface{}, result interface{}) error {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+
+	token := c.token()
+	err := c.post(id, "call", []interface{}{token, module, method, params}, token, result)
+	if err == nil || !isSessionError(err) || !c.canLogin() {
+		return err
+	}
+
+	token, err = c.relogin(token)
+	if err != nil {
+		return err
+	}
+	return c.post(id, "call", []interface{}{token, module, method, params}, token, result)
+}
+
+// post sends a JSON-RPC request to the router and decodes its result into
+// result, which may be nil to discard it
+func (c *Client) post(id int, method string, params interface{}, token string, result interface{}) error {
+	// Create request payload
+	req := struct {
+		JSONRPC string      `json:"jsonrpc"`
+		ID      int         `json:"id"`
+		Method  string      `json:"method"`
+		Params  interface{} `json:"params"`
+	}{
+		JSONRPC: "2.0",
+		ID:      id,
+		Method:  method,
+		Params:  params,
+	}
+
+	// Marshal the request to JSON
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+
+	// Create HTTP request
+	httpReq, err := http.NewRequest(http.MethodPost, c.RouterURL+"/rpc", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+
+	// Set headers
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/plain, */*")
+
+	// Add cookie
+	if token != "" {
+		cookie := &http.Cookie{
+			Name:  "Admin-Token",
+			Value: token,
+		}
+		httpReq.AddCookie(cookie)
+	}
+
+	// Make the request
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("error making request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != http.StatusOK {
+		return &statusError{code: resp.StatusCode}
+	}
+
+	// Decode response
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+
+	if result == nil || len(rpcResp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("error decoding %s result: %w", method, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package glinet
+
+import (
+	"fmt"
+)
+
+// WireGuardPeer represents a peer of the router's WireGuard server
+type WireGuardPeer struct {
+	PeerID       int    `json:"peer_id"`
+	Name         string `json:"name"`
+	PublicKey    string `json:"public_key"`
+	PresharedKey string `json:"preshared_key,omitempty"`
+	ClientIP     string `json:"client_ip"`
+	AllowedIPs   string `json:"allowed_ips,omitempty"`
+}
+
+// OpenVPNUser represents a user of the router's OpenVPN server
+type OpenVPNUser struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// GetWireGuardPeers retrieves the list of WireGuard server peers from the router
+func (c *Client) GetWireGuardPeers() ([]WireGuardPeer, error) {
+	var result struct {
+		Peers []WireGuardPeer `json:"peers"`
+	}
+	if err := c.call(12, "wg-server", "get_peer_list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Peers, nil
+}
+
+// GetWireGuardPeerByName returns a specific WireGuard server peer by its name
+func (c *Client) GetWireGuardPeerByName(name string) (*WireGuardPeer, error) {
+	peers, err := c.GetWireGuardPeers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, peer := range peers {
+		if peer.Name == name {
+			return &peer, nil
+		}
+	}
+
+	return nil, fmt.Errorf("WireGuard peer with name %s not found", name)
+}
+
+// AddWireGuardPeer creates a WireGuard server peer. The router generates the
+// peer's keys, and assigns the next free address when clientIP is empty.
+func (c *Client) AddWireGuardPeer(name, clientIP string) error {
+	params := map[string]interface{}{"name": name}
+	if clientIP != "" {
+		params["client_ip"] = clientIP
+	}
+
+	if err := c.call(13, "wg-server", "add_peer", params, nil); err != nil {
+		return fmt.Errorf("error adding WireGuard peer %s: %w", name, err)
+	}
+	return nil
+}
+
+// RemoveWireGuardPeer removes a WireGuard server peer by its ID
+func (c *Client) RemoveWireGuardPeer(peerID int) error {
+	if err := c.call(14, "wg-server", "remove_peer", map[string]interface{}{"peer_id": peerID}, nil); err != nil {
+		return fmt.Errorf("error removing WireGuard peer %d: %w", peerID, err)
+	}
+	return nil
+}
+
+// GetWireGuardPeerConfig returns the client configuration file for a WireGuard
+// server peer, ready to import into a WireGuard client
+func (c *Client) GetWireGuardPeerConfig(peerID int) (string, error) {
+	var result struct {
+		Config string `json:"config"`
+	}
+	if err := c.call(15, "wg-server", "get_peer_config", map[string]interface{}{"peer_id": peerID}, &result); err != nil {
+		return "", fmt.Errorf("error getting WireGuard peer %d config: %w", peerID, err)
+	}
+	return result.Config, nil
+}
+
+// GetOpenVPNUsers retrieves the list of OpenVPN server users from the router
+func (c *Client) GetOpenVPNUsers() ([]OpenVPNUser, error) {
+	var result struct {
+		Users []OpenVPNUser `json:"users"`
+	}
+	if err := c.call(16, "ovpn-server", "get_user_list", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Users, nil
+}
+
+// AddOpenVPNUser creates an OpenVPN server user
+func (c *Client) AddOpenVPNUser(username, password string) error {
+	params := map[string]interface{}{"username": username, "password": password}
+	if err := c.call(17, "ovpn-server", "add_user", params, nil); err != nil {
+		return fmt.Errorf("error adding OpenVPN user %s: %w", username, err)
+	}
+	return nil
+}
+
+// RemoveOpenVPNUser removes an OpenVPN server user by its ID
+func (c *Client) RemoveOpenVPNUser(userID int) error {
+	if err := c.call(18, "ovpn-server", "remove_user", map[string]interface{}{"user_id": userID}, nil); err != nil {
+		return fmt.Errorf("error removing OpenVPN user %d: %w", userID, err)
+	}
+	return nil
+}
+
+// GetOpenVPNConfig returns the client configuration file shared by the OpenVPN
+// server's users, who authenticate with their username and password
+func (c *Client) GetOpenVPNConfig() (string, error) {
+	var result struct {
+		Config string `json:"config"`
+	}
+	if err := c.call(19, "ovpn-server", "get_client_config", nil, &result); err != nil {
+		return "", fmt.Errorf("error getting OpenVPN client config: %w", err)
+	}
+	return result.Config, nil
+}
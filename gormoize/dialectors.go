@@ -0,0 +1,66 @@
+package gormoize
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DialectorFactory creates a GORM dialector for a DSN
+type DialectorFactory func(dsn string) gorm.Dialector
+
+// RegisterDialector maps a DSN scheme (e.g. "postgres", "mysql", "file") to a
+// dialector factory used by GetFromDSN. The factory receives the full DSN.
+func (c *DBCache) RegisterDialector(scheme string, factory DialectorFactory) *DBCache {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.dialectors == nil {
+		c.dialectors = make(map[string]DialectorFactory)
+	}
+	c.dialectors[strings.ToLower(scheme)] = factory
+	return c
+}
+
+// RegisterDialector registers a dialector factory on the singleton cache
+func RegisterDialector(scheme string, factory DialectorFactory) *DBCache {
+	return Instance().RegisterDialector(scheme, factory)
+}
+
+// dialectorFor returns a dialector for dsn using the factory registered for its scheme
+func (c *DBCache) dialectorFor(dsn string) (gorm.Dialector, error) {
+	scheme := dsnScheme(dsn)
+	if scheme == "" {
+		return nil, fmt.Errorf("gormoize: no scheme in DSN")
+	}
+
+	c.mutex.RLock()
+	factory, exists := c.dialectors[scheme]
+	c.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("gormoize: no dialector registered for scheme %q", scheme)
+	}
+	return factory(dsn), nil
+}
+
+// dsnScheme extracts the scheme from "scheme://..." or "scheme:..." DSNs
+func dsnScheme(dsn string) string {
+	if i := strings.Index(dsn, "://"); i > 0 {
+		return strings.ToLower(dsn[:i])
+	}
+	if i := strings.Index(dsn, ":"); i > 0 {
+		return strings.ToLower(dsn[:i])
+	}
+	return ""
+}
+
+// GetFromDSN retrieves a cached connection or creates a new one, selecting
+// the dialector registered for the DSN's scheme
+func GetFromDSN(dsn string) (*gorm.DB, error) {
+	dialector, err := Instance().dialectorFor(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return Connection().WithDSN(dsn).WithDialector(dialector).Get()
+}
@@ -1,7 +1,10 @@
 package gormoize
 
 import (
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -15,7 +18,15 @@ var (
 // DBCache provides thread-safe caching of database connections
 type DBCache struct {
 	connections map[string]*gorm.DB
+	openers     map[string]func() (*gorm.DB, error)
+	lastUsed    map[string]*atomic.Int64
+	dialectors  map[string]DialectorFactory
 	mutex       sync.RWMutex
+
+	maxConnections int
+	idleTTL        time.Duration
+	health         *healthChecker
+	janitor        *idleJanitor
 }
 
 // Instance returns the singleton instance of DBCache
@@ -23,6 +34,9 @@ func Instance() *DBCache {
 	once.Do(func() {
 		instance = &DBCache{
 			connections: make(map[string]*gorm.DB),
+			openers:     make(map[string]func() (*gorm.DB, error)),
+			lastUsed:    make(map[string]*atomic.Int64),
+			dialectors:  make(map[string]DialectorFactory),
 		}
 	})
 	return instance
@@ -33,6 +47,8 @@ func (c *DBCache) Clear() *DBCache {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 	c.connections = make(map[string]*gorm.DB)
+	c.openers = make(map[string]func() (*gorm.DB, error))
+	c.lastUsed = make(map[string]*atomic.Int64)
 	return c
 }
 
@@ -51,6 +67,9 @@ type ConnectionBuilder struct {
 	config    *gorm.Config
 	factory   func() (*gorm.DB, error)
 	mockDB    *gorm.DB
+
+	replicas         []string
+	replicaDialector DialectorFactory
 }
 
 // WithDSN sets the DSN for the connection
@@ -95,7 +114,10 @@ func (b *ConnectionBuilder) Get() (*gorm.DB, error) {
 	}
 
 	b.cache.mutex.RLock()
-	db, exists := b.cache.connections[b.dsn]
+	db, exists := b.cache.connections[b.key()]
+	if exists {
+		b.cache.touch(b.key())
+	}
 	b.cache.mutex.RUnlock()
 
 	if exists {
@@ -119,33 +141,55 @@ func (b *ConnectionBuilder) MustGet() *gorm.DB {
 
 // create establishes a new database connection
 func (b *ConnectionBuilder) create() (*gorm.DB, error) {
+	// Ensure config is not nil before passing to gorm.Open
+	if b.factory == nil && b.dialector != nil && b.config == nil {
+		b.config = &gorm.Config{}
+	}
+
+	// Keep a copy of the builder so the connection can be reopened
+	opener := *b
+	opener.replicas = slices.Clone(b.replicas)
+	db, err := opener.open()
+	if err != nil {
+		return nil, err
+	}
+
+	// Store the connection in the cache
+	b.cache.mutex.Lock()
+	cached, evicted := b.cache.storeLocked(b.key(), db, opener.open)
+	b.cache.mutex.Unlock()
+	releaseAll(evicted)
+
+	// Close the connection opened here if another caller cached one first,
+	// unless the factory returned that same connection
+	if cached != db {
+		closeAll([]*gorm.DB{db})
+	}
+	return cached, nil
+}
+
+// open opens a connection with the factory if provided, otherwise the
+// dialector, and configures any replicas
+func (b *ConnectionBuilder) open() (*gorm.DB, error) {
 	var (
 		db  *gorm.DB
 		err error
 	)
-
-	// Use factory if provided, otherwise use dialector
 	if b.factory != nil {
 		db, err = b.factory()
 	} else if b.dialector != nil {
-		// Ensure config is not nil before passing to gorm.Open
-		if b.config == nil {
-			b.config = &gorm.Config{}
-		}
 		db, err = gorm.Open(b.dialector, b.config)
 	} else {
 		panic("either dialector or factory must be provided")
 	}
 
-	if err != nil {
+	if err != nil || len(b.replicas) == 0 {
+		return db, err
+	}
+	if err := b.useReplicas(db); err != nil {
+		closeAll([]*gorm.DB{db})
 		return nil, err
 	}
-
-	// Store the connection in the cache
-	b.cache.mutex.Lock()
-	defer b.cache.mutex.Unlock()
-	b.cache.connections[b.dsn] = db
-
 	return db, nil
 }
 
@@ -153,7 +197,7 @@ func (b *ConnectionBuilder) create() (*gorm.DB, error) {
 func (b *ConnectionBuilder) Remove() *ConnectionBuilder {
 	b.cache.mutex.Lock()
 	defer b.cache.mutex.Unlock()
-	delete(b.cache.connections, b.dsn)
+	b.cache.removeLocked(b.key())
 	return b
 }
 
@@ -0,0 +1,202 @@
+package gormoize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HealthCheckConfig configures the background health checker
+type HealthCheckConfig struct {
+	// Interval is the time between health checks (default: 30 seconds)
+	Interval time.Duration
+
+	// Timeout limits each connection's ping (default: 5 seconds)
+	Timeout time.Duration
+
+	// Rebuild reopens evicted connections the way they were first opened,
+	// retrying at every check until it succeeds. Otherwise they are reopened
+	// by the next Get that can create them.
+	Rebuild bool
+
+	// OnEvict is called after a dead connection is evicted
+	OnEvict func(dsn string, db *gorm.DB, err error)
+}
+
+// healthChecker is a running background health checker
+type healthChecker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// StartHealthCheck starts pinging every cached connection in the background,
+// evicting connections that fail. Starting a health checker stops any
+// previous one.
+func (c *DBCache) StartHealthCheck(config HealthCheckConfig) *DBCache {
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+
+	// Swap the checkers under one lock so that concurrent calls each stop the
+	// checker they replaced. A replaced checker whose goroutine hasn't started
+	// yet is canceled already and exits at once.
+	ctx, cancel := context.WithCancel(context.Background())
+	checker := &healthChecker{cancel: cancel, done: make(chan struct{})}
+	c.mutex.Lock()
+	previous := c.health
+	c.health = checker
+	c.mutex.Unlock()
+
+	if previous != nil {
+		previous.cancel()
+		<-previous.done
+	}
+
+	go func() {
+		defer close(checker.done)
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = c.checkHealth(ctx, config)
+			}
+		}
+	}()
+	return c
+}
+
+// StartHealthCheck starts the health checker on the singleton cache
+func StartHealthCheck(config HealthCheckConfig) *DBCache {
+	return Instance().StartHealthCheck(config)
+}
+
+// StopHealthCheck stops the background health checker, if running, and waits
+// for any check in progress to finish
+func (c *DBCache) StopHealthCheck() *DBCache {
+	c.mutex.Lock()
+	checker := c.health
+	c.health = nil
+	c.mutex.Unlock()
+
+	if checker != nil {
+		checker.cancel()
+		<-checker.done
+	}
+	return c
+}
+
+// CheckHealth pings every cached connection once, evicting connections that
+// fail as the health checker does. It returns the evicted connections' errors.
+func (c *DBCache) CheckHealth(ctx context.Context, config HealthCheckConfig) error {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	return c.checkHealth(ctx, config)
+}
+
+// checkHealth pings every cached connection and evicts dead ones
+func (c *DBCache) checkHealth(ctx context.Context, config HealthCheckConfig) error {
+	c.mutex.RLock()
+	connections := make(map[string]*gorm.DB, len(c.connections))
+	for dsn, db := range c.connections {
+		connections[dsn] = db
+	}
+	var missing []string
+	for dsn := range c.openers {
+		if _, exists := c.connections[dsn]; !exists {
+			missing = append(missing, dsn)
+		}
+	}
+	c.mutex.RUnlock()
+
+	// Retry connections that couldn't be rebuilt after an earlier eviction
+	if config.Rebuild {
+		for _, dsn := range missing {
+			c.rebuild(dsn)
+		}
+	}
+
+	var errs []error
+	for dsn, db := range connections {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := ping(ctx, db, config.Timeout); err != nil {
+			errs = append(errs, fmt.Errorf("gormoize: health check %s: %w", dsn, err))
+			c.evict(dsn, db, err, config)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ping checks that a connection's database is reachable
+func ping(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// evict removes a dead connection from the cache, releases it and optionally
+// reopens it. Connections replaced or removed since they were pinged are left alone.
+func (c *DBCache) evict(dsn string, db *gorm.DB, err error, config HealthCheckConfig) {
+	c.mutex.Lock()
+	if c.connections[dsn] != db {
+		c.mutex.Unlock()
+		return
+	}
+	opener := c.openers[dsn]
+	c.removeLocked(dsn)
+	if config.Rebuild {
+		// Keep the opener so the connection can be rebuilt
+		c.openers[dsn] = opener
+	}
+	c.mutex.Unlock()
+
+	releaseAll([]*gorm.DB{db})
+	if config.OnEvict != nil {
+		config.OnEvict(dsn, db, err)
+	}
+
+	if config.Rebuild {
+		c.rebuild(dsn)
+	}
+}
+
+// rebuild reopens an evicted connection unless it was reopened or removed meanwhile
+func (c *DBCache) rebuild(dsn string) {
+	c.mutex.RLock()
+	opener := c.openers[dsn]
+	c.mutex.RUnlock()
+	if opener == nil {
+		return
+	}
+
+	db, err := opener()
+	if err != nil {
+		return
+	}
+
+	c.mutex.Lock()
+	if _, exists := c.connections[dsn]; exists || c.openers[dsn] == nil {
+		c.mutex.Unlock()
+		closeAll([]*gorm.DB{db})
+		return
+	}
+	_, evicted := c.storeLocked(dsn, db, opener)
+	c.mutex.Unlock()
+	releaseAll(evicted)
+}
@@ -0,0 +1,147 @@
+package gormoize_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/gormoize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// closeDB closes a connection's underlying database so pings fail
+func closeDB(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+// TestCheckHealthEvicts tests that dead connections are evicted and healthy ones kept
+func TestCheckHealthEvicts(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dir := t.TempDir()
+	healthyDSN := filepath.Join(dir, "healthy.db")
+	deadDSN := filepath.Join(dir, "dead.db")
+
+	healthy, err := gormoize.Connection().WithDSN(healthyDSN).WithDialector(sqlite.Open(healthyDSN)).Get()
+	require.NoError(t, err)
+	dead, err := gormoize.Connection().WithDSN(deadDSN).WithDialector(sqlite.Open(deadDSN)).Get()
+	require.NoError(t, err)
+	closeDB(t, dead)
+
+	var evicted []string
+	err = gormoize.Instance().CheckHealth(context.Background(), gormoize.HealthCheckConfig{
+		OnEvict: func(dsn string, db *gorm.DB, err error) {
+			assert.Same(t, dead, db)
+			assert.Error(t, err)
+			evicted = append(evicted, dsn)
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), deadDSN)
+	assert.Equal(t, []string{deadDSN}, evicted)
+
+	connections := gormoize.GetAll()
+	assert.Len(t, connections, 1)
+	assert.Same(t, healthy, connections[healthyDSN])
+}
+
+// TestCheckHealthRebuilds tests that evicted connections are reopened with their factory
+func TestCheckHealthRebuilds(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dsn := filepath.Join(t.TempDir(), "rebuild.db")
+	var opened int32
+	factory := func() (*gorm.DB, error) {
+		atomic.AddInt32(&opened, 1)
+		return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	}
+
+	db, err := gormoize.Connection().WithDSN(dsn).WithFactory(factory).Get()
+	require.NoError(t, err)
+	closeDB(t, db)
+
+	err = gormoize.Instance().CheckHealth(context.Background(), gormoize.HealthCheckConfig{Rebuild: true})
+	require.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&opened))
+
+	// The rebuilt connection is cached and healthy
+	rebuilt, err := gormoize.Connection().WithDSN(dsn).Get()
+	require.NoError(t, err)
+	assert.NotSame(t, db, rebuilt)
+	require.NoError(t, rebuilt.Exec("CREATE TABLE rebuilt (id INTEGER)").Error)
+
+	require.NoError(t, gormoize.Instance().CheckHealth(context.Background(), gormoize.HealthCheckConfig{Rebuild: true}))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&opened))
+}
+
+// TestStartHealthCheck tests the background health checker
+func TestStartHealthCheck(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dsn := filepath.Join(t.TempDir(), "background.db")
+	db, err := gormoize.Connection().WithDSN(dsn).WithDialector(sqlite.Open(dsn)).Get()
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		evicted []string
+	)
+	gormoize.StartHealthCheck(gormoize.HealthCheckConfig{
+		Interval: 10 * time.Millisecond,
+		OnEvict: func(dsn string, db *gorm.DB, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			evicted = append(evicted, dsn)
+		},
+	})
+	defer gormoize.Instance().StopHealthCheck()
+
+	closeDB(t, db)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(evicted) == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Empty(t, gormoize.GetAll())
+}
+
+// TestStartHealthCheckConcurrent tests that concurrent starts leave a single
+// checker, so stopping it leaves none running
+func TestStartHealthCheckConcurrent(t *testing.T) {
+	cache := gormoize.Instance().Clear()
+
+	var evictions atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.StartHealthCheck(gormoize.HealthCheckConfig{
+				Interval: 5 * time.Millisecond,
+				OnEvict: func(dsn string, db *gorm.DB, err error) {
+					evictions.Add(1)
+				},
+			})
+		}()
+	}
+	wg.Wait()
+	cache.StopHealthCheck()
+
+	dsn := filepath.Join(t.TempDir(), "stopped.db")
+	db, err := gormoize.Connection().WithDSN(dsn).WithDialector(sqlite.Open(dsn)).Get()
+	require.NoError(t, err)
+	closeDB(t, db)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, evictions.Load())
+	assert.Contains(t, gormoize.GetAll(), dsn)
+}
@@ -0,0 +1,172 @@
+package gormoize
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// idleJanitor is a running background sweep of idle connections
+type idleJanitor struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// SetMaxConnections limits the number of cached connections. When a new
+// connection exceeds the limit, the least recently used connection is
+// evicted. Zero or less means unlimited.
+func (c *DBCache) SetMaxConnections(n int) *DBCache {
+	c.mutex.Lock()
+	c.maxConnections = n
+	evicted := c.evictLocked("")
+	c.mutex.Unlock()
+
+	releaseAll(evicted)
+	return c
+}
+
+// SetIdleTTL evicts connections that haven't been retrieved for ttl,
+// checking in the background. Zero or less disables idle eviction.
+func (c *DBCache) SetIdleTTL(ttl time.Duration) *DBCache {
+	// Swap the janitors under one lock so that concurrent calls each stop the
+	// janitor they replaced
+	var (
+		janitor *idleJanitor
+		ctx     context.Context
+	)
+	if ttl > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(context.Background())
+		janitor = &idleJanitor{cancel: cancel, done: make(chan struct{})}
+	}
+	c.mutex.Lock()
+	c.idleTTL = ttl
+	previous := c.janitor
+	c.janitor = janitor
+	c.mutex.Unlock()
+
+	if previous != nil {
+		previous.cancel()
+		<-previous.done
+	}
+	if janitor == nil {
+		return c
+	}
+
+	go func() {
+		defer close(janitor.done)
+		ticker := time.NewTicker(max(ttl/2, 10*time.Millisecond))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.EvictIdle()
+			}
+		}
+	}()
+	return c
+}
+
+// EvictIdle evicts connections idle for longer than the idle TTL and returns
+// how many were evicted
+func (c *DBCache) EvictIdle() int {
+	c.mutex.Lock()
+	evicted := c.evictLocked("")
+	c.mutex.Unlock()
+
+	releaseAll(evicted)
+	return len(evicted)
+}
+
+// touch records that a connection was used. The cache must be at least read-locked.
+func (c *DBCache) touch(dsn string) {
+	if lastUsed := c.lastUsed[dsn]; lastUsed != nil {
+		lastUsed.Store(time.Now().UnixNano())
+	}
+}
+
+// storeLocked caches a connection unless one was cached for dsn meanwhile,
+// and returns the cached connection along with the connections evicted to
+// make room, which the caller releases after unlocking the cache
+func (c *DBCache) storeLocked(dsn string, db *gorm.DB, opener func() (*gorm.DB, error)) (*gorm.DB, []*gorm.DB) {
+	// The existing connection may already be in use, so it is kept
+	if existing, exists := c.connections[dsn]; exists {
+		c.touch(dsn)
+		return existing, nil
+	}
+
+	c.connections[dsn] = db
+	c.openers[dsn] = opener
+	c.lastUsed[dsn] = new(atomic.Int64)
+	c.touch(dsn)
+	return db, c.evictLocked(dsn)
+}
+
+// removeLocked removes a connection from the cache without closing it
+func (c *DBCache) removeLocked(dsn string) {
+	delete(c.connections, dsn)
+	delete(c.openers, dsn)
+	delete(c.lastUsed, dsn)
+}
+
+// evictLocked removes idle connections and then the least recently used ones
+// above the limit, never the one for keep, and returns the removed connections
+func (c *DBCache) evictLocked(keep string) []*gorm.DB {
+	if c.idleTTL <= 0 && (c.maxConnections <= 0 || len(c.connections) <= c.maxConnections) {
+		return nil
+	}
+
+	type usage struct {
+		dsn      string
+		lastUsed int64
+	}
+	usages := make([]usage, 0, len(c.connections))
+	for dsn := range c.connections {
+		if dsn != keep {
+			usages = append(usages, usage{dsn, c.lastUsed[dsn].Load()})
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].lastUsed < usages[j].lastUsed
+	})
+
+	var evicted []*gorm.DB
+	idleBefore := time.Now().Add(-c.idleTTL).UnixNano()
+	for _, u := range usages {
+		idle := c.idleTTL > 0 && u.lastUsed < idleBefore
+		full := c.maxConnections > 0 && len(c.connections) > c.maxConnections
+		if !idle && !full {
+			break
+		}
+		evicted = append(evicted, c.connections[u.dsn])
+		c.removeLocked(u.dsn)
+	}
+	return evicted
+}
+
+// releaseAll stops evicted connections from keeping idle database connections
+// open. Callers may still hold an evicted *gorm.DB, so it is not closed: it
+// stays usable, opening database connections as needed, and whoever holds it
+// may close it.
+func releaseAll(dbs []*gorm.DB) {
+	for _, db := range dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.SetMaxIdleConns(0)
+		}
+	}
+}
+
+// closeAll closes the underlying databases of connections no caller was
+// given, letting queries in progress finish
+func closeAll(dbs []*gorm.DB) {
+	for _, db := range dbs {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gormoize_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/gormoize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openSQLite caches a SQLite connection for a file in dir
+func openSQLite(t *testing.T, dir, name string) (string, *gorm.DB) {
+	dsn := filepath.Join(dir, name+".db")
+	db, err := gormoize.Connection().WithDSN(dsn).WithDialector(sqlite.Open(dsn)).Get()
+	require.NoError(t, err)
+	return dsn, db
+}
+
+// assertClosed checks that a connection's underlying database was closed
+func assertClosed(t *testing.T, db *gorm.DB) {
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Error(t, sqlDB.Ping())
+}
+
+// assertReleased checks that an evicted connection is still usable but keeps
+// no idle database connections open
+func assertReleased(t *testing.T, db *gorm.DB) {
+	require.NoError(t, db.Exec("SELECT 1").Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	assert.Zero(t, sqlDB.Stats().Idle)
+}
+
+// TestMaxConnections tests that the least recently used connection is evicted
+func TestMaxConnections(t *testing.T) {
+	cache := gormoize.Instance().Clear().SetMaxConnections(2)
+	defer cache.SetMaxConnections(0)
+
+	dir := t.TempDir()
+	dsn1, db1 := openSQLite(t, dir, "one")
+	time.Sleep(time.Millisecond)
+	dsn2, db2 := openSQLite(t, dir, "two")
+	time.Sleep(time.Millisecond)
+
+	// Using the first connection makes the second the least recently used
+	_, err := gormoize.Connection().WithDSN(dsn1).Get()
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	dsn3, _ := openSQLite(t, dir, "three")
+
+	connections := gormoize.GetAll()
+	assert.Len(t, connections, 2)
+	assert.Contains(t, connections, dsn1)
+	assert.Contains(t, connections, dsn3)
+	assert.NotContains(t, connections, dsn2)
+	assertReleased(t, db2)
+	require.NoError(t, db1.Exec("SELECT 1").Error)
+
+	// Lowering the limit evicts immediately
+	cache.SetMaxConnections(1)
+	connections = gormoize.GetAll()
+	assert.Len(t, connections, 1)
+	assert.Contains(t, connections, dsn3)
+	assertReleased(t, db1)
+}
+
+// TestEvictIdle tests that connections idle for longer than the TTL are evicted
+func TestEvictIdle(t *testing.T) {
+	cache := gormoize.Instance().Clear()
+	dir := t.TempDir()
+	_, idle := openSQLite(t, dir, "idle")
+
+	cache.SetIdleTTL(time.Hour)
+	defer cache.SetIdleTTL(0)
+	assert.Equal(t, 0, cache.EvictIdle())
+
+	cache.SetIdleTTL(20 * time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	activeDSN, _ := openSQLite(t, dir, "active")
+
+	// The new connection is kept while the idle one is evicted
+	connections := gormoize.GetAll()
+	assert.Len(t, connections, 1)
+	assert.Contains(t, connections, activeDSN)
+	assertReleased(t, idle)
+}
+
+// TestEvictedConnectionUsable tests that a caller holding a connection can
+// keep using it after the cache evicts it
+func TestEvictedConnectionUsable(t *testing.T) {
+	cache := gormoize.Instance().Clear().SetMaxConnections(1)
+	defer cache.SetMaxConnections(0)
+
+	dir := t.TempDir()
+	dsn, held := openSQLite(t, dir, "held")
+	require.NoError(t, held.Exec("CREATE TABLE items (name TEXT)").Error)
+
+	openSQLite(t, dir, "other")
+	require.NotContains(t, gormoize.GetAll(), dsn)
+
+	require.NoError(t, held.Exec("INSERT INTO items VALUES ('a')").Error)
+	var count int64
+	require.NoError(t, held.Table("items").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// The next Get opens a new connection to the same database
+	db, err := gormoize.Connection().WithDSN(dsn).WithDialector(sqlite.Open(dsn)).Get()
+	require.NoError(t, err)
+	assert.NotSame(t, held, db)
+	require.NoError(t, db.Table("items").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+// TestIdleTTLBackground tests that idle connections are evicted without new activity
+func TestIdleTTLBackground(t *testing.T) {
+	cache := gormoize.Instance().Clear()
+	dir := t.TempDir()
+	for i := 0; i < 3; i++ {
+		openSQLite(t, dir, fmt.Sprintf("db%d", i))
+	}
+
+	cache.SetIdleTTL(20 * time.Millisecond)
+	defer cache.SetIdleTTL(0)
+
+	assert.Eventually(t, func() bool {
+		return len(gormoize.GetAll()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestCreateKeepsCachedConnection tests that a connection opened while another
+// caller cached the same DSN is closed instead of replacing the cached one
+func TestCreateKeepsCachedConnection(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dsn := filepath.Join(t.TempDir(), "race.db")
+	var opened, cached *gorm.DB
+	db, err := gormoize.Connection().WithDSN(dsn).WithFactory(func() (*gorm.DB, error) {
+		var err error
+		opened, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		require.NoError(t, err)
+
+		// Another caller caches the connection while this one is opening
+		cached, err = gormoize.Connection().WithDSN(dsn).WithDialector(sqlite.Open(dsn)).Get()
+		require.NoError(t, err)
+		return opened, nil
+	}).Get()
+	require.NoError(t, err)
+
+	assert.Same(t, cached, db)
+	assert.Same(t, cached, gormoize.GetAll()[dsn])
+	assertClosed(t, opened)
+	require.NoError(t, cached.Exec("SELECT 1").Error)
+}
@@ -0,0 +1,56 @@
+package gormoize
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// WithReplicas adds read replicas to the connection. Reads are spread across
+// the replicas and writes go to the primary, using GORM's dbresolver plugin.
+// The primary and its replicas are cached together under one key, so the same
+// DSN with other replicas is a separate connection. Replica dialectors are
+// selected by DSN scheme unless set with WithReplicaDialector.
+func (b *ConnectionBuilder) WithReplicas(dsns ...string) *ConnectionBuilder {
+	b.replicas = append(b.replicas, dsns...)
+	return b
+}
+
+// WithReplicaDialector sets the factory creating dialectors for replica DSNs
+func (b *ConnectionBuilder) WithReplicaDialector(factory DialectorFactory) *ConnectionBuilder {
+	b.replicaDialector = factory
+	return b
+}
+
+// key returns the cache key of the connection: its DSN, followed by its
+// replicas' DSNs if it has any
+func (b *ConnectionBuilder) key() string {
+	if len(b.replicas) == 0 {
+		return b.dsn
+	}
+	return b.dsn + "#replicas=" + strings.Join(b.replicas, ",")
+}
+
+// useReplicas registers the dbresolver plugin routing reads to the replicas
+func (b *ConnectionBuilder) useReplicas(db *gorm.DB) error {
+	dialectors := make([]gorm.Dialector, 0, len(b.replicas))
+	for _, dsn := range b.replicas {
+		if b.replicaDialector != nil {
+			dialectors = append(dialectors, b.replicaDialector(dsn))
+			continue
+		}
+
+		dialector, err := b.cache.dialectorFor(dsn)
+		if err != nil {
+			return fmt.Errorf("gormoize: replica: %w", err)
+		}
+		dialectors = append(dialectors, dialector)
+	}
+
+	return db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   dbresolver.RandomPolicy{},
+	}))
+}
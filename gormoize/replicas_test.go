@@ -0,0 +1,108 @@
+package gormoize_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/presbrey/pkg/gormoize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type replicaItem struct {
+	ID   uint
+	Name string
+}
+
+// seedSQLite creates a SQLite database holding one item with the given name
+func seedSQLite(t *testing.T, dsn, name string) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&replicaItem{}))
+	require.NoError(t, db.Create(&replicaItem{Name: name}).Error)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+}
+
+func sqliteDialector(dsn string) gorm.Dialector {
+	return sqlite.Open(dsn)
+}
+
+// TestWithReplicas tests that reads go to the replica and writes to the primary
+func TestWithReplicas(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dir := t.TempDir()
+	primaryDSN := filepath.Join(dir, "primary.db")
+	replicaDSN := filepath.Join(dir, "replica.db")
+	seedSQLite(t, primaryDSN, "primary")
+	seedSQLite(t, replicaDSN, "replica")
+
+	db, err := gormoize.Connection().
+		WithDSN(primaryDSN).
+		WithDialector(sqlite.Open(primaryDSN)).
+		WithReplicas(replicaDSN).
+		WithReplicaDialector(sqliteDialector).
+		Get()
+	require.NoError(t, err)
+
+	var item replicaItem
+	require.NoError(t, db.First(&item).Error)
+	assert.Equal(t, "replica", item.Name)
+
+	require.NoError(t, db.Create(&replicaItem{Name: "written"}).Error)
+	var count int64
+	require.NoError(t, db.Model(&replicaItem{}).Where("name = ?", "written").Count(&count).Error)
+	assert.Zero(t, count, "write should not reach the replica")
+
+	primary, err := gorm.Open(sqlite.Open(primaryDSN), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, primary.Model(&replicaItem{}).Where("name = ?", "written").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// The primary and replica set is memoized under one key
+	cached, err := gormoize.Connection().WithDSN(primaryDSN).WithReplicas(replicaDSN).Get()
+	require.NoError(t, err)
+	assert.Same(t, db, cached)
+
+	connections := gormoize.GetAll()
+	assert.Len(t, connections, 1)
+	assert.NotContains(t, connections, primaryDSN)
+
+	gormoize.Connection().WithDSN(primaryDSN).WithReplicas(replicaDSN).Remove()
+	assert.Empty(t, gormoize.GetAll())
+}
+
+// TestWithReplicasRegisteredDialector tests that replica dialectors are selected by scheme
+func TestWithReplicasRegisteredDialector(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dir := t.TempDir()
+	primaryDSN := filepath.Join(dir, "primary.db")
+	replicaDSN := "file:" + filepath.Join(dir, "replica.db")
+	seedSQLite(t, primaryDSN, "primary")
+	seedSQLite(t, replicaDSN, "replica")
+
+	gormoize.RegisterDialector("file", sqliteDialector)
+
+	db, err := gormoize.Connection().
+		WithDSN(primaryDSN).
+		WithDialector(sqlite.Open(primaryDSN)).
+		WithReplicas(replicaDSN).
+		Get()
+	require.NoError(t, err)
+
+	var item replicaItem
+	require.NoError(t, db.First(&item).Error)
+	assert.Equal(t, "replica", item.Name)
+
+	_, err = gormoize.Connection().
+		WithDSN(primaryDSN).
+		WithDialector(sqlite.Open(primaryDSN)).
+		WithReplicas("unknown://replica").
+		Get()
+	assert.ErrorContains(t, err, `no dialector registered for scheme "unknown"`)
+}
@@ -0,0 +1,99 @@
+package gormoize
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// WarmConcurrency is the maximum number of connections Warm opens at once
+var WarmConcurrency = 4
+
+// ConnSpec describes a connection to open ahead of time with Warm.
+// If neither Dialector nor Factory is set, the dialector registered for the
+// DSN's scheme is used.
+type ConnSpec struct {
+	DSN       string
+	Dialector gorm.Dialector
+	Config    *gorm.Config
+	Factory   func() (*gorm.DB, error)
+}
+
+// Warm opens the listed connections concurrently and caches them so later
+// Get calls are cache hits. Connections that are already cached are skipped.
+// Errors for individual DSNs are joined into the returned error.
+func (c *DBCache) Warm(specs []ConnSpec) error {
+	limit := WarmConcurrency
+	if limit < 1 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		seen = make(map[string]bool, len(specs))
+	)
+	for _, spec := range specs {
+		if spec.DSN == "" {
+			errs = append(errs, fmt.Errorf("gormoize: warm: empty DSN"))
+			continue
+		}
+		if seen[spec.DSN] {
+			continue
+		}
+		seen[spec.DSN] = true
+
+		wg.Add(1)
+		go func(spec ConnSpec) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := c.warmOne(spec); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("gormoize: warm %s: %w", spec.DSN, err))
+				mu.Unlock()
+			}
+		}(spec)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Warm opens the listed connections on the singleton cache
+func Warm(specs []ConnSpec) error {
+	return Instance().Warm(specs)
+}
+
+// warmOne opens and caches a single connection unless it is already cached
+func (c *DBCache) warmOne(spec ConnSpec) error {
+	c.mutex.RLock()
+	_, exists := c.connections[spec.DSN]
+	c.mutex.RUnlock()
+	if exists {
+		return nil
+	}
+
+	builder := &ConnectionBuilder{
+		cache:     c,
+		dsn:       spec.DSN,
+		dialector: spec.Dialector,
+		config:    spec.Config,
+		factory:   spec.Factory,
+	}
+	if builder.dialector == nil && builder.factory == nil {
+		dialector, err := c.dialectorFor(spec.DSN)
+		if err != nil {
+			return err
+		}
+		builder.dialector = dialector
+	}
+
+	_, err := builder.create()
+	return err
+}
@@ -0,0 +1,80 @@
+package gormoize_test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/presbrey/pkg/gormoize"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestWarm tests that Warm opens and caches connections ahead of time
+func TestWarm(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	dir := t.TempDir()
+	dsn1 := filepath.Join(dir, "one.db")
+	dsn2 := filepath.Join(dir, "two.db")
+
+	var opened int32
+	factory := func(dsn string) func() (*gorm.DB, error) {
+		return func() (*gorm.DB, error) {
+			atomic.AddInt32(&opened, 1)
+			return gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		}
+	}
+
+	err := gormoize.Warm([]gormoize.ConnSpec{
+		{DSN: dsn1, Factory: factory(dsn1)},
+		{DSN: dsn2, Dialector: sqlite.Open(dsn2)},
+		{DSN: dsn1, Factory: factory(dsn1)}, // Duplicates are opened once
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opened))
+
+	connections := gormoize.GetAll()
+	require.Len(t, connections, 2)
+	require.Contains(t, connections, dsn1)
+	require.Contains(t, connections, dsn2)
+
+	// Subsequent Gets are cache hits and never call the factory
+	db1, err := gormoize.Connection().WithDSN(dsn1).WithFactory(factory(dsn1)).Get()
+	require.NoError(t, err)
+	assert.Same(t, connections[dsn1], db1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opened))
+
+	db2, err := gormoize.Connection().WithDSN(dsn2).WithDialector(sqlite.Open(dsn2)).Get()
+	require.NoError(t, err)
+	assert.Same(t, connections[dsn2], db2)
+	require.NoError(t, db2.Exec("CREATE TABLE warmed (id INTEGER)").Error)
+
+	// Warming cached connections is a no-op
+	require.NoError(t, gormoize.Warm([]gormoize.ConnSpec{{DSN: dsn1, Factory: factory(dsn1)}}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&opened))
+}
+
+// TestWarmErrors tests that Warm aggregates per-DSN errors
+func TestWarmErrors(t *testing.T) {
+	gormoize.Instance().Clear()
+
+	errBoom := errors.New("boom")
+	err := gormoize.Warm([]gormoize.ConnSpec{
+		{DSN: "bad-1", Factory: func() (*gorm.DB, error) { return nil, errBoom }},
+		{DSN: "unknown://db"},
+		{DSN: "good", Dialector: mockDialector{}},
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errBoom)
+	assert.Contains(t, err.Error(), "bad-1")
+	assert.Contains(t, err.Error(), `no dialector registered for scheme "unknown"`)
+
+	// Successful connections are still cached
+	connections := gormoize.GetAll()
+	assert.Len(t, connections, 1)
+	assert.Contains(t, connections, "good")
+}
@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"context"
+	"time"
+)
+
+// ContextHook defines a hook function that receives a context, which is
+// cancelled when the run is cancelled or the hook's timeout expires
+type ContextHook[T any] func(ctx context.Context, target T) error
+
+// Option configures a hook at registration
+type Option func(*hookOptions)
+
+// hookOptions holds the settings applied by Options
+type hookOptions struct {
+	priority int64
+	timeout  time.Duration
+	phase    Phase
+}
+
+// WithPriority sets a hook's priority (lower values run first, like Unix nice)
+func WithPriority(priority int64) Option {
+	return func(o *hookOptions) {
+		o.priority = priority
+	}
+}
+
+// WithTimeout limits how long a hook may run. A hook that runs too long is
+// abandoned and reports context.DeadlineExceeded; a ContextHook should return
+// once its context is done.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *hookOptions) {
+		o.timeout = timeout
+	}
+}
+
+// RegisterContext adds a new context-aware hook to the registry with default
+// priority (0), unless changed by the options. The returned handle removes the
+// hook.
+func (r *Registry[T]) RegisterContext(hook ContextHook[T], opts ...Option) *Handle {
+	h, _ := r.add(HookInfo[T]{Name: funcName(hook), ContextHook: hook}, opts)
+	return h
+}
+
+// RunAllContext executes all hooks in priority order like RunAll, passing ctx
+// to context-aware hooks and enforcing hook timeouts. Once ctx is done, the
+// remaining hooks are skipped and report ctx.Err().
+func (r *Registry[T]) RunAllContext(ctx context.Context, target T) map[string]error {
+	return r.runHooks(ctx, target, nil, false)
+}
+
+// RunParallel executes all hooks like RunAll, except that hooks of equal
+// priority run concurrently. Each priority band finishes before the next one
+// starts, so hooks still run after those with lower priority values. Hooks in
+// the same band must be safe to run concurrently on the same target.
+func (r *Registry[T]) RunParallel(target T) map[string]error {
+	return r.runHooks(context.Background(), target, nil, true)
+}
+
+// RunParallelContext executes all hooks like RunParallel, passing ctx to
+// context-aware hooks and enforcing hook timeouts as RunAllContext does
+func (r *Registry[T]) RunParallelContext(ctx context.Context, target T) map[string]error {
+	return r.runHooks(ctx, target, nil, true)
+}
@@ -0,0 +1,121 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Errors returned when registering or running named hooks
+var (
+	ErrDuplicateName     = errors.New("hook name already registered")
+	ErrDependencyCycle   = errors.New("dependency cycle")
+	ErrMissingDependency = errors.New("missing dependency")
+)
+
+// RegisterNamed adds a new hook to the registry under a unique name, to run
+// after the hooks it depends on. Dependencies take precedence over priorities,
+// so a hook always runs after its dependencies, and otherwise in priority
+// order. Dependencies may be registered later, but a hook whose dependencies
+// are still missing when the hooks run is skipped with ErrMissingDependency.
+// RegisterNamed returns ErrDuplicateName if the name is taken and
+// ErrDependencyCycle if the dependencies would form a cycle. The returned
+// handle removes the hook.
+func (r *Registry[T]) RegisterNamed(name string, hook Hook[T], dependsOn ...string) (*Handle, error) {
+	if name == "" {
+		return nil, errors.New("hook name must not be empty")
+	}
+	return r.add(HookInfo[T]{
+		Name:      name,
+		Hook:      hook,
+		DependsOn: dependsOn,
+		named:     true,
+	}, nil)
+}
+
+// sortHooks orders hooks so that each runs after its dependencies, choosing
+// among the hooks that are ready the one in the earliest phase, then with the
+// lowest priority value, and then the earliest registered
+func sortHooks[T any](hooks []HookInfo[T]) ([]HookInfo[T], error) {
+	byName := make(map[string][]int, len(hooks))
+	for i, hi := range hooks {
+		byName[hi.Name] = append(byName[hi.Name], i)
+	}
+
+	// pending counts each hook's dependencies that haven't been placed yet
+	pending := make([]int, len(hooks))
+	dependents := make([][]int, len(hooks))
+	for i, hi := range hooks {
+		for _, dep := range hi.DependsOn {
+			for _, j := range byName[dep] {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	sorted := make([]HookInfo[T], 0, len(hooks))
+	placed := make([]bool, len(hooks))
+	for len(sorted) < len(hooks) {
+		next := -1
+		for i, hi := range hooks {
+			if placed[i] || pending[i] > 0 {
+				continue
+			}
+			if next < 0 || runsBefore(hi, hooks[next]) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			var names []string
+			for i, hi := range hooks {
+				if !placed[i] {
+					names = append(names, hi.Name)
+				}
+			}
+			return nil, fmt.Errorf("%w among %s", ErrDependencyCycle, strings.Join(names, ", "))
+		}
+
+		placed[next] = true
+		sorted = append(sorted, hooks[next])
+		for _, i := range dependents[next] {
+			pending[i]--
+		}
+	}
+	return sorted, nil
+}
+
+// runsBefore reports whether hook a runs before hook b, ignoring dependencies
+func runsBefore[T any](a, b HookInfo[T]) bool {
+	if a.Phase != b.Phase {
+		return a.Phase < b.Phase
+	}
+	if a.Priority != b.Priority {
+		return a.Priority < b.Priority
+	}
+	return a.seq < b.seq
+}
+
+// missingDependency returns an error naming the first dependency of a hook
+// that isn't registered
+func missingDependency[T any](hi HookInfo[T], registered map[string]bool) error {
+	for _, dep := range hi.DependsOn {
+		if !registered[dep] {
+			return fmt.Errorf("%w: %s", ErrMissingDependency, dep)
+		}
+	}
+	return nil
+}
+
+// dependsOnAny reports whether a hook depends on any of the given hooks
+func dependsOnAny[T any](hi HookInfo[T], hooks []HookInfo[T]) bool {
+	for _, dep := range hi.DependsOn {
+		for _, other := range hooks {
+			if other.Name == dep {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package hooks
+
+// Handle refers to a registered hook, so it can be removed when the module
+// that registered it unloads
+type Handle struct {
+	remove func() bool
+}
+
+// Unregister removes the hook from its registry. It reports whether the hook
+// was removed, which is false if it was already removed, cleared or, for
+// one-shot hooks, has run. Runs in progress are not affected.
+func (h *Handle) Unregister() bool {
+	if h == nil {
+		return false
+	}
+	return h.remove()
+}
+
+// RegisterOnce adds a new hook to the registry that runs only the first time
+// it is selected by a run, after which it is removed
+func (r *Registry[T]) RegisterOnce(hook Hook[T], opts ...Option) *Handle {
+	h, _ := r.add(HookInfo[T]{Name: funcName(hook), Hook: hook, once: true}, opts)
+	return h
+}
+
+// remove removes the hook with the given registration sequence number,
+// reporting whether it was found
+func (r *Registry[T]) remove(seq uint64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, hi := range r.hooks {
+		if hi.seq == seq {
+			r.hooks = append(r.hooks[:i:i], r.hooks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,16 @@
 package hooks
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"reflect"
 	"runtime"
-	"sort"
+	"slices"
 	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
 )
 
 // Hook defines a generic hook function that returns an error if it fails
@@ -15,15 +19,25 @@ type Hook[T any] func(context T) error
 
 // HookInfo stores information about a registered hook including its priority
 type HookInfo[T any] struct {
-	Name     string  // Name of the hook function
-	Hook     Hook[T] // The hook function itself
-	Priority int64   // Priority value (lower values run first, like Unix nice)
+	Name        string         // Name of the hook function
+	Hook        Hook[T]        // The hook function itself
+	ContextHook ContextHook[T] // The hook function, if it accepts a context
+	Priority    int64          // Priority value (lower values run first, like Unix nice)
+	Phase       Phase          // Phase of a run the hook belongs to
+	Timeout     time.Duration  // Maximum time the hook may run, or zero for no limit
+	DependsOn   []string       // Names of hooks that must run before this one
+
+	named bool   // Registered with RegisterNamed, so its name is unique
+	once  bool   // Registered with RegisterOnce, so it is removed when it runs
+	seq   uint64 // Registration order, used to break ties between hooks
 }
 
 // Registry manages hook registration and execution for a specific context type
 type Registry[T any] struct {
-	mu    sync.RWMutex
-	hooks []HookInfo[T]
+	mu         sync.RWMutex
+	hooks      []HookInfo[T]
+	seq        uint64
+	middleware []Middleware[T]
 }
 
 // NewRegistry creates a new hook registry for the given context type
@@ -33,58 +47,138 @@ func NewRegistry[T any]() *Registry[T] {
 	}
 }
 
-// Register adds a new hook to the registry with default priority (0)
-func (r *Registry[T]) Register(hook Hook[T]) {
-	r.RegisterWithPriority(hook, 0)
+// Register adds a new hook to the registry with default priority (0), unless
+// changed by the options. The returned handle removes the hook.
+func (r *Registry[T]) Register(hook Hook[T], opts ...Option) *Handle {
+	h, _ := r.add(HookInfo[T]{Name: funcName(hook), Hook: hook}, opts)
+	return h
 }
 
 // RegisterWithPriority adds a new hook to the registry with the specified priority
 // Hooks with lower priority values run first (like Unix nice)
-func (r *Registry[T]) RegisterWithPriority(hook Hook[T], priority int64) {
-	name := runtime.FuncForPC(reflect.ValueOf(hook).Pointer()).Name()
+func (r *Registry[T]) RegisterWithPriority(hook Hook[T], priority int64) *Handle {
+	return r.Register(hook, WithPriority(priority))
+}
+
+// funcName returns the name of a hook function
+func funcName(fn any) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
+// add applies the options to a hook and adds it to the registry, leaving the
+// registry unchanged if the hook's name or dependencies are invalid. Only named
+// hooks can be invalid.
+func (r *Registry[T]) add(info HookInfo[T], opts []Option) (*Handle, error) {
+	var o hookOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	info.Priority = o.priority
+	info.Timeout = o.timeout
+	info.Phase = o.phase
 
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.hooks = append(r.hooks, HookInfo[T]{
-		Name:     name,
-		Hook:     hook,
-		Priority: priority,
-	})
-	// Sort hooks by priority (lowest first) after each registration
-	sort.Slice(r.hooks, func(i, j int) bool {
-		return r.hooks[i].Priority < r.hooks[j].Priority
-	})
+	for _, hi := range r.hooks {
+		if info.named && hi.Name == info.Name {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateName, info.Name)
+		}
+	}
+
+	info.seq = r.seq
+	hooks := append(slices.Clone(r.hooks), info)
+	// Sort hooks after each registration
+	sorted, err := sortHooks(hooks)
+	if err != nil {
+		return nil, err
+	}
+	r.hooks = sorted
+	r.seq++
+
+	seq := info.seq
+	return &Handle{remove: func() bool { return r.remove(seq) }}, nil
 }
 
 // runHooksWithFilter is a helper to execute hooks matching a filter, in priority order.
-func (r *Registry[T]) runHooksWithFilter(context T, filter func(HookInfo[T]) bool) map[string]error {
+func (r *Registry[T]) runHooksWithFilter(target T, filter func(HookInfo[T]) bool) map[string]error {
+	return r.runHooks(context.Background(), target, filter, false)
+}
+
+// runHooks executes hooks matching a filter in priority order. In parallel
+// mode, hooks of equal priority run concurrently. Once ctx is done, the
+// remaining hooks are skipped and report the context's error.
+func (r *Registry[T]) runHooks(ctx context.Context, target T, filter func(HookInfo[T]) bool, parallel bool) map[string]error {
+	var mu sync.Mutex
+	hookErrors := make(map[string]error)
+	record := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if hookErrors[name] == nil {
+			hookErrors[name] = err
+		}
+	}
+
 	r.mu.RLock()
+	middleware := r.middleware
+	registered := make(map[string]bool, len(r.hooks))
+	for _, hi := range r.hooks {
+		registered[hi.Name] = true
+	}
 	hooks := make([]HookInfo[T], 0, len(r.hooks))
 	for _, hi := range r.hooks {
-		if filter == nil || filter(hi) {
-			hooks = append(hooks, hi)
+		if filter != nil && !filter(hi) {
+			continue
+		}
+		if err := missingDependency(hi, registered); err != nil {
+			log.Printf("ERROR in hook %s: %v", hi.Name, err)
+			record(hi.Name, err)
+			continue
 		}
+		hooks = append(hooks, hi)
 	}
 	r.mu.RUnlock()
 
-	hookErrors := make(map[string]error)
+	for start := 0; start < len(hooks); {
+		if err := ctx.Err(); err != nil {
+			for _, skipped := range hooks[start:] {
+				record(skipped.Name, err)
+			}
+			break
+		}
+
+		// A band is a run of independent hooks with the same phase and
+		// priority in parallel mode, or a single hook otherwise
+		end := start + 1
+		for parallel && end < len(hooks) && hooks[end].Phase == hooks[start].Phase &&
+			hooks[end].Priority == hooks[start].Priority && !dependsOnAny(hooks[end], hooks[start:end]) {
+			end++
+		}
+
+		run := func(hookInfo HookInfo[T]) {
+			// One-shot hooks are removed as they run, so they run only once
+			// even when the registry is run concurrently
+			if hookInfo.once && !r.remove(hookInfo.seq) {
+				return
+			}
+			if err := runHook(ctx, target, hookInfo, middleware); err != nil {
+				record(hookInfo.Name, err)
+			}
+		}
 
-	for _, hookInfo := range hooks {
-		err := func() error {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Printf("PANIC in hook %s: %v", hookInfo.Name, r)
-					err := fmt.Errorf("panic in hook %s: %v", hookInfo.Name, r)
-					hookErrors[hookInfo.Name] = err
-				}
-			}()
-			return hookInfo.Hook(context)
-		}()
-		if err != nil && hookErrors[hookInfo.Name] == nil {
-			hookErrors[hookInfo.Name] = err
-			log.Printf("ERROR in hook %s: %v", hookInfo.Name, err)
+		if end-start == 1 {
+			run(hooks[start])
+		} else {
+			var g errgroup.Group
+			for _, hookInfo := range hooks[start:end] {
+				g.Go(func() error {
+					run(hookInfo)
+					return nil
+				})
+			}
+			g.Wait()
 		}
+		start = end
 	}
 
 	if len(hookErrors) == 0 {
@@ -93,6 +187,66 @@ func (r *Registry[T]) runHooksWithFilter(context T, filter func(HookInfo[T]) boo
 	return hookErrors
 }
 
+// runHook executes a single hook wrapped in middleware, enforcing its timeout. A
+// hook that doesn't return before ctx is done is abandoned and reports the
+// context's error.
+func runHook[T any](ctx context.Context, target T, info HookInfo[T], middleware []Middleware[T]) error {
+	if info.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, info.Timeout)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		return callHook(ctx, target, info, middleware)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- callHook(ctx, target, info, middleware)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Prefer the hook's own result if it returned as the context finished
+	select {
+	case err := <-done:
+		return err
+	default:
+		err := ctx.Err()
+		log.Printf("ERROR in hook %s: %v", info.Name, err)
+		return err
+	}
+}
+
+// callHook calls a hook wrapped in middleware, recovering from any panic
+func callHook[T any](ctx context.Context, target T, info HookInfo[T], middleware []Middleware[T]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC in hook %s: %v", info.Name, r)
+			err = fmt.Errorf("panic in hook %s: %v", info.Name, r)
+		}
+	}()
+
+	hook := info.ContextHook
+	if hook == nil {
+		hook = func(ctx context.Context, target T) error {
+			return info.Hook(target)
+		}
+	}
+	for i := len(middleware) - 1; i >= 0; i-- {
+		hook = middleware[i](info, hook)
+	}
+
+	if err = hook(ctx, target); err != nil {
+		log.Printf("ERROR in hook %s: %v", info.Name, err)
+	}
+	return err
+}
+
 // RunEarly executes hooks with priority < 0
 func (r *Registry[T]) RunEarly(context T) map[string]error {
 	return r.RunPriorityLessThan(context, 0)
@@ -1,6 +1,7 @@
 package hooks
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -305,3 +306,371 @@ func BenchmarkRegistryExecution(b *testing.B) {
 		registry.RunAll(ctx)
 	}
 }
+
+func TestRunAllContext(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	registry.RegisterContext(func(ctx context.Context, tc *TestContext) error {
+		tc.AddToOrder("first")
+		return nil
+	}, WithPriority(-1))
+
+	registry.RegisterContext(func(ctx context.Context, tc *TestContext) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(10*time.Millisecond))
+
+	registry.Register(func(tc *TestContext) error {
+		tc.AddToOrder("last")
+		return nil
+	}, WithPriority(1))
+
+	tc := &TestContext{}
+	hookErrors := registry.RunAllContext(context.Background(), tc)
+
+	if len(hookErrors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", hookErrors)
+	}
+	for _, err := range hookErrors {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	}
+
+	tc.Mutex.Lock()
+	defer tc.Mutex.Unlock()
+	if len(tc.Order) != 2 || tc.Order[0] != "first" || tc.Order[1] != "last" {
+		t.Errorf("Expected the hooks around the slow hook to run, got %v", tc.Order)
+	}
+}
+
+func TestRunAllContextTimeoutAbandonsHook(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	release := make(chan struct{})
+	defer close(release)
+	registry.Register(func(tc *TestContext) error {
+		<-release
+		return nil
+	}, WithTimeout(10*time.Millisecond))
+
+	start := time.Now()
+	hookErrors := registry.RunAll(&TestContext{})
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the hook to be abandoned after its timeout")
+	}
+	if len(hookErrors) != 1 {
+		t.Errorf("Expected 1 error, got %v", hookErrors)
+	}
+}
+
+func TestRunAllContextCancelled(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	registry.Register(func(tc *TestContext) error {
+		tc.AddToOrder("first")
+		cancel()
+		return nil
+	}, WithPriority(-1))
+
+	registry.Register(func(tc *TestContext) error {
+		tc.AddToOrder("skipped")
+		return nil
+	})
+
+	tc := &TestContext{}
+	hookErrors := registry.RunAllContext(ctx, tc)
+
+	if len(tc.Order) != 1 || tc.Order[0] != "first" {
+		t.Errorf("Expected only the first hook to run, got %v", tc.Order)
+	}
+	if len(hookErrors) != 1 {
+		t.Fatalf("Expected 1 error for the skipped hook, got %v", hookErrors)
+	}
+	for _, err := range hookErrors {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected context canceled, got %v", err)
+		}
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	// Two hooks in the same band wait for each other, which only completes if
+	// they run concurrently
+	var band sync.WaitGroup
+	band.Add(2)
+	for i := 0; i < 2; i++ {
+		registry.Register(func(tc *TestContext) error {
+			band.Done()
+			band.Wait()
+			tc.AddToOrder("middle")
+			return nil
+		}, WithTimeout(time.Second))
+	}
+
+	registry.RegisterWithPriority(func(tc *TestContext) error {
+		tc.AddToOrder("first")
+		return nil
+	}, -1)
+
+	registry.RegisterWithPriority(func(tc *TestContext) error {
+		tc.AddToOrder("last")
+		return errors.New("late failure")
+	}, 1)
+
+	tc := &TestContext{}
+	hookErrors := registry.RunParallel(tc)
+
+	if len(hookErrors) != 1 {
+		t.Errorf("Expected only the late hook to fail, got %v", hookErrors)
+	}
+
+	expected := []string{"first", "middle", "middle", "last"}
+	if len(tc.Order) != len(expected) {
+		t.Fatalf("Expected execution order %v, got %v", expected, tc.Order)
+	}
+	for i, v := range expected {
+		if tc.Order[i] != v {
+			t.Errorf("Expected execution order %v, got %v", expected, tc.Order)
+			break
+		}
+	}
+}
+
+func TestRegisterNamed(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	record := func(name string) Hook[*TestContext] {
+		return func(tc *TestContext) error {
+			tc.AddToOrder(name)
+			return nil
+		}
+	}
+
+	// Dependencies override priorities, and may be registered later
+	if _, err := registry.RegisterNamed("routes", record("routes"), "db", "cache"); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+	if _, err := registry.RegisterNamed("cache", record("cache"), "config"); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+	if _, err := registry.RegisterNamed("db", record("db"), "config"); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+	registry.RegisterWithPriority(record("late"), 10)
+	if _, err := registry.RegisterNamed("config", record("config")); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+
+	if _, err := registry.RegisterNamed("db", record("db")); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Expected ErrDuplicateName, got %v", err)
+	}
+	if _, err := registry.RegisterNamed("migrate", record("migrate"), "migrate"); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected ErrDependencyCycle for a self dependency, got %v", err)
+	}
+	if _, err := registry.RegisterNamed("seed", record("seed"), "routes", "migrations"); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+	if _, err := registry.RegisterNamed("migrations", record("migrations"), "seed"); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected ErrDependencyCycle, got %v", err)
+	}
+	if registry.Count() != 6 {
+		t.Errorf("Expected hooks that failed to register to be discarded, got %d hooks", registry.Count())
+	}
+
+	tc := &TestContext{}
+	hookErrors := registry.RunAll(tc)
+
+	// seed depends on the unregistered migrations hook, so it is skipped
+	if len(hookErrors) != 1 || !errors.Is(hookErrors["seed"], ErrMissingDependency) {
+		t.Errorf("Expected a missing dependency error for seed, got %v", hookErrors)
+	}
+
+	expected := []string{"config", "cache", "db", "routes", "late"}
+	if len(tc.Order) != len(expected) {
+		t.Fatalf("Expected execution order %v, got %v", expected, tc.Order)
+	}
+	for i, v := range expected {
+		if tc.Order[i] != v {
+			t.Errorf("Expected execution order %v, got %v", expected, tc.Order)
+			break
+		}
+	}
+
+	// In parallel mode, dependent hooks don't share a band
+	tc = &TestContext{}
+	registry.RunParallel(tc)
+	if len(tc.Order) != len(expected) || tc.Order[0] != "config" || tc.Order[3] != "routes" {
+		t.Errorf("Expected dependencies to be respected in parallel, got %v", tc.Order)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	handle := registry.Register(func(tc *TestContext) error {
+		tc.AddToOrder("removed")
+		return nil
+	})
+	registry.Register(func(tc *TestContext) error {
+		tc.AddToOrder("kept")
+		return nil
+	})
+
+	if !handle.Unregister() {
+		t.Errorf("Expected Unregister to remove the hook")
+	}
+	if handle.Unregister() {
+		t.Errorf("Expected a second Unregister to report false")
+	}
+	if registry.Count() != 1 {
+		t.Errorf("Expected 1 hook, got %d hooks", registry.Count())
+	}
+
+	tc := &TestContext{}
+	registry.RunAll(tc)
+	if len(tc.Order) != 1 || tc.Order[0] != "kept" {
+		t.Errorf("Expected only the kept hook to run, got %v", tc.Order)
+	}
+
+	var nilHandle *Handle
+	if nilHandle.Unregister() {
+		t.Errorf("Expected Unregister on a nil handle to report false")
+	}
+}
+
+func TestRegisterOnce(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	var runs int
+	var mu sync.Mutex
+	registry.RegisterOnce(func(tc *TestContext) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs++
+		return nil
+	})
+	registry.Register(func(tc *TestContext) error { return nil })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			registry.RunAll(&TestContext{})
+		}()
+	}
+	wg.Wait()
+
+	if runs != 1 {
+		t.Errorf("Expected the one-shot hook to run once, ran %d times", runs)
+	}
+	if registry.Count() != 1 {
+		t.Errorf("Expected the one-shot hook to be removed, got %d hooks", registry.Count())
+	}
+
+	// A one-shot hook that isn't selected by a run stays registered
+	handle := registry.RegisterOnce(func(tc *TestContext) error { return nil }, WithPriority(5))
+	registry.RunEarly(&TestContext{})
+	if !handle.Unregister() {
+		t.Errorf("Expected the unselected one-shot hook to still be registered")
+	}
+}
+
+func TestPhases(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	registry.After(func(tc *TestContext) error {
+		tc.AddToOrder("after")
+		return nil
+	}, WithPriority(-100))
+	registry.RegisterWithPriority(func(tc *TestContext) error {
+		tc.AddToOrder("main")
+		return nil
+	}, -10)
+	registry.Before(func(tc *TestContext) error {
+		tc.AddToOrder("before")
+		return nil
+	}, WithPriority(100))
+
+	for _, run := range []func(*TestContext) map[string]error{registry.RunAll, registry.RunParallel} {
+		tc := &TestContext{}
+		run(tc)
+
+		expected := []string{"before", "main", "after"}
+		if len(tc.Order) != len(expected) {
+			t.Fatalf("Expected execution order %v, got %v", expected, tc.Order)
+		}
+		for i, v := range expected {
+			if tc.Order[i] != v {
+				t.Errorf("Expected execution order %v, got %v", expected, tc.Order)
+				break
+			}
+		}
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	registry := NewRegistry[*TestContext]()
+
+	trace := func(label string) Middleware[*TestContext] {
+		return func(info HookInfo[*TestContext], next ContextHook[*TestContext]) ContextHook[*TestContext] {
+			return func(ctx context.Context, tc *TestContext) error {
+				tc.AddToOrder(label + ":" + info.Name)
+				err := next(ctx, tc)
+				tc.AddToOrder(label + ":done")
+				return err
+			}
+		}
+	}
+	registry.Use(trace("outer"), trace("inner"))
+
+	if _, err := registry.RegisterNamed("hook", func(tc *TestContext) error {
+		tc.AddToOrder("hook")
+		return nil
+	}); err != nil {
+		t.Fatalf("RegisterNamed failed: %v", err)
+	}
+
+	// Middleware can recover from panics, turning them into errors
+	failure := errors.New("recovered")
+	registry.Use(func(info HookInfo[*TestContext], next ContextHook[*TestContext]) ContextHook[*TestContext] {
+		return func(ctx context.Context, tc *TestContext) (err error) {
+			defer func() {
+				if recover() != nil {
+					err = failure
+				}
+			}()
+			return next(ctx, tc)
+		}
+	})
+	registry.RegisterWithPriority(func(tc *TestContext) error {
+		panic("hook panic")
+	}, 1)
+
+	tc := &TestContext{}
+	hookErrors := registry.RunAll(tc)
+
+	expected := []string{"outer:hook", "inner:hook", "hook", "inner:done", "outer:done"}
+	if len(tc.Order) < len(expected) {
+		t.Fatalf("Expected execution order %v, got %v", expected, tc.Order)
+	}
+	for i, v := range expected {
+		if tc.Order[i] != v {
+			t.Errorf("Expected execution order %v, got %v", expected, tc.Order)
+			break
+		}
+	}
+
+	if len(hookErrors) != 1 {
+		t.Fatalf("Expected 1 error, got %v", hookErrors)
+	}
+	for _, err := range hookErrors {
+		if err != failure {
+			t.Errorf("Expected the middleware's error, got %v", err)
+		}
+	}
+}
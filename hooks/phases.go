@@ -0,0 +1,46 @@
+package hooks
+
+// Phase divides a run into stages. Every hook of a phase runs before any hook
+// of a later phase, unless a dependency requires otherwise.
+type Phase int
+
+// Phases of a run
+const (
+	PhaseBefore Phase = -1 // Runs first, such as to set up instrumentation
+	PhaseMain   Phase = 0  // The default phase
+	PhaseAfter  Phase = 1  // Runs last, such as to report on the run
+)
+
+// WithPhase sets the phase a hook runs in (default: PhaseMain)
+func WithPhase(phase Phase) Option {
+	return func(o *hookOptions) {
+		o.phase = phase
+	}
+}
+
+// Before adds a new hook to the registry that runs in PhaseBefore, ahead of
+// the hooks of the other phases
+func (r *Registry[T]) Before(hook Hook[T], opts ...Option) *Handle {
+	return r.Register(hook, append(opts[:len(opts):len(opts)], WithPhase(PhaseBefore))...)
+}
+
+// After adds a new hook to the registry that runs in PhaseAfter, once the
+// hooks of the other phases have run
+func (r *Registry[T]) After(hook Hook[T], opts ...Option) *Handle {
+	return r.Register(hook, append(opts[:len(opts):len(opts)], WithPhase(PhaseAfter))...)
+}
+
+// Middleware wraps the execution of every hook in a registry, such as to log,
+// time or recover from each hook. It is given the hook's information and the
+// next function to call, and returns the function to call in its place.
+type Middleware[T any] func(info HookInfo[T], next ContextHook[T]) ContextHook[T]
+
+// Use adds middleware that wraps every hook the registry runs. Middleware runs
+// in the order it was added, so the first added is the outermost. Hooks are
+// still recovered from panics outside all middleware.
+func (r *Registry[T]) Use(middleware ...Middleware[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middleware = append(r.middleware[:len(r.middleware):len(r.middleware)], middleware...)
+}
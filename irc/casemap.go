@@ -0,0 +1,65 @@
+package irc
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/secure/precis"
+)
+
+// Casemappings understood by FoldCase, named as advertised in the CASEMAPPING
+// ISUPPORT token
+const (
+	CaseMappingASCII         = "ascii"
+	CaseMappingRFC1459       = "rfc1459"
+	CaseMappingStrictRFC1459 = "strict-rfc1459"
+	CaseMappingRFC8265       = "rfc8265" // Unicode-aware, PRECIS UsernameCaseMapped
+)
+
+// rfc1459Folder lowercases the characters RFC 1459 treats as upper case
+// versions of {}|^
+var (
+	rfc1459Folder       = strings.NewReplacer("[", "{", "]", "}", "\\", "|", "~", "^")
+	strictRFC1459Folder = strings.NewReplacer("[", "{", "]", "}", "\\", "|")
+	unicodeFolder       = cases.Fold()
+)
+
+// ValidCaseMapping checks if the casemapping is supported by FoldCase
+func ValidCaseMapping(mapping string) bool {
+	switch mapping {
+	case CaseMappingASCII, CaseMappingRFC1459, CaseMappingStrictRFC1459, CaseMappingRFC8265:
+		return true
+	}
+	return false
+}
+
+// FoldCase returns the canonical form of a nickname or channel name under the
+// casemapping, so that names differing only in case compare equal. Unknown
+// mappings fold as rfc1459.
+func FoldCase(mapping, name string) string {
+	switch mapping {
+	case CaseMappingASCII:
+		return asciiLower(name)
+	case CaseMappingStrictRFC1459:
+		return strictRFC1459Folder.Replace(asciiLower(name))
+	case CaseMappingRFC8265:
+		if folded, err := precis.UsernameCaseMapped.CompareKey(name); err == nil {
+			return folded
+		}
+		// Names PRECIS rejects, such as those containing '#' or spaces, are
+		// still folded so lookups stay case-insensitive
+		return unicodeFolder.String(name)
+	default:
+		return rfc1459Folder.Replace(asciiLower(name))
+	}
+}
+
+// asciiLower lowercases only the ASCII letters A-Z
+func asciiLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s)
+}
@@ -19,6 +19,13 @@ type Config struct {
 	Server struct {
 		Name    string `yaml:"name" toml:"name" json:"name" env:"IRCD_SERVER_NAME"`
 		Network string `yaml:"network" toml:"network" json:"network" env:"IRCD_NETWORK"`
+		// Casemapping for nickname and channel comparisons: rfc1459 (default),
+		// strict-rfc1459, ascii or rfc8265 (Unicode). Changes need a restart.
+		CaseMapping string `yaml:"casemapping" toml:"casemapping" json:"casemapping" env:"IRCD_SERVER_CASEMAPPING"`
+		// File or URL the MOTD template is read from, re-read on rehash. The
+		// variables {{.Server}}, {{.Network}}, {{.Nick}}, {{.Uptime}},
+		// {{.Users}} and {{.Channels}} are replaced when it is shown.
+		MOTD string `yaml:"motd" toml:"motd" json:"motd" env:"IRCD_SERVER_MOTD"`
 	} `yaml:"server" toml:"server" json:"server"`
 
 	// ListenIRC settings - non-TLS connection settings
@@ -47,6 +54,14 @@ type Config struct {
 		TLS     bool   `yaml:"tls" toml:"tls" json:"tls" env:"IRCD_WEB_TLS"`
 	} `yaml:"web_portal" toml:"web_portal" json:"web_portal"`
 
+	// Metrics settings - Prometheus exporter
+	Metrics struct {
+		Enabled bool   `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_METRICS_ENABLED"`
+		Host    string `yaml:"host" toml:"host" json:"host" env:"IRCD_METRICS_HOST"`
+		Port    int    `yaml:"port" toml:"port" json:"port" env:"IRCD_METRICS_PORT"`
+		Path    string `yaml:"path" toml:"path" json:"path" env:"IRCD_METRICS_PATH"` // Endpoint path, /metrics when unset
+	} `yaml:"metrics" toml:"metrics" json:"metrics"`
+
 	// Bot API settings
 	Bots struct {
 		Enabled      bool     `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_BOTS_ENABLED"`
@@ -55,14 +70,123 @@ type Config struct {
 		BearerTokens []string `yaml:"bearer_tokens" toml:"bearer_tokens" json:"bearer_tokens" env:"IRCD_BOTS_TOKENS"`
 	} `yaml:"bots" toml:"bots" json:"bots"`
 
+	// Console settings - local control interface for ircctl
+	Console struct {
+		Enabled  bool   `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_CONSOLE_ENABLED"`
+		Socket   string `yaml:"socket" toml:"socket" json:"socket" env:"IRCD_CONSOLE_SOCKET"`         // Unix socket path, used instead of host and port when set
+		Host     string `yaml:"host" toml:"host" json:"host" env:"IRCD_CONSOLE_HOST"`                 // TCP listen host
+		Port     int    `yaml:"port" toml:"port" json:"port" env:"IRCD_CONSOLE_PORT"`                 // TCP listen port
+		Password string `yaml:"password" toml:"password" json:"password" env:"IRCD_CONSOLE_PASSWORD"` // Required to AUTH over TCP
+	} `yaml:"console" toml:"console" json:"console"`
+
+	// Protocol limits advertised in RPL_ISUPPORT and enforced by the handlers
+	Limits struct {
+		NickLen     int  `yaml:"nicklen" toml:"nicklen" json:"nicklen" env:"IRCD_LIMITS_NICKLEN"`                     // 30 when unset
+		ChannelLen  int  `yaml:"channellen" toml:"channellen" json:"channellen" env:"IRCD_LIMITS_CHANNELLEN"`         // 50 when unset
+		TopicLen    int  `yaml:"topiclen" toml:"topiclen" json:"topiclen" env:"IRCD_LIMITS_TOPICLEN"`                 // 390 when unset
+		KickLen     int  `yaml:"kicklen" toml:"kicklen" json:"kicklen" env:"IRCD_LIMITS_KICKLEN"`                     // 255 when unset
+		MaxChannels int  `yaml:"max_channels" toml:"max_channels" json:"max_channels" env:"IRCD_LIMITS_MAX_CHANNELS"` // Channels per client, 20 when unset
+		Modes       int  `yaml:"modes" toml:"modes" json:"modes" env:"IRCD_LIMITS_MODES"`                             // Parameterized mode changes per MODE, 4 when unset
+		MaxList     int  `yaml:"max_list" toml:"max_list" json:"max_list" env:"IRCD_LIMITS_MAX_LIST"`                 // Entries per ban, exception and invite exception list, 100 when unset
+		LineLen     int  `yaml:"linelen" toml:"linelen" json:"linelen" env:"IRCD_LIMITS_LINELEN"`                     // Bytes per line including CRLF, excluding tags, 512 when unset
+		UTF8Only    bool `yaml:"utf8only" toml:"utf8only" json:"utf8only" env:"IRCD_LIMITS_UTF8ONLY"`                 // Reject lines that are not valid UTF-8
+	} `yaml:"limits" toml:"limits" json:"limits"`
+
 	// Operator definitions
 	Operators []struct {
 		Username string `yaml:"username" toml:"username" json:"username"`
 		Password string `yaml:"password" toml:"password" json:"password"`
 		Email    string `yaml:"email" toml:"email" json:"email"`
 		Mask     string `yaml:"mask" toml:"mask" json:"mask"`
+		CertFP   string `yaml:"certfp" toml:"certfp" json:"certfp"` // SHA-256 client certificate fingerprint for SASL EXTERNAL
 	} `yaml:"operators" toml:"operators" json:"operators"`
 
+	// Flood protection settings - per-client token buckets with fakelag
+	Flood struct {
+		Enabled       bool `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_FLOOD_ENABLED"`
+		CommandRate   int  `yaml:"command_rate" toml:"command_rate" json:"command_rate" env:"IRCD_FLOOD_COMMAND_RATE"`         // Commands per second, 5 when unset
+		CommandBurst  int  `yaml:"command_burst" toml:"command_burst" json:"command_burst" env:"IRCD_FLOOD_COMMAND_BURST"`     // Commands allowed in a burst, 20 when unset
+		MessageRate   int  `yaml:"message_rate" toml:"message_rate" json:"message_rate" env:"IRCD_FLOOD_MESSAGE_RATE"`         // PRIVMSG/NOTICE/TAGMSG per second, 2 when unset
+		MessageBurst  int  `yaml:"message_burst" toml:"message_burst" json:"message_burst" env:"IRCD_FLOOD_MESSAGE_BURST"`     // Messages allowed in a burst, 10 when unset
+		MaxLag        int  `yaml:"max_lag" toml:"max_lag" json:"max_lag" env:"IRCD_FLOOD_MAX_LAG"`                             // Seconds a client may stay fakelagged before being disconnected, 10 when unset
+		KLineDuration int  `yaml:"kline_duration" toml:"kline_duration" json:"kline_duration" env:"IRCD_FLOOD_KLINE_DURATION"` // Seconds to K-line the IP of a disconnected flooder, 0 disables
+	} `yaml:"flood" toml:"flood" json:"flood"`
+
+	// KNOCK rate limits
+	Knock struct {
+		Delay        int `yaml:"delay" toml:"delay" json:"delay" env:"IRCD_KNOCK_DELAY"`                                 // Seconds between KNOCKs from one client, 300 when unset
+		ChannelDelay int `yaml:"channel_delay" toml:"channel_delay" json:"channel_delay" env:"IRCD_KNOCK_CHANNEL_DELAY"` // Seconds between KNOCKs on one channel, 60 when unset
+	} `yaml:"knock" toml:"knock" json:"knock"`
+
+	// Connection limits and clone detection
+	Connections struct {
+		MaxClients int      `yaml:"max_clients" toml:"max_clients" json:"max_clients" env:"IRCD_CONNECTIONS_MAX_CLIENTS"` // Connections to the whole server, 0 for no limit
+		MaxPerIP   int      `yaml:"max_per_ip" toml:"max_per_ip" json:"max_per_ip" env:"IRCD_CONNECTIONS_MAX_PER_IP"`     // Connections from one IP, 0 for no limit
+		CloneWarn  int      `yaml:"clone_warn" toml:"clone_warn" json:"clone_warn" env:"IRCD_CONNECTIONS_CLONE_WARN"`     // Registered clients on one host that trigger an operator notice, 0 disables
+		Exempt     []string `yaml:"exempt" toml:"exempt" json:"exempt" env:"IRCD_CONNECTIONS_EXEMPT"`                     // IPs or CIDR ranges exempt from the limits and clone notices
+	} `yaml:"connections" toml:"connections" json:"connections"`
+
+	// Connection screening settings - DNS blocklists and Tor exit nodes
+	DNSBL struct {
+		Enabled   bool `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_DNSBL_ENABLED"`
+		Threshold int  `yaml:"threshold" toml:"threshold" json:"threshold" env:"IRCD_DNSBL_THRESHOLD"` // Total score that rejects a connection, 1 when unset
+		Lists     []struct {
+			Zone   string `yaml:"zone" toml:"zone" json:"zone"`
+			Score  int    `yaml:"score" toml:"score" json:"score"` // Added to the host score when listed, 1 when unset
+			Reason string `yaml:"reason" toml:"reason" json:"reason"`
+		} `yaml:"lists" toml:"lists" json:"lists"`
+		TorExitList     string   `yaml:"tor_exit_list" toml:"tor_exit_list" json:"tor_exit_list" env:"IRCD_DNSBL_TOR_EXIT_LIST"`             // URL or file of Tor exit node IPs
+		TorScore        int      `yaml:"tor_score" toml:"tor_score" json:"tor_score" env:"IRCD_DNSBL_TOR_SCORE"`                             // Added to the host score for Tor exits, 1 when unset
+		TorRefresh      int      `yaml:"tor_refresh" toml:"tor_refresh" json:"tor_refresh" env:"IRCD_DNSBL_TOR_REFRESH"`                     // Seconds between Tor exit list reloads, 3600 when unset
+		Exempt          []string `yaml:"exempt" toml:"exempt" json:"exempt" env:"IRCD_DNSBL_EXEMPT"`                                         // IPs or CIDR ranges that are never screened
+		ExemptListeners []string `yaml:"exempt_listeners" toml:"exempt_listeners" json:"exempt_listeners" env:"IRCD_DNSBL_EXEMPT_LISTENERS"` // Listeners that are not screened, "irc" or "tls"
+	} `yaml:"dnsbl" toml:"dnsbl" json:"dnsbl"`
+
+	// Hostname settings - connect-time lookups and cloaking
+	Hostnames struct {
+		DisableDNS  bool   `yaml:"disable_dns" toml:"disable_dns" json:"disable_dns" env:"IRCD_HOSTNAMES_DISABLE_DNS"`     // Skip reverse DNS lookups and show IPs
+		Ident       bool   `yaml:"ident" toml:"ident" json:"ident" env:"IRCD_HOSTNAMES_IDENT"`                             // Query RFC1413 ident, prefixing unverified usernames with "~"
+		Timeout     int    `yaml:"timeout" toml:"timeout" json:"timeout" env:"IRCD_HOSTNAMES_TIMEOUT"`                     // Seconds to wait for lookups, 5 when unset
+		Cloak       bool   `yaml:"cloak" toml:"cloak" json:"cloak" env:"IRCD_HOSTNAMES_CLOAK"`                             // Show hashed cloaks in place of hostnames and IPs
+		CloakSecret string `yaml:"cloak_secret" toml:"cloak_secret" json:"cloak_secret" env:"IRCD_HOSTNAMES_CLOAK_SECRET"` // Key for the cloak hashes, required with cloak
+		CloakPrefix string `yaml:"cloak_prefix" toml:"cloak_prefix" json:"cloak_prefix" env:"IRCD_HOSTNAMES_CLOAK_PREFIX"` // Prefix of hostname cloaks, the network name when unset
+	} `yaml:"hostnames" toml:"hostnames" json:"hostnames"`
+
+	// Services settings - built-in NickServ and ChanServ
+	Services struct {
+		Enabled      bool   `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_SERVICES_ENABLED"`
+		Store        string `yaml:"store" toml:"store" json:"store" env:"IRCD_SERVICES_STORE"`                                 // JSON file registrations are persisted to, kept in memory when empty
+		EnforceDelay int    `yaml:"enforce_delay" toml:"enforce_delay" json:"enforce_delay" env:"IRCD_SERVICES_ENFORCE_DELAY"` // Seconds to identify to a registered nick before being renamed, 0 disables enforcement
+	} `yaml:"services" toml:"services" json:"services"`
+
+	// Message history settings - channel history served by CHATHISTORY and on join
+	History struct {
+		Enabled  bool   `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_HISTORY_ENABLED"`
+		Backend  string `yaml:"backend" toml:"backend" json:"backend" env:"IRCD_HISTORY_BACKEND"`     // memory (default) or sqlite
+		Path     string `yaml:"path" toml:"path" json:"path" env:"IRCD_HISTORY_PATH"`                 // Database file for the sqlite backend
+		Limit    int    `yaml:"limit" toml:"limit" json:"limit" env:"IRCD_HISTORY_LIMIT"`             // Messages kept per channel, 1000 when unset
+		Playback int    `yaml:"playback" toml:"playback" json:"playback" env:"IRCD_HISTORY_PLAYBACK"` // Messages replayed on join to clients without chathistory, 0 disables
+	} `yaml:"history" toml:"history" json:"history"`
+
+	// Persistence settings - bans, operators added at runtime and channel state kept across restarts
+	Persistence struct {
+		Enabled bool   `yaml:"enabled" toml:"enabled" json:"enabled" env:"IRCD_PERSISTENCE_ENABLED"`
+		Backend string `yaml:"backend" toml:"backend" json:"backend" env:"IRCD_PERSISTENCE_BACKEND"` // sqlite (default) or postgres
+		Path    string `yaml:"path" toml:"path" json:"path" env:"IRCD_PERSISTENCE_PATH"`             // Database file for the sqlite backend
+		DSN     string `yaml:"dsn" toml:"dsn" json:"dsn" env:"IRCD_PERSISTENCE_DSN"`                 // Connection string for the postgres backend
+	} `yaml:"persistence" toml:"persistence" json:"persistence"`
+
+	// Logging settings - leveled structured logs per subsystem and destination
+	Logging struct {
+		Level      string            `yaml:"level" toml:"level" json:"level" env:"IRCD_LOG_LEVEL"` // debug, info (default), warn or error
+		Subsystems map[string]string `yaml:"subsystems" toml:"subsystems" json:"subsystems"`       // Level by subsystem: server, connections, commands, raw, http, storage
+		Outputs    []LogOutput       `yaml:"outputs" toml:"outputs" json:"outputs"`                // Destinations, stderr when empty
+		Trace      []string          `yaml:"trace" toml:"trace" json:"trace" env:"IRCD_LOG_TRACE"` // Nicknames whose raw lines are logged under the raw subsystem
+	} `yaml:"logging" toml:"logging" json:"logging"`
+
+	// Virtual hosts shown in place of the real host, keyed by account name
+	VHosts map[string]string `yaml:"vhosts" toml:"vhosts" json:"vhosts"`
+
 	// Plugins/Extensions
 	Plugins []struct {
 		Name    string                 `yaml:"name" toml:"name" json:"name"`
@@ -74,6 +198,14 @@ type Config struct {
 	Source string
 }
 
+// LogOutput is a destination for log records
+type LogOutput struct {
+	Type   string `yaml:"type" toml:"type" json:"type"`       // stderr (default), stdout, file or syslog
+	Path   string `yaml:"path" toml:"path" json:"path"`       // File path, or syslog address such as udp://host:514 (local syslog when empty)
+	Level  string `yaml:"level" toml:"level" json:"level"`    // Minimum level written to this output, unfiltered when unset
+	Format string `yaml:"format" toml:"format" json:"format"` // text (default) or json
+}
+
 // Load loads configuration from a file or URL
 func Load(source string) (*Config, error) {
 	cfg := &Config{
@@ -87,6 +219,7 @@ func Load(source string) (*Config, error) {
 	cfg.ListenIRC.Host = "0.0.0.0"
 	cfg.ListenIRC.Port = 6667
 	cfg.ListenTLS.Port = 6697
+	cfg.Services.EnforceDelay = 60
 
 	// Load configuration from file or URL
 	err := cfg.loadFromSource(source)
@@ -100,7 +233,9 @@ func Load(source string) (*Config, error) {
 	return cfg, nil
 }
 
-// Reload reloads the configuration from the original source or a new source
+// Reload reloads the configuration from the original source or a new source,
+// overwriting c in place. Code sharing c with other goroutines should Load a
+// new configuration instead.
 func (c *Config) Reload(newSource string) error {
 	if newSource != "" {
 		c.Source = newSource
@@ -114,6 +249,7 @@ func (c *Config) Reload(newSource string) error {
 	newCfg.ListenIRC.Host = "0.0.0.0"
 	newCfg.ListenIRC.Port = 6667
 	newCfg.ListenTLS.Port = 6697
+	newCfg.Services.EnforceDelay = 60
 
 	// Load configuration
 	err := newCfg.loadFromSource(c.Source)
@@ -135,7 +271,7 @@ func (c *Config) loadFromSource(source string) error {
 	var err error
 
 	// Check if the source is a URL
-	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+	if isURL(source) {
 		// Load from URL
 		resp, err := http.Get(source)
 		if err != nil {
@@ -281,6 +417,16 @@ func (c *Config) GetWebListenAddress() string {
 	return fmt.Sprintf("%s:%d", c.WebPortal.Host, c.WebPortal.Port)
 }
 
+// GetMetricsListenAddress returns the formatted listen address for the metrics exporter
+func (c *Config) GetMetricsListenAddress() string {
+	return fmt.Sprintf("%s:%d", c.Metrics.Host, c.Metrics.Port)
+}
+
+// GetConsoleListenAddress returns the formatted TCP listen address for the console
+func (c *Config) GetConsoleListenAddress() string {
+	return fmt.Sprintf("%s:%d", c.Console.Host, c.Console.Port)
+}
+
 // GetBotAPIListenAddress returns the formatted listen address for the bot API
 func (c *Config) GetBotAPIListenAddress() string {
 	return fmt.Sprintf("%s:%d", c.Bots.Host, c.Bots.Port)
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long Watch waits for file events to settle before reloading
+var watchDebounce = 100 * time.Millisecond
+
+// isURL reports whether source is fetched over HTTP
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Watch calls reload whenever the configuration should be reloaded: when the
+// process receives SIGHUP, and when the configuration file is modified. URL
+// sources are only re-fetched on SIGHUP. Watch blocks until ctx is done and
+// then returns ctx.Err().
+func (c *Config) Watch(ctx context.Context, reload func()) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// The directory is watched so that atomic replacements of the file are seen
+	source := c.Source
+	if !isURL(source) {
+		if err := watcher.Add(filepath.Dir(source)); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", source, err)
+		}
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-hup:
+			reload()
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == filepath.Clean(source) && !event.Has(fsnotify.Chmod) {
+				debounce = time.After(watchDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("Error watching configuration", "source", source, "error", err)
+
+		case <-debounce:
+			debounce = nil
+			reload()
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ircd.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  name: one.irc.local\n"), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "one.irc.local", cfg.Server.Name)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- cfg.Watch(ctx, func() { reloaded <- struct{}{} })
+	}()
+
+	// Give the watcher time to start before changing the file
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  name: two.irc.local\n"), 0o600))
+
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("reload was not called after the file changed")
+	}
+	require.NoError(t, cfg.Reload(""))
+	assert.Equal(t, "two.irc.local", cfg.Server.Name)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
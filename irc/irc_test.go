@@ -407,3 +407,40 @@ func TestMessageParsing(t *testing.T) {
 	assert.Equal(t, "user1", msg.Params[2], "Should parse the third parameter")
 	assert.Equal(t, "user2", msg.Params[3], "Should parse the fourth parameter")
 }
+
+// TestMessageTags tests parsing and formatting message tags
+func TestMessageTags(t *testing.T) {
+	msg := irc.ParseMessage(`@+draft/reply=abc;+example/note=semi\:colon\sspace;time=2024 :nick!user@host TAGMSG #channel`)
+	assert.NotNil(t, msg, "Should parse the message")
+	assert.Equal(t, "TAGMSG", msg.Command, "Should parse the command")
+	assert.Equal(t, "nick!user@host", msg.Prefix, "Should parse the prefix")
+	assert.Equal(t, "abc", msg.Tags["+draft/reply"], "Should parse a client tag")
+	assert.Equal(t, "semi;colon space", msg.Tags["+example/note"], "Should unescape tag values")
+	assert.Equal(t, "2024", msg.Tags["time"], "Should parse a server tag")
+
+	// Only client tags are relayed
+	clientTags := msg.ClientTags()
+	assert.Len(t, clientTags, 2, "Should only include + prefixed tags")
+	assert.NotContains(t, clientTags, "time", "Should drop server tags")
+
+	// Tags round-trip through String
+	out := &irc.Message{Tags: clientTags, Prefix: "nick!user@host", Command: "TAGMSG", Params: []string{"#channel"}}
+	assert.Equal(t, `@+draft/reply=abc;+example/note=semi\:colon\sspace :nick!user@host TAGMSG #channel`, out.String())
+
+	// Messages without tags are unchanged
+	msg = irc.ParseMessage("PING :server1")
+	assert.Nil(t, msg.Tags, "Should not allocate tags")
+	assert.Nil(t, msg.ClientTags(), "Should have no client tags")
+}
+
+// TestFoldCase tests nickname and channel casefolding
+func TestFoldCase(t *testing.T) {
+	assert.Equal(t, "{foo}|^", irc.FoldCase(irc.CaseMappingRFC1459, "[FOO]\\~"), "Should fold RFC 1459 special characters")
+	assert.Equal(t, "{foo}|~", irc.FoldCase(irc.CaseMappingStrictRFC1459, "[FOO]\\~"), "Should not fold ~ under strict-rfc1459")
+	assert.Equal(t, "[foo]\\~", irc.FoldCase(irc.CaseMappingASCII, "[FOO]\\~"), "Should only fold letters under ascii")
+	assert.Equal(t, "ÉLAN", strings.ToUpper(irc.FoldCase(irc.CaseMappingASCII, "ÉLAN")), "Should leave non-ASCII letters under ascii")
+	assert.Equal(t, irc.FoldCase(irc.CaseMappingRFC8265, "élan"), irc.FoldCase(irc.CaseMappingRFC8265, "ÉLAN"), "Should fold Unicode letters under rfc8265")
+	assert.Equal(t, irc.FoldCase(irc.CaseMappingRFC8265, "#straße"), irc.FoldCase(irc.CaseMappingRFC8265, "#STRASSE"), "Should fold channel names under rfc8265")
+	assert.True(t, irc.ValidCaseMapping(irc.CaseMappingRFC8265))
+	assert.False(t, irc.ValidCaseMapping("utf-16"))
+}
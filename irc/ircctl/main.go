@@ -0,0 +1,86 @@
+// Command ircctl sends commands to the ircd console.
+//
+//	ircctl -socket /run/ircd/console.sock STATUS
+//	ircctl -addr 127.0.0.1:6680 -password secret KLINE *@203.0.113.7 1h Spam
+//
+// Without a command, ircctl reads commands from standard input.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+func main() {
+	socket := flag.String("socket", "", "Path to the console unix socket")
+	addr := flag.String("addr", "", "Address of a TCP console (host:port)")
+	password := flag.String("password", os.Getenv("IRCD_CONSOLE_PASSWORD"), "Password of a TCP console")
+	flag.Parse()
+
+	var conn net.Conn
+	var err error
+	switch {
+	case *socket != "":
+		conn, err = net.Dial("unix", *socket)
+	case *addr != "":
+		conn, err = net.Dial("tcp", *addr)
+	default:
+		fmt.Fprintln(os.Stderr, "ircctl: one of -socket or -addr is required")
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ircctl: %v\n", err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+	replies := bufio.NewScanner(conn)
+
+	if *addr != "" && *socket == "" {
+		if !run(conn, replies, "AUTH "+*password) {
+			os.Exit(1)
+		}
+	}
+
+	// Run the command given as arguments, or each line of standard input
+	if flag.NArg() > 0 {
+		if !run(conn, replies, strings.Join(flag.Args(), " ")) {
+			os.Exit(1)
+		}
+		return
+	}
+	ok := true
+	input := bufio.NewScanner(os.Stdin)
+	for input.Scan() {
+		if strings.TrimSpace(input.Text()) != "" {
+			ok = run(conn, replies, input.Text()) && ok
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run sends one command and prints its reply, reporting whether it succeeded
+func run(conn net.Conn, replies *bufio.Scanner, command string) bool {
+	if _, err := fmt.Fprintf(conn, "%s\n", command); err != nil {
+		fmt.Fprintf(os.Stderr, "ircctl: %v\n", err)
+		return false
+	}
+	for replies.Scan() {
+		line := replies.Text()
+		switch {
+		case line == "OK":
+			return true
+		case strings.HasPrefix(line, "ERROR "):
+			fmt.Fprintf(os.Stderr, "ircctl: %s\n", strings.TrimPrefix(line, "ERROR "))
+			return false
+		}
+		fmt.Println(line)
+	}
+	fmt.Fprintln(os.Stderr, "ircctl: connection closed")
+	return false
+}
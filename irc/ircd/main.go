@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -29,6 +31,9 @@ func main() {
 		log.Fatalf("Failed to initialize server: %v", err)
 	}
 
+	// Route the standard logger through the server's structured logs
+	slog.SetDefault(srv.Logger(server.LogServer))
+
 	// Start the server
 	go func() {
 		if err := srv.Start(); err != nil {
@@ -47,12 +52,26 @@ func main() {
 		fmt.Printf("  - Listening for TLS encrypted connections on %s\n", cfg.GetTLSListenAddress())
 	}
 
-	// Handle graceful shutdown
+	// Reload the configuration on SIGHUP and when the file changes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := srv.WatchConfig(ctx); err != nil && err != context.Canceled {
+			slog.Error("Configuration watcher stopped", "error", err)
+		}
+	}()
+
+	// Handle graceful shutdown, or exit once the console has stopped the server
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	select {
+	case <-sigChan:
+	case <-srv.Done():
+		slog.Info("Server stopped from the console")
+		return
+	}
 
-	fmt.Println("Shutting down server...")
+	slog.Info("Shutting down server")
 	if err := srv.Stop(); err != nil {
 		log.Fatalf("Error shutting down server: %v", err)
 	}
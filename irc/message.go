@@ -7,6 +7,7 @@ import (
 
 // Message represents an IRC message
 type Message struct {
+	Tags    map[string]string // IRCv3 message tags, nil when absent
 	Prefix  string
 	Command string
 	Params  []string
@@ -22,6 +23,19 @@ func ParseMessage(line string) *Message {
 		Params: make([]string, 0),
 	}
 
+	// Check if the message has tags
+	if line[0] == '@' {
+		parts := strings.SplitN(line[1:], " ", 2)
+		if len(parts) < 2 {
+			return nil
+		}
+		msg.Tags = ParseTags(parts[0])
+		line = strings.TrimLeft(parts[1], " ")
+		if line == "" {
+			return nil
+		}
+	}
+
 	// Check if the message has a prefix
 	if line[0] == ':' {
 		parts := strings.SplitN(line[1:], " ", 2)
@@ -68,6 +82,13 @@ func ParseMessage(line string) *Message {
 func (m *Message) String() string {
 	var builder strings.Builder
 
+	// Add tags if present
+	if len(m.Tags) > 0 {
+		builder.WriteString("@")
+		builder.WriteString(FormatTags(m.Tags))
+		builder.WriteString(" ")
+	}
+
 	// Add prefix if present
 	if m.Prefix != "" {
 		builder.WriteString(":")
@@ -82,8 +103,8 @@ func (m *Message) String() string {
 	for i, param := range m.Params {
 		builder.WriteString(" ")
 
-		// If this is the last parameter and it contains spaces or starts with a colon, add a colon
-		if i == len(m.Params)-1 && (strings.Contains(param, " ") || strings.HasPrefix(param, ":")) {
+		// If this is the last parameter and it is empty, contains spaces or starts with a colon, add a colon
+		if i == len(m.Params)-1 && (param == "" || strings.Contains(param, " ") || strings.HasPrefix(param, ":")) {
 			builder.WriteString(":")
 			builder.WriteString(param)
 		} else {
@@ -136,3 +157,34 @@ func ParseHostmask(hostmask string) (nick, user, host string) {
 func FormatHostmask(nick, user, host string) string {
 	return fmt.Sprintf("%s!%s@%s", nick, user, host)
 }
+
+// MatchMask reports whether s matches the glob pattern, where '*' matches any
+// sequence of characters and '?' matches exactly one. Matching is
+// case-insensitive, as is conventional for IRC hostmasks.
+func MatchMask(pattern, s string) bool {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(s))
+
+	pi, ti := 0, 0
+	starP, starT := -1, 0
+	for ti < len(t) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == t[ti]):
+			pi++
+			ti++
+		case pi < len(p) && p[pi] == '*':
+			starP, starT = pi, ti
+			pi++
+		case starP >= 0:
+			pi = starP + 1
+			starT++
+			ti = starT
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
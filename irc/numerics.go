@@ -12,15 +12,19 @@ const (
 	RPL_MYINFO        = 4   // <servername> <version> <available user modes> <available channel modes>
 	RPL_BOUNCE        = 5   // Try server <server name>, port <port number>
 	RPL_ISUPPORT      = 5   // Also used for ISUPPORT (newer IRCDs)
+	RPL_SNOMASKIS     = 8   // <snomask> :Server notice mask
 	RPL_STATSCOMMANDS = 212 // <command> <count> <byte count> <remote count>
+	RPL_STATSKLINE    = 216 // K <mask> <expires in> <set ago> <set by> :<reason>
 	RPL_ENDOFSTATS    = 219 // <stats letter> :End of STATS report
 	RPL_UMODEIS       = 221 // <user mode string>
+	RPL_STATSGLINE    = 223 // G <mask> <expires in> <set ago> <set by> :<reason>
 	RPL_SERVLIST      = 234 // <name> <server> <mask> <type> <hopcount> <info>
 	RPL_SERVLISTEND   = 235 // <mask> <type> :End of service listing
 	RPL_STATSLLINE    = 241 // L <hostmask> * <servername> <maxdepth>
 	RPL_STATSUPTIME   = 242 // :Server Up %d days, %.2f hours
 	RPL_STATSOLINE    = 243 // O <hostmask> * <name>
 	RPL_STATSHLINE    = 244 // H <hostmask> * <servername>
+	RPL_STATSDEBUG    = 249 // <stats letter> <info>
 	RPL_STATSDLINE    = 250 // Highest connection count: %d (%d clients)
 	RPL_LUSERCLIENT   = 251 // :There are <integer> users and <integer> services on <integer> servers
 	RPL_LUSEROP       = 252 // <integer> :operator(s) online
@@ -59,6 +63,7 @@ const (
 	RPL_UNIQOPIS        = 325 // <channel> <nickname>
 	RPL_NOTOPIC         = 331 // <channel> :No topic is set
 	RPL_TOPIC           = 332 // <channel> :<topic>
+	RPL_WHOISACTUALLY   = 338 // <nick> <user@host> <ip> :Actually using host
 	RPL_INVITING        = 341 // <channel> <nick>
 	RPL_SUMMONING       = 342 // <user> :Summoning user to IRC
 	RPL_INVITELIST      = 346 // <channel> <invitemask>
@@ -85,6 +90,7 @@ const (
 	RPL_USERS           = 393 // :<username> <ttyline> <hostname>
 	RPL_ENDOFUSERS      = 394 // :End of users
 	RPL_NOUSERS         = 395 // :Nobody logged in
+	RPL_HOSTHIDDEN      = 396 // <host> :is now your displayed host
 
 	// 400 - 599: Error replies
 	ERR_NOSUCHNICK        = 401 // <nickname> :No such nick/channel
@@ -96,11 +102,13 @@ const (
 	ERR_TOOMANYTARGETS    = 407 // <target> :<error code> recipients. <abort message>
 	ERR_NOSUCHSERVICE     = 408 // <service name> :No such service
 	ERR_NOORIGIN          = 409 // :No origin specified
+	ERR_INVALIDCAPCMD     = 410 // <subcommand> :Invalid CAP command
 	ERR_NORECIPIENT       = 411 // :No recipient given (<command>)
 	ERR_NOTEXTTOSEND      = 412 // :No text to send
 	ERR_NOTOPLEVEL        = 413 // <mask> :No toplevel domain specified
 	ERR_WILDTOPLEVEL      = 414 // <mask> :Wildcard in toplevel domain
 	ERR_BADMASK           = 415 // <mask> :Bad Server/host mask
+	ERR_INPUTTOOLONG      = 417 // :Input line was too long
 	ERR_UNKNOWNCOMMAND    = 421 // <command> :Unknown command
 	ERR_NOMOTD            = 422 // :MOTD File is missing
 	ERR_NOADMININFO       = 423 // <server> :No administrative info available
@@ -132,6 +140,7 @@ const (
 	ERR_BADCHANMASK       = 476 // <channel> :Bad Channel Mask
 	ERR_NOCHANMODES       = 477 // <channel> :Channel doesn't support modes
 	ERR_BANLISTFULL       = 478 // <channel> <char> :Channel list is full
+	ERR_CANNOTKNOCK       = 480 // :Cannot knock on <channel> (<reason>)
 	ERR_NOPRIVILEGES      = 481 // :Permission Denied- You're not an IRC operator
 	ERR_CHANOPRIVSNEEDED  = 482 // <channel> :You're not channel operator
 	ERR_CANTKILLSERVER    = 483 // :You can't kill a server!
@@ -153,4 +162,22 @@ const (
 	ERR_KILLDENY          = 561 // :Cannot kill client
 	ERR_INVALIDACCOUNT    = 577 // :Invalid account
 	ERR_NEEDREGGEDNICK    = 599 // :You must connect with a registered nickname
+
+	// 710 - 714: KNOCK
+	RPL_KNOCK        = 710 // <channel> <nick>!<user>@<host> :has asked for an invite
+	RPL_KNOCKDLVR    = 711 // <channel> :Your KNOCK has been delivered
+	ERR_TOOMANYKNOCK = 712 // <channel> :Too many KNOCKs (<channel|user>)
+	ERR_CHANOPEN     = 713 // <channel> :Channel is open
+	ERR_KNOCKONCHAN  = 714 // <channel> :You're already on that channel
+
+	// 900 - 999: SASL
+	RPL_LOGGEDIN    = 900 // <nick>!<user>@<host> <account> :You are now logged in as <account>
+	RPL_LOGGEDOUT   = 901 // <nick>!<user>@<host> :You are now logged out
+	ERR_NICKLOCKED  = 902 // :You must use a nick assigned to you
+	RPL_SASLSUCCESS = 903 // :SASL authentication successful
+	ERR_SASLFAIL    = 904 // :SASL authentication failed
+	ERR_SASLTOOLONG = 905 // :SASL message too long
+	ERR_SASLABORTED = 906 // :SASL authentication aborted
+	ERR_SASLALREADY = 907 // :You have already authenticated using SASL
+	RPL_SASLMECHS   = 908 // <mechanisms> :are available SASL mechanisms
 )
@@ -0,0 +1,40 @@
+package server
+
+import "time"
+
+const (
+	// authFreeFailures is how many wrong passwords a client may send before
+	// further attempts are delayed
+	authFreeFailures = 3
+
+	// authFailureDelay is how long a client must wait after a failure once
+	// its free failures are used up
+	authFailureDelay = 30 * time.Second
+)
+
+// authThrottled reports whether the client must wait before its next
+// password attempt
+func (c *Client) authThrottled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.authFailures >= authFreeFailures && c.Server.Now().Sub(c.lastAuthFail) < authFailureDelay
+}
+
+// authFailed records a wrong password from the client
+func (c *Client) authFailed() {
+	now := c.Server.Now()
+	c.mu.Lock()
+	c.authFailures++
+	c.lastAuthFail = now
+	failures, nick := c.authFailures, c.Nickname
+	c.mu.Unlock()
+	c.Server.Logger(LogCommands).Info("Authentication failed", "nick", nick, "ip", c.IP, "failures", failures)
+}
+
+// authSucceeded clears the client's failed password attempts
+func (c *Client) authSucceeded() {
+	c.mu.Lock()
+	c.authFailures = 0
+	c.lastAuthFail = time.Time{}
+	c.mu.Unlock()
+}
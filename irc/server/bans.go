@@ -0,0 +1,317 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Server ban types
+const (
+	BanTypeKLine = 'K' // Local server ban
+	BanTypeGLine = 'G' // Network-wide ban
+)
+
+// ServerBan is a K-line or G-line banning a user@host mask from the server
+type ServerBan struct {
+	Type      rune
+	Mask      string
+	SetBy     string
+	Reason    string
+	SetAt     time.Time
+	ExpiresAt time.Time // Zero for permanent bans
+}
+
+// Expired reports whether the ban has expired at the given time
+func (b *ServerBan) Expired(now time.Time) bool {
+	return !b.ExpiresAt.IsZero() && !now.Before(b.ExpiresAt)
+}
+
+// Matches reports whether the ban applies to the client
+func (b *ServerBan) Matches(client *Client) bool {
+	return irc.MatchMask(b.Mask, client.Username+"@"+client.Hostname) ||
+		irc.MatchMask(b.Mask, client.Username+"@"+client.RealHost()) ||
+		irc.MatchMask(b.Mask, client.Username+"@"+client.IP)
+}
+
+// banKey returns the key a ban is stored under
+func banKey(banType rune, mask string) string {
+	return string(banType) + ":" + strings.ToLower(mask)
+}
+
+// AddBan adds or replaces a server ban. A zero duration makes the ban permanent.
+func (s *Server) AddBan(banType rune, mask, setBy, reason string, duration time.Duration) *ServerBan {
+	now := s.Now()
+	ban := &ServerBan{
+		Type:   banType,
+		Mask:   mask,
+		SetBy:  setBy,
+		Reason: reason,
+		SetAt:  now,
+	}
+	if duration > 0 {
+		ban.ExpiresAt = now.Add(duration)
+	}
+	s.bans.Store(banKey(banType, mask), ban)
+	if s.state != nil {
+		s.persist("ban "+mask, s.state.SaveBan(ban))
+	}
+	s.publish(EventBan, map[string]interface{}{"action": "add", "type": string(banType), "mask": mask, "set_by": setBy, "reason": reason})
+	return ban
+}
+
+// RemoveBan removes a server ban, reporting whether it existed
+func (s *Server) RemoveBan(banType rune, mask string) bool {
+	_, existed := s.bans.LoadAndDelete(banKey(banType, mask))
+	if existed && s.state != nil {
+		s.persist("ban "+mask, s.state.DeleteBan(banType, mask))
+	}
+	if existed {
+		s.publish(EventBan, map[string]interface{}{"action": "remove", "type": string(banType), "mask": mask})
+	}
+	return existed
+}
+
+// expireBan drops an expired ban
+func (s *Server) expireBan(key interface{}, ban *ServerBan) {
+	s.bans.Delete(key)
+	if s.state != nil {
+		s.persist("ban "+ban.Mask, s.state.DeleteBan(ban.Type, ban.Mask))
+	}
+}
+
+// GetBans returns the active bans of the given type ordered by creation time.
+// Expired bans are dropped.
+func (s *Server) GetBans(banType rune) []*ServerBan {
+	now := s.Now()
+	bans := make([]*ServerBan, 0)
+	s.bans.Range(func(key, value interface{}) bool {
+		ban := value.(*ServerBan)
+		if ban.Expired(now) {
+			s.expireBan(key, ban)
+			return true
+		}
+		if ban.Type == banType {
+			bans = append(bans, ban)
+		}
+		return true
+	})
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].SetAt.Before(bans[j].SetAt)
+	})
+	return bans
+}
+
+// FindBan returns the first active ban matching the client, if any
+func (s *Server) FindBan(client *Client) *ServerBan {
+	now := s.Now()
+	var result *ServerBan
+	s.bans.Range(func(key, value interface{}) bool {
+		ban := value.(*ServerBan)
+		if ban.Expired(now) {
+			s.expireBan(key, ban)
+			return true
+		}
+		if ban.Matches(client) {
+			result = ban
+			return false
+		}
+		return true
+	})
+	return result
+}
+
+// rejectIfBanned disconnects the client if it matches an active server ban
+func (s *Server) rejectIfBanned(client *Client) bool {
+	ban := s.FindBan(client)
+	if ban == nil {
+		return false
+	}
+
+	s.Logger(LogConnections).Warn("Connection rejected by ban", "ip", client.IP, "mask", ban.Mask, "type", string(ban.Type))
+	client.SendError(irc.ERR_YOUREBANNEDCREEP, fmt.Sprintf("You are banned from this server: %s", ban.Reason))
+	client.SendRaw(fmt.Sprintf("ERROR :Closing Link: %s (%c-Lined: %s)", client.Hostname, ban.Type, ban.Reason))
+	client.Quit(fmt.Sprintf("%c-Lined", ban.Type))
+	return true
+}
+
+// parseBanDuration parses a ban duration given either as seconds or as a Go duration
+func parseBanDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// handleKLine handles the KLINE command
+func handleKLine(params *HookParams) error {
+	return handleServerBan(params, BanTypeKLine)
+}
+
+// handleGLine handles the GLINE command
+func handleGLine(params *HookParams) error {
+	return handleServerBan(params, BanTypeGLine)
+}
+
+// handleServerBan handles KLINE/GLINE <user@host> [<duration>] :<reason>
+func handleServerBan(params *HookParams, banType rune) error {
+	client := params.Client
+	message := params.Message
+	command := message.Command
+
+	// Check if the client is an operator
+	if !client.IsOper {
+		client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+		return nil
+	}
+
+	if len(message.Params) < 2 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, command, "Not enough parameters")
+		return nil
+	}
+
+	mask := message.Params[0]
+	if !strings.Contains(mask, "@") {
+		mask = "*@" + mask
+	}
+
+	var duration time.Duration
+	reason := message.Params[len(message.Params)-1]
+	if len(message.Params) > 2 {
+		var err error
+		duration, err = parseBanDuration(message.Params[1])
+		if err != nil || duration < 0 {
+			client.SendMessage(client.Server.GetConfig().Server.Name, "NOTICE", client.Nickname, fmt.Sprintf("Invalid duration: %s", message.Params[1]))
+			return nil
+		}
+	}
+
+	ban := client.Server.AddBan(banType, mask, client.Nickname, reason, duration)
+
+	expiry := "permanently"
+	if duration > 0 {
+		expiry = fmt.Sprintf("for %s", duration)
+	}
+	client.SendMessage(client.Server.GetConfig().Server.Name, "NOTICE", client.Nickname, fmt.Sprintf("Added %c-Line for %s %s: %s", banType, mask, expiry, reason))
+	client.Server.SendServerNotice(SnomaskKill, fmt.Sprintf("%s added %c-Line for %s %s: %s", client.Nickname, banType, mask, expiry, reason))
+
+	client.Server.enforceBan(ban, client)
+
+	return nil
+}
+
+// enforceBan disconnects registered clients matching a newly added ban,
+// except the client that set it
+func (s *Server) enforceBan(ban *ServerBan, setter *Client) {
+	victims := make([]*Client, 0)
+	s.clients.Range(func(_, value interface{}) bool {
+		c := value.(*Client)
+		if c.Registered && c != setter && ban.Matches(c) {
+			victims = append(victims, c)
+		}
+		return true
+	})
+	for _, victim := range victims {
+		s.rejectIfBanned(victim)
+	}
+}
+
+// handleUnKLine handles the UNKLINE command
+func handleUnKLine(params *HookParams) error {
+	return handleRemoveServerBan(params, BanTypeKLine)
+}
+
+// handleUnGLine handles the UNGLINE command
+func handleUnGLine(params *HookParams) error {
+	return handleRemoveServerBan(params, BanTypeGLine)
+}
+
+// handleRemoveServerBan handles UNKLINE/UNGLINE <user@host>
+func handleRemoveServerBan(params *HookParams, banType rune) error {
+	client := params.Client
+	message := params.Message
+
+	// Check if the client is an operator
+	if !client.IsOper {
+		client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+		return nil
+	}
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, message.Command, "Not enough parameters")
+		return nil
+	}
+
+	mask := message.Params[0]
+	if !strings.Contains(mask, "@") {
+		mask = "*@" + mask
+	}
+
+	notice := fmt.Sprintf("No %c-Line for %s", banType, mask)
+	if client.Server.RemoveBan(banType, mask) {
+		notice = fmt.Sprintf("Removed %c-Line for %s", banType, mask)
+	}
+	client.SendMessage(client.Server.GetConfig().Server.Name, "NOTICE", client.Nickname, notice)
+
+	return nil
+}
+
+// handleStats handles the STATS command
+func handleStats(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "STATS", "Not enough parameters")
+		return nil
+	}
+
+	query := message.Params[0]
+	client.Server.SendStatsLinksNotice(fmt.Sprintf("STATS %s requested by %s (%s@%s)", query, client.Nickname, client.Username, client.Hostname))
+
+	switch query {
+	case "k", "K", "g", "G":
+		// Ban lists are only visible to operators
+		if !client.IsOper {
+			client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+			return nil
+		}
+
+		banType, numeric := BanTypeKLine, irc.RPL_STATSKLINE
+		if strings.EqualFold(query, "g") {
+			banType, numeric = BanTypeGLine, irc.RPL_STATSGLINE
+		}
+
+		now := client.Server.Now()
+		for _, ban := range client.Server.GetBans(banType) {
+			var expiresIn int64
+			if !ban.ExpiresAt.IsZero() {
+				expiresIn = int64(ban.ExpiresAt.Sub(now).Seconds())
+			}
+			client.SendReply(numeric,
+				string(ban.Type),
+				ban.Mask,
+				strconv.FormatInt(expiresIn, 10),
+				strconv.FormatInt(int64(now.Sub(ban.SetAt).Seconds()), 10),
+				ban.SetBy,
+				ban.Reason)
+		}
+	case "b", "B":
+		// Screening statistics are only visible to operators
+		if !client.IsOper {
+			client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+			return nil
+		}
+		client.Server.sendScreenStats(client)
+	case "u", "U":
+		client.SendReply(irc.RPL_STATSUPTIME, "Server Up "+formatUptime(client.Server.GetUptime()))
+	}
+
+	client.SendReply(irc.RPL_ENDOFSTATS, query, "End of /STATS report")
+
+	return nil
+}
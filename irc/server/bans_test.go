@@ -0,0 +1,83 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsLines sends STATS <query> and returns the report lines with the given numeric
+func statsLines(tc *testClient, query, numeric string) []string {
+	tc.send("STATS " + query)
+	var lines []string
+	for _, line := range tc.collect(" 219 ") {
+		if strings.Contains(line, " "+numeric+" ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestStatsListsActiveBans(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+	alice.drain()
+
+	alice.send("KLINE spammer@*.example.com 3600 :Spamming")
+	alice.expect("Added K-Line for spammer@*.example.com for 1h0m0s: Spamming")
+	alice.send("KLINE 10.0.0.1 60 :Flooding")
+	alice.expect("Added K-Line for *@10.0.0.1 for 1m0s: Flooding")
+	alice.send("GLINE troll@* :Trolling everywhere")
+	alice.expect("Added G-Line for troll@* permanently: Trolling everywhere")
+
+	clock.Advance(2 * time.Minute)
+
+	// The short K-line has expired and must not be listed
+	lines := statsLines(alice, "k", "216")
+	require.Len(t, lines, 1)
+	assert.Equal(t, ":test.irc.local 216 alice K spammer@*.example.com 3480 120 alice Spamming", lines[0])
+
+	lines = statsLines(alice, "g", "223")
+	require.Len(t, lines, 1)
+	assert.Equal(t, ":test.irc.local 223 alice G troll@* 0 120 alice :Trolling everywhere", lines[0])
+
+	// Removal drops the ban from the listing
+	alice.send("UNKLINE spammer@*.example.com")
+	alice.expect("Removed K-Line for spammer@*.example.com")
+	assert.Empty(t, statsLines(alice, "k", "216"))
+	assert.Empty(t, srv.GetBans(BanTypeKLine))
+	assert.Len(t, srv.GetBans(BanTypeGLine), 1)
+}
+
+func TestBanCommandsRequireOper(t *testing.T) {
+	srv := newTestServer(t, nil)
+	bob := srv.register(t, "bob")
+
+	bob.send("KLINE *@* :everyone")
+	bob.expect(" 481 ")
+	bob.send("STATS k")
+	bob.expect(" 481 ")
+	assert.Empty(t, srv.GetBans(BanTypeKLine))
+}
+
+func TestBannedClientIsRejected(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+	srv.AddBan(BanTypeKLine, "mallory@*", "alice", "Go away", time.Minute)
+
+	mallory := srv.connect(t)
+	mallory.send("NICK mallory")
+	mallory.send("USER mallory 0 * :Mallory")
+	assert.Equal(t, ":test.irc.local 465 * :You are banned from this server: Go away", mallory.expect(" 465 "))
+	require.Eventually(t, func() bool { return srv.GetClient("mallory") == nil }, time.Second, 5*time.Millisecond)
+
+	// Once the ban expires the client can connect
+	clock.Advance(time.Minute)
+	srv.register(t, "mallory")
+}
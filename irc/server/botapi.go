@@ -3,7 +3,6 @@ package server
 import (
 	"crypto/subtle"
 	"fmt"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -44,12 +43,13 @@ func NewBotAPI(server *Server, cfg *config.Config) (*BotAPI, error) {
 // Start starts the bot API
 func (b *BotAPI) Start() error {
 	// Start the HTTP server
+	b.server.Logger(LogHTTP).Info("Starting bot API", "address", b.config.GetBotAPIListenAddress())
 	return b.echo.Start(b.config.GetBotAPIListenAddress())
 }
 
 // Stop stops the bot API
 func (b *BotAPI) Stop() error {
-	log.Println("Stopping bot API")
+	b.server.Logger(LogHTTP).Info("Stopping bot API")
 	return b.echo.Close()
 }
 
@@ -569,9 +569,9 @@ func (b *BotAPI) handleList(c echo.Context) error {
 
 	// Get the channels
 	channels := make([]map[string]interface{}, 0)
-	b.server.channels.Range(func(key, channelVal interface{}) bool {
+	b.server.channels.Range(func(_, channelVal interface{}) bool {
 		channel := channelVal.(*Channel)
-		name := key.(string)
+		name := channel.Name
 		// If a mask is specified, filter the channels
 		if mask != "" && !strings.Contains(name, mask) {
 			return true
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Client capabilities supported by the server
+const (
+	CapChghost       = "chghost"           // Host changes are announced with CHGHOST
+	CapMessageTags   = "message-tags"      // Client tags are relayed and TAGMSG is delivered
+	CapSASL          = "sasl"              // AUTHENTICATE is available before registration
+	CapServerTime    = "server-time"       // Relayed messages carry a @time tag
+	CapBatch         = "batch"             // History replies are grouped in a BATCH
+	CapChathistory   = "draft/chathistory" // CHATHISTORY replaces playback on join
+	CapAwayNotify    = "away-notify"       // Away changes of channel members are sent with AWAY
+	CapAccountNotify = "account-notify"    // Account changes of channel members are sent with ACCOUNT
+	CapExtendedJoin  = "extended-join"     // JOIN carries the account and real name
+)
+
+// supportedCaps lists the capabilities advertised in CAP LS
+var supportedCaps = []string{
+	CapChghost,
+	CapMessageTags,
+	CapSASL,
+	CapServerTime,
+	CapBatch,
+	CapChathistory,
+	CapAwayNotify,
+	CapAccountNotify,
+	CapExtendedJoin,
+}
+
+// capValues holds the values advertised with a capability in CAP LS 302
+var capValues = map[string]string{
+	CapSASL: strings.Join(saslMechanisms, ","),
+}
+
+// capList formats the supported capabilities for CAP LS, including values
+// when the client negotiates version 302 or later
+func capList(version int) string {
+	caps := make([]string, 0, len(supportedCaps))
+	for _, name := range supportedCaps {
+		if value := capValues[name]; value != "" && version >= 302 {
+			name += "=" + value
+		}
+		caps = append(caps, name)
+	}
+	return strings.Join(caps, " ")
+}
+
+// isSupportedCap checks if the server supports a capability
+func isSupportedCap(name string) bool {
+	for _, c := range supportedCaps {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCap checks if the client has enabled a capability
+func (c *Client) HasCap(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.caps[name]
+}
+
+// capTarget returns the nickname used in CAP replies
+func (c *Client) capTarget() string {
+	if c.Nickname == "" {
+		return "*"
+	}
+	return c.Nickname
+}
+
+// handleCap handles the CAP command
+func handleCap(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "CAP", "Not enough parameters")
+		return nil
+	}
+
+	subcommand := strings.ToUpper(message.Params[0])
+	switch subcommand {
+	case "LS":
+		// Registration is held until CAP END
+		client.mu.Lock()
+		if !client.Registered {
+			client.capNegotiating = true
+		}
+		client.mu.Unlock()
+		version := 0
+		if len(message.Params) > 1 {
+			version, _ = strconv.Atoi(message.Params[1])
+		}
+		client.SendServerLine("CAP", client.capTarget(), "LS", capList(version))
+
+	case "LIST":
+		client.mu.RLock()
+		enabled := make([]string, 0, len(client.caps))
+		for _, name := range supportedCaps {
+			if client.caps[name] {
+				enabled = append(enabled, name)
+			}
+		}
+		client.mu.RUnlock()
+		client.SendServerLine("CAP", client.capTarget(), "LIST", strings.Join(enabled, " "))
+
+	case "REQ":
+		if len(message.Params) < 2 {
+			client.SendError(irc.ERR_NEEDMOREPARAMS, "CAP", "Not enough parameters")
+			return nil
+		}
+		requested := strings.Fields(message.Params[1])
+
+		// The request is accepted or rejected as a whole
+		for _, name := range requested {
+			if !isSupportedCap(strings.TrimPrefix(name, "-")) {
+				client.SendServerLine("CAP", client.capTarget(), "NAK", message.Params[1])
+				return nil
+			}
+		}
+
+		client.mu.Lock()
+		if !client.Registered {
+			client.capNegotiating = true
+		}
+		if client.caps == nil {
+			client.caps = make(map[string]bool)
+		}
+		for _, name := range requested {
+			if strings.HasPrefix(name, "-") {
+				delete(client.caps, name[1:])
+			} else {
+				client.caps[name] = true
+			}
+		}
+		client.mu.Unlock()
+		client.SendServerLine("CAP", client.capTarget(), "ACK", message.Params[1])
+
+	case "END":
+		client.mu.Lock()
+		client.capNegotiating = false
+		aborted := client.sasl != nil
+		client.sasl = nil
+		client.mu.Unlock()
+
+		// Ending negotiation abandons any unfinished AUTHENTICATE exchange
+		if aborted {
+			client.SendError(irc.ERR_SASLABORTED, "SASL authentication aborted")
+		}
+		completeRegistration(client)
+
+	default:
+		client.SendError(irc.ERR_INVALIDCAPCMD, subcommand, "Invalid CAP command")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaseInsensitiveNicks(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	bob := srv.connect(t)
+	bob.send("NICK ALICE")
+	bob.expect(" 433 ")
+	bob.send("NICK [bob]")
+	bob.send("USER bob 0 * :Bob")
+	assert.Contains(t, bob.expect(" 005 "), "CASEMAPPING=rfc1459")
+	bob.expect(" 376 ")
+	assert.Same(t, srv.GetClient("[bob]"), srv.GetClient("{BOB}"))
+
+	// A client may change the case of its own nickname
+	alice.send("NICK Alice")
+	assert.Equal(t, ":alice!alice@ NICK Alice", alice.expect(" NICK "))
+	assert.NotNil(t, srv.GetClient("ALICE"))
+}
+
+func TestCaseInsensitiveChannels(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	bob := srv.register(t, "bob")
+	alice.send("JOIN #Test")
+	alice.expect(" 366 ")
+	bob.send("JOIN #TEST")
+	assert.Equal(t, ":bob!bob@ JOIN #Test", bob.expect(" JOIN "))
+	bob.expect(" 366 ")
+
+	channel := srv.GetChannel("#test")
+	require.NotNil(t, channel)
+	assert.Equal(t, 2, channel.MemberCount())
+
+	// Membership and status follow nick changes
+	alice.send("NICK Alice2")
+	bob.expect(" NICK ")
+	assert.True(t, channel.IsOperator(srv.GetClient("alice2")))
+	bob.send("PRIVMSG #tEST :hi")
+	assert.Equal(t, ":bob!bob@ PRIVMSG #Test :hi", alice.expect(" PRIVMSG "))
+
+	bob.send("PART #test")
+	bob.expect(" PART ")
+	alice.expect(":bob!bob@ PART ")
+	alice.send("PART #TEST")
+	alice.expect(":Alice2!alice@ PART ")
+	require.Eventually(t, func() bool { return srv.GetChannel("#Test") == nil }, time.Second, 5*time.Millisecond)
+}
+
+func TestUnknownCaseMapping(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Server.CaseMapping = "ebcdic"
+	_, err := NewServer(cfg)
+	assert.Error(t, err)
+}
@@ -0,0 +1,245 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Channel list modes
+const (
+	ListBan             = 'b' // Bans, +b
+	ListBanException    = 'e' // Ban exceptions, +e
+	ListInviteException = 'I' // Invite exceptions, +I
+)
+
+// timedMaskPrefix marks a list entry that expires, as in "~t:<minutes>:<mask>"
+const timedMaskPrefix = "~t:"
+
+// ChannelMask is an entry of a channel ban, ban exception or invite
+// exception list
+type ChannelMask struct {
+	Mask      string // nick!user@host mask, which may contain wildcards
+	SetBy     string
+	SetAt     time.Time
+	ExpiresAt time.Time // Zero for permanent entries
+}
+
+// Expired reports whether the entry has expired at the given time
+func (m *ChannelMask) Expired(now time.Time) bool {
+	return !m.ExpiresAt.IsZero() && !now.Before(m.ExpiresAt)
+}
+
+// normalizeMask expands a partial mask to nick!user@host form: "nick" becomes
+// "nick!*@*", "user@host" becomes "*!user@host" and "host.name" becomes
+// "*!*@host.name"
+func normalizeMask(mask string) string {
+	nick, rest, hasUser := strings.Cut(mask, "!")
+	if !hasUser {
+		switch {
+		case strings.Contains(mask, "@"):
+			return "*!" + mask
+		case strings.ContainsAny(mask, ".:"):
+			return "*!*@" + mask
+		default:
+			return mask + "!*@*"
+		}
+	}
+	if nick == "" {
+		nick = "*"
+	}
+	user, host, hasHost := strings.Cut(rest, "@")
+	if user == "" {
+		user = "*"
+	}
+	if !hasHost || host == "" {
+		host = "*"
+	}
+	return nick + "!" + user + "@" + host
+}
+
+// parseListMask splits a list mode parameter into its normalized mask and
+// duration. A "~t:<minutes>:" prefix makes the entry expire.
+func parseListMask(param string) (string, time.Duration) {
+	if rest, ok := strings.CutPrefix(param, timedMaskPrefix); ok {
+		if minutes, mask, ok := strings.Cut(rest, ":"); ok {
+			if n, err := strconv.Atoi(minutes); err == nil && n > 0 && mask != "" {
+				return normalizeMask(mask), time.Duration(n) * time.Minute
+			}
+		}
+	}
+	return normalizeMask(param), 0
+}
+
+// list returns the channel list for mode. The caller must hold c.mu.
+func (c *Channel) list(mode rune) *[]*ChannelMask {
+	switch mode {
+	case ListBan:
+		return &c.BanList
+	case ListBanException:
+		return &c.ExceptionList
+	case ListInviteException:
+		return &c.InviteExceptionList
+	}
+	return nil
+}
+
+// expireMasks removes the expired entries of a list. The caller must hold c.mu
+// for writing.
+func (c *Channel) expireMasks(list *[]*ChannelMask) {
+	now := c.Server.Now()
+	kept := (*list)[:0]
+	for _, entry := range *list {
+		if !entry.Expired(now) {
+			kept = append(kept, entry)
+		}
+	}
+	*list = kept
+}
+
+// AddMask adds an entry to the list for mode, expiring after duration unless
+// it is zero. It reports false when the mask is already listed or the list
+// is full.
+func (c *Channel) AddMask(mode rune, mask, setBy string, duration time.Duration) (added bool) {
+	defer func() {
+		if added {
+			c.Server.saveChannel(c)
+		}
+	}()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.list(mode)
+	if list == nil {
+		return false
+	}
+	c.expireMasks(list)
+	for _, entry := range *list {
+		if strings.EqualFold(entry.Mask, mask) {
+			return false
+		}
+	}
+	if len(*list) >= c.Server.limits().MaxList {
+		return false
+	}
+
+	entry := &ChannelMask{Mask: mask, SetBy: setBy, SetAt: c.Server.Now()}
+	if duration > 0 {
+		entry.ExpiresAt = entry.SetAt.Add(duration)
+	}
+	*list = append(*list, entry)
+	return true
+}
+
+// RemoveMask removes an entry from the list for mode, reporting whether it
+// was listed
+func (c *Channel) RemoveMask(mode rune, mask string) (removed bool) {
+	defer func() {
+		if removed {
+			c.Server.saveChannel(c)
+		}
+	}()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.list(mode)
+	if list == nil {
+		return false
+	}
+	c.expireMasks(list)
+	for i, entry := range *list {
+		if strings.EqualFold(entry.Mask, mask) {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Masks returns the unexpired entries of the list for mode
+func (c *Channel) Masks(mode rune) []*ChannelMask {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	list := c.list(mode)
+	if list == nil {
+		return nil
+	}
+	c.expireMasks(list)
+	return append([]*ChannelMask(nil), *list...)
+}
+
+// matchesMask reports whether the client matches an entry of the list for
+// mode, by displayed host, real host or IP
+func (c *Channel) matchesMask(mode rune, client *Client) bool {
+	client.mu.RLock()
+	prefix := client.Nickname + "!" + client.Username + "@"
+	hosts := []string{client.Hostname, client.RealHost()}
+	if client.IP != "" {
+		hosts = append(hosts, client.IP)
+	}
+	client.mu.RUnlock()
+
+	for _, entry := range c.Masks(mode) {
+		for _, host := range hosts {
+			if irc.MatchMask(entry.Mask, prefix+host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsBanExempt checks if a client matches the ban exception list
+func (c *Channel) IsBanExempt(client *Client) bool {
+	return c.matchesMask(ListBanException, client)
+}
+
+// IsInviteExempt checks if a client matches the invite exception list
+func (c *Channel) IsInviteExempt(client *Client) bool {
+	return c.matchesMask(ListInviteException, client)
+}
+
+// listModeReplies maps each list mode to its list and end numerics and the
+// name used in its replies
+var listModeReplies = map[rune]struct {
+	item, end int
+	name      string
+}{
+	ListBan:             {irc.RPL_BANLIST, irc.RPL_ENDOFBANLIST, "ban"},
+	ListBanException:    {irc.RPL_EXCEPTLIST, irc.RPL_ENDOFEXCEPTLIST, "exception"},
+	ListInviteException: {irc.RPL_INVITELIST, irc.RPL_ENDOFINVITELIST, "invite"},
+}
+
+// sendMaskList sends the list for mode to the client
+func (c *Channel) sendMaskList(client *Client, mode rune) {
+	replies := listModeReplies[mode]
+	for _, entry := range c.Masks(mode) {
+		client.SendReply(replies.item, c.Name, entry.Mask, entry.SetBy, strconv.FormatInt(entry.SetAt.Unix(), 10))
+	}
+	client.SendReply(replies.end, c.Name, fmt.Sprintf("End of channel %s list", replies.name))
+}
+
+// changeMask applies a +/- list mode change from the client and announces it
+// to the channel
+func (c *Channel) changeMask(client *Client, mode rune, set bool, param string) {
+	mask, duration := parseListMask(param)
+	sign := '+'
+	if set {
+		if !c.AddMask(mode, mask, client.Nickname, duration) {
+			if len(c.Masks(mode)) >= c.Server.limits().MaxList {
+				client.SendError(irc.ERR_BANLISTFULL, c.Name, string(mode), "Channel list is full")
+			}
+			return
+		}
+	} else {
+		sign = '-'
+		if !c.RemoveMask(mode, mask) {
+			return
+		}
+	}
+	c.SendToAll(fmt.Sprintf(":%s!%s@%s MODE %s %c%c %s", client.Nickname, client.Username, client.Hostname, c.Name, sign, mode, mask), nil)
+}
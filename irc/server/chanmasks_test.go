@@ -0,0 +1,100 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/irc/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMask(t *testing.T) {
+	assert.Equal(t, "bob!*@*", normalizeMask("bob"))
+	assert.Equal(t, "*!bob@host", normalizeMask("bob@host"))
+	assert.Equal(t, "*!*@example.com", normalizeMask("example.com"))
+	assert.Equal(t, "bob!*@*", normalizeMask("bob!"))
+	assert.Equal(t, "*!*@*", normalizeMask("!@"))
+
+	mask, duration := parseListMask("~t:10:*!*@host")
+	assert.Equal(t, "*!*@host", mask)
+	assert.Equal(t, 10*time.Minute, duration)
+}
+
+func TestChannelBans(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, newTestConfig(), WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	bob := srv.register(t, "bob")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+
+	// Banned members without voice cannot speak
+	alice.send("MODE #test +b b?b")
+	assert.Equal(t, ":alice!alice@ MODE #test +b b?b!*@*", bob.expect(" MODE "))
+	alice.expect(" MODE ")
+	bob.send("PRIVMSG #test :hello")
+	assert.Contains(t, bob.expect(" 404 "), "Cannot send to channel (+b)")
+
+	bob.send("PART #test")
+	bob.expect(" PART ")
+	bob.send("JOIN #test")
+	bob.expect(" 474 ")
+
+	// Anyone can list the bans
+	bob.send("MODE #test b")
+	assert.Regexp(t, `^:test.irc.local 367 bob #test b\?b!\*@\* alice \d+$`, bob.expect(" 367 "))
+	bob.expect(" 368 ")
+
+	// Exceptions override bans
+	alice.send("MODE #test +e *!bob@*")
+	alice.expect(" MODE ")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+	bob.send("PART #test")
+	bob.expect(" PART ")
+	alice.send("MODE #test -e *!bob@*")
+	alice.expect(" MODE ")
+
+	// Timed bans expire
+	alice.send("MODE #test -b b?b!*@*")
+	alice.expect(" MODE ")
+	alice.send("MODE #test +b ~t:5:bob")
+	assert.Equal(t, ":alice!alice@ MODE #test +b bob!*@*", alice.expect(" MODE "))
+	bob.send("JOIN #test")
+	bob.expect(" 474 ")
+	clock.Advance(5 * time.Minute)
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+	assert.Empty(t, srv.GetChannel("#test").Masks(ListBan))
+}
+
+func TestChannelInviteExceptions(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	alice.send("MODE #test +i")
+	alice.expect(" MODE ")
+
+	bob := srv.register(t, "bob")
+	bob.send("JOIN #test")
+	bob.expect(" 473 ")
+
+	alice.send("MODE #test +I bob!*@*")
+	alice.expect(" MODE ")
+	alice.send("MODE #test I")
+	assert.Contains(t, alice.expect(" 346 "), "#test bob!*@* alice")
+	alice.expect(" 347 ")
+
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+
+	// Lists are limited in size
+	srv.updateConfig(func(cfg *config.Config) { cfg.Limits.MaxList = 1 })
+	alice.send("MODE #test +I carol")
+	assert.Contains(t, alice.expect(" 478 "), "#test I :Channel list is full")
+}
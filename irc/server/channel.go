@@ -10,22 +10,24 @@ import (
 
 // Channel represents an IRC channel
 type Channel struct {
-	Name          string
-	Topic         string
-	TopicSetBy    string
-	TopicSetAt    time.Time
-	Members       map[string]*Client
-	Operators     map[string]bool
-	Voices        map[string]bool
-	Halfops       map[string]bool
-	Owners        map[string]bool
-	Admins        map[string]bool
-	Modes         ChannelModes
-	BanList       []string
-	InviteList    []string
-	ExceptionList []string
-	Server        *Server
-	mu            sync.RWMutex
+	Name                string
+	Topic               string
+	TopicSetBy          string
+	TopicSetAt          time.Time
+	Members             map[string]*Client
+	Operators           map[string]bool
+	Voices              map[string]bool
+	Halfops             map[string]bool
+	Owners              map[string]bool
+	Admins              map[string]bool
+	Modes               ChannelModes
+	BanList             []*ChannelMask // +b
+	ExceptionList       []*ChannelMask // +e
+	InviteExceptionList []*ChannelMask // +I
+	InviteList          []string       // Nicknames invited with INVITE
+	lastKnock           time.Time      // Time of the last delivered KNOCK
+	Server              *Server
+	mu                  sync.RWMutex
 }
 
 // ChannelModes represents the modes of a channel
@@ -68,18 +70,16 @@ type ChannelMember struct {
 // NewChannel creates a new channel
 func NewChannel(server *Server, name string) *Channel {
 	c := &Channel{
-		Name:          name,
-		Server:        server,
-		Members:       make(map[string]*Client),
-		Operators:     make(map[string]bool),
-		Voices:        make(map[string]bool),
-		Halfops:       make(map[string]bool),
-		Owners:        make(map[string]bool),
-		Admins:        make(map[string]bool),
-		BanList:       make([]string, 0),
-		InviteList:    make([]string, 0),
-		ExceptionList: make([]string, 0),
-		Modes:         DefaultChannelModes(),
+		Name:       name,
+		Server:     server,
+		Members:    make(map[string]*Client),
+		Operators:  make(map[string]bool),
+		Voices:     make(map[string]bool),
+		Halfops:    make(map[string]bool),
+		Owners:     make(map[string]bool),
+		Admins:     make(map[string]bool),
+		InviteList: make([]string, 0),
+		Modes:      DefaultChannelModes(),
 	}
 	return c
 }
@@ -93,20 +93,33 @@ func DefaultChannelModes() ChannelModes {
 	}
 }
 
+// key returns the map key for a nickname in the member and status maps
+func (c *Channel) key(nickname string) string {
+	return c.Server.Fold(nickname)
+}
+
 // AddMember adds a client to the channel
 func (c *Channel) AddMember(client *Client) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.Members[c.key(client.Nickname)] = client
+	users := len(c.Members)
+	c.mu.Unlock()
 
-	c.Members[client.Nickname] = client
+	c.Server.publish(EventJoin, map[string]interface{}{"channel": c.Name, "nickname": client.Nickname, "users": users})
 }
 
 // RemoveMember removes a client from the channel
 func (c *Channel) RemoveMember(client *Client) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	key := c.key(client.Nickname)
+	_, member := c.Members[key]
+	delete(c.Members, key)
+	users := len(c.Members)
+	c.mu.Unlock()
 
-	delete(c.Members, client.Nickname)
+	if member {
+		c.Server.publish(EventPart, map[string]interface{}{"channel": c.Name, "nickname": client.Nickname, "users": users})
+	}
 }
 
 // GetMember gets a client by nickname
@@ -114,7 +127,29 @@ func (c *Channel) GetMember(nickname string) *Client {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Members[nickname]
+	return c.Members[c.key(nickname)]
+}
+
+// renameMember moves a member and its channel status to the client's new
+// nickname after a nick change
+func (c *Channel) renameMember(client *Client, oldNick string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	oldKey, newKey := c.key(oldNick), c.key(client.Nickname)
+	if oldKey == newKey {
+		return
+	}
+	if _, ok := c.Members[oldKey]; ok {
+		delete(c.Members, oldKey)
+		c.Members[newKey] = client
+	}
+	for _, status := range []map[string]bool{c.Operators, c.Voices, c.Halfops, c.Admins, c.Owners} {
+		if status[oldKey] {
+			delete(status, oldKey)
+			status[newKey] = true
+		}
+	}
 }
 
 // MemberCount returns the number of members in the channel
@@ -143,8 +178,10 @@ func (c *Channel) SetTopic(topic, setBy string) {
 	c.mu.Lock()
 	c.Topic = topic
 	c.TopicSetBy = setBy
-	c.TopicSetAt = time.Now()
+	c.TopicSetAt = c.Server.Now()
 	c.mu.Unlock()
+	c.Server.saveChannel(c)
+	c.Server.publish(EventTopic, map[string]interface{}{"channel": c.Name, "topic": topic, "set_by": setBy})
 }
 
 // GetTopic gets the channel topic
@@ -178,6 +215,7 @@ func (c *Channel) SendNames(client *Client) {
 
 // SetMode sets a mode for the channel
 func (c *Channel) SetMode(mode rune, enable bool, param string) {
+	defer c.Server.saveChannel(c)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -320,38 +358,24 @@ func (c *Channel) IsMember(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	_, ok := c.Members[client.Nickname]
+	_, ok := c.Members[c.key(client.Nickname)]
 	return ok
 }
 
-// AddBan adds a ban to the ban list
+// AddBan adds a permanent ban to the ban list
 func (c *Channel) AddBan(mask string, setBy string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.BanList = append(c.BanList, mask)
+	c.AddMask(ListBan, normalizeMask(mask), setBy, 0)
 }
 
 // RemoveBan removes a ban from the ban list
 func (c *Channel) RemoveBan(mask string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	for i, ban := range c.BanList {
-		if ban == mask {
-			c.BanList = append(c.BanList[:i], c.BanList[i+1:]...)
-			break
-		}
-	}
+	c.RemoveMask(ListBan, normalizeMask(mask))
 }
 
-// IsBanned checks if a client is banned from the channel
+// IsBanned checks if a client matches the ban list and not the ban
+// exception list
 func (c *Channel) IsBanned(client *Client) bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	// TODO: Implement mask matching
-	return false
+	return c.matchesMask(ListBan, client) && !c.IsBanExempt(client)
 }
 
 // AddInvite adds a client to the invite list
@@ -368,7 +392,7 @@ func (c *Channel) RemoveInvite(nickname string) {
 	defer c.mu.Unlock()
 
 	for i, nick := range c.InviteList {
-		if nick == nickname {
+		if c.key(nick) == c.key(nickname) {
 			c.InviteList = append(c.InviteList[:i], c.InviteList[i+1:]...)
 			break
 		}
@@ -381,7 +405,7 @@ func (c *Channel) IsInvited(client *Client) bool {
 	defer c.mu.RUnlock()
 
 	for _, nick := range c.InviteList {
-		if nick == client.Nickname {
+		if c.key(nick) == c.key(client.Nickname) {
 			return true
 		}
 	}
@@ -393,7 +417,7 @@ func (c *Channel) IsOperator(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Operators[client.Nickname] || c.IsAdmin(client) || c.IsOwner(client)
+	return c.Operators[c.key(client.Nickname)] || c.IsAdmin(client) || c.IsOwner(client)
 }
 
 // IsVoice checks if a client has voice in the channel
@@ -401,7 +425,7 @@ func (c *Channel) IsVoice(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Voices[client.Nickname] || c.IsOperator(client) || c.IsHalfop(client) || c.IsAdmin(client) || c.IsOwner(client)
+	return c.Voices[c.key(client.Nickname)] || c.IsOperator(client) || c.IsHalfop(client) || c.IsAdmin(client) || c.IsOwner(client)
 }
 
 // IsHalfop checks if a client is a half-operator in the channel
@@ -409,7 +433,7 @@ func (c *Channel) IsHalfop(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Halfops[client.Nickname] || c.IsOperator(client) || c.IsAdmin(client) || c.IsOwner(client)
+	return c.Halfops[c.key(client.Nickname)] || c.IsOperator(client) || c.IsAdmin(client) || c.IsOwner(client)
 }
 
 // IsAdmin checks if a client is an admin in the channel
@@ -417,7 +441,7 @@ func (c *Channel) IsAdmin(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Admins[client.Nickname] || c.IsOwner(client)
+	return c.Admins[c.key(client.Nickname)] || c.IsOwner(client)
 }
 
 // IsOwner checks if a client is an owner in the channel
@@ -425,11 +449,16 @@ func (c *Channel) IsOwner(client *Client) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	return c.Owners[client.Nickname]
+	return c.Owners[c.key(client.Nickname)]
 }
 
 // CanSendToChannel checks if a client can send messages to the channel
 func (c *Channel) CanSendToChannel(client *Client) bool {
+	// Banned clients without voice cannot speak
+	if c.IsQuieted(client) {
+		return false
+	}
+
 	// Channel member always allowed if channel isn't moderated
 	if !c.Modes.Moderated {
 		return c.IsMember(client) || !c.Modes.NoExternalMsgs
@@ -439,6 +468,12 @@ func (c *Channel) CanSendToChannel(client *Client) bool {
 	return c.IsVoice(client) || client.IsOper
 }
 
+// IsQuieted checks if a banned client is kept from speaking in the channel.
+// Voiced and higher members may speak even when banned.
+func (c *Channel) IsQuieted(client *Client) bool {
+	return !client.IsOper && !c.IsVoice(client) && c.IsBanned(client)
+}
+
 // CanChangeChannelModes checks if a client can change channel modes
 func (c *Channel) CanChangeChannelModes(client *Client) bool {
 	return c.IsOperator(client) || client.IsOper
@@ -480,6 +515,6 @@ func (c *Channel) Kick(client *Client, target *Client, reason string) {
 
 	// Remove the channel from the target's channel list
 	target.mu.Lock()
-	delete(target.Channels, c.Name)
+	delete(target.Channels, c.key(c.Name))
 	target.mu.Unlock()
 }
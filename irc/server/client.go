@@ -2,6 +2,7 @@ package server
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"strings"
@@ -14,25 +15,41 @@ import (
 
 // Client represents a connected IRC client
 type Client struct {
-	ID          string
-	Nickname    string
-	Username    string
-	Realname    string
-	Hostname    string
-	IP          string
-	Modes       UserModes
-	Channels    map[string]*Channel
-	Server      *Server
-	Conn        net.Conn
-	LastPing    time.Time
-	Registered  bool
-	Away        bool
-	AwayMessage string
-	IsOper      bool
-	mu          sync.RWMutex
-	quit        chan struct{}
+	ID            string
+	Nickname      string
+	Username      string
+	Realname      string
+	Hostname      string
+	RealHostname  string // Resolved hostname, kept when the displayed Hostname is cloaked
+	IP            string
+	Listener      string // Listener the client connected through, "irc" or "tls"
+	Modes         UserModes
+	Channels      map[string]*Channel
+	Server        *Server
+	Conn          net.Conn
+	LastPing      time.Time
+	Registered    bool
+	Away          bool
+	AwayMessage   string
+	IsOper        bool
+	Account       string          // Account the client is logged in to, used for vhosts
+	snomask       map[rune]bool   // Server notice mask categories
+	caps          map[string]bool // Enabled client capabilities
+	certfp        string          // SHA-256 fingerprint of the TLS client certificate
+	ident         string          // Username returned by the client's ident server
+	sasl          *saslSession    // In-progress AUTHENTICATE exchange
+	commandBucket *tokenBucket    // Flood limiter for all commands, used by the read loop only
+	messageBucket *tokenBucket    // Flood limiter for PRIVMSG, NOTICE and TAGMSG
+	lastKnock     time.Time       // Time of the last delivered KNOCK
+	authFailures  int             // Wrong passwords sent to IDENTIFY, DROP or SASL PLAIN
+	lastAuthFail  time.Time       // Time of the last wrong password
+	quitReason    string          // Message given to Quit
+	floodingSince time.Time       // Start of the current run of fakelagged messages
+	mu            sync.RWMutex
+	quit          chan struct{}
 
 	PasswordProvided bool // Tracks if the client has provided the server password
+	capNegotiating   bool // Registration is held until CAP END
 }
 
 // NewClient creates a new client
@@ -41,15 +58,17 @@ func NewClient(server *Server, conn net.Conn) *Client {
 	ip, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
 
 	return &Client{
-		ID:       uuid.New().String(),
-		Server:   server,
-		Conn:     conn,
-		IP:       ip,
-		Hostname: ip, // Initially set hostname to IP
-		Channels: make(map[string]*Channel),
-		LastPing: time.Now(),
-		quit:     make(chan struct{}),
-		Modes:    NewUserModes(),
+		ID:           uuid.New().String(),
+		Server:       server,
+		Conn:         conn,
+		IP:           ip,
+		Listener:     listenerName(conn),
+		Hostname:     ip, // Initially set hostname to IP
+		RealHostname: ip,
+		Channels:     make(map[string]*Channel),
+		LastPing:     server.Now(),
+		quit:         make(chan struct{}),
+		Modes:        NewUserModes(),
 	}
 }
 
@@ -57,27 +76,26 @@ func NewClient(server *Server, conn net.Conn) *Client {
 func (c *Client) Handle() {
 	defer c.cleanup()
 
-	// Send welcome message and perform actual hostname lookup
-	c.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** Looking up your hostname...", c.Server.GetConfig().Server.Name))
-
-	// Get remote IP address
-	remoteAddr := c.Conn.RemoteAddr()
-	if tcpAddr, ok := remoteAddr.(*net.TCPAddr); ok {
-		// Perform reverse DNS lookup
-		names, err := net.LookupAddr(tcpAddr.IP.String())
-		if err == nil && len(names) > 0 {
-			// Successfully found hostname - use the first one returned
-			// Remove trailing dot from hostname if present
-			hostname := strings.TrimSuffix(names[0], ".")
-			c.Hostname = hostname
-			c.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** Found your hostname: %s", c.Server.GetConfig().Server.Name, hostname))
-		} else {
-			// Lookup failed - keep IP as hostname
-			c.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** Could not find your hostname, using IP address instead", c.Server.GetConfig().Server.Name))
+	// Complete the TLS handshake so the client certificate is known before any
+	// AUTHENTICATE exchange
+	if tlsConn, ok := c.Conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			return
 		}
-	} else {
-		// Not a TCP connection or couldn't get IP
-		c.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** Could not determine your connection type, using IP address", c.Server.GetConfig().Server.Name))
+		c.certfp = certFingerprint(tlsConn.ConnectionState())
+	}
+
+	// Enforce the connection limits before spending time on lookups
+	if c.Server.rejectIfTooMany(c) {
+		return
+	}
+
+	// Resolve the hostname and ident before accepting any commands
+	c.lookupHost()
+
+	// Screen the IP against blocklists before accepting any commands
+	if c.Server.rejectIfListed(c) {
+		return
 	}
 
 	// Start goroutines for reading from and writing to the client
@@ -85,17 +103,27 @@ func (c *Client) Handle() {
 
 	reader := bufio.NewReader(c.Conn)
 	for {
-		// Read a line from the client
-		line, err := reader.ReadString('\n')
+		// Read a line from the client, bounded by the tag and line length limits
+		line, tooLong, err := readLine(reader, maxClientTagsLen+2+c.Server.limits().LineLen)
 		if err != nil {
 			break
 		}
+		if tooLong {
+			c.SendError(irc.ERR_INPUTTOOLONG, "Input line was too long")
+			continue
+		}
 
 		// Trim whitespace
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
+		c.traceRaw("recv", line)
+
+		// Reject malformed lines rather than relaying them
+		if !c.validateLine(line) {
+			continue
+		}
 
 		// Parse the message
 		msg := irc.ParseMessage(line)
@@ -103,9 +131,14 @@ func (c *Client) Handle() {
 			continue
 		}
 
+		// Apply flood protection before handling the message
+		if !c.throttle(msg) {
+			break
+		}
+
 		// Handle the message
 		if err := c.handleMessage(msg, line); err != nil {
-			fmt.Printf("Error handling message: %v\n", err)
+			c.Server.Logger(LogCommands).Warn("Closing connection after command error", "nick", c.Nickname, "command", msg.Command, "error", err)
 			break
 		}
 	}
@@ -114,7 +147,9 @@ func (c *Client) Handle() {
 // handleMessage handles an IRC message
 func (c *Client) handleMessage(msg *irc.Message, raw string) error {
 	// Update last activity time for ping/pong tracking
-	c.LastPing = time.Now()
+	c.touch()
+	c.Server.metrics.commandReceived(msg.Command)
+	c.Server.Logger(LogCommands).Debug("Command received", "nick", c.Nickname, "command", msg.Command, "params", len(msg.Params))
 
 	// Create hook parameters
 	params := &HookParams{
@@ -137,12 +172,12 @@ func (c *Client) handleMessage(msg *irc.Message, raw string) error {
 	return c.Server.RunHooks(msg.Command, params)
 }
 
-// SendRaw sends a raw message to the client
+// SendRaw sends a raw message to the client, truncated to the line length
+// limit
 func (c *Client) SendRaw(message string) {
 	// Ensure the message ends with CRLF
-	if !strings.HasSuffix(message, "\r\n") {
-		message += "\r\n"
-	}
+	message = c.Server.clampLine(strings.TrimSuffix(message, "\r\n")) + "\r\n"
+	c.traceRaw("send", message)
 
 	c.Conn.Write([]byte(message))
 }
@@ -172,7 +207,7 @@ func (c *Client) SendNumericWithTarget(numeric int, target string, params ...str
 	allParams := make([]string, 0, len(params)+1)
 	allParams = append(allParams, target)
 	allParams = append(allParams, params...)
-	c.SendServerLine(fmt.Sprintf("%d", numeric), allParams...)
+	c.SendServerLine(fmt.Sprintf("%03d", numeric), allParams...)
 }
 
 // SendError sends an error response to the client
@@ -199,7 +234,7 @@ func (c *Client) pingLoop() {
 		select {
 		case <-ticker.C:
 			// Check if the client hasn't responded to a ping for too long
-			if time.Since(c.LastPing) > 2*time.Minute {
+			if c.Server.Since(c.lastActive()) > 2*time.Minute {
 				c.Quit("Ping timeout")
 				return
 			}
@@ -224,7 +259,9 @@ func (c *Client) Quit(message string) {
 	default:
 		close(c.quit)
 	}
+	c.quitReason = message
 	c.mu.Unlock()
+	c.Server.Logger(LogConnections).Info("Client disconnected", "id", c.ID, "nick", c.Nickname, "ip", c.IP, "reason", message)
 
 	// Send a quit message to all channels the client is in
 	for _, channel := range c.Channels {
@@ -250,6 +287,29 @@ func (c *Client) cleanup() {
 	c.Conn.Close()
 }
 
+// RealHost returns the client's actual hostname, ignoring any cloak
+func (c *Client) RealHost() string {
+	if c.RealHostname != "" {
+		return c.RealHostname
+	}
+	return c.Hostname
+}
+
+// touch records activity from the client
+func (c *Client) touch() {
+	now := c.Server.Now()
+	c.mu.Lock()
+	c.LastPing = now
+	c.mu.Unlock()
+}
+
+// lastActive returns the time of the client's last command or PONG
+func (c *Client) lastActive() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.LastPing
+}
+
 // SendWelcome sends the welcome messages to the client
 func (c *Client) SendWelcome() {
 	serverName := c.Server.GetConfig().Server.Name
@@ -260,12 +320,34 @@ func (c *Client) SendWelcome() {
 	c.SendReply(irc.RPL_YOURHOST, fmt.Sprintf("Your host is %s, running version GoIRCd-1.0", serverName))
 	c.SendReply(irc.RPL_CREATED, fmt.Sprintf("This server was created %s", c.Server.startTime.Format(time.RFC1123)))
 	c.SendReply(irc.RPL_MYINFO, serverName, "GoIRCd-1.0", "iwosxz", "biklmnopstv")
+	c.SendISupport()
+
+	c.SendMOTD()
+}
+
+// changeNick renames a registered client and announces it to the client and
+// its channels
+func (c *Client) changeNick(newNick string) {
+	c.mu.Lock()
+	oldNick := c.Nickname
+	c.Nickname = newNick
+	c.mu.Unlock()
+	c.Server.publish(EventNick, map[string]interface{}{"old": oldNick, "nickname": newNick})
 
-	// Send MOTD
-	c.SendReply(irc.RPL_MOTDSTART, fmt.Sprintf("- %s Message of the Day -", serverName))
-	c.SendReply(irc.RPL_MOTD, "- Welcome to GoIRCd!")
-	c.SendReply(irc.RPL_MOTD, "- This server is running GoIRCd, a Go IRC Server")
-	c.SendReply(irc.RPL_ENDOFMOTD, "End of /MOTD command")
+	line := fmt.Sprintf(":%s!%s@%s NICK %s", oldNick, c.Username, c.Hostname, newNick)
+	notified := map[string]bool{c.ID: true}
+	c.SendRaw(line)
+	for _, channel := range c.Channels {
+		channel.renameMember(c, oldNick)
+		channel.mu.RLock()
+		for _, member := range channel.Members {
+			if !notified[member.ID] {
+				notified[member.ID] = true
+				member.SendRaw(line)
+			}
+		}
+		channel.mu.RUnlock()
+	}
 }
 
 // JoinChannel makes the client join a channel
@@ -281,17 +363,17 @@ func (c *Client) JoinChannel(channelName string) {
 
 	// Add the channel to the client's channel list
 	c.mu.Lock()
-	c.Channels[channelName] = channel
+	c.Channels[c.Server.Fold(channelName)] = channel
 	c.mu.Unlock()
 
 	// Send join message to all members
-	channel.SendToAll(fmt.Sprintf(":%s!%s@%s JOIN %s", c.Nickname, c.Username, c.Hostname, channelName), nil)
+	c.sendJoin(channel)
 
 	// Send the channel topic
 	if channel.Topic != "" {
-		c.SendReply(irc.RPL_TOPIC, channelName, channel.Topic)
+		c.SendReply(irc.RPL_TOPIC, channel.Name, channel.Topic)
 	} else {
-		c.SendReply(irc.RPL_NOTOPIC, channelName, "No topic is set")
+		c.SendReply(irc.RPL_NOTOPIC, channel.Name, "No topic is set")
 	}
 
 	// Send the list of users in the channel
@@ -302,7 +384,7 @@ func (c *Client) JoinChannel(channelName string) {
 func (c *Client) PartChannel(channelName, reason string) {
 	// Check if the client is in the channel
 	c.mu.RLock()
-	channel, ok := c.Channels[channelName]
+	channel, ok := c.Channels[c.Server.Fold(channelName)]
 	c.mu.RUnlock()
 
 	if !ok {
@@ -310,19 +392,19 @@ func (c *Client) PartChannel(channelName, reason string) {
 	}
 
 	// Send part message to all members
-	channel.SendToAll(fmt.Sprintf(":%s!%s@%s PART %s :%s", c.Nickname, c.Username, c.Hostname, channelName, reason), nil)
+	channel.SendToAll(fmt.Sprintf(":%s!%s@%s PART %s :%s", c.Nickname, c.Username, c.Hostname, channel.Name, reason), nil)
 
 	// Remove the client from the channel
 	channel.RemoveMember(c)
 
 	// Remove the channel from the client's channel list
 	c.mu.Lock()
-	delete(c.Channels, channelName)
+	delete(c.Channels, c.Server.Fold(channelName))
 	c.mu.Unlock()
 
 	// If the channel is now empty, remove it
 	if channel.MemberCount() == 0 {
-		c.Server.RemoveChannel(channelName)
+		c.Server.RemoveChannel(channel.Name)
 	}
 }
 
@@ -353,20 +435,6 @@ func (c *Client) SetMode(mode string, enable bool) {
 	c.SendServerLine("MODE", c.Nickname, modeStr)
 }
 
-// SetAway sets the client's away status
-func (c *Client) SetAway(away bool, message string) {
-	c.mu.Lock()
-	c.Away = away
-	c.AwayMessage = message
-	c.mu.Unlock()
-
-	if away {
-		c.SendReply(irc.RPL_AWAY, "You have been marked as being away")
-	} else {
-		c.SendReply(irc.RPL_UNAWAY, "You are no longer marked as being away")
-	}
-}
-
 // SetOper sets the client's operator status
 func (c *Client) SetOper(isOper bool) {
 	c.mu.Lock()
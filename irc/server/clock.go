@@ -0,0 +1,40 @@
+package server
+
+import "time"
+
+// Clock provides the current time to the server
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock backed by time.Now
+type realClock struct{}
+
+// Now returns the current wall clock time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Option configures optional Server behavior
+type Option func(*Server)
+
+// WithClock makes the server read the current time from clock instead of the
+// system clock, e.g. to make idle times and expiries deterministic in tests
+func WithClock(clock Clock) Option {
+	return func(s *Server) {
+		s.clock = clock
+	}
+}
+
+// Now returns the current time according to the server's clock
+func (s *Server) Now() time.Time {
+	if s == nil || s.clock == nil {
+		return time.Now()
+	}
+	return s.clock.Now()
+}
+
+// Since returns the time elapsed since t according to the server's clock
+func (s *Server) Since(t time.Time) time.Duration {
+	return s.Now().Sub(t)
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhoisIdleUsesClock(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	srv.register(t, "bob")
+
+	clock.Advance(42 * time.Second)
+
+	alice.send("WHOIS bob")
+	line := alice.expect(" 317 ")
+	assert.Equal(t, ":test.irc.local 317 alice bob 42 :seconds idle", line)
+
+	clock.Advance(8 * time.Second)
+
+	alice.send("WHOIS bob")
+	line = alice.expect(" 317 ")
+	assert.Equal(t, ":test.irc.local 317 alice bob 50 :seconds idle", line)
+}
+
+func TestTopicAndUptimeUseClock(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, time.Hour, srv.GetUptime())
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+
+	srv.GetChannel("#test").SetTopic("hello", "alice")
+
+	alice.send("TOPIC #test")
+	line := alice.expect(" 333 ")
+	assert.Equal(t, fmt.Sprintf(":test.irc.local 333 alice #test alice %d", clock.Now().Unix()), line)
+}
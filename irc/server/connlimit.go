@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// ipInList checks if ip is one of the IPs or within one of the CIDR ranges
+// in list
+func ipInList(ip string, list []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range list {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(parsed) {
+				return true
+			}
+		} else if entry == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// countConnections returns the number of connected clients, registered or
+// not, and how many of them share ip
+func (s *Server) countConnections(ip string) (total, fromIP int) {
+	s.clients.Range(func(key, value interface{}) bool {
+		total++
+		if ip != "" && value.(*Client).IP == ip {
+			fromIP++
+		}
+		return true
+	})
+	return total, fromIP
+}
+
+// rejectIfTooMany disconnects the client when its IP or the server as a whole
+// is over the configured connection limits. Clients in connections.exempt are
+// never rejected.
+func (s *Server) rejectIfTooMany(client *Client) bool {
+	cfg := s.GetConfig().Connections
+	if ipInList(client.IP, cfg.Exempt) {
+		return false
+	}
+
+	// The client itself is already counted
+	total, fromIP := s.countConnections(client.IP)
+	var reason string
+	switch {
+	case cfg.MaxClients > 0 && total > cfg.MaxClients:
+		reason = "Too many connections"
+	case cfg.MaxPerIP > 0 && client.IP != "" && fromIP > cfg.MaxPerIP:
+		reason = "Too many connections from your IP"
+	default:
+		return false
+	}
+
+	s.Logger(LogConnections).Warn("Connection rejected by limit", "ip", client.IP, "reason", reason, "total", total, "from_ip", fromIP)
+	s.SendServerNotice(SnomaskConnect, fmt.Sprintf("Rejected connection from %s: %s", client.IP, reason))
+	client.SendRaw(fmt.Sprintf("ERROR :Closing Link: %s (%s)", client.IP, reason))
+	client.Quit(reason)
+	return true
+}
+
+// checkClones notifies operators when a newly registered client brings the
+// number of registered clients on its real host to connections.clone_warn
+// or more
+func (s *Server) checkClones(client *Client) {
+	cfg := s.GetConfig().Connections
+	host := client.RealHost()
+	if cfg.CloneWarn <= 0 || host == "" || ipInList(client.IP, cfg.Exempt) {
+		return
+	}
+
+	var nicks []string
+	s.clients.Range(func(key, value interface{}) bool {
+		other := value.(*Client)
+		if other.Registered && other.RealHost() == host {
+			nicks = append(nicks, other.Nickname)
+		}
+		return true
+	})
+	if len(nicks) < cfg.CloneWarn {
+		return
+	}
+
+	sort.Strings(nicks)
+	s.Logger(LogConnections).Warn("Clones detected", "host", host, "count", len(nicks))
+	s.SendServerNotice(SnomaskConnect, fmt.Sprintf("Clones detected from %s: %d connections (%s)", host, len(nicks), strings.Join(nicks, ", ")))
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerTCP registers a client over a loopback TCP connection
+func (s *Server) registerTCP(t *testing.T, nick string) *testClient {
+	t.Helper()
+	tc := s.connectTCP(t)
+	tc.send("NICK " + nick)
+	tc.send("USER " + nick + " 0 * :Test " + nick)
+	tc.expect(" 376 ")
+	return tc
+}
+
+func TestConnectionLimitPerIP(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hostnames.DisableDNS = true
+	cfg.Connections.MaxPerIP = 2
+	srv := newTestServer(t, cfg)
+
+	admin := srv.register(t, "admin")
+	srv.oper(t, admin, "admin")
+	admin.drain()
+
+	first := srv.registerTCP(t, "first")
+	srv.registerTCP(t, "second")
+	third := srv.connectTCP(t)
+	assert.Equal(t, "ERROR :Closing Link: 127.0.0.1 (Too many connections from your IP)", third.expect("ERROR"))
+	assert.Contains(t, admin.expect("Rejected connection"), "127.0.0.1: Too many connections from your IP")
+
+	// A slot frees up once a client leaves
+	first.send("QUIT")
+	require.Eventually(t, func() bool { return srv.GetClient("first") == nil }, time.Second, 5*time.Millisecond)
+	srv.registerTCP(t, "fourth")
+}
+
+func TestConnectionLimitGlobalExempt(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hostnames.DisableDNS = true
+	cfg.Connections.MaxClients = 1
+	cfg.Connections.Exempt = []string{"127.0.0.0/8"}
+	srv := newTestServer(t, cfg)
+
+	srv.register(t, "alice")
+	srv.registerTCP(t, "trusted")
+	assert.Equal(t, "ERROR :Closing Link:  (Too many connections)", srv.connect(t).expect("ERROR"))
+}
+
+func TestCloneDetection(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hostnames.DisableDNS = true
+	cfg.Connections.CloneWarn = 2
+	srv := newTestServer(t, cfg)
+
+	admin := srv.register(t, "admin")
+	srv.oper(t, admin, "admin")
+	admin.drain()
+
+	srv.registerTCP(t, "clone1")
+	srv.registerTCP(t, "clone2")
+	assert.Contains(t, admin.expect("Clones detected"), "Clones detected from 127.0.0.1: 2 connections (clone1, clone2)")
+}
@@ -0,0 +1,472 @@
+package server
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// consoleSetter is the name recorded as the setter of bans added from the console
+const consoleSetter = "console"
+
+// Console is the local control interface. It serves line-based commands on a
+// unix socket, or on a TCP listener where clients must AUTH first. Each
+// command is answered with zero or more lines followed by "OK" or
+// "ERROR <message>".
+type Console struct {
+	server   *Server
+	listener net.Listener
+}
+
+// consoleCommand is a command available on the console
+type consoleCommand struct {
+	usage string
+	help  string
+	run   func(s *Server, args []string) ([]string, error)
+}
+
+// consoleCommands maps upper-case command names to their implementation
+var consoleCommands map[string]consoleCommand
+
+func init() {
+	consoleCommands = map[string]consoleCommand{
+		"HELP":     {"HELP", "List the available commands", consoleHelp},
+		"STATUS":   {"STATUS", "Show uptime and counts of clients, channels and operators", consoleStatus},
+		"CLIENTS":  {"CLIENTS", "List connected clients", consoleClients},
+		"CLIENT":   {"CLIENT <nick>", "Show details of a client", consoleClient},
+		"CHANNELS": {"CHANNELS", "List channels", consoleChannels},
+		"CHANNEL":  {"CHANNEL <name>", "Show details of a channel", consoleChannel},
+		"KILL":     {"KILL <nick> [reason]", "Disconnect a client", consoleKill},
+		"BANS":     {"BANS", "List K-lines and G-lines", consoleBans},
+		"KLINE":    {"KLINE <user@host> <duration> [reason]", "Add a K-line, duration 0 is permanent", consoleAddBan(BanTypeKLine)},
+		"GLINE":    {"GLINE <user@host> <duration> [reason]", "Add a G-line, duration 0 is permanent", consoleAddBan(BanTypeGLine)},
+		"UNKLINE":  {"UNKLINE <user@host>", "Remove a K-line", consoleRemoveBan(BanTypeKLine)},
+		"UNGLINE":  {"UNGLINE <user@host>", "Remove a G-line", consoleRemoveBan(BanTypeGLine)},
+		"REHASH":   {"REHASH [source]", "Reload the configuration", consoleRehash},
+		"SHUTDOWN": {"SHUTDOWN [reason]", "Disconnect everyone and stop the server", consoleShutdown},
+	}
+}
+
+// newConsole creates the control interface for a server
+func newConsole(s *Server) *Console {
+	return &Console{server: s}
+}
+
+// Start listens on the configured unix socket or TCP address and serves
+// console clients until Stop is called
+func (c *Console) Start() error {
+	cfg := c.server.GetConfig()
+	network, address := "tcp", cfg.GetConsoleListenAddress()
+	if cfg.Console.Socket != "" {
+		network, address = "unix", cfg.Console.Socket
+		// Remove a socket left behind by a previous run
+		if info, err := os.Stat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(address)
+		}
+	}
+
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return fmt.Errorf("failed to start console on %s: %v", address, err)
+	}
+	if network == "unix" {
+		os.Chmod(address, 0o600)
+	}
+	c.listener = listener
+	c.server.Logger(LogServer).Info("Starting console", "network", network, "address", address)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go c.serve(conn, network == "unix")
+		}
+	}()
+	return nil
+}
+
+// Stop closes the console listener
+func (c *Console) Stop() error {
+	if c.listener == nil {
+		return nil
+	}
+	c.server.Logger(LogServer).Info("Stopping console")
+	return c.listener.Close()
+}
+
+// serve reads commands from one console connection. Unix socket connections
+// are trusted through the socket's file permissions; TCP connections must
+// authenticate with the console password first.
+func (c *Console) serve(conn net.Conn, authenticated bool) {
+	defer conn.Close()
+	s := c.server
+
+	reply := func(lines []string, err error) {
+		var b strings.Builder
+		for _, line := range lines {
+			b.WriteString(line + "\n")
+		}
+		if err != nil {
+			b.WriteString("ERROR " + err.Error() + "\n")
+		} else {
+			b.WriteString("OK\n")
+		}
+		conn.Write([]byte(b.String()))
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		name, args := strings.ToUpper(fields[0]), fields[1:]
+
+		switch {
+		case name == "QUIT":
+			reply(nil, nil)
+			return
+
+		case name == "AUTH":
+			password := s.GetConfig().Console.Password
+			if len(args) != 1 || password == "" || subtle.ConstantTimeCompare([]byte(args[0]), []byte(password)) != 1 {
+				s.Logger(LogServer).Warn("Console authentication failed", "remote", conn.RemoteAddr().String())
+				reply(nil, errors.New("authentication failed"))
+				return
+			}
+			authenticated = true
+			reply(nil, nil)
+
+		case !authenticated:
+			reply(nil, errors.New("authentication required"))
+
+		default:
+			command, ok := consoleCommands[name]
+			if !ok {
+				reply(nil, fmt.Errorf("unknown command %s, try HELP", name))
+				continue
+			}
+			s.Logger(LogCommands).Info("Console command", "command", name, "args", strings.Join(args, " "))
+			lines, err := command.run(s, args)
+			reply(lines, err)
+			if name == "SHUTDOWN" && err == nil {
+				s.Stop()
+				return
+			}
+		}
+	}
+}
+
+// consoleUsage returns the usage error of a console command
+func consoleUsage(name string) error {
+	return fmt.Errorf("usage: %s", consoleCommands[name].usage)
+}
+
+func consoleHelp(s *Server, args []string) ([]string, error) {
+	names := make([]string, 0, len(consoleCommands))
+	for name := range consoleCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		command := consoleCommands[name]
+		lines = append(lines, fmt.Sprintf("%-40s %s", command.usage, command.help))
+	}
+	return append(lines, fmt.Sprintf("%-40s %s", "QUIT", "Close the console connection")), nil
+}
+
+func consoleStatus(s *Server, args []string) ([]string, error) {
+	cfg := s.GetConfig()
+	return []string{
+		"server " + cfg.Server.Name,
+		"network " + cfg.Server.Network,
+		"uptime " + formatUptime(s.GetUptime()),
+		"clients " + strconv.Itoa(s.ClientCount()),
+		"channels " + strconv.Itoa(s.ChannelCount()),
+		"operators " + strconv.Itoa(s.OperCount()),
+	}, nil
+}
+
+// consoleClientInfo holds the client fields shown on the console
+type consoleClientInfo struct {
+	nick       string
+	user       string
+	realname   string
+	host       string
+	realHost   string
+	ip         string
+	listener   string
+	account    string
+	registered bool
+	oper       bool
+	lastActive time.Time
+	channels   []string
+}
+
+// consoleInfo copies the fields of a client shown on the console under the
+// client lock, since the client's own goroutine may be changing them
+func consoleInfo(client *Client) consoleClientInfo {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	info := consoleClientInfo{
+		nick:       client.Nickname,
+		user:       client.Username,
+		realname:   client.Realname,
+		host:       client.Hostname,
+		realHost:   client.RealHostname,
+		ip:         client.IP,
+		listener:   client.Listener,
+		account:    client.Account,
+		registered: client.Registered,
+		oper:       client.IsOper,
+		lastActive: client.LastPing,
+		channels:   make([]string, 0, len(client.Channels)),
+	}
+	if info.realHost == "" {
+		info.realHost = info.host
+	}
+	for _, channel := range client.Channels {
+		info.channels = append(info.channels, channel.Name)
+	}
+	sort.Strings(info.channels)
+	return info
+}
+
+// sortedClients returns the console fields of the connected clients ordered
+// by nickname
+func (s *Server) sortedClients() []consoleClientInfo {
+	var clients []consoleClientInfo
+	s.clients.Range(func(key, value interface{}) bool {
+		clients = append(clients, consoleInfo(value.(*Client)))
+		return true
+	})
+	sort.Slice(clients, func(i, j int) bool { return clients[i].nick < clients[j].nick })
+	return clients
+}
+
+func consoleClients(s *Server, args []string) ([]string, error) {
+	var lines []string
+	for _, client := range s.sortedClients() {
+		nick := client.nick
+		if nick == "" {
+			nick = "*"
+		}
+		line := fmt.Sprintf("%s %s@%s [%s]", nick, client.user, client.realHost, client.ip)
+		if !client.registered {
+			line += " unregistered"
+		}
+		if client.oper {
+			line += " oper"
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+func consoleClient(s *Server, args []string) ([]string, error) {
+	if len(args) != 1 {
+		return nil, consoleUsage("CLIENT")
+	}
+	client := s.GetClient(args[0])
+	if client == nil {
+		return nil, fmt.Errorf("no such nick %s", args[0])
+	}
+
+	info := consoleInfo(client)
+	account := info.account
+	if account == "" {
+		account = "*"
+	}
+	return []string{
+		"nick " + info.nick,
+		"user " + info.user,
+		"realname " + info.realname,
+		"host " + info.host,
+		"realhost " + info.realHost,
+		"ip " + info.ip,
+		"listener " + info.listener,
+		"account " + account,
+		"oper " + strconv.FormatBool(info.oper),
+		"idle " + s.Since(info.lastActive).Round(time.Second).String(),
+		"channels " + strings.Join(info.channels, " "),
+	}, nil
+}
+
+// sortedChannels returns the channels ordered by name
+func (s *Server) sortedChannels() []*Channel {
+	var channels []*Channel
+	s.channels.Range(func(key, value interface{}) bool {
+		channels = append(channels, value.(*Channel))
+		return true
+	})
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+	return channels
+}
+
+func consoleChannels(s *Server, args []string) ([]string, error) {
+	var lines []string
+	for _, channel := range s.sortedChannels() {
+		topic, _, _ := channel.GetTopic()
+		lines = append(lines, fmt.Sprintf("%s %d %s :%s", channel.Name, channel.MemberCount(), channel.GetModeString(), topic))
+	}
+	return lines, nil
+}
+
+func consoleChannel(s *Server, args []string) ([]string, error) {
+	if len(args) != 1 {
+		return nil, consoleUsage("CHANNEL")
+	}
+	channel := s.GetChannel(args[0])
+	if channel == nil {
+		return nil, fmt.Errorf("no such channel %s", args[0])
+	}
+
+	topic, setBy, setAt := channel.GetTopic()
+	lines := []string{
+		"name " + channel.Name,
+		"modes " + channel.GetModeString(),
+		"topic " + topic,
+	}
+	if setBy != "" {
+		lines = append(lines, fmt.Sprintf("topic_set_by %s at %s", setBy, setAt.UTC().Format(time.RFC3339)))
+	}
+
+	channel.mu.RLock()
+	clients := make([]*Client, 0, len(channel.Members))
+	for _, member := range channel.Members {
+		clients = append(clients, member)
+	}
+	channel.mu.RUnlock()
+	members := make([]string, 0, len(clients))
+	for _, member := range clients {
+		member.mu.RLock()
+		members = append(members, member.Nickname)
+		member.mu.RUnlock()
+	}
+	sort.Strings(members)
+	lines = append(lines, "members "+strings.Join(members, " "))
+
+	for _, mode := range []rune{ListBan, ListBanException, ListInviteException} {
+		for _, mask := range channel.Masks(mode) {
+			lines = append(lines, fmt.Sprintf("+%c %s by %s", mode, mask.Mask, mask.SetBy))
+		}
+	}
+	return lines, nil
+}
+
+func consoleKill(s *Server, args []string) ([]string, error) {
+	if len(args) < 1 {
+		return nil, consoleUsage("KILL")
+	}
+	target := s.GetClient(args[0])
+	if target == nil {
+		return nil, fmt.Errorf("no such nick %s", args[0])
+	}
+	reason := strings.Join(args[1:], " ")
+	if reason == "" {
+		reason = "Killed by operator"
+	}
+
+	s.SendServerNotice(SnomaskKill, fmt.Sprintf("Received KILL message for %s!%s@%s from %s: %s", target.Nickname, target.Username, target.Hostname, consoleSetter, reason))
+	killMessage := fmt.Sprintf("Killed by %s: %s", consoleSetter, reason)
+	target.SendMessage(s.GetConfig().Server.Name, "KILL", target.Nickname, killMessage)
+	target.Quit(killMessage)
+	return []string{"Killed " + target.Nickname}, nil
+}
+
+func consoleBans(s *Server, args []string) ([]string, error) {
+	var lines []string
+	for _, banType := range []rune{BanTypeKLine, BanTypeGLine} {
+		for _, ban := range s.GetBans(banType) {
+			expiry := "permanent"
+			if !ban.ExpiresAt.IsZero() {
+				expiry = "expires " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+			lines = append(lines, fmt.Sprintf("%c %s by %s, %s: %s", ban.Type, ban.Mask, ban.SetBy, expiry, ban.Reason))
+		}
+	}
+	return lines, nil
+}
+
+// consoleAddBan returns the console command adding a K-line or G-line
+func consoleAddBan(banType rune) func(s *Server, args []string) ([]string, error) {
+	return func(s *Server, args []string) ([]string, error) {
+		name := string(banType) + "LINE"
+		if len(args) < 2 {
+			return nil, consoleUsage(name)
+		}
+		mask := args[0]
+		if !strings.Contains(mask, "@") {
+			mask = "*@" + mask
+		}
+		duration, err := parseBanDuration(args[1])
+		if err != nil || duration < 0 {
+			return nil, fmt.Errorf("invalid duration %s", args[1])
+		}
+		reason := strings.Join(args[2:], " ")
+		if reason == "" {
+			reason = "Banned by operator"
+		}
+
+		ban := s.AddBan(banType, mask, consoleSetter, reason, duration)
+		expiry := "permanently"
+		if duration > 0 {
+			expiry = fmt.Sprintf("for %s", duration)
+		}
+		s.SendServerNotice(SnomaskKill, fmt.Sprintf("%s added %c-Line for %s %s: %s", consoleSetter, banType, mask, expiry, reason))
+		s.enforceBan(ban, nil)
+		return []string{fmt.Sprintf("Added %c-Line for %s %s: %s", banType, mask, expiry, reason)}, nil
+	}
+}
+
+// consoleRemoveBan returns the console command removing a K-line or G-line
+func consoleRemoveBan(banType rune) func(s *Server, args []string) ([]string, error) {
+	return func(s *Server, args []string) ([]string, error) {
+		if len(args) != 1 {
+			return nil, consoleUsage("UN" + string(banType) + "LINE")
+		}
+		mask := args[0]
+		if !strings.Contains(mask, "@") {
+			mask = "*@" + mask
+		}
+		if !s.RemoveBan(banType, mask) {
+			return nil, fmt.Errorf("no %c-Line for %s", banType, mask)
+		}
+		s.SendServerNotice(SnomaskKill, fmt.Sprintf("%s removed %c-Line for %s", consoleSetter, banType, mask))
+		return []string{fmt.Sprintf("Removed %c-Line for %s", banType, mask)}, nil
+	}
+}
+
+func consoleRehash(s *Server, args []string) ([]string, error) {
+	source := strings.Join(args, " ")
+	if err := s.Rehash(source); err != nil {
+		s.SendServerNotice(SnomaskLocops, fmt.Sprintf("Failed to reload configuration: %v", err))
+		return nil, err
+	}
+	s.SendServerNotice(SnomaskLocops, "Configuration reloaded from "+s.GetConfig().Source)
+	return []string{"Configuration reloaded from " + s.GetConfig().Source}, nil
+}
+
+// consoleShutdown announces the shutdown; the console stops the server once
+// the reply has been sent
+func consoleShutdown(s *Server, args []string) ([]string, error) {
+	reason := strings.Join(args, " ")
+	if reason == "" {
+		reason = "Server shutting down"
+	}
+	s.Logger(LogServer).Info("Shutdown requested from the console", "reason", reason)
+	s.Broadcast(fmt.Sprintf(":%s NOTICE * :*** %s", s.GetConfig().Server.Name, reason))
+	return []string{"Shutting down"}, nil
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// consoleConn is a connection to a server console
+type consoleConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// startConsole starts the server console and returns its address
+func startConsole(t *testing.T, srv *Server) net.Addr {
+	t.Helper()
+	require.NoError(t, srv.console.Start())
+	t.Cleanup(func() { srv.console.Stop() })
+	return srv.console.listener.Addr()
+}
+
+// dialConsole connects to a console
+func dialConsole(t *testing.T, addr net.Addr) *consoleConn {
+	t.Helper()
+	conn, err := net.Dial(addr.Network(), addr.String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &consoleConn{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// run sends a command and returns the reply lines and the final status line
+func (cc *consoleConn) run(command string) ([]string, string) {
+	cc.t.Helper()
+	_, err := fmt.Fprintf(cc.conn, "%s\n", command)
+	require.NoError(cc.t, err)
+
+	var lines []string
+	cc.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		line, err := cc.reader.ReadString('\n')
+		require.NoError(cc.t, err)
+		line = strings.TrimRight(line, "\n")
+		if line == "OK" || strings.HasPrefix(line, "ERROR ") {
+			return lines, line
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestConsoleUnixSocket(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Console.Enabled = true
+	cfg.Console.Socket = filepath.Join(t.TempDir(), "console.sock")
+	srv := newTestServer(t, cfg)
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+
+	console := dialConsole(t, startConsole(t, srv))
+
+	lines, status := console.run("status")
+	assert.Equal(t, "OK", status)
+	assert.Contains(t, lines, "clients 1")
+	assert.Contains(t, lines, "channels 1")
+
+	lines, _ = console.run("CLIENTS")
+	assert.Equal(t, []string{"alice alice@ []"}, lines)
+	lines, _ = console.run("CLIENT alice")
+	assert.Contains(t, lines, "channels #test")
+	_, status = console.run("CLIENT nobody")
+	assert.Equal(t, "ERROR no such nick nobody", status)
+
+	lines, _ = console.run("CHANNEL #test")
+	assert.Contains(t, lines, "members alice")
+
+	lines, status = console.run("KLINE mallory@* 1h Spamming links")
+	assert.Equal(t, "OK", status)
+	assert.Equal(t, []string{"Added K-Line for mallory@* for 1h0m0s: Spamming links"}, lines)
+	lines, _ = console.run("BANS")
+	require.Len(t, lines, 1)
+	assert.Contains(t, lines[0], "K mallory@* by console")
+	_, status = console.run("UNKLINE mallory@*")
+	assert.Equal(t, "OK", status)
+	_, status = console.run("UNKLINE mallory@*")
+	assert.Equal(t, "ERROR no K-Line for mallory@*", status)
+
+	_, status = console.run("FROB")
+	assert.Equal(t, "ERROR unknown command FROB, try HELP", status)
+
+	// Shutting down warns the clients and stops the server
+	_, status = console.run("SHUTDOWN Maintenance")
+	assert.Equal(t, "OK", status)
+	assert.Equal(t, ":test.irc.local NOTICE * :*** Maintenance", alice.expect("NOTICE"))
+	select {
+	case <-srv.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not stop")
+	}
+}
+
+func TestConsoleConcurrentChanges(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Console.Enabled = true
+	cfg.Console.Socket = filepath.Join(t.TempDir(), "console.sock")
+	srv := newTestServer(t, cfg)
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+
+	console := dialConsole(t, startConsole(t, srv))
+
+	// Reading clients while they change nickname doesn't race
+	for i := 0; i < 20; i++ {
+		nick := fmt.Sprintf("alice%d", i)
+		alice.send("NICK " + nick)
+		_, status := console.run("CLIENTS")
+		assert.Equal(t, "OK", status)
+		_, status = console.run("CHANNEL #test")
+		assert.Equal(t, "OK", status)
+		alice.expect(" NICK " + nick)
+	}
+	lines, _ := console.run("CLIENT alice19")
+	assert.Contains(t, lines, "nick alice19")
+}
+
+func TestConsoleTCPAuth(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Console.Enabled = true
+	cfg.Console.Host = "127.0.0.1"
+	cfg.Console.Port = 1
+	cfg.Console.Password = "hunter2"
+	srv := newTestServer(t, cfg)
+	srv.GetConfig().Console.Port = 0 // Pick a free port
+
+	addr := startConsole(t, srv)
+	console := dialConsole(t, addr)
+	_, status := console.run("STATUS")
+	assert.Equal(t, "ERROR authentication required", status)
+	_, status = console.run("AUTH hunter2")
+	assert.Equal(t, "OK", status)
+	_, status = console.run("STATUS")
+	assert.Equal(t, "OK", status)
+
+	other := dialConsole(t, addr)
+	_, status = other.run("AUTH wrong")
+	assert.Equal(t, "ERROR authentication failed", status)
+}
+
+func TestConsoleConfigValidation(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Console.Enabled = true
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, "console.socket or console.port is required")
+
+	cfg.Console.Port = 6680
+	_, err = NewServer(cfg)
+	assert.ErrorContains(t, err, "console.password is required")
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Listener names used by dnsbl.exempt_listeners
+const (
+	ListenerIRC = "irc"
+	ListenerTLS = "tls"
+)
+
+const (
+	// dnsblTimeout bounds the time spent querying blocklists for one connection
+	dnsblTimeout = 5 * time.Second
+
+	// defaultTorRefresh is the Tor exit list refresh interval when
+	// dnsbl.tor_refresh is not configured
+	defaultTorRefresh = time.Hour
+
+	// torListName is the name Tor exit list hits are counted under
+	torListName = "tor"
+)
+
+// Screener scores connecting IPs against DNS blocklists and the Tor exit list
+// before registration
+type Screener struct {
+	server     *Server
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	torExits   map[string]bool
+	checked    int
+	rejected   int
+	hits       map[string]int // Hits by blocklist zone, or "tor"
+	mu         sync.RWMutex
+}
+
+// ScreenStats is a snapshot of the screener counters
+type ScreenStats struct {
+	Checked  int            `json:"checked"`
+	Rejected int            `json:"rejected"`
+	Hits     map[string]int `json:"hits"`
+}
+
+// newScreener creates the connection screener for a server
+func newScreener(s *Server) *Screener {
+	return &Screener{
+		server:     s,
+		lookupHost: net.DefaultResolver.LookupHost,
+		torExits:   make(map[string]bool),
+		hits:       make(map[string]int),
+	}
+}
+
+// listenerName returns the name of the listener a connection was accepted on
+func listenerName(conn net.Conn) string {
+	if _, ok := conn.(*tls.Conn); ok {
+		return ListenerTLS
+	}
+	return ListenerIRC
+}
+
+// reverseIP returns ip in the reversed form used by DNS blocklists, or an
+// empty string if ip is not valid
+func reverseIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	if v4 := parsed.To4(); v4 != nil {
+		return fmt.Sprintf("%d.%d.%d.%d", v4[3], v4[2], v4[1], v4[0])
+	}
+
+	// IPv6 addresses are reversed nibble by nibble
+	const hex = "0123456789abcdef"
+	nibbles := make([]string, 0, 32)
+	for i := len(parsed) - 1; i >= 0; i-- {
+		nibbles = append(nibbles, string(hex[parsed[i]&0x0f]), string(hex[parsed[i]>>4]))
+	}
+	return strings.Join(nibbles, ".")
+}
+
+// isExempt checks if the client is exempt from screening by IP or listener
+func (sc *Screener) isExempt(client *Client) bool {
+	cfg := sc.server.GetConfig().DNSBL
+	for _, name := range cfg.ExemptListeners {
+		if strings.EqualFold(name, client.Listener) {
+			return true
+		}
+	}
+
+	return net.ParseIP(client.IP) == nil || ipInList(client.IP, cfg.Exempt)
+}
+
+// Screen scores the client's IP and returns the reasons it was listed for
+// when the total score reaches the configured threshold
+func (sc *Screener) Screen(client *Client) ([]string, bool) {
+	if sc == nil || sc.isExempt(client) {
+		return nil, false
+	}
+	cfg := sc.server.GetConfig().DNSBL
+	reversed := reverseIP(client.IP)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsblTimeout)
+	defer cancel()
+
+	type result struct {
+		zone   string
+		score  int
+		reason string
+	}
+	results := make(chan result, len(cfg.Lists))
+	for _, list := range cfg.Lists {
+		zone, score, reason := list.Zone, list.Score, list.Reason
+		if score <= 0 {
+			score = 1
+		}
+		if reason == "" {
+			reason = "Listed in " + zone
+		}
+		go func() {
+			if sc.listed(ctx, reversed+"."+zone) {
+				results <- result{zone, score, reason}
+			} else {
+				results <- result{}
+			}
+		}()
+	}
+
+	var hits []result
+	for range cfg.Lists {
+		if r := <-results; r.zone != "" {
+			hits = append(hits, r)
+		}
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.torExits[client.IP] {
+		score := cfg.TorScore
+		if score <= 0 {
+			score = 1
+		}
+		hits = append(hits, result{torListName, score, "Tor exit node"})
+	}
+
+	sc.checked++
+	total := 0
+	reasons := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		sc.hits[hit.zone]++
+		total += hit.score
+		reasons = append(reasons, hit.reason)
+	}
+
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if total < threshold {
+		return nil, false
+	}
+	sc.rejected++
+	return reasons, true
+}
+
+// listed checks whether a blocklist query name resolves to a 127.0.0.0/8
+// listing address
+func (sc *Screener) listed(ctx context.Context, name string) bool {
+	addrs, err := sc.lookupHost(ctx, name)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ip := net.ParseIP(addr).To4(); ip != nil && ip[0] == 127 {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats returns a snapshot of the screener counters
+func (sc *Screener) Stats() ScreenStats {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	hits := make(map[string]int, len(sc.hits))
+	for zone, count := range sc.hits {
+		hits[zone] = count
+	}
+	return ScreenStats{Checked: sc.checked, Rejected: sc.rejected, Hits: hits}
+}
+
+// SetTorExits replaces the set of known Tor exit node IPs
+func (sc *Screener) SetTorExits(ips []string) {
+	exits := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		exits[ip] = true
+	}
+	sc.mu.Lock()
+	sc.torExits = exits
+	sc.mu.Unlock()
+}
+
+// loadTorExits reads the Tor exit list from a URL or file. Blank lines and
+// lines starting with '#' are ignored.
+func loadTorExits(source string) ([]string, error) {
+	reader, err := openSource(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Tor exit list: %v", err)
+	}
+	defer reader.Close()
+
+	var ips []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if net.ParseIP(line) != nil {
+			ips = append(ips, line)
+		}
+	}
+	return ips, scanner.Err()
+}
+
+// refreshTorExits periodically reloads the Tor exit list until the server stops
+func (sc *Screener) refreshTorExits() {
+	cfg := sc.server.GetConfig().DNSBL
+	interval := time.Duration(cfg.TorRefresh) * time.Second
+	if interval <= 0 {
+		interval = defaultTorRefresh
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if ips, err := loadTorExits(cfg.TorExitList); err != nil {
+			sc.server.Logger(LogConnections).Warn("Failed to load Tor exit list", "source", cfg.TorExitList, "error", err)
+		} else {
+			sc.SetTorExits(ips)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-sc.server.quit:
+			return
+		}
+	}
+}
+
+// rejectIfListed disconnects the client if the screener rejects its IP
+func (s *Server) rejectIfListed(client *Client) bool {
+	reasons, rejected := s.screener.Screen(client)
+	if !rejected {
+		return false
+	}
+
+	reason := strings.Join(reasons, ", ")
+	s.Logger(LogConnections).Warn("Connection rejected by blocklist", "ip", client.IP, "reason", reason)
+	s.SendServerNotice(SnomaskConnect, fmt.Sprintf("Rejected connection from %s: %s", client.IP, reason))
+	client.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** Your IP address %s is blocked: %s", s.GetConfig().Server.Name, client.IP, reason))
+	client.SendRaw(fmt.Sprintf("ERROR :Closing Link: %s (Blocked: %s)", client.IP, reason))
+	client.Quit("Blocked")
+	return true
+}
+
+// sendScreenStats answers STATS B with the screener counters
+func (s *Server) sendScreenStats(client *Client) {
+	if s.screener == nil {
+		return
+	}
+	stats := s.screener.Stats()
+	client.SendReply(irc.RPL_STATSDEBUG, "B", "checked", strconv.Itoa(stats.Checked), "rejected", strconv.Itoa(stats.Rejected))
+
+	zones := make([]string, 0, len(stats.Hits))
+	for zone := range stats.Hits {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	for _, zone := range zones {
+		client.SendReply(irc.RPL_STATSDEBUG, "B", zone, strconv.Itoa(stats.Hits[zone]))
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseIP(t *testing.T) {
+	assert.Equal(t, "4.2.0.192", reverseIP("192.0.2.4"))
+	assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2", reverseIP("2001:db8::1"))
+	assert.Equal(t, "", reverseIP("not-an-ip"))
+}
+
+func TestScreen(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.DNSBL.Enabled = true
+	cfg.DNSBL.Threshold = 2
+	cfg.DNSBL.Lists = make([]struct {
+		Zone   string `yaml:"zone" toml:"zone" json:"zone"`
+		Score  int    `yaml:"score" toml:"score" json:"score"`
+		Reason string `yaml:"reason" toml:"reason" json:"reason"`
+	}, 2)
+	cfg.DNSBL.Lists[0].Zone = "bl.example"
+	cfg.DNSBL.Lists[0].Reason = "Open proxy"
+	cfg.DNSBL.Lists[1].Zone = "other.example"
+	cfg.DNSBL.Exempt = []string{"198.51.100.0/24"}
+	cfg.DNSBL.ExemptListeners = []string{ListenerTLS}
+	srv := newTestServer(t, cfg)
+
+	listed := map[string]bool{"4.2.0.192.bl.example": true, "5.2.0.192.bl.example": true, "5.2.0.192.other.example": true}
+	srv.screener.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		if listed[host] {
+			return []string{"127.0.0.2"}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	// One list alone stays below the threshold
+	_, rejected := srv.screener.Screen(&Client{IP: "192.0.2.4", Listener: ListenerIRC})
+	assert.False(t, rejected)
+
+	// Two lists together reach it
+	reasons, rejected := srv.screener.Screen(&Client{IP: "192.0.2.5", Listener: ListenerIRC})
+	assert.True(t, rejected)
+	assert.ElementsMatch(t, []string{"Open proxy", "Listed in other.example"}, reasons)
+
+	// Tor exits add to the score
+	srv.screener.SetTorExits([]string{"192.0.2.4"})
+	reasons, rejected = srv.screener.Screen(&Client{IP: "192.0.2.4", Listener: ListenerIRC})
+	assert.True(t, rejected)
+	assert.ElementsMatch(t, []string{"Open proxy", "Tor exit node"}, reasons)
+
+	// Exempt ranges and listeners are never screened
+	srv.screener.SetTorExits([]string{"198.51.100.7", "192.0.2.5"})
+	_, rejected = srv.screener.Screen(&Client{IP: "198.51.100.7", Listener: ListenerIRC})
+	assert.False(t, rejected)
+	_, rejected = srv.screener.Screen(&Client{IP: "192.0.2.5", Listener: ListenerTLS})
+	assert.False(t, rejected)
+
+	stats := srv.screener.Stats()
+	assert.Equal(t, 3, stats.Checked)
+	assert.Equal(t, 2, stats.Rejected)
+	assert.Equal(t, map[string]int{"bl.example": 3, "other.example": 1, "tor": 1}, stats.Hits)
+
+	// Operators can read the counters with STATS B
+	alice := srv.register(t, "alice")
+	alice.send("STATS B")
+	alice.expect(" 481 ")
+	srv.oper(t, alice, "alice")
+	alice.send("STATS B")
+	assert.Equal(t, ":test.irc.local 249 alice B checked 3 rejected 2", alice.expect(" 249 "))
+	assert.Equal(t, ":test.irc.local 249 alice B bl.example 3", alice.expect(" 249 "))
+}
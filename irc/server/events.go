@@ -0,0 +1,88 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types pushed to live dashboards
+const (
+	EventConnect = "connect" // A client completed registration
+	EventQuit    = "quit"    // A registered client disconnected, including kills
+	EventNick    = "nick"    // A client changed nickname
+	EventJoin    = "join"    // A client was added to a channel
+	EventPart    = "part"    // A client left a channel by PART, KICK or disconnecting
+	EventTopic   = "topic"   // A channel topic was changed
+	EventBan     = "ban"     // A K-line or G-line was added or removed
+)
+
+// eventBuffer is the number of events queued per subscriber before it is
+// considered lagged and cut off
+const eventBuffer = 256
+
+// Event is a change of server state
+type Event struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// eventHub fans events out to subscribers without ever blocking the server
+type eventHub struct {
+	subscribers map[chan Event]struct{}
+	lagged      atomic.Uint64 // Subscribers cut off for falling behind
+	mu          sync.Mutex
+}
+
+// newEventHub creates a hub without subscribers
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[chan Event]struct{})}
+}
+
+// SubscribeEvents returns a channel receiving server events and a function
+// that ends the subscription. A subscriber that falls behind has its channel
+// closed instead of silently missing events, and must subscribe again and
+// reload the state it tracks.
+func (s *Server) SubscribeEvents() (<-chan Event, func()) {
+	ch := make(chan Event, eventBuffer)
+	s.events.mu.Lock()
+	s.events.subscribers[ch] = struct{}{}
+	s.events.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.events.mu.Lock()
+			delete(s.events.subscribers, ch)
+			s.events.mu.Unlock()
+		})
+	}
+}
+
+// publish sends an event to every subscriber
+func (s *Server) publish(eventType string, data map[string]interface{}) {
+	s.events.mu.Lock()
+	if len(s.events.subscribers) == 0 {
+		s.events.mu.Unlock()
+		return
+	}
+
+	event := Event{Type: eventType, Time: s.Now().UTC(), Data: data}
+	lagged := 0
+	for ch := range s.events.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(s.events.subscribers, ch)
+			close(ch)
+			lagged++
+		}
+	}
+	s.events.mu.Unlock()
+
+	if lagged > 0 {
+		s.events.lagged.Add(uint64(lagged))
+		s.Logger(LogHTTP).Warn("Closed lagged event subscribers", "subscribers", lagged, "event", eventType)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Flood protection defaults used when a setting is not configured
+const (
+	defaultCommandRate  = 5
+	defaultCommandBurst = 20
+	defaultMessageRate  = 2
+	defaultMessageBurst = 10
+	defaultMaxLag       = 10
+)
+
+// tokenBucket is a rate limiter that allows bursts of up to burst tokens and
+// refills rate tokens per second. Tokens may go negative, which is the
+// fakelag owed by the client.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// take spends one token and returns how long the caller must wait before the
+// token is actually available
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// floodLimits returns the configured flood settings with defaults applied
+func (s *Server) floodLimits() (commandRate, commandBurst, messageRate, messageBurst int, maxLag time.Duration) {
+	cfg := s.GetConfig().Flood
+	commandRate, commandBurst = cfg.CommandRate, cfg.CommandBurst
+	messageRate, messageBurst = cfg.MessageRate, cfg.MessageBurst
+	if commandRate <= 0 {
+		commandRate = defaultCommandRate
+	}
+	if commandBurst <= 0 {
+		commandBurst = defaultCommandBurst
+	}
+	if messageRate <= 0 {
+		messageRate = defaultMessageRate
+	}
+	if messageBurst <= 0 {
+		messageBurst = defaultMessageBurst
+	}
+	maxLag = time.Duration(cfg.MaxLag) * time.Second
+	if cfg.MaxLag <= 0 {
+		maxLag = defaultMaxLag * time.Second
+	}
+	return
+}
+
+// throttle applies flood protection to a message read from the client. It
+// delays the client while it owes fakelag and reports false once the client
+// has been disconnected for staying fakelagged longer than the maximum lag.
+func (c *Client) throttle(msg *irc.Message) bool {
+	if !c.Server.GetConfig().Flood.Enabled || c.IsOper {
+		return true
+	}
+
+	commandRate, commandBurst, messageRate, messageBurst, maxLag := c.Server.floodLimits()
+	now := c.Server.Now()
+	if c.commandBucket == nil {
+		c.commandBucket = newTokenBucket(commandRate, commandBurst, now)
+		c.messageBucket = newTokenBucket(messageRate, messageBurst, now)
+	}
+
+	lag := c.commandBucket.take(now)
+	if msg.Command == "PRIVMSG" || msg.Command == "NOTICE" || msg.Command == "TAGMSG" {
+		if messageLag := c.messageBucket.take(now); messageLag > lag {
+			lag = messageLag
+		}
+	}
+
+	if lag <= 0 {
+		c.floodingSince = time.Time{}
+		return true
+	}
+
+	if c.floodingSince.IsZero() {
+		c.floodingSince = now
+	}
+	if lag > maxLag || now.Sub(c.floodingSince) > maxLag {
+		c.excessFlood()
+		return false
+	}
+	time.Sleep(lag)
+	return true
+}
+
+// excessFlood disconnects a client that kept flooding past the fakelag limit,
+// K-lining its IP when flood.kline_duration is set
+func (c *Client) excessFlood() {
+	s := c.Server
+	s.Logger(LogConnections).Warn("Excess flood", "nick", c.Nickname, "ip", c.IP)
+	s.SendServerNotice(SnomaskFlood, fmt.Sprintf("Excess flood from %s (%s@%s) [%s]", c.Nickname, c.Username, c.RealHost(), c.IP))
+
+	if seconds := s.GetConfig().Flood.KLineDuration; seconds > 0 && c.IP != "" {
+		s.AddBan(BanTypeKLine, "*@"+c.IP, s.GetConfig().Server.Name, "Excess flood", time.Duration(seconds)*time.Second)
+	}
+
+	c.SendRaw(fmt.Sprintf("ERROR :Closing Link: %s (Excess Flood)", c.Hostname))
+	c.Quit("Excess Flood")
+}
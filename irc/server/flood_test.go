@@ -0,0 +1,74 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(2, 2, now)
+
+	assert.Zero(t, bucket.take(now))
+	assert.Zero(t, bucket.take(now))
+	assert.Equal(t, 500*time.Millisecond, bucket.take(now))
+	assert.Equal(t, time.Second, bucket.take(now))
+
+	// Refills are capped at the burst size
+	now = now.Add(time.Minute)
+	assert.Zero(t, bucket.take(now))
+	assert.Zero(t, bucket.take(now))
+	assert.Equal(t, 500*time.Millisecond, bucket.take(now))
+}
+
+func TestFloodFakelag(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Flood.Enabled = true
+	cfg.Flood.MessageRate = 20
+	cfg.Flood.MessageBurst = 2
+	srv := newTestServer(t, cfg)
+
+	alice := srv.register(t, "alice")
+	bob := srv.register(t, "bob")
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		alice.send("PRIVMSG bob :spam")
+	}
+	for i := 0; i < 6; i++ {
+		bob.expect(" PRIVMSG ")
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond, "messages past the burst are delayed")
+}
+
+func TestFloodExcessKLine(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Flood.Enabled = true
+	cfg.Flood.MessageRate = 2
+	cfg.Flood.MessageBurst = 1
+	cfg.Flood.MaxLag = 1
+	cfg.Flood.KLineDuration = 60
+	cfg.Hostnames.DisableDNS = true
+	srv := newTestServer(t, cfg)
+
+	oper := srv.register(t, "oper")
+	srv.oper(t, oper, "oper")
+	oper.drain()
+
+	// alice connects over TCP so that the K-line has an IP to match
+	alice := srv.registerTCP(t, "alice")
+
+	// Flooding for longer than max_lag disconnects the client
+	for i := 0; i < 4; i++ {
+		alice.send("PRIVMSG oper :spam")
+	}
+	alice.expect("ERROR :Closing Link")
+	assert.Contains(t, oper.expect("FLOOD"), "Excess flood from alice")
+
+	bans := srv.GetBans(BanTypeKLine)
+	require.Len(t, bans, 1)
+	assert.Equal(t, "*@127.0.0.1", bans[0].Mask)
+}
@@ -21,6 +21,18 @@ func handleNick(params *HookParams) error {
 
 	newNick := message.Params[0]
 
+	// Check the nickname length
+	if len(newNick) > client.Server.limits().NickLen {
+		client.SendError(irc.ERR_ERRONEUSNICKNAME, newNick, "Erroneous nickname")
+		return nil
+	}
+
+	// Service names are reserved while services are running
+	if client.Server.services != nil && isServiceName(newNick) {
+		client.SendError(irc.ERR_ERRONEUSNICKNAME, newNick, "Nickname is reserved for services")
+		return nil
+	}
+
 	// Check if the nickname is already in use
 	existingClient := client.Server.GetClient(newNick)
 	if existingClient != nil && existingClient.ID != client.ID {
@@ -28,30 +40,20 @@ func handleNick(params *HookParams) error {
 		return nil
 	}
 
-	// Acquire the write lock before modifying client fields
-	client.mu.Lock()
-
-	// Store the old nickname for notifications
-	oldNick := client.Nickname
+	client.mu.RLock()
 	wasRegistered := client.Registered
-
-	// Update the client's nickname
-	client.Nickname = newNick
-
-	// Release the lock
-	client.mu.Unlock()
+	client.mu.RUnlock()
 
 	// If the client wasn't registered before, check if they are now
-	if !wasRegistered && client.Username != "" {
+	if !wasRegistered {
 		client.mu.Lock()
-		client.Registered = true
+		client.Nickname = newNick
 		client.mu.Unlock()
-		client.SendWelcome()
-	} else if wasRegistered {
-		// Notify all channels the client is in about the nick change
-		for _, channel := range client.Channels {
-			channel.SendToAll(fmt.Sprintf(":%s!%s@%s NICK %s", oldNick, client.Username, client.Hostname, newNick), nil)
-		}
+		completeRegistration(client)
+	} else {
+		// Notify the client and its channels about the nick change
+		client.changeNick(newNick)
+		client.Server.services.checkNick(client)
 	}
 
 	return nil
@@ -75,31 +77,51 @@ func handleUser(params *HookParams) error {
 	}
 
 	// Update the client's user information
-	client.Username = message.Params[0]
+	username := client.username(message.Params[0])
+	client.mu.Lock()
+	client.Username = username
 	client.Realname = message.Params[3]
+	client.mu.Unlock()
 
 	// Check if the client is now registered
-	if client.Nickname != "" {
-		// Check if server password is required but not provided
-		serverPassword := client.Server.GetConfig().ListenIRC.Password
-		if serverPassword != "" {
-			client.mu.RLock()
-			passwordProvided := client.PasswordProvided
-			client.mu.RUnlock()
-
-			if !passwordProvided {
-				client.SendError(irc.ERR_PASSWDMISMATCH, "Password required")
-				return nil
-			}
-		}
+	completeRegistration(client)
 
-		client.mu.Lock()
-		client.Registered = true
-		client.mu.Unlock()
-		client.SendWelcome()
+	return nil
+}
+
+// completeRegistration registers the client once NICK and USER have been
+// received and any capability negotiation has ended
+func completeRegistration(client *Client) {
+	client.mu.RLock()
+	ready := !client.Registered && !client.capNegotiating && client.Nickname != "" && client.Username != ""
+	passwordProvided := client.PasswordProvided
+	client.mu.RUnlock()
+
+	if !ready {
+		return
 	}
 
-	return nil
+	// Check if server password is required but not provided
+	if client.Server.GetConfig().ListenIRC.Password != "" && !passwordProvided {
+		client.SendError(irc.ERR_PASSWDMISMATCH, "Password required")
+		return
+	}
+
+	if client.Server.rejectIfBanned(client) {
+		return
+	}
+
+	client.mu.Lock()
+	client.Registered = true
+	client.mu.Unlock()
+	client.SendWelcome()
+	client.Server.metrics.clientRegistered()
+	client.Server.applyVHost(client)
+	client.Server.services.checkNick(client)
+	client.Server.Logger(LogConnections).Info("Client registered", "id", client.ID, "nick", client.Nickname, "user", client.Username, "host", client.RealHost(), "ip", client.IP)
+	client.Server.SendServerNotice(SnomaskConnect, fmt.Sprintf("Client connecting: %s (%s@%s) [%s]", client.Nickname, client.Username, client.RealHost(), client.IP))
+	client.Server.checkClones(client)
+	client.Server.publish(EventConnect, map[string]interface{}{"nickname": client.Nickname, "username": client.Username, "hostname": client.Hostname, "ip": client.IP})
 }
 
 // handleJoin handles the JOIN command
@@ -123,12 +145,17 @@ func handleJoin(params *HookParams) error {
 	}
 
 	// Join each channel
+	limits := client.Server.limits()
 	for i, channelName := range channels {
 		// Validate channel name
 		if !strings.HasPrefix(channelName, "#") {
 			client.SendError(irc.ERR_NOSUCHCHANNEL, channelName, "No such channel")
 			continue
 		}
+		if len(channelName) > limits.ChannelLen {
+			client.SendError(irc.ERR_BADCHANMASK, channelName, "Bad Channel Mask")
+			continue
+		}
 
 		// Get the channel key, if any
 		var key string
@@ -136,14 +163,24 @@ func handleJoin(params *HookParams) error {
 			key = keys[i]
 		}
 
+		// Check the number of channels the client is in
+		client.mu.RLock()
+		_, joined := client.Channels[client.Server.Fold(channelName)]
+		count := len(client.Channels)
+		client.mu.RUnlock()
+		if !joined && count >= limits.MaxChannels {
+			client.SendError(irc.ERR_TOOMANYCHANNELS, channelName, "You have joined too many channels")
+			continue
+		}
+
 		// Get or create the channel
 		channel := client.Server.GetChannel(channelName)
 		if channel == nil {
 			channel = client.Server.CreateChannel(channelName)
 			// First user to join a new channel becomes an operator and owner
 			channel.mu.Lock()
-			channel.Operators[client.Nickname] = true
-			channel.Owners[client.Nickname] = true
+			channel.Operators[channel.key(client.Nickname)] = true
+			channel.Owners[channel.key(client.Nickname)] = true
 			channel.mu.Unlock()
 		}
 
@@ -154,7 +191,7 @@ func handleJoin(params *HookParams) error {
 		}
 
 		// Check if the channel is invite-only
-		if channel.Modes.InviteOnly && !channel.IsInvited(client) {
+		if channel.Modes.InviteOnly && !channel.IsInvited(client) && !channel.IsInviteExempt(client) {
 			client.SendError(irc.ERR_INVITEONLYCHAN, channelName, "Cannot join channel (+i) - you must be invited")
 			continue
 		}
@@ -171,8 +208,10 @@ func handleJoin(params *HookParams) error {
 			continue
 		}
 
-		// Join the channel
+		// Join the channel, opping registered founders
 		client.JoinChannel(channelName)
+		client.Server.services.onJoin(client, channel)
+		client.Server.playbackHistory(client, channel)
 	}
 
 	return nil
@@ -268,6 +307,11 @@ func handlePrivmsg(params *HookParams) error {
 	target := message.Params[0]
 	text := message.Params[1]
 
+	// Messages to NickServ and ChanServ are handled by the services
+	if client.Server.services.handleMessage(client, target, text) {
+		return nil
+	}
+
 	// Check if the target is a channel
 	if strings.HasPrefix(target, "#") {
 		// Get the channel
@@ -281,6 +325,8 @@ func handlePrivmsg(params *HookParams) error {
 		if !channel.CanSendToChannel(client) {
 			if !channel.IsMember(client) && channel.Modes.NoExternalMsgs {
 				client.SendError(irc.ERR_CANNOTSENDTOCHAN, target, "Cannot send to channel")
+			} else if channel.IsQuieted(client) {
+				client.SendError(irc.ERR_CANNOTSENDTOCHAN, target, "Cannot send to channel (+b)")
 			} else if channel.Modes.Moderated {
 				client.SendError(irc.ERR_CANNOTSENDTOCHAN, target, "Cannot send to channel (+m)")
 			} else {
@@ -289,8 +335,9 @@ func handlePrivmsg(params *HookParams) error {
 			return nil
 		}
 
-		// Send the message to the channel
-		channel.SendToAll(fmt.Sprintf(":%s!%s@%s PRIVMSG %s :%s", client.Nickname, client.Username, client.Hostname, target, text), client)
+		// Send the message to the channel, relaying client tags to capable members
+		channel.SendTaggedToAll(message.ClientTags(), fmt.Sprintf(":%s!%s@%s PRIVMSG %s :%s", client.Nickname, client.Username, client.Hostname, channel.Name, text), client)
+		client.Server.recordHistory(client, "PRIVMSG", channel.Name, text, message.ClientTags())
 	} else {
 		// Get the target client
 		targetClient := client.Server.GetClient(target)
@@ -299,13 +346,53 @@ func handlePrivmsg(params *HookParams) error {
 			return nil
 		}
 
-		// Send the message to the target client
-		targetClient.SendPrivmsg(client, text)
+		// Send the message to the target client, relaying client tags if it is capable
+		targetClient.SendTagged(message.ClientTags(), fmt.Sprintf(":%s!%s@%s PRIVMSG %s :%s", client.Nickname, client.Username, client.Hostname, targetClient.Nickname, text))
+
+		// Let the sender know the target is away
+		targetClient.mu.RLock()
+		away, awayMessage := targetClient.Away, targetClient.AwayMessage
+		targetClient.mu.RUnlock()
+		if away {
+			client.SendReply(irc.RPL_AWAY, targetClient.Nickname, awayMessage)
+		}
 	}
 
 	return nil
 }
 
+// handleNotice handles the NOTICE command. Unlike PRIVMSG, delivery failures
+// are never answered with an error.
+func handleNotice(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 2 {
+		return nil
+	}
+
+	target := message.Params[0]
+	text := message.Params[1]
+
+	if strings.HasPrefix(target, "#") {
+		channel := client.Server.GetChannel(target)
+		if channel == nil || !channel.CanSendToChannel(client) {
+			return nil
+		}
+		channel.SendTaggedToAll(message.ClientTags(), fmt.Sprintf(":%s!%s@%s NOTICE %s :%s", client.Nickname, client.Username, client.Hostname, channel.Name, text), client)
+		client.Server.recordHistory(client, "NOTICE", channel.Name, text, message.ClientTags())
+		return nil
+	}
+
+	targetClient := client.Server.GetClient(target)
+	if targetClient == nil {
+		return nil
+	}
+	targetClient.SendTagged(message.ClientTags(), fmt.Sprintf(":%s!%s@%s NOTICE %s :%s", client.Nickname, client.Username, client.Hostname, targetClient.Nickname, text))
+
+	return nil
+}
+
 // handleQuit handles the QUIT command
 func handleQuit(params *HookParams) error {
 	client := params.Client
@@ -365,18 +452,24 @@ func handleChannelMode(params *HookParams) error {
 		return nil
 	}
 
-	// Check if the client has permission to change channel modes
-	if !channel.CanChangeChannelModes(client) {
+	// Anyone may view the ban and exception lists; other changes need
+	// channel operator status
+	listQuery := len(message.Params) == 2 && strings.Trim(message.Params[1], "+beI") == ""
+	if !listQuery && !channel.CanChangeChannelModes(client) {
 		client.SendError(irc.ERR_CHANOPRIVSNEEDED, channelName, "You're not a channel operator")
 		return nil
 	}
 
-	// Parse the mode string
+	// Parse the mode string. Modes following the MODES-th parameter are ignored.
 	modeStr := message.Params[1]
 	modeSet := true
 	paramIndex := 2
+	maxParamIndex := paramIndex + client.Server.limits().MaxModes
 
 	for _, mode := range modeStr {
+		if paramIndex >= maxParamIndex {
+			break
+		}
 		if mode == '+' {
 			modeSet = true
 			continue
@@ -388,29 +481,13 @@ func handleChannelMode(params *HookParams) error {
 
 		// Process the mode
 		switch mode {
-		case 'b': // Ban list
+		case ListBan, ListBanException, ListInviteException: // Ban and exception lists
 			if len(message.Params) <= paramIndex {
-				// Show the ban list
-				for _, ban := range channel.BanList {
-					client.SendReply(irc.RPL_BANLIST, channelName, ban, "", "0")
-				}
-				client.SendReply(irc.RPL_ENDOFBANLIST, channelName, "End of channel ban list")
+				channel.sendMaskList(client, mode)
 				continue
 			}
-			mask := message.Params[paramIndex]
+			channel.changeMask(client, mode, modeSet, message.Params[paramIndex])
 			paramIndex++
-			if modeSet {
-				channel.AddBan(mask, client.Nickname)
-			} else {
-				channel.RemoveBan(mask)
-			}
-			channel.SendToAll(fmt.Sprintf(":%s!%s@%s MODE %s %c%c %s", client.Nickname, client.Username, client.Hostname, channelName, func() byte {
-				if modeSet {
-					return '+'
-				} else {
-					return '-'
-				}
-			}(), mode, mask), nil)
 		case 'k': // Channel key
 			if modeSet {
 				if len(message.Params) <= paramIndex {
@@ -476,6 +553,7 @@ func handleUserMode(params *HookParams) error {
 	// Parse the mode string
 	modeStr := message.Params[1]
 	modeSet := true
+	paramIndex := 2
 
 	for _, mode := range modeStr {
 		if mode == '+' {
@@ -495,6 +573,24 @@ func handleUserMode(params *HookParams) error {
 				continue
 			}
 			client.SetMode(string(mode), false)
+		case 's': // Server notices, with an optional snomask parameter
+			if !modeSet {
+				client.ClearSnomask()
+				client.SetMode("s", false)
+				continue
+			}
+			// Only operators can receive server notices
+			if !client.IsOper {
+				continue
+			}
+			if len(message.Params) > paramIndex {
+				client.SetSnomask(message.Params[paramIndex])
+				paramIndex++
+			} else if client.GetSnomaskString() == "+" {
+				client.SetSnomask(DefaultOperSnomask)
+			}
+			client.SetMode("s", true)
+			client.SendSnomask()
 		default:
 			// Handle other modes
 			client.SetMode(string(mode), modeSet)
@@ -524,7 +620,7 @@ func handlePing(params *HookParams) error {
 // handlePong handles the PONG command
 func handlePong(params *HookParams) error {
 	// Just update the client's last ping time
-	params.Client.LastPing = time.Now()
+	params.Client.touch()
 	return nil
 }
 
@@ -610,8 +706,17 @@ func handleWhois(params *HookParams) error {
 		client.SendReply(irc.RPL_WHOISOPERATOR, targetClient.Nickname, "is an IRC Operator")
 	}
 
+	// Operators can see the actual host and IP behind any cloak
+	if client.IsOper {
+		ip := targetClient.IP
+		if ip == "" {
+			ip = targetClient.RealHost()
+		}
+		client.SendReply(irc.RPL_WHOISACTUALLY, targetClient.Nickname, targetClient.Username+"@"+targetClient.RealHost(), ip, "Actually using host")
+	}
+
 	// Send idle time
-	client.SendReply(irc.RPL_WHOISIDLE, targetClient.Nickname, fmt.Sprintf("%d", int(time.Since(targetClient.LastPing).Seconds())), "seconds idle")
+	client.SendReply(irc.RPL_WHOISIDLE, targetClient.Nickname, fmt.Sprintf("%d", int(client.Server.Since(targetClient.lastActive()).Seconds())), "seconds idle")
 
 	// End of WHOIS
 	client.SendReply(irc.RPL_ENDOFWHOIS, targetClient.Nickname, "End of WHOIS list")
@@ -638,10 +743,9 @@ func handleList(params *HookParams) error {
 		}
 	} else {
 		// List all channels
-		client.Server.channels.Range(func(key, value interface{}) bool {
-			channelName := key.(string)
+		client.Server.channels.Range(func(_, value interface{}) bool {
 			channel := value.(*Channel)
-			client.SendReply(irc.RPL_LIST, channelName, fmt.Sprintf("%d", channel.MemberCount()), channel.Topic)
+			client.SendReply(irc.RPL_LIST, channel.Name, fmt.Sprintf("%d", channel.MemberCount()), channel.Topic)
 			return true // Continue iteration
 		})
 	}
@@ -722,7 +826,7 @@ func handleTopic(params *HookParams) error {
 	}
 
 	// Set the topic
-	topic := message.Params[1]
+	topic := truncate(message.Params[1], client.Server.limits().TopicLen)
 	channel.SetTopic(topic, client.Nickname)
 
 	// Notify all members
@@ -747,7 +851,7 @@ func handleKick(params *HookParams) error {
 
 	reason := "No reason given"
 	if len(message.Params) > 2 {
-		reason = message.Params[2]
+		reason = truncate(message.Params[2], client.Server.limits().KickLen)
 	}
 
 	// Get the channel
@@ -820,7 +924,7 @@ func handleInvite(params *HookParams) error {
 	}
 
 	// Check if the channel is invite-only and the client is not an operator
-	if channel.Modes.InviteOnly && !client.IsOper {
+	if channel.Modes.InviteOnly && !channel.CanChangeChannelModes(client) {
 		client.SendError(irc.ERR_CHANOPRIVSNEEDED, channelName, "You're not a channel operator")
 		return nil
 	}
@@ -871,8 +975,22 @@ func handleOper(params *HookParams) error {
 		return nil
 	}
 
-	// Set the client as an operator
+	// Set the client as an operator and subscribe it to server notices
 	client.SetOper(true)
+	client.SetSnomask(DefaultOperSnomask)
+	client.SetMode("s", true)
+	client.SendSnomask()
+
+	// Log the client in to the operator's account and apply any vhost
+	client.mu.RLock()
+	loggedIn := client.Account != ""
+	client.mu.RUnlock()
+	if !loggedIn {
+		client.SetAccount(operator.Username)
+	}
+	client.Server.applyVHost(client)
+
+	client.Server.SendServerNotice(SnomaskOper, fmt.Sprintf("%s (%s@%s) is now an IRC operator", client.Nickname, client.Username, client.RealHost()))
 
 	return nil
 }
@@ -904,6 +1022,8 @@ func handleKill(params *HookParams) error {
 		return nil
 	}
 
+	client.Server.SendServerNotice(SnomaskKill, fmt.Sprintf("Received KILL message for %s!%s@%s from %s: %s", targetClient.Nickname, targetClient.Username, targetClient.Hostname, client.Nickname, reason))
+
 	// Kill the target
 	// First send the kill message to the target
 	killMessage := fmt.Sprintf("Killed by %s: %s", client.Nickname, reason)
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/irc/config"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a Clock that only moves when advanced
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// newTestConfig returns a minimal configuration with no listeners started
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.Name = "test.irc.local"
+	cfg.Server.Network = "TestNet"
+	cfg.Logging.Level = "error"
+	return cfg
+}
+
+// newTestServer creates a server that is driven through in-memory connections
+func newTestServer(t *testing.T, cfg *config.Config, opts ...Option) *Server {
+	t.Helper()
+	if cfg == nil {
+		cfg = newTestConfig()
+	}
+	srv, err := NewServer(cfg, opts...)
+	require.NoError(t, err)
+	return srv
+}
+
+// updateConfig changes the configuration of a running server by swapping in
+// a modified copy, as Rehash does, so connections never see a partial update
+func (s *Server) updateConfig(update func(cfg *config.Config)) {
+	cfg := *s.GetConfig()
+	update(&cfg)
+	s.config.Store(&cfg)
+}
+
+// testClient is one end of an in-memory connection to a test server
+type testClient struct {
+	t     *testing.T
+	conn  net.Conn
+	lines chan string
+}
+
+// connect attaches a new in-memory client to the server. Lines sent by the
+// server are read continuously so that broadcasts never block.
+func (s *Server) connect(t *testing.T) *testClient {
+	t.Helper()
+	local, remote := net.Pipe()
+	return s.attach(t, local, remote)
+}
+
+// attach serves remote and returns a test client reading from local
+func (s *Server) attach(t *testing.T, local, remote net.Conn) *testClient {
+	t.Helper()
+	tc := &testClient{
+		t:     t,
+		conn:  local,
+		lines: make(chan string, 1024),
+	}
+
+	go func() {
+		defer close(tc.lines)
+		reader := bufio.NewReader(local)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tc.lines <- strings.TrimRight(line, "\r\n")
+		}
+	}()
+
+	go s.handleConnection(remote)
+	t.Cleanup(func() { local.Close() })
+	return tc
+}
+
+// register connects a client and completes NICK/USER registration
+func (s *Server) register(t *testing.T, nick string) *testClient {
+	t.Helper()
+	tc := s.connect(t)
+	tc.send("NICK " + nick)
+	tc.send("USER " + nick + " 0 * :Test " + nick)
+	tc.expect(" 376 ")
+	return tc
+}
+
+// send writes a raw line to the server
+func (tc *testClient) send(line string) {
+	tc.t.Helper()
+	_, err := tc.conn.Write([]byte(line + "\r\n"))
+	require.NoError(tc.t, err)
+}
+
+// expect returns the first line containing substr, failing after a timeout
+func (tc *testClient) expect(substr string) string {
+	tc.t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-tc.lines:
+			if !ok {
+				tc.t.Fatalf("connection closed while waiting for %q", substr)
+			}
+			if strings.Contains(line, substr) {
+				return line
+			}
+		case <-timeout:
+			tc.t.Fatalf("timed out waiting for %q", substr)
+		}
+	}
+}
+
+// collect returns all lines up to and including the first containing substr
+func (tc *testClient) collect(substr string) []string {
+	tc.t.Helper()
+	var lines []string
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case line, ok := <-tc.lines:
+			if !ok {
+				tc.t.Fatalf("connection closed while waiting for %q", substr)
+			}
+			lines = append(lines, line)
+			if strings.Contains(line, substr) {
+				return lines
+			}
+		case <-timeout:
+			tc.t.Fatalf("timed out waiting for %q", substr)
+		}
+	}
+}
+
+// drain discards any lines already received
+func (tc *testClient) drain() {
+	for {
+		select {
+		case <-tc.lines:
+		case <-time.After(50 * time.Millisecond):
+			return
+		}
+	}
+}
+
+// oper registers an operator account for nick and opers the client up
+func (s *Server) oper(t *testing.T, tc *testClient, nick string) {
+	t.Helper()
+	s.operators.Store(nick, &Operator{Username: nick, Password: "secret"})
+	tc.send("OPER " + nick + " secret")
+	tc.expect(" 381 ")
+}
+
+// registerWithCaps registers a client after requesting the given capabilities
+func (s *Server) registerWithCaps(t *testing.T, nick string, caps ...string) *testClient {
+	t.Helper()
+	tc := s.connect(t)
+	tc.send("CAP LS 302")
+	tc.send("CAP REQ :" + strings.Join(caps, " "))
+	tc.expect(" ACK ")
+	tc.send("NICK " + nick)
+	tc.send("USER " + nick + " 0 * :Test " + nick)
+	tc.send("CAP END")
+	tc.expect(" 376 ")
+	return tc
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/presbrey/pkg/irc"
+)
+
+const (
+	// defaultHistoryLimit is the number of messages kept per channel when
+	// history.limit is not configured
+	defaultHistoryLimit = 1000
+
+	// maxChatHistory bounds the number of messages returned by one CHATHISTORY
+	maxChatHistory = 100
+)
+
+// HistoryMessage is a channel message recorded for playback
+type HistoryMessage struct {
+	Seq     uint64            `gorm:"primaryKey;autoIncrement" json:"-"`
+	MsgID   string            `gorm:"uniqueIndex" json:"msgid"`
+	Target  string            `gorm:"index" json:"target"`
+	Time    time.Time         `gorm:"index" json:"time"`
+	Source  string            `json:"source"`  // nick!user@host of the sender
+	Command string            `json:"command"` // PRIVMSG or NOTICE
+	Text    string            `json:"text"`
+	Tags    map[string]string `gorm:"serializer:json" json:"tags,omitempty"` // Client-only tags
+}
+
+// HistoryStore keeps the most recent messages of each channel. Results are
+// always ordered oldest first.
+type HistoryStore interface {
+	// Append records a message, discarding the oldest messages of its target
+	// beyond the store's limit
+	Append(msg *HistoryMessage) error
+	// Before returns the newest limit messages sent strictly before t, or the
+	// newest messages overall when t is zero
+	Before(target string, t time.Time, limit int) ([]*HistoryMessage, error)
+	// After returns the oldest limit messages sent strictly after t
+	After(target string, t time.Time, limit int) ([]*HistoryMessage, error)
+	// Find returns the message with the given msgid, or nil
+	Find(target, msgid string) (*HistoryMessage, error)
+}
+
+// WithHistoryStore enables message history backed by store, regardless of
+// the history settings in the configuration
+func WithHistoryStore(store HistoryStore) Option {
+	return func(s *Server) {
+		s.history = store
+	}
+}
+
+// newHistoryStore opens the history backend selected in the configuration
+func newHistoryStore(s *Server) (HistoryStore, error) {
+	cfg := s.GetConfig().History
+	limit := cfg.Limit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	switch strings.ToLower(cfg.Backend) {
+	case "", "memory":
+		return NewMemoryHistoryStore(limit), nil
+	case "sqlite":
+		return OpenSQLiteHistoryStore(cfg.Path, limit)
+	default:
+		return nil, fmt.Errorf("unknown history backend %q", cfg.Backend)
+	}
+}
+
+// MemoryHistoryStore keeps history in memory
+type MemoryHistoryStore struct {
+	limit    int
+	messages map[string][]*HistoryMessage
+	mu       sync.RWMutex
+}
+
+// NewMemoryHistoryStore creates a store keeping limit messages per channel
+func NewMemoryHistoryStore(limit int) *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		limit:    limit,
+		messages: make(map[string][]*HistoryMessage),
+	}
+}
+
+// Append records a message
+func (m *MemoryHistoryStore) Append(msg *HistoryMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := append(m.messages[msg.Target], msg)
+	if len(messages) > m.limit {
+		messages = append([]*HistoryMessage(nil), messages[len(messages)-m.limit:]...)
+	}
+	m.messages[msg.Target] = messages
+	return nil
+}
+
+// Before returns the newest limit messages sent strictly before t
+func (m *MemoryHistoryStore) Before(target string, t time.Time, limit int) ([]*HistoryMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := m.messages[target]
+	end := len(messages)
+	if !t.IsZero() {
+		for end > 0 && !messages[end-1].Time.Before(t) {
+			end--
+		}
+	}
+	start := end - limit
+	if start < 0 {
+		start = 0
+	}
+	return append([]*HistoryMessage(nil), messages[start:end]...), nil
+}
+
+// After returns the oldest limit messages sent strictly after t
+func (m *MemoryHistoryStore) After(target string, t time.Time, limit int) ([]*HistoryMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	messages := m.messages[target]
+	start := 0
+	for start < len(messages) && !messages[start].Time.After(t) {
+		start++
+	}
+	end := start + limit
+	if end > len(messages) {
+		end = len(messages)
+	}
+	return append([]*HistoryMessage(nil), messages[start:end]...), nil
+}
+
+// Find returns the message with the given msgid
+func (m *MemoryHistoryStore) Find(target, msgid string) (*HistoryMessage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, msg := range m.messages[target] {
+		if msg.MsgID == msgid {
+			return msg, nil
+		}
+	}
+	return nil, nil
+}
+
+// recordHistory stores a message relayed to a channel
+func (s *Server) recordHistory(client *Client, command, target, text string, tags map[string]string) {
+	if s.history == nil {
+		return
+	}
+	msg := &HistoryMessage{
+		MsgID:   uuid.New().String(),
+		Target:  target,
+		Time:    s.Now().UTC(),
+		Source:  fmt.Sprintf("%s!%s@%s", client.Nickname, client.Username, client.Hostname),
+		Command: command,
+		Text:    text,
+		Tags:    tags,
+	}
+	if err := s.history.Append(msg); err != nil {
+		s.Logger(LogStorage).Error("Failed to record history", "target", target, "error", err)
+	}
+}
+
+// sendHistory replays messages to the client, inside a batch of batchType
+// when the client enabled the batch capability
+func (c *Client) sendHistory(batchType, target string, messages []*HistoryMessage) {
+	var ref string
+	if c.HasCap(CapBatch) {
+		ref = strings.ReplaceAll(uuid.New().String(), "-", "")[:12]
+		c.SendServerLine("BATCH", "+"+ref, batchType, target)
+	}
+
+	for _, msg := range messages {
+		tags := c.negotiatedTags(msg.Tags, msg.Time)
+		if c.HasCap(CapMessageTags) {
+			tags["msgid"] = msg.MsgID
+		}
+		if ref != "" {
+			tags["batch"] = ref
+		}
+		line := fmt.Sprintf(":%s %s %s :%s", msg.Source, msg.Command, msg.Target, msg.Text)
+		if len(tags) > 0 {
+			line = "@" + irc.FormatTags(tags) + " " + line
+		}
+		c.SendRaw(line)
+	}
+
+	if ref != "" {
+		c.SendServerLine("BATCH", "-"+ref)
+	}
+}
+
+// playbackHistory replays recent channel messages to a client that joined
+// without the chathistory capability
+func (s *Server) playbackHistory(client *Client, channel *Channel) {
+	playback := s.GetConfig().History.Playback
+	if s.history == nil || playback <= 0 || client.HasCap(CapChathistory) {
+		return
+	}
+
+	messages, err := s.history.Before(channel.Name, time.Time{}, playback)
+	if err != nil || len(messages) == 0 {
+		return
+	}
+	client.sendHistory("chathistory", channel.Name, messages)
+}
+
+// chathistoryFail sends a CHATHISTORY standard reply failure
+func chathistoryFail(client *Client, code string, params ...string) {
+	client.SendServerLine("FAIL", append([]string{"CHATHISTORY", code}, params...)...)
+}
+
+// resolveHistorySelector converts a CHATHISTORY selector, "*",
+// timestamp=<time> or msgid=<id>, to a time. "*" yields the zero time.
+func (s *Server) resolveHistorySelector(target, selector string) (time.Time, bool) {
+	if selector == "*" {
+		return time.Time{}, true
+	}
+
+	kind, value, ok := strings.Cut(selector, "=")
+	if !ok {
+		return time.Time{}, false
+	}
+	switch kind {
+	case "timestamp":
+		t, err := time.Parse(time.RFC3339Nano, value)
+		return t, err == nil
+	case "msgid":
+		msg, err := s.history.Find(target, value)
+		if err != nil || msg == nil {
+			return time.Time{}, false
+		}
+		return msg.Time, true
+	}
+	return time.Time{}, false
+}
+
+// handleChathistory handles the IRCv3 CHATHISTORY command
+func handleChathistory(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+	srv := client.Server
+
+	if srv.history == nil {
+		client.SendError(irc.ERR_UNKNOWNCOMMAND, "CHATHISTORY", "Unknown command")
+		return nil
+	}
+	if len(message.Params) < 4 {
+		chathistoryFail(client, "NEED_MORE_PARAMS", "Missing parameters")
+		return nil
+	}
+
+	subcommand := strings.ToUpper(message.Params[0])
+	target := message.Params[1]
+
+	channel := srv.GetChannel(target)
+	if channel == nil || !channel.IsMember(client) {
+		chathistoryFail(client, "INVALID_TARGET", subcommand, target, "Messages could not be retrieved")
+		return nil
+	}
+	target = channel.Name
+
+	limitParam := message.Params[len(message.Params)-1]
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit < 0 {
+		chathistoryFail(client, "INVALID_PARAMS", subcommand, limitParam, "Invalid limit")
+		return nil
+	}
+	if limit == 0 || limit > maxChatHistory {
+		limit = maxChatHistory
+	}
+
+	first, ok := srv.resolveHistorySelector(target, message.Params[2])
+	if !ok {
+		chathistoryFail(client, "INVALID_PARAMS", subcommand, message.Params[2], "Invalid message reference")
+		return nil
+	}
+
+	var messages []*HistoryMessage
+	switch subcommand {
+	case "LATEST":
+		messages, err = srv.history.Before(target, time.Time{}, limit)
+		if !first.IsZero() {
+			messages = filterHistory(messages, func(m *HistoryMessage) bool { return m.Time.After(first) })
+		}
+
+	case "BEFORE":
+		messages, err = srv.history.Before(target, first, limit)
+
+	case "AFTER":
+		messages, err = srv.history.After(target, first, limit)
+
+	case "AROUND":
+		var after []*HistoryMessage
+		messages, err = srv.history.Before(target, first, limit/2)
+		if err == nil {
+			after, err = srv.history.After(target, first.Add(-time.Nanosecond), limit-len(messages))
+			messages = append(messages, after...)
+		}
+
+	case "BETWEEN":
+		if len(message.Params) < 5 {
+			chathistoryFail(client, "NEED_MORE_PARAMS", "Missing parameters")
+			return nil
+		}
+		second, ok := srv.resolveHistorySelector(target, message.Params[3])
+		if !ok {
+			chathistoryFail(client, "INVALID_PARAMS", subcommand, message.Params[3], "Invalid message reference")
+			return nil
+		}
+		if first.Before(second) {
+			messages, err = srv.history.After(target, first, limit)
+			messages = filterHistory(messages, func(m *HistoryMessage) bool { return m.Time.Before(second) })
+		} else {
+			messages, err = srv.history.Before(target, first, limit)
+			messages = filterHistory(messages, func(m *HistoryMessage) bool { return m.Time.After(second) })
+		}
+
+	default:
+		chathistoryFail(client, "INVALID_PARAMS", subcommand, "Unknown subcommand")
+		return nil
+	}
+
+	if err != nil {
+		chathistoryFail(client, "MESSAGE_ERROR", subcommand, target, "Messages could not be retrieved")
+		return nil
+	}
+	client.sendHistory("chathistory", channel.Name, messages)
+	return nil
+}
+
+// filterHistory returns the messages for which keep returns true
+func filterHistory(messages []*HistoryMessage, keep func(*HistoryMessage) bool) []*HistoryMessage {
+	filtered := messages[:0]
+	for _, msg := range messages {
+		if keep(msg) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryServer creates a test server with in-memory history enabled
+func newHistoryServer(t *testing.T, clock *fakeClock, playback int) *Server {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.History.Enabled = true
+	cfg.History.Limit = 3
+	cfg.History.Playback = playback
+	return newTestServer(t, cfg, WithClock(clock))
+}
+
+// historyTexts returns the message texts of replayed lines, skipping BATCH lines
+func historyTexts(lines []string) []string {
+	var texts []string
+	for _, line := range lines {
+		if i := strings.LastIndex(line, " :"); i >= 0 && !strings.Contains(line, " BATCH ") {
+			texts = append(texts, line[i+2:])
+		}
+	}
+	return texts
+}
+
+func TestChathistory(t *testing.T) {
+	clock := newFakeClock()
+	srv := newHistoryServer(t, clock, 0)
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	for _, text := range []string{"one", "two", "three", "four"} {
+		clock.Advance(time.Second)
+		alice.send("PRIVMSG #test :" + text)
+
+		// Wait for the message to be stamped before moving the clock again
+		alice.send("PING sync")
+		alice.expect("PONG")
+	}
+	alice.send("NOTICE #test :five")
+	alice.send("PING sync")
+	alice.expect("PONG")
+
+	bob := srv.registerWithCaps(t, "bob", CapBatch, CapServerTime, CapChathistory)
+	bob.send("CHATHISTORY LATEST #test * 10")
+	bob.expect("FAIL CHATHISTORY INVALID_TARGET LATEST #test")
+
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+
+	// Only the newest three messages are kept, replayed in a batch
+	bob.send("CHATHISTORY LATEST #test * 10")
+	lines := bob.collect(" BATCH -")
+	require.Len(t, lines, 5)
+	assert.Regexp(t, `^:test.irc.local BATCH \+\w+ chathistory #test$`, lines[0])
+	assert.Regexp(t, `^@batch=\w+;time=2024-01-01T12:00:03.000Z :alice!alice@ PRIVMSG #test :three$`, lines[1])
+	assert.Equal(t, []string{"three", "four", "five"}, historyTexts(lines))
+
+	bob.send("CHATHISTORY BEFORE #test timestamp=2024-01-01T12:00:04.000Z 10")
+	assert.Equal(t, []string{"three"}, historyTexts(bob.collect(" BATCH -")))
+
+	bob.send("CHATHISTORY AFTER #test timestamp=2024-01-01T12:00:03.000Z 10")
+	assert.Equal(t, []string{"four", "five"}, historyTexts(bob.collect(" BATCH -")))
+
+	bob.send("CHATHISTORY BETWEEN #test timestamp=2024-01-01T12:00:04.000Z timestamp=2024-01-01T12:00:01.000Z 1")
+	assert.Equal(t, []string{"three"}, historyTexts(bob.collect(" BATCH -")))
+
+	bob.send("CHATHISTORY LATEST #test bogus 10")
+	bob.expect("FAIL CHATHISTORY INVALID_PARAMS LATEST bogus")
+}
+
+func TestHistoryPlaybackOnJoin(t *testing.T) {
+	clock := newFakeClock()
+	srv := newHistoryServer(t, clock, 2)
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	alice.send("PRIVMSG #test :one")
+	alice.send("PRIVMSG #test :two")
+	alice.send("PRIVMSG #test :three")
+	alice.send("PING sync")
+	alice.expect("PONG")
+
+	// Clients without chathistory get the newest messages after NAMES
+	bob := srv.register(t, "bob")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :two", bob.expect(" PRIVMSG "))
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :three", bob.expect(" PRIVMSG "))
+}
+
+func TestSQLHistoryStore(t *testing.T) {
+	store, err := OpenSQLiteHistoryStore(filepath.Join(t.TempDir(), "history.db"), 3)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, text := range []string{"one", "two", "three", "four"} {
+		require.NoError(t, store.Append(&HistoryMessage{
+			MsgID:   text,
+			Target:  "#test",
+			Time:    start.Add(time.Duration(i) * time.Second),
+			Command: "PRIVMSG",
+			Text:    text,
+			Tags:    map[string]string{"+draft/reply": "x"},
+		}))
+	}
+
+	texts := func(messages []*HistoryMessage) []string {
+		var out []string
+		for _, msg := range messages {
+			out = append(out, msg.Text)
+		}
+		return out
+	}
+
+	latest, err := store.Before("#test", time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"two", "three", "four"}, texts(latest))
+	assert.Equal(t, "x", latest[0].Tags["+draft/reply"])
+
+	before, err := store.Before("#test", start.Add(3*time.Second), 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three"}, texts(before))
+
+	after, err := store.After("#test", start.Add(time.Second), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"three", "four"}, texts(after))
+
+	msg, err := store.Find("#test", "three")
+	require.NoError(t, err)
+	assert.True(t, msg.Time.Equal(start.Add(2*time.Second)))
+	msg, err = store.Find("#test", "one")
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+}
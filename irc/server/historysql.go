@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// SQLHistoryStore keeps history in a SQL database through GORM
+type SQLHistoryStore struct {
+	db    *gorm.DB
+	limit int
+}
+
+// NewSQLHistoryStore creates a store keeping limit messages per channel in db,
+// migrating the history table if needed
+func NewSQLHistoryStore(db *gorm.DB, limit int) (*SQLHistoryStore, error) {
+	if err := db.AutoMigrate(&HistoryMessage{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate history table: %v", err)
+	}
+	return &SQLHistoryStore{db: db, limit: limit}, nil
+}
+
+// OpenSQLiteHistoryStore creates a store backed by the SQLite database at path
+func OpenSQLiteHistoryStore(path string, limit int) (*SQLHistoryStore, error) {
+	if path == "" {
+		return nil, errors.New("history.path is required for the sqlite backend")
+	}
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %v", err)
+	}
+	return NewSQLHistoryStore(db, limit)
+}
+
+// Append records a message and trims the oldest messages of its target
+func (s *SQLHistoryStore) Append(msg *HistoryMessage) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(msg).Error; err != nil {
+			return err
+		}
+
+		// Find the oldest sequence number still within the limit
+		var keep []uint64
+		err := tx.Model(&HistoryMessage{}).
+			Where("target = ?", msg.Target).
+			Order("seq DESC").
+			Offset(s.limit-1).
+			Limit(1).
+			Pluck("seq", &keep).Error
+		if err != nil || len(keep) == 0 {
+			return err
+		}
+		return tx.Where("target = ? AND seq < ?", msg.Target, keep[0]).Delete(&HistoryMessage{}).Error
+	})
+}
+
+// Before returns the newest limit messages sent strictly before t
+func (s *SQLHistoryStore) Before(target string, t time.Time, limit int) ([]*HistoryMessage, error) {
+	query := s.db.Where("target = ?", target)
+	if !t.IsZero() {
+		query = query.Where("time < ?", t.UTC())
+	}
+
+	var messages []*HistoryMessage
+	if err := query.Order("seq DESC").Limit(limit).Find(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	// Restore chronological order
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	return messages, nil
+}
+
+// After returns the oldest limit messages sent strictly after t
+func (s *SQLHistoryStore) After(target string, t time.Time, limit int) ([]*HistoryMessage, error) {
+	var messages []*HistoryMessage
+	err := s.db.Where("target = ? AND time > ?", target, t.UTC()).
+		Order("seq ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// Find returns the message with the given msgid
+func (s *SQLHistoryStore) Find(target, msgid string) (*HistoryMessage, error) {
+	var messages []*HistoryMessage
+	err := s.db.Where("target = ? AND msg_id = ?", target, msgid).Limit(1).Find(&messages).Error
+	if err != nil || len(messages) == 0 {
+		return nil, err
+	}
+	return messages[0], nil
+}
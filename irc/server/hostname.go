@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLookupTimeout bounds each connect-time lookup when
+	// hostnames.timeout is not configured
+	defaultLookupTimeout = 5 * time.Second
+
+	// identPort is the RFC1413 ident service port
+	identPort = 113
+)
+
+// hostLookups performs the reverse DNS and ident lookups made at connect time
+type hostLookups struct {
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+	identPort  int
+}
+
+// newHostLookups creates lookups using the system resolver
+func newHostLookups() *hostLookups {
+	return &hostLookups{
+		lookupAddr: net.DefaultResolver.LookupAddr,
+		lookupHost: net.DefaultResolver.LookupHost,
+		identPort:  identPort,
+	}
+}
+
+// hostname returns the name ip resolves to, accepted only when the name
+// resolves back to ip
+func (l *hostLookups) hostname(ctx context.Context, ip string) (string, bool) {
+	names, err := l.lookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return "", false
+	}
+	hostname := strings.TrimSuffix(names[0], ".")
+
+	addrs, err := l.lookupHost(ctx, hostname)
+	if err != nil {
+		return "", false
+	}
+	for _, addr := range addrs {
+		if net.ParseIP(addr).Equal(net.ParseIP(ip)) {
+			return hostname, true
+		}
+	}
+	return "", false
+}
+
+// ident queries the ident server of the host at remote for the user owning
+// the connection between remote and local
+func (l *hostLookups) ident(ctx context.Context, remote, local *net.TCPAddr) (string, bool) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(remote.IP.String(), strconv.Itoa(l.identPort)))
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := fmt.Fprintf(conn, "%d, %d\r\n", remote.Port, local.Port); err != nil {
+		return "", false
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", false
+	}
+	return parseIdentReply(reply, remote.Port, local.Port)
+}
+
+// parseIdentReply extracts the user id from an ident reply of the form
+// "<remote port>, <local port> : USERID : <os> : <user>"
+func parseIdentReply(reply string, remotePort, localPort int) (string, bool) {
+	fields := strings.SplitN(strings.TrimSpace(reply), ":", 4)
+	if len(fields) != 4 || strings.TrimSpace(fields[1]) != "USERID" {
+		return "", false
+	}
+
+	ports := strings.Split(fields[0], ",")
+	if len(ports) != 2 ||
+		strings.TrimSpace(ports[0]) != strconv.Itoa(remotePort) ||
+		strings.TrimSpace(ports[1]) != strconv.Itoa(localPort) {
+		return "", false
+	}
+
+	user := strings.TrimSpace(fields[3])
+	if user == "" || strings.ContainsAny(user, " @!*?") {
+		return "", false
+	}
+	return user, true
+}
+
+// lookupTimeout returns the configured lookup timeout with the default applied
+func (s *Server) lookupTimeout() time.Duration {
+	if seconds := s.GetConfig().Hostnames.Timeout; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultLookupTimeout
+}
+
+// lookupHost resolves the client's hostname and ident, as configured, and
+// cloaks the resulting host
+func (c *Client) lookupHost() {
+	s := c.Server
+	cfg := s.GetConfig().Hostnames
+	notice := func(text string) {
+		c.SendRaw(fmt.Sprintf(":%s NOTICE Auth :*** %s", s.GetConfig().Server.Name, text))
+	}
+	defer c.applyCloak()
+
+	remote, ok := c.Conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		// Not a TCP connection or couldn't get IP
+		notice("Could not determine your connection type, using IP address")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.lookupTimeout())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var hostname, ident string
+	var resolved, identified bool
+	if !cfg.DisableDNS {
+		notice("Looking up your hostname...")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hostname, resolved = s.lookups.hostname(ctx, remote.IP.String())
+		}()
+	}
+	local, isTCP := c.Conn.LocalAddr().(*net.TCPAddr)
+	if cfg.Ident && isTCP {
+		notice("Checking Ident")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ident, identified = s.lookups.ident(ctx, remote, local)
+		}()
+	}
+	wg.Wait()
+
+	if !cfg.DisableDNS {
+		if resolved {
+			c.mu.Lock()
+			c.Hostname = hostname
+			c.RealHostname = hostname
+			c.mu.Unlock()
+			notice("Found your hostname: " + hostname)
+		} else {
+			notice("Could not find your hostname, using IP address instead")
+		}
+	}
+	if cfg.Ident && isTCP {
+		if identified {
+			c.mu.Lock()
+			c.ident = ident
+			c.mu.Unlock()
+			notice("Got Ident response")
+		} else {
+			notice("No Ident response")
+		}
+	}
+}
+
+// username returns the username to register the client with: the ident
+// reply when available, or the USER parameter prefixed with "~" when ident
+// lookups are enabled but the client has no ident
+func (c *Client) username(requested string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ident != "" {
+		return c.ident
+	}
+	if c.Server.GetConfig().Hostnames.Ident {
+		return "~" + requested
+	}
+	return requested
+}
+
+// applyCloak replaces the client's displayed host with its cloak when
+// cloaking is enabled. The real host stays visible to operators and
+// K-lines and G-lines keep matching it.
+func (c *Client) applyCloak() {
+	cfg := c.Server.GetConfig()
+	if !cfg.Hostnames.Cloak || c.IP == "" {
+		return
+	}
+
+	prefix := cfg.Hostnames.CloakPrefix
+	if prefix == "" {
+		prefix = cfg.Server.Network
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Hostname = cloakHost(cfg.Hostnames.CloakSecret, prefix, c.RealHostname, c.IP)
+}
+
+// cloakHash returns a short keyed hash of value
+func cloakHash(secret, value string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return strings.ToUpper(hex.EncodeToString(mac.Sum(nil))[:8])
+}
+
+// cloakHost returns the cloak shown in place of host. Resolved hostnames
+// keep their domain, as in "Net-1A2B3C4D.example.com". IPs become a hash of
+// the address followed by hashes of its enclosing networks, as in
+// "1A2B3C4D.5E6F7A8B.9C0D1E2F.IP", so that bans on the trailing parts of a
+// cloak cover a whole /24 or /16 (/64 or /48 for IPv6).
+func cloakHost(secret, prefix, host, ip string) string {
+	if host != "" && host != ip {
+		hash := cloakHash(secret, host)
+		if prefix != "" {
+			hash = prefix + "-" + hash
+		}
+		if _, domain, ok := strings.Cut(host, "."); ok && strings.Contains(domain, ".") {
+			return hash + "." + domain
+		}
+		return hash
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return cloakHash(secret, ip) + ".IP"
+	}
+	bits := []int{24, 16}
+	if parsed.To4() == nil {
+		bits = []int{64, 48}
+	} else {
+		parsed = parsed.To4()
+	}
+
+	parts := []string{cloakHash(secret, parsed.String())}
+	for _, size := range bits {
+		network := parsed.Mask(net.CIDRMask(size, len(parsed)*8))
+		parts = append(parts, cloakHash(secret, network.String()+"/"+strconv.Itoa(size)))
+	}
+	return strings.Join(parts, ".") + ".IP"
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// connectTCP attaches a client to the server over a loopback TCP connection
+func (s *Server) connectTCP(t *testing.T) *testClient {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	local, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	remote, err := listener.Accept()
+	require.NoError(t, err)
+	return s.attach(t, local, remote)
+}
+
+// serveIdent answers ident queries on a loopback port with user
+func serveIdent(t *testing.T, user string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			fmt.Fprintf(conn, "%s : USERID : UNIX : %s\r\n", strings.TrimSpace(query), user)
+			conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestHostnameLookups(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hostnames.Ident = true
+	srv := newTestServer(t, cfg)
+	srv.lookups.lookupAddr = func(ctx context.Context, addr string) ([]string, error) {
+		return []string{"client.example.com."}, nil
+	}
+	srv.lookups.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"127.0.0.1"}, nil
+	}
+	srv.lookups.identPort = serveIdent(t, "alice")
+
+	tc := srv.connectTCP(t)
+	assert.Contains(t, tc.expect("Found your hostname"), "client.example.com")
+	tc.expect("Got Ident response")
+	tc.send("NICK alice")
+	tc.send("USER ignored 0 * :Alice")
+	assert.Contains(t, tc.expect(" 001 "), "alice!alice@client.example.com")
+
+	// Names that do not resolve back to the IP are not trusted, and
+	// usernames without an ident response are marked with "~"
+	srv.lookups.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return []string{"192.0.2.1"}, nil
+	}
+	srv.lookups.identPort = 1
+	tc = srv.connectTCP(t)
+	tc.expect("Could not find your hostname")
+	tc.expect("No Ident response")
+	tc.send("NICK bob")
+	tc.send("USER bob 0 * :Bob")
+	assert.Contains(t, tc.expect(" 001 "), "bob!~bob@127.0.0.1")
+}
+
+func TestCloaking(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Hostnames.DisableDNS = true
+	cfg.Hostnames.Cloak = true
+	cfg.Hostnames.CloakSecret = "secret"
+	srv := newTestServer(t, cfg)
+
+	cloak := cloakHost("secret", "TestNet", "127.0.0.1", "127.0.0.1")
+	assert.Regexp(t, `^[0-9A-F]{8}\.[0-9A-F]{8}\.[0-9A-F]{8}\.IP$`, cloak)
+
+	alice := srv.connectTCP(t)
+	alice.send("NICK alice")
+	alice.send("USER alice 0 * :Alice")
+	assert.Contains(t, alice.expect(" 001 "), "alice!alice@"+cloak)
+
+	bob := srv.register(t, "bob")
+	bob.send("WHOIS alice")
+	assert.Equal(t, ":test.irc.local 311 bob alice alice "+cloak+" * Alice", bob.expect(" 311 "))
+
+	// A K-line on the real IP still applies to the cloaked client
+	client := srv.GetClient("alice")
+	ban := &ServerBan{Mask: "*@127.0.0.1"}
+	assert.True(t, ban.Matches(client))
+	ban = &ServerBan{Mask: "*@*." + strings.SplitN(cloak, ".", 2)[1]}
+	assert.True(t, ban.Matches(client))
+}
+
+func TestCloakHost(t *testing.T) {
+	host := cloakHost("secret", "Net", "dsl-1.isp.example.com", "192.0.2.1")
+	assert.Regexp(t, `^Net-[0-9A-F]{8}\.isp\.example\.com$`, host)
+	assert.Equal(t, host, cloakHost("secret", "Net", "dsl-1.isp.example.com", "192.0.2.1"))
+	assert.NotEqual(t, host, cloakHost("other", "Net", "dsl-1.isp.example.com", "192.0.2.1"))
+
+	// Addresses in the same network share the trailing hashes
+	a := strings.SplitN(cloakHost("secret", "Net", "", "192.0.2.1"), ".", 2)
+	b := strings.SplitN(cloakHost("secret", "Net", "", "192.0.2.200"), ".", 2)
+	assert.NotEqual(t, a[0], b[0])
+	assert.Equal(t, a[1], b[1])
+
+	v6 := cloakHost("secret", "Net", "2001:db8::1", "2001:db8::1")
+	assert.Regexp(t, `^[0-9A-F]{8}\.[0-9A-F]{8}\.[0-9A-F]{8}\.IP$`, v6)
+}
+
+func TestParseIdentReply(t *testing.T) {
+	user, ok := parseIdentReply("6193, 23 : USERID : UNIX : stjohns\r\n", 6193, 23)
+	assert.True(t, ok)
+	assert.Equal(t, "stjohns", user)
+
+	_, ok = parseIdentReply("6193, 23 : ERROR : NO-USER", 6193, 23)
+	assert.False(t, ok)
+	_, ok = parseIdentReply("6195, 23 : USERID : UNIX : stjohns", 6193, 23)
+	assert.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Protocol limits used when a setting is not configured
+const (
+	defaultNickLen     = 30
+	defaultChannelLen  = 50
+	defaultTopicLen    = 390
+	defaultKickLen     = 255
+	defaultMaxChannels = 20
+	defaultMaxModes    = 4
+	defaultMaxList     = 100
+	defaultLineLen     = 512
+)
+
+// serverLimits are the protocol limits advertised in RPL_ISUPPORT
+type serverLimits struct {
+	NickLen     int
+	ChannelLen  int
+	TopicLen    int
+	KickLen     int
+	MaxChannels int // Channels a client may join
+	MaxModes    int // Mode changes with a parameter per MODE command
+	MaxList     int // Entries in each ban and exception list
+	LineLen     int // Bytes per line including CRLF, excluding tags
+	UTF8Only    bool
+}
+
+// limits returns the configured protocol limits with defaults applied
+func (s *Server) limits() serverLimits {
+	cfg := s.GetConfig().Limits
+	limits := serverLimits{
+		NickLen:     cfg.NickLen,
+		ChannelLen:  cfg.ChannelLen,
+		TopicLen:    cfg.TopicLen,
+		KickLen:     cfg.KickLen,
+		MaxChannels: cfg.MaxChannels,
+		MaxModes:    cfg.Modes,
+		MaxList:     cfg.MaxList,
+		LineLen:     cfg.LineLen,
+		UTF8Only:    cfg.UTF8Only,
+	}
+	if limits.NickLen <= 0 {
+		limits.NickLen = defaultNickLen
+	}
+	if limits.ChannelLen <= 0 {
+		limits.ChannelLen = defaultChannelLen
+	}
+	if limits.TopicLen <= 0 {
+		limits.TopicLen = defaultTopicLen
+	}
+	if limits.KickLen <= 0 {
+		limits.KickLen = defaultKickLen
+	}
+	if limits.MaxChannels <= 0 {
+		limits.MaxChannels = defaultMaxChannels
+	}
+	if limits.MaxModes <= 0 {
+		limits.MaxModes = defaultMaxModes
+	}
+	if limits.MaxList <= 0 {
+		limits.MaxList = defaultMaxList
+	}
+	if limits.LineLen < defaultLineLen {
+		limits.LineLen = defaultLineLen
+	}
+	return limits
+}
+
+// truncate shortens text to at most n bytes
+func truncate(text string, n int) string {
+	if len(text) > n {
+		return text[:n]
+	}
+	return text
+}
+
+// isupportTokens returns the RPL_ISUPPORT tokens describing the server
+func (s *Server) isupportTokens() []string {
+	limits := s.limits()
+	tokens := []string{
+		"CASEMAPPING=" + s.casemap,
+		"CHANLIMIT=#:" + fmt.Sprint(limits.MaxChannels),
+		"CHANMODES=beI,k,l,CDKNPRScfimnpst",
+		"CHANNELLEN=" + fmt.Sprint(limits.ChannelLen),
+		"CHANTYPES=#",
+		"EXCEPTS=e",
+		"INVEX=I",
+		"KICKLEN=" + fmt.Sprint(limits.KickLen),
+		"KNOCK",
+		"LINELEN=" + fmt.Sprint(limits.LineLen),
+		"MAXLIST=beI:" + fmt.Sprint(limits.MaxList),
+		"MODES=" + fmt.Sprint(limits.MaxModes),
+		"NETWORK=" + s.GetConfig().Server.Network,
+		"NICKLEN=" + fmt.Sprint(limits.NickLen),
+		"PREFIX=(ov)@+",
+		"TOPICLEN=" + fmt.Sprint(limits.TopicLen),
+	}
+	if limits.UTF8Only {
+		tokens = append(tokens, "UTF8ONLY")
+	}
+	return tokens
+}
+
+// isupportPerLine is the number of tokens sent in one RPL_ISUPPORT line
+const isupportPerLine = 13
+
+// SendISupport advertises the server features with RPL_ISUPPORT
+func (c *Client) SendISupport() {
+	tokens := c.Server.isupportTokens()
+	for len(tokens) > 0 {
+		n := isupportPerLine
+		if n > len(tokens) {
+			n = len(tokens)
+		}
+		line := append(append([]string(nil), tokens[:n]...), "are supported by this server")
+		c.SendReply(irc.RPL_ISUPPORT, line...)
+		tokens = tokens[n:]
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestISupport(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Limits.NickLen = 9
+	cfg.Limits.ChannelLen = 10
+	cfg.Limits.TopicLen = 5
+	cfg.Limits.MaxChannels = 2
+	cfg.Limits.Modes = 1
+	srv := newTestServer(t, cfg)
+
+	alice := srv.connect(t)
+	alice.send("NICK alice")
+	alice.send("USER alice 0 * :Alice")
+	isupport := alice.expect(" 005 ")
+	assert.True(t, strings.HasSuffix(isupport, ":are supported by this server"))
+	isupport += " " + alice.expect(" 005 ")
+	for _, token := range []string{"CASEMAPPING=rfc1459", "CHANLIMIT=#:2", "CHANNELLEN=10", "CHANTYPES=#", "MODES=1", "NETWORK=TestNet", "NICKLEN=9", "PREFIX=(ov)@+", "TOPICLEN=5", "EXCEPTS=e", "INVEX=I", "MAXLIST=beI:100"} {
+		assert.Contains(t, strings.Fields(isupport), token)
+	}
+	alice.expect(" 376 ")
+
+	alice.send("NICK alice_is_long")
+	alice.expect(" 432 ")
+
+	alice.send("JOIN #waytoolong")
+	alice.expect(" 476 ")
+	alice.send("JOIN #a,#b,#c")
+	alice.expect(" 366 ")
+	alice.expect(" 366 ")
+	alice.expect(" 405 ")
+
+	alice.send("MODE #a -t")
+	alice.expect(" MODE ")
+	alice.send("TOPIC #a :Hello world")
+	assert.Equal(t, ":alice!alice@ TOPIC #a :Hello", alice.expect(" TOPIC "))
+
+	// Only the first parameterized mode is applied
+	alice.send("MODE #a +kl secret 10")
+	alice.expect(" MODE ")
+	channel := srv.GetChannel("#a")
+	assert.Equal(t, "secret", channel.Modes.Key)
+	assert.Equal(t, 0, channel.Modes.UserLimit)
+}
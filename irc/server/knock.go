@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// KNOCK rate limits used when a setting is not configured
+const (
+	defaultKnockDelay        = 300 * time.Second
+	defaultKnockChannelDelay = 60 * time.Second
+)
+
+// knockDelays returns the configured KNOCK rate limits with defaults applied
+func (s *Server) knockDelays() (user, channel time.Duration) {
+	cfg := s.GetConfig().Knock
+	user, channel = defaultKnockDelay, defaultKnockChannelDelay
+	if cfg.Delay > 0 {
+		user = time.Duration(cfg.Delay) * time.Second
+	}
+	if cfg.ChannelDelay > 0 {
+		channel = time.Duration(cfg.ChannelDelay) * time.Second
+	}
+	return user, channel
+}
+
+// isOpen reports whether anyone may join the channel without an invitation
+func (c *Channel) isOpen() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.Modes.InviteOnly && c.Modes.Key == "" &&
+		(c.Modes.UserLimit <= 0 || len(c.Members) < c.Modes.UserLimit)
+}
+
+// handleKnock handles KNOCK <channel> [message], asking the operators of a
+// closed channel for an invitation
+func handleKnock(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+	srv := client.Server
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "KNOCK", "Not enough parameters")
+		return nil
+	}
+
+	channel := srv.GetChannel(message.Params[0])
+	if channel == nil {
+		client.SendError(irc.ERR_NOSUCHCHANNEL, message.Params[0], "No such channel")
+		return nil
+	}
+	if channel.IsMember(client) {
+		client.SendError(irc.ERR_KNOCKONCHAN, channel.Name, "You're already on that channel")
+		return nil
+	}
+	if channel.isOpen() {
+		client.SendError(irc.ERR_CHANOPEN, channel.Name, "Channel is open")
+		return nil
+	}
+	channel.mu.RLock()
+	noKnock := channel.Modes.NoKnock
+	channel.mu.RUnlock()
+	if noKnock {
+		client.SendError(irc.ERR_CANNOTKNOCK, fmt.Sprintf("Cannot knock on %s (+K)", channel.Name))
+		return nil
+	}
+	if channel.IsBanned(client) {
+		client.SendError(irc.ERR_CANNOTKNOCK, fmt.Sprintf("Cannot knock on %s (you're banned)", channel.Name))
+		return nil
+	}
+
+	// Limit how often a client may knock, and how often a channel is knocked on
+	userDelay, channelDelay := srv.knockDelays()
+	now := srv.Now()
+	client.mu.RLock()
+	lastKnock := client.lastKnock
+	client.mu.RUnlock()
+	if !lastKnock.IsZero() && now.Sub(lastKnock) < userDelay {
+		client.SendError(irc.ERR_TOOMANYKNOCK, channel.Name, "Too many KNOCKs (user)")
+		return nil
+	}
+	channel.mu.Lock()
+	if !channel.lastKnock.IsZero() && now.Sub(channel.lastKnock) < channelDelay {
+		channel.mu.Unlock()
+		client.SendError(irc.ERR_TOOMANYKNOCK, channel.Name, "Too many KNOCKs (channel)")
+		return nil
+	}
+	channel.lastKnock = now
+	channel.mu.Unlock()
+	client.mu.Lock()
+	client.lastKnock = now
+	client.mu.Unlock()
+
+	text := "has asked for an invite"
+	if len(message.Params) > 1 && message.Params[1] != "" {
+		text += ": " + message.Params[1]
+	}
+	source := fmt.Sprintf("%s!%s@%s", client.Nickname, client.Username, client.Hostname)
+
+	channel.mu.RLock()
+	members := make([]*Client, 0, len(channel.Members))
+	for _, member := range channel.Members {
+		members = append(members, member)
+	}
+	channel.mu.RUnlock()
+	for _, member := range members {
+		if channel.IsOperator(member) && !member.Modes.HasMode('k') {
+			member.SendReply(irc.RPL_KNOCK, channel.Name, source, text)
+		}
+	}
+
+	client.SendReply(irc.RPL_KNOCKDLVR, channel.Name, "Your KNOCK has been delivered")
+	return nil
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKnock(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #open")
+	alice.expect(" 366 ")
+	alice.send("JOIN #priv")
+	alice.expect(" 366 ")
+	alice.send("MODE #priv +i")
+	alice.expect(" MODE ")
+	bob := srv.register(t, "bob")
+	alice.send("INVITE bob #priv")
+	alice.expect(" 341 ")
+	bob.send("JOIN #priv")
+	bob.expect(" 366 ")
+
+	mallory := srv.register(t, "mallory")
+	mallory.send("KNOCK #nowhere")
+	mallory.expect(" 403 ")
+	mallory.send("KNOCK #open")
+	assert.Equal(t, ":test.irc.local 713 mallory #open :Channel is open", mallory.expect(" 713 "))
+	bob.send("KNOCK #priv")
+	bob.expect(" 714 ")
+
+	// Channel operators are asked for an invitation
+	mallory.send("KNOCK #priv :let me in")
+	assert.Equal(t, ":test.irc.local 711 mallory #priv :Your KNOCK has been delivered", mallory.expect(" 711 "))
+	assert.Equal(t, ":test.irc.local 710 alice #priv mallory!mallory@ :has asked for an invite: let me in", alice.expect(" 710 "))
+	bob.send("PING :check")
+	assert.Contains(t, bob.expect("PONG"), "check")
+
+	// Repeat knocks are rate limited per client and per channel
+	mallory.send("KNOCK #priv")
+	assert.Equal(t, ":test.irc.local 712 mallory #priv :Too many KNOCKs (user)", mallory.expect(" 712 "))
+	dave := srv.register(t, "dave")
+	dave.send("KNOCK #priv")
+	assert.Contains(t, dave.expect(" 712 "), "Too many KNOCKs (channel)")
+	clock.Advance(61 * time.Second)
+	dave.send("KNOCK #priv")
+	dave.expect(" 711 ")
+	alice.expect(" 710 ")
+	clock.Advance(61 * time.Second)
+	mallory.send("KNOCK #priv")
+	mallory.expect(" 712 ")
+
+	// A channel operator can answer with an invitation
+	alice.send("INVITE mallory #priv")
+	alice.expect(" 341 ")
+	mallory.expect(" INVITE ")
+	mallory.send("JOIN #priv")
+	mallory.expect(" 366 ")
+
+	alice.send("MODE #priv +K")
+	alice.expect(" MODE ")
+	clock.Advance(time.Hour)
+	dave.send("KNOCK #priv")
+	assert.Equal(t, ":test.irc.local 480 dave :Cannot knock on #priv (+K)", dave.expect(" 480 "))
+}
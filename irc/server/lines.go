@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bufio"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+const (
+	// maxClientTagsLen bounds the tag data a client may send, excluding the
+	// leading '@' and the trailing space
+	maxClientTagsLen = 4094
+
+	// maxTagsLen bounds the tag section of a line sent by the server,
+	// including the leading '@' and the trailing space
+	maxTagsLen = 8191
+)
+
+// readLine reads one line from reader. Lines longer than max bytes are
+// consumed and discarded, and reported as too long.
+func readLine(reader *bufio.Reader, max int) (line string, tooLong bool, err error) {
+	var buf []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if !tooLong {
+			if len(buf)+len(chunk) > max {
+				tooLong, buf = true, nil
+			} else {
+				buf = append(buf, chunk...)
+			}
+		}
+		if err != bufio.ErrBufferFull {
+			return string(buf), tooLong, err
+		}
+	}
+}
+
+// splitTags splits a line into its tag section, including the leading '@'
+// and the trailing space, and the rest of the line
+func splitTags(line string) (tags, rest string) {
+	if !strings.HasPrefix(line, "@") {
+		return "", line
+	}
+	if i := strings.IndexByte(line, ' '); i >= 0 {
+		return line[:i+1], line[i+1:]
+	}
+	return line, ""
+}
+
+// truncateUTF8 shortens text to at most n bytes without splitting a UTF-8
+// sequence
+func truncateUTF8(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+// clampLine truncates an outgoing line, without its CRLF, at the first line
+// break or NUL and to the tag and line length limits
+func (s *Server) clampLine(line string) string {
+	if i := strings.IndexAny(line, "\r\n\x00"); i >= 0 {
+		line = line[:i]
+	}
+	tags, rest := splitTags(line)
+	maxRest := s.limits().LineLen - 2
+	if len(tags) <= maxTagsLen && len(rest) <= maxRest {
+		return line
+	}
+	if len(tags) > maxTagsLen {
+		// A truncated tag section cannot be parsed, so it is dropped
+		tags = ""
+	}
+	return tags + truncateUTF8(rest, maxRest)
+}
+
+// validateLine checks a line read from the client, without its CRLF, and
+// answers the client when the line is rejected
+func (c *Client) validateLine(line string) bool {
+	tags, rest := splitTags(line)
+	if len(tags) > maxClientTagsLen+2 || len(rest) > c.Server.limits().LineLen-2 {
+		c.SendError(irc.ERR_INPUTTOOLONG, "Input line was too long")
+		return false
+	}
+
+	// A CR or NUL inside a line would end or corrupt it when relayed
+	if strings.ContainsAny(line, "\r\x00") {
+		c.Server.Logger(LogCommands).Debug("Dropped malformed line", "nick", c.Nickname, "ip", c.IP)
+		return false
+	}
+
+	if c.Server.limits().UTF8Only && !utf8.ValidString(line) {
+		command := "*"
+		if msg := irc.ParseMessage(strings.ToValidUTF8(line, "")); msg != nil {
+			command = msg.Command
+		}
+		c.SendServerLine("FAIL", command, "INVALID_UTF8", "Message rejected, your message contained invalid UTF-8")
+		return false
+	}
+	return true
+}
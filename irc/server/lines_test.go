@@ -0,0 +1,65 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineLength(t *testing.T) {
+	srv := newTestServer(t, nil)
+	alice := srv.register(t, "alice")
+	bob := srv.register(t, "bob")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+	alice.drain()
+
+	// Lines past 512 bytes are rejected without being relayed
+	alice.send("PRIVMSG #test :" + strings.Repeat("a", 600))
+	assert.Contains(t, alice.expect(" 417 "), "Input line was too long")
+
+	// Lines that fit are truncated once the sender's prefix is added, without
+	// splitting a UTF-8 sequence
+	text := strings.Repeat("a", 480)
+	alice.send("PRIVMSG #test :" + text + "ébc")
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :"+text, bob.expect(" PRIVMSG "))
+
+	// Tags do not count towards the line length
+	alice.send("@+draft/reply=" + strings.Repeat("x", 1000) + " PRIVMSG #test :hello")
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :hello", bob.expect(" PRIVMSG "))
+	alice.send("@+draft/reply=" + strings.Repeat("x", 5000) + " PRIVMSG #test :hello")
+	alice.expect(" 417 ")
+
+	// Line breaks and NULs never reach other clients
+	alice.send("PRIVMSG #test :hi\x00there")
+	alice.send("PRIVMSG #test :still here")
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :still here", bob.expect(" PRIVMSG "))
+	assert.Equal(t, ":bot PRIVMSG #test :hi", srv.clampLine(":bot PRIVMSG #test :hi\r\nQUIT"))
+}
+
+func TestUTF8Only(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Limits.LineLen = 1024
+	cfg.Limits.UTF8Only = true
+	srv := newTestServer(t, cfg)
+
+	alice := srv.connect(t)
+	alice.send("NICK alice")
+	alice.send("USER alice 0 * :Alice")
+	isupport := alice.expect(" 005 ") + " " + alice.expect(" 005 ")
+	assert.Contains(t, strings.Fields(isupport), "UTF8ONLY")
+	assert.Contains(t, strings.Fields(isupport), "LINELEN=1024")
+	alice.expect(" 376 ")
+
+	bob := srv.register(t, "bob")
+	alice.send("PRIVMSG bob :caf\xe9")
+	assert.Equal(t, ":test.irc.local FAIL PRIVMSG INVALID_UTF8 :Message rejected, your message contained invalid UTF-8", alice.expect(" FAIL "))
+
+	// The negotiated line length allows longer lines
+	text := strings.Repeat("é", 400)
+	alice.send("PRIVMSG bob :" + text)
+	assert.Equal(t, ":alice!alice@ PRIVMSG bob :"+text, bob.expect(" PRIVMSG "))
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/presbrey/pkg/irc/config"
+)
+
+// Logging subsystems, each of which can be given its own level
+const (
+	LogServer      = "server"      // Startup, shutdown and configuration reloads
+	LogConnections = "connections" // Connections, registrations, disconnections and rejections
+	LogCommands    = "commands"    // Commands received and hook errors
+	LogRaw         = "raw"         // Raw lines of traced nicknames
+	LogHTTP        = "http"        // Web portal, bot API and metrics listeners
+	LogStorage     = "storage"     // History and persistence backends
+)
+
+// Logs routes structured log records to the configured outputs, filtered by
+// level per subsystem and per output
+type Logs struct {
+	handler slog.Handler
+	level   slog.Level            // Level of subsystems without their own
+	levels  map[string]slog.Level // Level by subsystem
+	trace   map[string]bool       // Casemapped nicknames whose raw lines are logged
+	closers []io.Closer
+	loggers sync.Map // map[string]*slog.Logger
+}
+
+// parseLevel parses a level name such as "debug" or "warn", returning def
+// when name is empty
+func parseLevel(name string, def slog.Level) (slog.Level, error) {
+	if name == "" {
+		return def, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// newLogs creates the loggers described by the logging settings. Without
+// outputs, text logs are written to stderr.
+func newLogs(cfg config.Config, fold func(string) string) (*Logs, error) {
+	settings := cfg.Logging
+	level, err := parseLevel(settings.Level, slog.LevelInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := &Logs{
+		level:  level,
+		levels: make(map[string]slog.Level),
+		trace:  make(map[string]bool),
+	}
+	for subsystem, name := range settings.Subsystems {
+		if logs.levels[subsystem], err = parseLevel(name, level); err != nil {
+			return nil, err
+		}
+	}
+	for _, nick := range settings.Trace {
+		logs.trace[fold(nick)] = true
+	}
+	// Raw lines are only logged for traced nicknames, so listing one is
+	// enough to see them
+	if _, ok := logs.levels[LogRaw]; !ok {
+		logs.levels[LogRaw] = slog.LevelDebug
+	}
+
+	outputs := settings.Outputs
+	if len(outputs) == 0 {
+		outputs = append(outputs, config.LogOutput{Type: "stderr"})
+	}
+	// Outputs without a level of their own accept every record a subsystem
+	// lets through
+	lowest := level
+	for _, l := range logs.levels {
+		lowest = min(lowest, l)
+	}
+	var handlers multiHandler
+	for _, output := range outputs {
+		handler, closer, err := newLogOutput(output, lowest)
+		if err != nil {
+			logs.Close()
+			return nil, err
+		}
+		if closer != nil {
+			logs.closers = append(logs.closers, closer)
+		}
+		handlers = append(handlers, handler)
+	}
+	logs.handler = handlers
+	return logs, nil
+}
+
+// newLogOutput creates the handler writing to one log destination
+func newLogOutput(output config.LogOutput, def slog.Level) (slog.Handler, io.Closer, error) {
+	level, err := parseLevel(output.Level, def)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var writer io.Writer
+	var closer io.Closer
+	switch strings.ToLower(output.Type) {
+	case "", "stderr":
+		writer = os.Stderr
+	case "stdout":
+		writer = os.Stdout
+	case "file":
+		file, err := os.OpenFile(output.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open log file: %v", err)
+		}
+		writer, closer = file, file
+	case "syslog":
+		w, err := dialSyslog(output.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to connect to syslog: %v", err)
+		}
+		writer, closer = w, w
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", output.Type)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(output.Format) {
+	case "", "text":
+		return slog.NewTextHandler(writer, opts), closer, nil
+	case "json":
+		return slog.NewJSONHandler(writer, opts), closer, nil
+	}
+	if closer != nil {
+		closer.Close()
+	}
+	return nil, nil, fmt.Errorf("unknown log format %q", output.Format)
+}
+
+// Logger returns the logger of a subsystem
+func (l *Logs) Logger(subsystem string) *slog.Logger {
+	if logger, ok := l.loggers.Load(subsystem); ok {
+		return logger.(*slog.Logger)
+	}
+	level, ok := l.levels[subsystem]
+	if !ok {
+		level = l.level
+	}
+	logger := slog.New(&levelHandler{
+		level: level,
+		next:  l.handler.WithAttrs([]slog.Attr{slog.String("subsystem", subsystem)}),
+	})
+	actual, _ := l.loggers.LoadOrStore(subsystem, logger)
+	return actual.(*slog.Logger)
+}
+
+// Close closes the log files and syslog connections
+func (l *Logs) Close() {
+	for _, closer := range l.closers {
+		closer.Close()
+	}
+}
+
+// levelHandler drops records below a subsystem's level
+type levelHandler struct {
+	level slog.Level
+	next  slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.next.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+// multiHandler sends records to every output that accepts their level
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(m))
+	for i, h := range m {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
+
+// Logger returns the logger of a subsystem, such as LogConnections
+func (s *Server) Logger(subsystem string) *slog.Logger {
+	s.mu.RLock()
+	logs := s.logs
+	s.mu.RUnlock()
+	return logs.Logger(subsystem)
+}
+
+// reloadLogs rebuilds the loggers from the configuration, keeping the current
+// ones on error
+func (s *Server) reloadLogs(cfg *config.Config) error {
+	logs, err := newLogs(*cfg, s.Fold)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	previous := s.logs
+	s.logs = logs
+	s.mu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+	return nil
+}
+
+// traceRaw logs a raw line sent to or received from a traced client
+func (c *Client) traceRaw(direction, line string) {
+	s := c.Server
+	s.mu.RLock()
+	logs := s.logs
+	s.mu.RUnlock()
+	if len(logs.trace) == 0 || !logs.trace[s.Fold(c.Nickname)] {
+		return
+	}
+	logs.Logger(LogRaw).Debug(direction, "nick", c.Nickname, "line", strings.TrimRight(line, "\r\n"))
+}
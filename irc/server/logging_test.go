@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/irc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readLogs decodes the JSON records written to a log file
+func readLogs(t *testing.T, path string) []map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// findLog returns the first record with the given subsystem and message
+func findLog(records []map[string]any, subsystem, msg string) map[string]any {
+	for _, record := range records {
+		if record["subsystem"] == subsystem && record["msg"] == msg {
+			return record
+		}
+	}
+	return nil
+}
+
+func TestLoggingSubsystemsAndTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ircd.log")
+	cfg := newTestConfig()
+	cfg.Logging.Level = "warn"
+	cfg.Logging.Subsystems = map[string]string{LogConnections: "info", LogCommands: "debug"}
+	cfg.Logging.Outputs = []config.LogOutput{{Type: "file", Path: path, Format: "json"}}
+	cfg.Logging.Trace = []string{"ALICE"}
+	srv := newTestServer(t, cfg)
+
+	alice := srv.register(t, "alice")
+	alice.send("PING :hello")
+	alice.expect("PONG")
+	bob := srv.register(t, "bob")
+	bob.send("PING :hello")
+	bob.expect("PONG")
+	srv.Logger(LogServer).Info("Dropped below the server level")
+	time.Sleep(50 * time.Millisecond)
+
+	records := readLogs(t, path)
+	registered := findLog(records, LogConnections, "Client registered")
+	require.NotNil(t, registered)
+	assert.Equal(t, "alice", registered["nick"])
+	assert.NotNil(t, findLog(records, LogCommands, "Command received"))
+	assert.Nil(t, findLog(records, LogServer, "Dropped below the server level"))
+
+	// Only the traced nickname has its raw lines logged
+	var raw []string
+	for _, record := range records {
+		if record["subsystem"] == LogRaw {
+			assert.Equal(t, "alice", record["nick"])
+			raw = append(raw, record["msg"].(string)+" "+record["line"].(string))
+		}
+	}
+	assert.Contains(t, raw, "recv PING :hello")
+	assert.Contains(t, raw, "send :test.irc.local PONG test.irc.local hello")
+}
+
+func TestLoggingPerOutputLevel(t *testing.T) {
+	dir := t.TempDir()
+	all, errors := filepath.Join(dir, "all.log"), filepath.Join(dir, "errors.log")
+	cfg := newTestConfig()
+	cfg.Logging.Level = "info"
+	cfg.Logging.Outputs = []config.LogOutput{
+		{Type: "file", Path: all, Format: "json"},
+		{Type: "file", Path: errors, Format: "json", Level: "error"},
+	}
+	srv := newTestServer(t, cfg)
+
+	srv.Logger(LogStorage).Info("Informational")
+	srv.Logger(LogStorage).Error("Failure")
+
+	assert.Len(t, readLogs(t, all), 2)
+	records := readLogs(t, errors)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Failure", records[0]["msg"])
+}
+
+func TestLoggingInvalidSettings(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Logging.Subsystems = map[string]string{LogCommands: "verbose"}
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, `unknown log level "verbose"`)
+
+	cfg = newTestConfig()
+	cfg.Logging.Outputs = []config.LogOutput{{Type: "carrier-pigeon"}}
+	_, err = NewServer(cfg)
+	assert.ErrorContains(t, err, `unknown log output "carrier-pigeon"`)
+}
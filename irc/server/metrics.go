@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultMetricsPath is the metrics endpoint when metrics.path is not configured
+const defaultMetricsPath = "/metrics"
+
+// unknownCommandLabel is the command label used for commands without a hook,
+// keeping the label cardinality bounded
+const unknownCommandLabel = "UNKNOWN"
+
+// Metrics exports server statistics in the Prometheus format
+type Metrics struct {
+	server   *Server
+	registry *prometheus.Registry
+	echo     *echo.Echo
+
+	commands       *prometheus.CounterVec
+	connections    prometheus.Counter
+	disconnections prometheus.Counter
+	registrations  prometheus.Counter
+}
+
+// newMetrics creates the metrics registry and collectors for a server
+func newMetrics(s *Server) *Metrics {
+	m := &Metrics{
+		server:   s,
+		registry: prometheus.NewRegistry(),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ircd_commands_total",
+			Help: "Total number of commands received by command",
+		}, []string{"command"}),
+		connections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ircd_connections_total",
+			Help: "Total number of accepted connections",
+		}),
+		disconnections: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ircd_disconnections_total",
+			Help: "Total number of closed connections",
+		}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "ircd_registrations_total",
+			Help: "Total number of clients that completed registration",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.commands,
+		m.connections,
+		m.disconnections,
+		m.registrations,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ircd_clients",
+			Help: "Number of connected clients",
+		}, func() float64 { return float64(s.ClientCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ircd_channels",
+			Help: "Number of active channels",
+		}, func() float64 { return float64(s.ChannelCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ircd_operators",
+			Help: "Number of connected operators",
+		}, func() float64 { return float64(s.OperCount()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ircd_uptime_seconds",
+			Help: "Seconds since the server started",
+		}, func() float64 { return s.GetUptime().Seconds() }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "ircd_event_subscribers_lagged_total",
+			Help: "Total number of event streams closed for falling behind",
+		}, func() float64 { return float64(s.events.lagged.Load()) }),
+	)
+
+	if s.screener != nil {
+		m.registry.MustRegister(
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name: "ircd_dnsbl_checked_total",
+				Help: "Total number of connections screened against blocklists",
+			}, func() float64 { return float64(s.screener.Stats().Checked) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name: "ircd_dnsbl_rejected_total",
+				Help: "Total number of connections rejected by screening",
+			}, func() float64 { return float64(s.screener.Stats().Rejected) }),
+		)
+	}
+
+	return m
+}
+
+// Handler returns an HTTP handler serving the metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// Start serves the metrics on the configured listener
+func (m *Metrics) Start() error {
+	cfg := m.server.GetConfig()
+	path := cfg.Metrics.Path
+	if path == "" {
+		path = defaultMetricsPath
+	}
+
+	m.echo = echo.New()
+	m.echo.HideBanner = true
+	m.echo.GET(path, echo.WrapHandler(m.Handler()))
+	m.server.Logger(LogHTTP).Info("Starting metrics listener", "address", cfg.GetMetricsListenAddress(), "path", path)
+	return m.echo.Start(cfg.GetMetricsListenAddress())
+}
+
+// Stop stops the metrics listener
+func (m *Metrics) Stop() error {
+	if m.echo == nil {
+		return nil
+	}
+	m.server.Logger(LogHTTP).Info("Stopping metrics listener")
+	return m.echo.Shutdown(context.Background())
+}
+
+// commandReceived counts a command read from a client
+func (m *Metrics) commandReceived(command string) {
+	if m == nil {
+		return
+	}
+	if !m.server.hasHook(command) {
+		command = unknownCommandLabel
+	}
+	m.commands.WithLabelValues(command).Inc()
+}
+
+// connectionOpened counts an accepted connection
+func (m *Metrics) connectionOpened() {
+	if m != nil {
+		m.connections.Inc()
+	}
+}
+
+// connectionClosed counts a closed connection
+func (m *Metrics) connectionClosed() {
+	if m != nil {
+		m.disconnections.Inc()
+	}
+}
+
+// clientRegistered counts a client completing registration
+func (m *Metrics) clientRegistered() {
+	if m != nil {
+		m.registrations.Inc()
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// scrape returns the metrics exposition of the server
+func scrape(t *testing.T, srv *Server) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	srv.metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Metrics.Enabled = true
+	srv := newTestServer(t, cfg)
+
+	alice := srv.register(t, "alice")
+	bob := srv.register(t, "bob")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	alice.send("FROBNICATE")
+	alice.send("PING :x")
+	alice.expect("PONG")
+	srv.oper(t, alice, "alice")
+
+	bob.send("QUIT :bye")
+	assert.Eventually(t, func() bool { return srv.ClientCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	metrics := scrape(t, srv)
+	assert.Contains(t, metrics, "ircd_clients 1\n")
+	assert.Contains(t, metrics, "ircd_channels 1\n")
+	assert.Contains(t, metrics, "ircd_operators 1\n")
+	assert.Contains(t, metrics, "ircd_connections_total 2\n")
+	assert.Contains(t, metrics, "ircd_disconnections_total 1\n")
+	assert.Contains(t, metrics, "ircd_registrations_total 2\n")
+	assert.Contains(t, metrics, `ircd_commands_total{command="JOIN"} 1`+"\n")
+	assert.Contains(t, metrics, `ircd_commands_total{command="NICK"} 2`+"\n")
+	assert.Contains(t, metrics, `ircd_commands_total{command="UNKNOWN"} 1`+"\n")
+	assert.Contains(t, metrics, "ircd_uptime_seconds")
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+	"github.com/presbrey/pkg/irc/config"
+)
+
+// defaultMOTD is shown when server.motd is not configured
+const defaultMOTD = `Welcome to {{.Network}}!
+This server is running GoIRCd, a Go IRC Server`
+
+// motdData holds the variables available to MOTD templates
+type motdData struct {
+	Server   string // Server name
+	Network  string // Network name
+	Nick     string // Nickname of the client reading the MOTD
+	Uptime   string // Server uptime, as in "3 days, 4h5m6s"
+	Users    int    // Connected clients
+	Channels int    // Existing channels
+}
+
+// openSource opens a local file or an http(s) URL for reading
+func openSource(source string) (io.ReadCloser, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		resp, err := http.Get(source)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("status: %s", resp.Status)
+		}
+		return resp.Body, nil
+	}
+	return os.Open(source)
+}
+
+// loadMOTD reads and parses the MOTD template configured in server.motd,
+// falling back to the built-in MOTD when none is configured
+func loadMOTD(source string) (*template.Template, error) {
+	text := defaultMOTD
+	if source != "" {
+		reader, err := openSource(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load MOTD: %v", err)
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MOTD: %v", err)
+		}
+		text = string(data)
+	}
+
+	motd, err := template.New("motd").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MOTD: %v", err)
+	}
+	return motd, nil
+}
+
+// reloadMOTD re-reads the configured MOTD, keeping the current one on error
+func (s *Server) reloadMOTD(cfg *config.Config) error {
+	motd, err := loadMOTD(cfg.Server.MOTD)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.motd = motd
+	s.mu.Unlock()
+	return nil
+}
+
+// formatUptime formats an uptime as days followed by the remaining time
+func formatUptime(uptime time.Duration) string {
+	days := int(uptime.Hours()) / 24
+	return fmt.Sprintf("%d days, %s", days, (uptime % (24 * time.Hour)).Truncate(time.Second))
+}
+
+// SendMOTD renders the MOTD for the client
+func (c *Client) SendMOTD() {
+	s := c.Server
+	s.mu.RLock()
+	motd := s.motd
+	s.mu.RUnlock()
+
+	cfg := s.GetConfig()
+	data := motdData{
+		Server:   cfg.Server.Name,
+		Network:  cfg.Server.Network,
+		Nick:     c.Nickname,
+		Uptime:   formatUptime(s.GetUptime()),
+		Users:    s.ClientCount(),
+		Channels: s.ChannelCount(),
+	}
+
+	var buf bytes.Buffer
+	if motd == nil || motd.Execute(&buf, data) != nil {
+		c.SendError(irc.ERR_NOMOTD, "MOTD File is missing")
+		return
+	}
+
+	c.SendReply(irc.RPL_MOTDSTART, fmt.Sprintf("- %s Message of the Day -", cfg.Server.Name))
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\r\n"), "\n") {
+		c.SendReply(irc.RPL_MOTD, "- "+strings.TrimRight(line, "\r"))
+	}
+	c.SendReply(irc.RPL_ENDOFMOTD, "End of /MOTD command")
+}
+
+// handleMotd handles the MOTD command
+func handleMotd(params *HookParams) error {
+	params.Client.SendMOTD()
+	return nil
+}
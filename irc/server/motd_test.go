@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/presbrey/pkg/irc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMOTDFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ircd.yaml")
+	motd := filepath.Join(t.TempDir(), "motd.txt")
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  name: test.irc.local\n  network: TestNet\n  motd: "+motd+"\n"), 0o600))
+	require.NoError(t, os.WriteFile(motd, []byte("Welcome to {{.Network}}, {{.Nick}}\nServing {{.Users}} users on {{.Server}}\n"), 0o600))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	srv := newTestServer(t, cfg)
+
+	tc := srv.connect(t)
+	tc.send("NICK alice")
+	tc.send("USER alice 0 * :Alice")
+	assert.Equal(t, ":test.irc.local 375 alice :- test.irc.local Message of the Day -", tc.expect(" 375 "))
+	assert.Equal(t, []string{
+		":test.irc.local 372 alice :- Welcome to TestNet, alice",
+		":test.irc.local 372 alice :- Serving 1 users on test.irc.local",
+		":test.irc.local 376 alice :End of /MOTD command",
+	}, tc.collect(" 376 "))
+
+	// The MOTD is re-read on rehash, and a broken template keeps the old one
+	require.NoError(t, os.WriteFile(motd, []byte("Up {{.Uptime}}\n"), 0o600))
+	require.NoError(t, srv.Rehash(""))
+	tc.send("MOTD")
+	assert.Regexp(t, `^:test.irc.local 372 alice :- Up 0 days, `, tc.expect(" 372 "))
+	tc.expect(" 376 ")
+
+	require.NoError(t, os.WriteFile(motd, []byte("{{.Missing"), 0o600))
+	assert.Error(t, srv.Rehash(""))
+	tc.send("MOTD")
+	assert.Contains(t, tc.expect(" 372 "), "Up 0 days")
+}
+
+func TestMOTDFromURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "Hello from {{.Network}}")
+	}))
+	defer ts.Close()
+
+	cfg := newTestConfig()
+	cfg.Server.MOTD = ts.URL
+	srv := newTestServer(t, cfg)
+	alice := srv.connect(t)
+	alice.send("NICK alice")
+	alice.send("USER alice 0 * :Alice")
+	assert.Equal(t, ":test.irc.local 372 alice :- Hello from TestNet", alice.expect(" 372 "))
+
+	cfg = newTestConfig()
+	cfg.Server.MOTD = filepath.Join(t.TempDir(), "missing.txt")
+	_, err := NewServer(cfg)
+	assert.ErrorContains(t, err, "failed to load MOTD")
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// notifyCommonChannels sends line once to every client sharing a channel with
+// c that enabled capability, and to c itself when includeSelf is set
+func (c *Client) notifyCommonChannels(capability, line string, includeSelf bool) {
+	notified := map[string]bool{c.ID: true}
+	if includeSelf && c.HasCap(capability) {
+		c.SendRaw(line)
+	}
+	for _, channel := range c.Channels {
+		channel.mu.RLock()
+		for _, member := range channel.Members {
+			if notified[member.ID] {
+				continue
+			}
+			notified[member.ID] = true
+			if member.HasCap(capability) {
+				member.SendRaw(line)
+			}
+		}
+		channel.mu.RUnlock()
+	}
+}
+
+// hostmask returns the client's nick!user@host
+func (c *Client) hostmask() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return fmt.Sprintf("%s!%s@%s", c.Nickname, c.Username, c.Hostname)
+}
+
+// SetAway sets the client's away status and announces it to channel members
+// with the away-notify capability
+func (c *Client) SetAway(away bool, message string) {
+	c.mu.Lock()
+	c.Away = away
+	c.AwayMessage = message
+	c.mu.Unlock()
+
+	line := fmt.Sprintf(":%s AWAY", c.hostmask())
+	if away {
+		c.SendReply(irc.RPL_NOWAWAY, "You have been marked as being away")
+		line += " :" + message
+	} else {
+		c.SendReply(irc.RPL_UNAWAY, "You are no longer marked as being away")
+	}
+	c.notifyCommonChannels(CapAwayNotify, line, false)
+}
+
+// SetAccount logs the client in to account, or out when account is empty, and
+// announces the change with ACCOUNT to channel members with account-notify
+func (c *Client) SetAccount(account string) {
+	c.mu.Lock()
+	changed := c.Account != account
+	c.Account = account
+	registered := c.Registered
+	c.mu.Unlock()
+
+	if !changed || !registered {
+		return
+	}
+
+	if account == "" {
+		account = "*"
+	}
+	c.notifyCommonChannels(CapAccountNotify, fmt.Sprintf(":%s ACCOUNT %s", c.hostmask(), account), true)
+}
+
+// sendJoin announces the client joining channel to its members, with the
+// account and real name for members with extended-join, followed by the away
+// status for members with away-notify
+func (c *Client) sendJoin(channel *Channel) {
+	c.mu.RLock()
+	account := c.Account
+	away, awayMessage := c.Away, c.AwayMessage
+	c.mu.RUnlock()
+	if account == "" {
+		account = "*"
+	}
+
+	mask := c.hostmask()
+	join := fmt.Sprintf(":%s JOIN %s", mask, channel.Name)
+	extendedJoin := fmt.Sprintf("%s %s :%s", join, account, c.Realname)
+
+	channel.mu.RLock()
+	defer channel.mu.RUnlock()
+	for _, member := range channel.Members {
+		if member.HasCap(CapExtendedJoin) {
+			member.SendRaw(extendedJoin)
+		} else {
+			member.SendRaw(join)
+		}
+		if away && member != c && member.HasCap(CapAwayNotify) {
+			member.SendRaw(fmt.Sprintf(":%s AWAY :%s", mask, awayMessage))
+		}
+	}
+}
+
+// handleAway handles the AWAY command
+func handleAway(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 1 || message.Params[0] == "" {
+		client.SetAway(false, "")
+		return nil
+	}
+	client.SetAway(true, message.Params[0])
+	return nil
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAwayNotify(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+
+	alice := srv.register(t, "alice")
+	bob := srv.registerWithCaps(t, "bob", CapAwayNotify)
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+	alice.drain()
+
+	alice.send("AWAY :Gone fishing")
+	assert.Contains(t, alice.expect(" 306 "), "You have been marked as being away")
+	assert.Equal(t, ":alice!alice@ AWAY :Gone fishing", bob.expect(" AWAY "))
+
+	bob.send("PRIVMSG alice :ping")
+	assert.Equal(t, ":test.irc.local 301 bob alice :Gone fishing", bob.expect(" 301 "))
+
+	alice.send("AWAY")
+	alice.expect(" 305 ")
+	assert.Equal(t, ":alice!alice@ AWAY", bob.expect(" AWAY"))
+}
+
+func TestExtendedJoin(t *testing.T) {
+	srv := newTestServer(t, newTestConfig())
+
+	alice := srv.registerWithCaps(t, "alice", CapExtendedJoin, CapAwayNotify)
+	alice.send("JOIN #test")
+	assert.Equal(t, ":alice!alice@ JOIN #test * :Test alice", alice.expect(" JOIN "))
+	alice.expect(" 366 ")
+
+	bob := srv.register(t, "bob")
+	bob.send("AWAY :brb")
+	bob.expect(" 306 ")
+	bob.send("JOIN #test")
+	assert.Equal(t, ":bob!bob@ JOIN #test", bob.expect(" JOIN "))
+	assert.Equal(t, ":bob!bob@ JOIN #test * :Test bob", alice.expect(" JOIN "))
+	assert.Equal(t, ":bob!bob@ AWAY :brb", alice.expect(" AWAY "))
+}
+
+func TestAccountNotify(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.Services.Enabled = true
+	srv := newTestServer(t, cfg)
+
+	alice := srv.register(t, "alice")
+	bob := srv.registerWithCaps(t, "bob", CapAccountNotify)
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	bob.send("JOIN #test")
+	bob.expect(" 366 ")
+
+	alice.send("PRIVMSG NickServ :REGISTER hunter2")
+	alice.expect(" 900 ")
+	assert.Equal(t, ":alice!alice@ ACCOUNT alice", bob.expect(" ACCOUNT "))
+
+	alice.send("PRIVMSG NickServ :DROP hunter2")
+	assert.Equal(t, ":alice!alice@ ACCOUNT *", bob.expect(" ACCOUNT "))
+}
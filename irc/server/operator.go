@@ -14,6 +14,7 @@ type Operator struct {
 	Password    string
 	Email       string
 	Mask        string
+	CertFP      string // SHA-256 fingerprint of the client certificate accepted by SASL EXTERNAL
 	LastLogin   time.Time
 	MagicTokens map[string]time.Time
 	mu          sync.RWMutex
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/presbrey/pkg/irc/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rehashConfig = `server:
+  name: test.irc.local
+listen_irc:
+  enabled: false
+operators:
+  - username: admin
+    password: secret
+`
+
+func TestWatchConfigRehash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ircd.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rehashConfig), 0o600))
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+	srv := newTestServer(t, cfg)
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+	alice.drain()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.WatchConfig(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	// Operators and limits are applied when the file changes
+	updated := rehashConfig + "  - username: root\n    password: hunter2\nlimits:\n  nicklen: 9\n"
+	require.NoError(t, os.WriteFile(path, []byte(updated), 0o600))
+	assert.Contains(t, alice.expect("Configuration reloaded"), path)
+	assert.NotNil(t, srv.GetOperator("root"))
+	assert.Equal(t, 9, srv.limits().NickLen)
+
+	// An invalid configuration is rejected and the previous one kept
+	require.NoError(t, os.WriteFile(path, []byte(updated+"hostnames:\n  cloak: true\n"), 0o600))
+	assert.Contains(t, alice.expect("Failed to reload configuration"), "cloak_secret")
+	assert.False(t, srv.GetConfig().Hostnames.Cloak)
+	assert.Equal(t, 9, srv.limits().NickLen)
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// SASL mechanisms supported by AUTHENTICATE
+const (
+	SASLPlain    = "PLAIN"
+	SASLExternal = "EXTERNAL"
+)
+
+// saslMechanisms lists the mechanisms advertised with the sasl capability
+var saslMechanisms = []string{SASLPlain, SASLExternal}
+
+const (
+	// saslChunkSize is the maximum length of one AUTHENTICATE payload line;
+	// a line of exactly this length means more data follows
+	saslChunkSize = 400
+
+	// saslMaxPayload bounds the total size of a buffered SASL payload
+	saslMaxPayload = 8192
+)
+
+// saslSession tracks an in-progress AUTHENTICATE exchange
+type saslSession struct {
+	mechanism string
+	buffer    strings.Builder
+}
+
+// certFingerprint returns the hex SHA-256 fingerprint of the peer certificate
+func certFingerprint(state tls.ConnectionState) string {
+	if len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(state.PeerCertificates[0].Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeFingerprint lowercases a fingerprint and strips colon separators
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(fp, ":", ""))
+}
+
+// getOperatorByCertFP finds the operator whose configured certificate
+// fingerprint matches fp
+func (s *Server) getOperatorByCertFP(fp string) *Operator {
+	fp = normalizeFingerprint(fp)
+	if fp == "" {
+		return nil
+	}
+
+	var found *Operator
+	s.operators.Range(func(key, value interface{}) bool {
+		op := value.(*Operator)
+		if op.CertFP != "" && normalizeFingerprint(op.CertFP) == fp {
+			found = op
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// handleAuthenticate handles the AUTHENTICATE command
+func handleAuthenticate(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "AUTHENTICATE", "Not enough parameters")
+		return nil
+	}
+
+	if !client.HasCap(CapSASL) {
+		client.SendError(irc.ERR_SASLFAIL, "SASL authentication failed")
+		return nil
+	}
+
+	client.mu.RLock()
+	loggedIn := client.Account != ""
+	session := client.sasl
+	client.mu.RUnlock()
+
+	if loggedIn {
+		client.SendError(irc.ERR_SASLALREADY, "You have already authenticated using SASL")
+		return nil
+	}
+
+	data := message.Params[0]
+
+	// A lone asterisk aborts the exchange
+	if data == "*" {
+		client.setSASLSession(nil)
+		client.SendError(irc.ERR_SASLABORTED, "SASL authentication aborted")
+		return nil
+	}
+
+	// The first AUTHENTICATE selects the mechanism
+	if session == nil {
+		mechanism := strings.ToUpper(data)
+		if !isSupportedSASLMechanism(mechanism) {
+			client.SendError(irc.RPL_SASLMECHS, strings.Join(saslMechanisms, ","), "are available SASL mechanisms")
+			client.SendError(irc.ERR_SASLFAIL, "SASL authentication failed")
+			return nil
+		}
+		client.setSASLSession(&saslSession{mechanism: mechanism})
+		client.SendRaw("AUTHENTICATE +")
+		return nil
+	}
+
+	if len(data) > saslChunkSize || session.buffer.Len()+len(data) > saslMaxPayload {
+		client.setSASLSession(nil)
+		client.SendError(irc.ERR_SASLTOOLONG, "SASL message too long")
+		return nil
+	}
+
+	// "+" carries an empty chunk; a full-length chunk means more data follows
+	if data != "+" {
+		session.buffer.WriteString(data)
+	}
+	if len(data) == saslChunkSize {
+		return nil
+	}
+	client.setSASLSession(nil)
+
+	payload, err := base64.StdEncoding.DecodeString(session.buffer.String())
+	if err != nil {
+		client.SendError(irc.ERR_SASLFAIL, "SASL authentication failed")
+		return nil
+	}
+
+	var account string
+	switch session.mechanism {
+	case SASLPlain:
+		if client.authThrottled() {
+			break
+		}
+		if account = client.Server.saslPlain(payload); account == "" {
+			client.authFailed()
+		} else {
+			client.authSucceeded()
+		}
+	case SASLExternal:
+		account = client.Server.saslExternal(client, payload)
+	}
+	if account == "" {
+		client.SendError(irc.ERR_SASLFAIL, "SASL authentication failed")
+		return nil
+	}
+
+	client.saslLogin(account)
+	return nil
+}
+
+// isSupportedSASLMechanism checks if the server supports a SASL mechanism
+func isSupportedSASLMechanism(mechanism string) bool {
+	for _, m := range saslMechanisms {
+		if m == mechanism {
+			return true
+		}
+	}
+	return false
+}
+
+// saslPlain validates a PLAIN payload of the form authzid NUL authcid NUL passwd
+// against the operators and then any NickServ registrations, returning the
+// authenticated account
+func (s *Server) saslPlain(payload []byte) string {
+	parts := bytes.Split(payload, []byte{0})
+	if len(parts) != 3 {
+		return ""
+	}
+	authzid, authcid, password := string(parts[0]), string(parts[1]), string(parts[2])
+	if authzid != "" && authzid != authcid {
+		return ""
+	}
+
+	if operator := s.GetOperator(authcid); operator != nil && operator.CheckPassword(password) {
+		return operator.Username
+	}
+	if s.services.Authenticate(authcid, password) {
+		reg, _ := s.services.store.Nick(authcid)
+		return reg.Nick
+	}
+	return ""
+}
+
+// saslExternal authenticates with the TLS client certificate, optionally
+// checking the requested authorization identity, and returns the account
+func (s *Server) saslExternal(client *Client, payload []byte) string {
+	client.mu.RLock()
+	certfp := client.certfp
+	client.mu.RUnlock()
+
+	operator := s.getOperatorByCertFP(certfp)
+	if operator == nil {
+		return ""
+	}
+	if authzid := string(payload); authzid != "" && authzid != operator.Username {
+		return ""
+	}
+	return operator.Username
+}
+
+// setSASLSession replaces the client's in-progress AUTHENTICATE exchange
+func (c *Client) setSASLSession(session *saslSession) {
+	c.mu.Lock()
+	c.sasl = session
+	c.mu.Unlock()
+}
+
+// saslLogin logs the client in to account and reports success
+func (c *Client) saslLogin(account string) {
+	c.SetAccount(account)
+
+	c.mu.RLock()
+	registered := c.Registered
+	mask := fmt.Sprintf("%s!%s@%s", c.capTarget(), c.Username, c.Hostname)
+	c.mu.RUnlock()
+
+	target := c.capTarget()
+	c.SendNumericWithTarget(irc.RPL_LOGGEDIN, target, mask, account, "You are now logged in as "+account)
+	c.SendNumericWithTarget(irc.RPL_SASLSUCCESS, target, "SASL authentication successful")
+
+	// Registered clients get their vhost now, others when registration completes
+	if registered {
+		c.Server.applyVHost(c)
+	}
+}
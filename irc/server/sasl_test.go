@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// saslPayload encodes a SASL payload for AUTHENTICATE
+func saslPayload(parts ...string) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Join(parts, "\x00")))
+}
+
+// startSASL opens CAP negotiation with sasl enabled and selects mechanism
+func (s *Server) startSASL(t *testing.T, mechanism string) *testClient {
+	t.Helper()
+	tc := s.connect(t)
+	tc.send("CAP LS 302")
+	assert.Contains(t, tc.expect(" LS "), "sasl=PLAIN,EXTERNAL")
+	tc.send("CAP REQ :sasl")
+	tc.expect(" ACK ")
+	tc.send("AUTHENTICATE " + mechanism)
+	tc.expect("AUTHENTICATE +")
+	return tc
+}
+
+func TestSASLPlain(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.operators.Store("alice", &Operator{Username: "alice", Password: "secret"})
+
+	// Wrong password fails and leaves the client logged out
+	tc := srv.startSASL(t, "PLAIN")
+	tc.send("AUTHENTICATE " + saslPayload("", "alice", "wrong"))
+	tc.expect(" 904 ")
+
+	// A second attempt with the right password logs the client in
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect("AUTHENTICATE +")
+	tc.send("AUTHENTICATE " + saslPayload("alice", "alice", "secret"))
+	assert.Contains(t, tc.expect(" 900 "), "alice :You are now logged in as alice")
+	tc.expect(" 903 ")
+
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect(" 907 ")
+
+	tc.send("NICK alice")
+	tc.send("USER alice 0 * :Alice")
+	tc.send("CAP END")
+	tc.expect(" 376 ")
+
+	client := srv.GetClient("alice")
+	assert.Equal(t, "alice", client.Account)
+	assert.False(t, client.IsOper, "SASL login does not grant operator status")
+}
+
+func TestSASLPlainThrottle(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+	srv.operators.Store("alice", &Operator{Username: "alice", Password: "secret"})
+
+	tc := srv.startSASL(t, "PLAIN")
+	for i := 0; i < authFreeFailures; i++ {
+		tc.send("AUTHENTICATE " + saslPayload("", "alice", "wrong"))
+		tc.expect(" 904 ")
+		tc.send("AUTHENTICATE PLAIN")
+		tc.expect("AUTHENTICATE +")
+	}
+
+	// The right password is refused until the delay passes
+	tc.send("AUTHENTICATE " + saslPayload("", "alice", "secret"))
+	tc.expect(" 904 ")
+
+	clock.Advance(authFailureDelay)
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect("AUTHENTICATE +")
+	tc.send("AUTHENTICATE " + saslPayload("", "alice", "secret"))
+	assert.Contains(t, tc.expect(" 900 "), "You are now logged in as alice")
+	tc.expect(" 903 ")
+}
+
+func TestSASLExternal(t *testing.T) {
+	srv := newTestServer(t, nil)
+	srv.operators.Store("alice", &Operator{Username: "alice", CertFP: "AB:CD:EF"})
+
+	// Without a client certificate EXTERNAL fails
+	tc := srv.startSASL(t, "EXTERNAL")
+	tc.send("AUTHENTICATE +")
+	tc.expect(" 904 ")
+
+	srv.clients.Range(func(key, value interface{}) bool {
+		client := value.(*Client)
+		client.mu.Lock()
+		client.certfp = "abcdef"
+		client.mu.Unlock()
+		return true
+	})
+
+	// A mismatched authorization identity is rejected
+	tc.send("AUTHENTICATE EXTERNAL")
+	tc.expect("AUTHENTICATE +")
+	tc.send("AUTHENTICATE " + saslPayload("bob"))
+	tc.expect(" 904 ")
+
+	tc.send("AUTHENTICATE EXTERNAL")
+	tc.expect("AUTHENTICATE +")
+	tc.send("AUTHENTICATE +")
+	assert.Contains(t, tc.expect(" 900 "), "You are now logged in as alice")
+	tc.expect(" 903 ")
+}
+
+func TestSASLErrors(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	// AUTHENTICATE requires the sasl capability
+	tc := srv.connect(t)
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect(" 904 ")
+
+	// Unknown mechanisms are answered with the supported list
+	tc = srv.startSASL(t, "PLAIN")
+	tc.send("AUTHENTICATE *")
+	tc.expect(" 906 ")
+	tc.send("AUTHENTICATE SCRAM-SHA-256")
+	assert.Contains(t, tc.expect(" 908 "), "PLAIN,EXTERNAL")
+	tc.expect(" 904 ")
+
+	// Oversized chunks abort the exchange
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect("AUTHENTICATE +")
+	tc.send("AUTHENTICATE " + strings.Repeat("A", saslChunkSize+1))
+	tc.expect(" 905 ")
+
+	// CAP END abandons an unfinished exchange
+	tc.send("AUTHENTICATE PLAIN")
+	tc.expect("AUTHENTICATE +")
+	tc.send("CAP END")
+	tc.expect(" 906 ")
+}
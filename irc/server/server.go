@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -14,6 +15,8 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/presbrey/pkg/irc"
@@ -22,18 +25,35 @@ import (
 
 // Server represents the IRC server
 type Server struct {
-	config    *config.Config
+	config    atomic.Pointer[config.Config] // Replaced as a whole on rehash
 	startTime time.Time
 	clients   sync.Map // map[string]*Client
 	channels  sync.Map // map[string]*Channel
 	operators sync.Map // map[string]*Operator
+	bans      sync.Map // map[string]*ServerBan
 	hooks     map[string][]Hook
 	mu        sync.RWMutex // Still needed for hooks and other operations
+	rehashMu  sync.Mutex   // Serializes Rehash
 	listener  net.Listener
 	listeners []net.Listener
 	botAPI    *BotAPI
 	webPortal *WebPortal
+	clock     Clock
 	quit      chan struct{}
+	done      chan struct{} // Closed once Stop has finished
+	casemap   string        // Casemapping fixed at startup, see irc.FoldCase
+
+	services     *Services          // Built-in NickServ/ChanServ, nil when disabled
+	serviceStore ServiceStore       // Store provided with WithServiceStore
+	history      HistoryStore       // Channel message history, nil when disabled
+	screener     *Screener          // DNSBL and Tor exit screening, nil when disabled
+	metrics      *Metrics           // Prometheus exporter, nil when disabled
+	lookups      *hostLookups       // Connect-time reverse DNS and ident lookups
+	motd         *template.Template // MOTD template, re-read on rehash
+	state        StateStore         // Bans, operators and channel state kept across restarts, nil when disabled
+	logs         *Logs              // Structured loggers, rebuilt on rehash
+	console      *Console           // Local control interface, nil when disabled
+	events       *eventHub          // Live state changes for dashboards
 }
 
 // Hook is a function that can be registered to handle various events
@@ -53,13 +73,54 @@ type HookParams struct {
 }
 
 // NewServer creates a new IRC server
-func NewServer(cfg *config.Config) (*Server, error) {
+func NewServer(cfg *config.Config, opts ...Option) (*Server, error) {
 	srv := &Server{
-		config:    cfg,
-		startTime: time.Now(),
+		clock: realClock{},
 		// sync.Map doesn't need initialization with make()
-		hooks: make(map[string][]Hook),
-		quit:  make(chan struct{}),
+		hooks:   make(map[string][]Hook),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		events:  newEventHub(),
+		lookups: newHostLookups(),
+	}
+	srv.config.Store(cfg)
+
+	// Apply options before anything reads the clock
+	for _, opt := range opts {
+		opt(srv)
+	}
+	srv.startTime = srv.Now()
+
+	if err := validateConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	// Fix the casemapping for the lifetime of the server, since channels and
+	// members are keyed by folded names
+	srv.casemap = cfg.Server.CaseMapping
+	if srv.casemap == "" {
+		srv.casemap = irc.CaseMappingRFC1459
+	}
+
+	if err := srv.reloadLogs(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := srv.reloadMOTD(cfg); err != nil {
+		return nil, err
+	}
+
+	// Restore persisted state before the configured operators, which take
+	// precedence over stored ones
+	if cfg.Persistence.Enabled && srv.state == nil {
+		state, err := newStateStore(srv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize persistence: %v", err)
+		}
+		srv.state = state
+	}
+	if err := srv.restoreState(); err != nil {
+		return nil, err
 	}
 
 	// Initialize the operator list
@@ -69,9 +130,38 @@ func NewServer(cfg *config.Config) (*Server, error) {
 			Password: op.Password,
 			Email:    op.Email,
 			Mask:     op.Mask,
+			CertFP:   op.CertFP,
 		})
 	}
 
+	// Initialize the services if enabled
+	if cfg.Services.Enabled {
+		services, err := newServices(srv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize services: %v", err)
+		}
+		srv.services = services
+	}
+
+	// Initialize message history if enabled and no store was provided
+	if cfg.History.Enabled && srv.history == nil {
+		history, err := newHistoryStore(srv)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize history: %v", err)
+		}
+		srv.history = history
+	}
+
+	// Initialize connection screening if enabled
+	if cfg.DNSBL.Enabled {
+		srv.screener = newScreener(srv)
+	}
+
+	// Initialize the metrics exporter if enabled
+	if cfg.Metrics.Enabled {
+		srv.metrics = newMetrics(srv)
+	}
+
 	// Initialize the web portal if enabled
 	if cfg.WebPortal.Enabled {
 		portal, err := NewWebPortal(srv, cfg)
@@ -90,6 +180,11 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		srv.botAPI = api
 	}
 
+	// Initialize the console if enabled
+	if cfg.Console.Enabled {
+		srv.console = newConsole(srv)
+	}
+
 	// Register default hooks
 	srv.registerDefaultHooks()
 
@@ -98,34 +193,38 @@ func NewServer(cfg *config.Config) (*Server, error) {
 
 // Start starts the IRC server with multiple possible listeners
 func (s *Server) Start() error {
+	cfg := s.GetConfig()
 	var listeners []net.Listener
 
 	// Start unencrypted IRC listener if enabled
-	if s.config.ListenIRC.Enabled {
+	if cfg.ListenIRC.Enabled {
 		// Create standard TCP listener
-		fmt.Printf("Starting unencrypted IRC server on %s\n", s.config.GetIRCListenAddress())
-		listener, err := net.Listen("tcp", s.config.GetIRCListenAddress())
+		s.Logger(LogServer).Info("Starting unencrypted IRC listener", "address", cfg.GetIRCListenAddress())
+		listener, err := net.Listen("tcp", cfg.GetIRCListenAddress())
 		if err != nil {
-			return fmt.Errorf("failed to listen on %s: %v", s.config.GetIRCListenAddress(), err)
+			return fmt.Errorf("failed to listen on %s: %v", cfg.GetIRCListenAddress(), err)
 		}
 		listeners = append(listeners, listener)
 	}
 
 	// Start TLS encrypted IRC listener if enabled
-	if s.config.ListenTLS.Enabled {
+	if cfg.ListenTLS.Enabled {
 		// Create TLS config
 		tlsConfig := &tls.Config{
 			MinVersion: tls.VersionTLS12,
+			// Client certificates are optional and only used by SASL EXTERNAL
+			ClientAuth: tls.RequestClientCert,
 		}
 
 		// Check if we need to generate certificates
-		if s.config.ListenTLS.Generation {
+		if cfg.ListenTLS.Generation {
 			cert, key, err := s.generateSelfSignedCert()
 			if err != nil {
 				return fmt.Errorf("failed to generate self-signed certificate: %v", err)
 			}
 
 			// Print the certificates instead of saving to disk
+			s.Logger(LogServer).Warn("Using a generated self-signed certificate")
 			fmt.Println("========== GENERATED CERTIFICATE ==========")
 			fmt.Println(cert)
 			fmt.Println("========== GENERATED PRIVATE KEY ==========")
@@ -138,9 +237,9 @@ func (s *Server) Start() error {
 				return fmt.Errorf("failed to parse generated certificate: %v", err)
 			}
 			tlsConfig.Certificates = []tls.Certificate{certPair}
-		} else if s.config.ListenTLS.Cert != "" && s.config.ListenTLS.Key != "" {
+		} else if cfg.ListenTLS.Cert != "" && cfg.ListenTLS.Key != "" {
 			// Load certificate and key from files
-			cert, err := tls.LoadX509KeyPair(s.config.ListenTLS.Cert, s.config.ListenTLS.Key)
+			cert, err := tls.LoadX509KeyPair(cfg.ListenTLS.Cert, cfg.ListenTLS.Key)
 			if err != nil {
 				return fmt.Errorf("failed to load TLS certificate: %v", err)
 			}
@@ -150,12 +249,12 @@ func (s *Server) Start() error {
 		}
 
 		// Create TLS listener
-		tlsHost := s.config.ListenTLS.Host
+		tlsHost := cfg.ListenTLS.Host
 		if tlsHost == "" {
-			tlsHost = s.config.ListenIRC.Host // Use the same host as IRC if not specified
+			tlsHost = cfg.ListenIRC.Host // Use the same host as IRC if not specified
 		}
-		tlsAddress := fmt.Sprintf("%s:%d", tlsHost, s.config.ListenTLS.Port)
-		fmt.Printf("Starting TLS encrypted IRC server on %s\n", tlsAddress)
+		tlsAddress := fmt.Sprintf("%s:%d", tlsHost, cfg.ListenTLS.Port)
+		s.Logger(LogServer).Info("Starting TLS encrypted IRC listener", "address", tlsAddress)
 		tlsListener, err := tls.Listen("tcp", tlsAddress, tlsConfig)
 		if err != nil {
 			// Close any previously created listeners
@@ -178,6 +277,11 @@ func (s *Server) Start() error {
 	// Store the first listener as the primary for backward compatibility
 	s.listener = listeners[0]
 
+	// Keep the Tor exit list up to date if configured
+	if s.screener != nil && cfg.DNSBL.TorExitList != "" {
+		go s.screener.refreshTorExits()
+	}
+
 	// Start the web portal if enabled
 	if s.webPortal != nil {
 		go s.webPortal.Start()
@@ -188,15 +292,36 @@ func (s *Server) Start() error {
 		go s.botAPI.Start()
 	}
 
+	// Start the metrics exporter if enabled
+	if s.metrics != nil {
+		go s.metrics.Start()
+	}
+
+	// Start the console if enabled
+	if s.console != nil {
+		if err := s.console.Start(); err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+	}
+
 	// Accept and handle connections
 	go s.acceptConnections()
 
 	return nil
 }
 
-// Stop stops the IRC server
+// Stop stops the IRC server. Calls after the first have no effect.
 func (s *Server) Stop() error {
+	s.mu.Lock()
+	if s.stopping() {
+		s.mu.Unlock()
+		return nil
+	}
 	close(s.quit)
+	s.mu.Unlock()
 
 	// Close all listeners
 	for _, listener := range s.listeners {
@@ -215,6 +340,16 @@ func (s *Server) Stop() error {
 		s.botAPI.Stop()
 	}
 
+	// Stop the metrics exporter
+	if s.metrics != nil {
+		s.metrics.Stop()
+	}
+
+	// Stop the console
+	if s.console != nil {
+		s.console.Stop()
+	}
+
 	// Create a list of clients to disconnect
 	clientsToDisconnect := make([]*Client, 0)
 	s.clients.Range(func(key, value interface{}) bool {
@@ -228,9 +363,18 @@ func (s *Server) Stop() error {
 		client.Quit("Server shutting down")
 	}
 
+	s.Logger(LogServer).Info("Server stopped")
+	s.logs.Close()
+	close(s.done)
 	return nil
 }
 
+// Done returns a channel that is closed once the server has stopped,
+// including when it is shut down from the console
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
 // acceptConnections accepts and handles new connections
 func (s *Server) acceptConnections() {
 	for i := range s.listeners {
@@ -258,7 +402,7 @@ func (s *Server) acceptConnections() {
 							return // Server is shutting down
 						default:
 							// Not shutting down, log the error
-							fmt.Printf("Failed to accept connection on listener %d: %v\n", listenerIndex, err)
+							s.Logger(LogConnections).Error("Failed to accept connection", "listener", listenerIndex, "error", err)
 							// Add a small delay to avoid tight loops on errors
 							time.Sleep(100 * time.Millisecond)
 							continue
@@ -276,10 +420,12 @@ func (s *Server) acceptConnections() {
 // handleConnection handles a new connection
 func (s *Server) handleConnection(conn net.Conn) {
 	client := NewClient(s, conn)
+	s.Logger(LogConnections).Info("Connection accepted", "id", client.ID, "ip", client.IP, "listener", client.Listener)
 
 	// Register the client (temporary ID before nick registration)
 	// No need for mutex with sync.Map
 	s.clients.Store(client.ID, client)
+	s.metrics.connectionOpened()
 
 	// Handle the client
 	client.Handle()
@@ -300,22 +446,37 @@ func (s *Server) RunHooks(event string, params *HookParams) error {
 
 	for _, hook := range hooks {
 		if err := hook(params); err != nil {
+			s.Logger(LogCommands).Error("Hook failed", "event", event, "error", err)
 			return err
 		}
 	}
 	return nil
 }
 
+// hasHook checks if any hook is registered for an event
+func (s *Server) hasHook(event string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.hooks[event]) > 0
+}
+
 // registerDefaultHooks registers the default hooks
 func (s *Server) registerDefaultHooks() {
 	// Register default command handlers
+	s.RegisterHook("CAP", handleCap)
+	s.RegisterHook("AUTHENTICATE", handleAuthenticate)
 	s.RegisterHook("PASS", handlePass)
 	s.RegisterHook("NICK", handleNick)
 	s.RegisterHook("USER", handleUser)
 	s.RegisterHook("JOIN", handleJoin)
 	s.RegisterHook("PART", handlePart)
 	s.RegisterHook("PRIVMSG", handlePrivmsg)
+	s.RegisterHook("NOTICE", handleNotice)
+	s.RegisterHook("TAGMSG", handleTagmsg)
+	s.RegisterHook("CHATHISTORY", handleChathistory)
 	s.RegisterHook("QUIT", handleQuit)
+	s.RegisterHook("AWAY", handleAway)
+	s.RegisterHook("MOTD", handleMotd)
 	s.RegisterHook("MODE", handleMode)
 	s.RegisterHook("PING", handlePing)
 	s.RegisterHook("PONG", handlePong)
@@ -326,15 +487,33 @@ func (s *Server) registerDefaultHooks() {
 	s.RegisterHook("TOPIC", handleTopic)
 	s.RegisterHook("KICK", handleKick)
 	s.RegisterHook("INVITE", handleInvite)
+	s.RegisterHook("KNOCK", handleKnock)
 	s.RegisterHook("OPER", handleOper)
 	s.RegisterHook("KILL", handleKill)
 	s.RegisterHook("REHASH", handleRehash)
+	s.RegisterHook("KLINE", handleKLine)
+	s.RegisterHook("UNKLINE", handleUnKLine)
+	s.RegisterHook("GLINE", handleGLine)
+	s.RegisterHook("UNGLINE", handleUnGLine)
+	s.RegisterHook("STATS", handleStats)
+	s.RegisterHook("GLOBOPS", handleGlobops)
+	s.RegisterHook("LOCOPS", handleLocops)
+	s.RegisterHook("WALLOPS", handleWallops)
+}
+
+// Fold returns the canonical form of a nickname or channel name under the
+// server casemapping
+func (s *Server) Fold(name string) string {
+	if s == nil {
+		return irc.FoldCase(irc.CaseMappingRFC1459, name)
+	}
+	return irc.FoldCase(s.casemap, name)
 }
 
 // GetChannel gets a channel by name
 func (s *Server) GetChannel(name string) *Channel {
 	// No mutex needed with sync.Map
-	value, exists := s.channels.Load(name)
+	value, exists := s.channels.Load(s.Fold(name))
 	if !exists {
 		return nil
 	}
@@ -345,20 +524,28 @@ func (s *Server) GetChannel(name string) *Channel {
 func (s *Server) CreateChannel(name string) *Channel {
 	// No mutex needed with sync.Map
 	channel := NewChannel(s, name)
-	s.channels.Store(name, channel)
+	s.restoreChannel(channel)
+	s.channels.Store(s.Fold(name), channel)
 	return channel
 }
 
-// RemoveChannel removes a channel
+// RemoveChannel removes a channel, forgetting its persisted state unless the
+// server is shutting down
 func (s *Server) RemoveChannel(name string) {
+	// Forget the stored state first so a channel that is gone from memory is
+	// never restored
+	if s.state != nil && !s.stopping() {
+		s.persist("channel "+name, s.state.DeleteChannel(s.Fold(name)))
+	}
 	// No mutex needed with sync.Map
-	s.channels.Delete(name)
+	s.channels.Delete(s.Fold(name))
 }
 
 // GetClient gets a client by nickname
 func (s *Server) GetClient(nickname string) *Client {
 	// This requires iteration since we're looking up by nickname, not ID
 	var result *Client
+	nickname = s.Fold(nickname)
 
 	// Use Range to iterate through all clients
 	s.clients.Range(func(key, value interface{}) bool {
@@ -366,7 +553,7 @@ func (s *Server) GetClient(nickname string) *Client {
 
 		// Add locking when accessing the client's nickname
 		client.mu.RLock()
-		isMatch := client.Nickname == nickname
+		isMatch := client.Nickname != "" && s.Fold(client.Nickname) == nickname
 		client.mu.RUnlock()
 
 		if isMatch {
@@ -389,7 +576,16 @@ func (s *Server) RemoveClient(client *Client) {
 	})
 
 	// Remove the client from the server
-	s.clients.Delete(client.ID)
+	if _, connected := s.clients.LoadAndDelete(client.ID); connected {
+		s.metrics.connectionClosed()
+		if client.Registered {
+			client.mu.RLock()
+			reason := client.quitReason
+			client.mu.RUnlock()
+			s.publish(EventQuit, map[string]interface{}{"nickname": client.Nickname, "reason": reason})
+		}
+	}
+	s.services.cancelEnforcement(client)
 }
 
 // GetOperator gets an operator by username
@@ -402,31 +598,114 @@ func (s *Server) GetOperator(username string) *Operator {
 	return value.(*Operator)
 }
 
-// Rehash reloads the server configuration
+// isOperatorName reports whether name matches an operator username, ignoring
+// case. Operator usernames and NickServ nicknames share the account namespace.
+func (s *Server) isOperatorName(name string) bool {
+	found := false
+	s.operators.Range(func(key, _ any) bool {
+		found = strings.EqualFold(key.(string), name)
+		return !found
+	})
+	return found
+}
+
+// validateConfig checks settings that NewServer and Rehash cannot apply
+func validateConfig(cfg *config.Config) error {
+	if cm := cfg.Server.CaseMapping; cm != "" && !irc.ValidCaseMapping(cm) {
+		return fmt.Errorf("unknown casemapping %q", cm)
+	}
+	if cfg.Hostnames.Cloak && cfg.Hostnames.CloakSecret == "" {
+		return fmt.Errorf("hostnames.cloak_secret is required when cloaking is enabled")
+	}
+	if c := cfg.Console; c.Enabled && c.Socket == "" {
+		if c.Port == 0 {
+			return fmt.Errorf("console.socket or console.port is required when the console is enabled")
+		}
+		if c.Password == "" {
+			return fmt.Errorf("console.password is required for a TCP console")
+		}
+	}
+	return nil
+}
+
+// AddOperator adds or replaces an operator, persisting it when a state store
+// is configured. Operators defined in the configuration replace stored
+// operators of the same name on restart and rehash.
+func (s *Server) AddOperator(op *Operator) error {
+	if s.state != nil {
+		if err := s.state.SaveOperator(op); err != nil {
+			return err
+		}
+	}
+	s.operators.Store(op.Username, op)
+	return nil
+}
+
+// RemoveOperator removes an operator added with AddOperator
+func (s *Server) RemoveOperator(username string) error {
+	if s.state != nil {
+		if err := s.state.DeleteOperator(username); err != nil {
+			return err
+		}
+	}
+	s.operators.Delete(username)
+	return nil
+}
+
+// Rehash reloads the server configuration. Settings read on use, such as
+// listener passwords and limits, apply immediately; an invalid configuration
+// is rejected and the previous one kept.
 func (s *Server) Rehash(newSource string) error {
-	// Reload the configuration
-	err := s.config.Reload(newSource)
+	s.rehashMu.Lock()
+	defer s.rehashMu.Unlock()
+
+	// Load the configuration into a new value, since connections keep
+	// reading the current one
+	previous := s.GetConfig()
+	source := newSource
+	if source == "" {
+		source = previous.Source
+	}
+	cfg, err := config.Load(source)
 	if err != nil {
 		return err
 	}
+	if err := validateConfig(cfg); err != nil {
+		return err
+	}
+
+	// Rebuild the loggers and re-read the MOTD, keeping the previous
+	// configuration if either fails
+	if err := s.reloadLogs(cfg); err != nil {
+		return err
+	}
+	if err := s.reloadMOTD(cfg); err != nil {
+		s.reloadLogs(previous)
+		return err
+	}
+	s.config.Store(cfg)
 
 	// Update operators
-	s.operators = sync.Map{}
-	for _, op := range s.config.Operators {
+	s.operators.Clear()
+	if err := s.restoreOperators(); err != nil {
+		return err
+	}
+	for _, op := range cfg.Operators {
 		s.operators.Store(op.Username, &Operator{
 			Username: op.Username,
 			Password: op.Password,
 			Email:    op.Email,
 			Mask:     op.Mask,
+			CertFP:   op.CertFP,
 		})
 	}
 
 	// Restart the web portal if needed
-	if s.config.WebPortal.Enabled {
+	if cfg.WebPortal.Enabled {
 		if s.webPortal != nil {
 			s.webPortal.Stop()
 		}
-		portal, err := NewWebPortal(s, s.config)
+		portal, err := NewWebPortal(s, cfg)
 		if err != nil {
 			return fmt.Errorf("failed to reinitialize web portal: %v", err)
 		}
@@ -438,11 +717,11 @@ func (s *Server) Rehash(newSource string) error {
 	}
 
 	// Restart the bot API if needed
-	if s.config.Bots.Enabled {
+	if cfg.Bots.Enabled {
 		if s.botAPI != nil {
 			s.botAPI.Stop()
 		}
-		api, err := NewBotAPI(s, s.config)
+		api, err := NewBotAPI(s, cfg)
 		if err != nil {
 			return fmt.Errorf("failed to reinitialize bot API: %v", err)
 		}
@@ -456,6 +735,21 @@ func (s *Server) Rehash(newSource string) error {
 	return nil
 }
 
+// WatchConfig rehashes the server whenever the configuration file changes or
+// the process receives SIGHUP, until ctx is done
+func (s *Server) WatchConfig(ctx context.Context) error {
+	return s.GetConfig().Watch(ctx, func() {
+		if err := s.Rehash(""); err != nil {
+			s.Logger(LogServer).Error("Failed to reload configuration", "error", err)
+			s.SendServerNotice(SnomaskLocops, fmt.Sprintf("Failed to reload configuration: %v", err))
+			return
+		}
+		source := s.GetConfig().Source
+		s.Logger(LogServer).Info("Configuration reloaded", "source", source)
+		s.SendServerNotice(SnomaskLocops, "Configuration reloaded from "+source)
+	})
+}
+
 // Broadcast sends a message to all clients
 func (s *Server) Broadcast(message string) {
 	s.clients.Range(func(key, value interface{}) bool {
@@ -467,12 +761,12 @@ func (s *Server) Broadcast(message string) {
 
 // GetConfig returns the server configuration
 func (s *Server) GetConfig() *config.Config {
-	return s.config
+	return s.config.Load()
 }
 
 // GetUptime returns the server uptime
 func (s *Server) GetUptime() time.Duration {
-	return time.Since(s.startTime)
+	return s.Since(s.startTime)
 }
 
 // GetUserList returns a list of all users
@@ -527,6 +821,18 @@ func (s *Server) ChannelCount() int {
 	return count
 }
 
+// OperCount returns the number of connected operators
+func (s *Server) OperCount() int {
+	count := 0
+	s.clients.Range(func(key, value interface{}) bool {
+		if value.(*Client).IsOper {
+			count++
+		}
+		return true // Continue iteration
+	})
+	return count
+}
+
 // generateSelfSignedCert generates a self-signed certificate and private key
 func (s *Server) generateSelfSignedCert() (string, string, error) {
 	// Generate private key
@@ -536,7 +842,7 @@ func (s *Server) generateSelfSignedCert() (string, string, error) {
 	}
 
 	// Define certificate template
-	serverName := s.config.Server.Name
+	serverName := s.GetConfig().Server.Name
 	if serverName == "" {
 		serverName = "goircd.local"
 	}
@@ -566,7 +872,7 @@ func (s *Server) generateSelfSignedCert() (string, string, error) {
 	}
 
 	// Add Subject Alternative Names for the server
-	host := s.config.ListenIRC.Host
+	host := s.GetConfig().ListenIRC.Host
 	// If host is 0.0.0.0 or ::, use localhost instead for the certificate
 	if host == "0.0.0.0" || host == "::" {
 		template.DNSNames = []string{serverName, "localhost"}
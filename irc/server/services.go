@@ -0,0 +1,417 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Names of the built-in service pseudo-clients
+const (
+	NickServ = "NickServ"
+	ChanServ = "ChanServ"
+)
+
+// Services implements NickServ and ChanServ on top of a ServiceStore
+type Services struct {
+	server       *Server
+	store        ServiceStore
+	enforceDelay time.Duration
+	pending      map[string]*time.Timer // Nick enforcement timers by client ID
+	mu           sync.Mutex
+}
+
+// WithServiceStore makes the services persist registrations to store instead
+// of the store configured under services.store
+func WithServiceStore(store ServiceStore) Option {
+	return func(s *Server) {
+		s.serviceStore = store
+	}
+}
+
+// newServices creates the services for a server, opening the configured store
+// unless one was provided with WithServiceStore
+func newServices(s *Server) (*Services, error) {
+	cfg := s.GetConfig().Services
+
+	store := s.serviceStore
+	if store == nil && cfg.Store != "" {
+		fileStore, err := NewFileServiceStore(cfg.Store)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	}
+	if store == nil {
+		store = NewMemoryServiceStore()
+	}
+
+	return &Services{
+		server:       s,
+		store:        store,
+		enforceDelay: time.Duration(cfg.EnforceDelay) * time.Second,
+		pending:      make(map[string]*time.Timer),
+	}, nil
+}
+
+// isServiceName checks if nick belongs to one of the service pseudo-clients
+func isServiceName(nick string) bool {
+	return strings.EqualFold(nick, NickServ) || strings.EqualFold(nick, ChanServ)
+}
+
+// notice sends a NOTICE from a service to the client
+func (sv *Services) notice(service string, client *Client, text string) {
+	prefix := fmt.Sprintf("%s!services@%s", service, sv.server.GetConfig().Server.Name)
+	client.SendMessage(prefix, "NOTICE", client.Nickname, text)
+}
+
+// handleMessage dispatches a PRIVMSG addressed to a service. It reports
+// whether target was a service.
+func (sv *Services) handleMessage(client *Client, target, text string) bool {
+	if sv == nil || !isServiceName(target) {
+		return false
+	}
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return true
+	}
+	command := strings.ToUpper(fields[0])
+	args := fields[1:]
+
+	if strings.EqualFold(target, NickServ) {
+		sv.handleNickServ(client, command, args)
+	} else {
+		sv.handleChanServ(client, command, args)
+	}
+	return true
+}
+
+// handleNickServ handles NickServ commands
+func (sv *Services) handleNickServ(client *Client, command string, args []string) {
+	switch command {
+	case "REGISTER":
+		if len(args) < 1 {
+			sv.notice(NickServ, client, "Syntax: REGISTER <password> [email]")
+			return
+		}
+		sv.registerNick(client, args)
+
+	case "IDENTIFY":
+		if len(args) < 1 {
+			sv.notice(NickServ, client, "Syntax: IDENTIFY [nick] <password>")
+			return
+		}
+		nick, password := client.Nickname, args[0]
+		if len(args) > 1 {
+			nick, password = args[0], args[1]
+		}
+		if !sv.checkPassword(client, nick, password) {
+			return
+		}
+		reg, _ := sv.store.Nick(nick)
+		sv.login(client, reg.Nick)
+		sv.notice(NickServ, client, "You are now identified for "+reg.Nick)
+
+	case "DROP":
+		if len(args) < 1 {
+			sv.notice(NickServ, client, "Syntax: DROP <password>")
+			return
+		}
+		if !sv.checkPassword(client, client.Nickname, args[0]) {
+			return
+		}
+		if err := sv.store.DeleteNick(client.Nickname); err != nil {
+			sv.notice(NickServ, client, "Failed to drop "+client.Nickname)
+			return
+		}
+		client.mu.RLock()
+		dropped := strings.EqualFold(client.Account, client.Nickname)
+		client.mu.RUnlock()
+		if dropped {
+			client.SetAccount("")
+		}
+		sv.notice(NickServ, client, client.Nickname+" has been dropped")
+
+	case "INFO":
+		nick := client.Nickname
+		if len(args) > 0 {
+			nick = args[0]
+		}
+		reg, err := sv.store.Nick(nick)
+		if err != nil || reg == nil {
+			sv.notice(NickServ, client, nick+" is not registered")
+			return
+		}
+		sv.notice(NickServ, client, fmt.Sprintf("%s is registered since %s", reg.Nick, reg.RegisteredAt.UTC().Format(time.RFC1123)))
+
+	case "HELP":
+		sv.notice(NickServ, client, "NickServ commands: REGISTER <password> [email], IDENTIFY [nick] <password>, DROP <password>, INFO [nick]")
+
+	default:
+		sv.notice(NickServ, client, "Unknown command "+command+". Use HELP for a list of commands")
+	}
+}
+
+// registerNick registers the client's current nickname
+func (sv *Services) registerNick(client *Client, args []string) {
+	if !client.Registered {
+		sv.notice(NickServ, client, "You must complete registration first")
+		return
+	}
+
+	nick := client.Nickname
+	if sv.server.isOperatorName(nick) {
+		sv.notice(NickServ, client, nick+" is reserved and cannot be registered")
+		return
+	}
+	if reg, err := sv.store.Nick(nick); err != nil || reg != nil {
+		sv.notice(NickServ, client, nick+" is already registered")
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(args[0]), bcrypt.DefaultCost)
+	if err != nil {
+		sv.notice(NickServ, client, "Failed to register "+nick)
+		return
+	}
+	reg := &NickRegistration{
+		Nick:         nick,
+		PasswordHash: string(hash),
+		RegisteredAt: sv.server.Now(),
+	}
+	if len(args) > 1 {
+		reg.Email = args[1]
+	}
+	if err := sv.store.SaveNick(reg); err != nil {
+		sv.notice(NickServ, client, "Failed to register "+nick)
+		return
+	}
+
+	sv.login(client, nick)
+	sv.notice(NickServ, client, nick+" is now registered to you")
+}
+
+// checkPassword checks a password sent to NickServ for nick, answering the
+// client when it is wrong or the client must wait before trying again
+func (sv *Services) checkPassword(client *Client, nick, password string) bool {
+	if client.authThrottled() {
+		sv.notice(NickServ, client, "Too many failed attempts, try again later")
+		return false
+	}
+	if !sv.Authenticate(nick, password) {
+		client.authFailed()
+		sv.notice(NickServ, client, "Invalid password for "+nick)
+		return false
+	}
+	client.authSucceeded()
+	return true
+}
+
+// Authenticate checks a password against a registered nickname. Nicknames
+// that match an operator username never authenticate, so a registration made
+// before the operator was configured cannot log in to the operator's account.
+func (sv *Services) Authenticate(nick, password string) bool {
+	if sv == nil || sv.server.isOperatorName(nick) {
+		return false
+	}
+	reg, err := sv.store.Nick(nick)
+	if err != nil || reg == nil {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(reg.PasswordHash), []byte(password)) == nil
+}
+
+// login logs the client in to account and stops any pending enforcement
+func (sv *Services) login(client *Client, account string) {
+	sv.cancelEnforcement(client)
+
+	client.SetAccount(account)
+
+	client.SendReply(irc.RPL_LOGGEDIN, fmt.Sprintf("%s!%s@%s", client.Nickname, client.Username, client.Hostname), account, "You are now logged in as "+account)
+	sv.server.applyVHost(client)
+}
+
+// checkNick warns a client using a registered nickname it is not identified
+// for and, when enforcement is enabled, renames it once the delay passes
+func (sv *Services) checkNick(client *Client) {
+	if sv == nil {
+		return
+	}
+	sv.cancelEnforcement(client)
+
+	client.mu.RLock()
+	nick, account := client.Nickname, client.Account
+	client.mu.RUnlock()
+
+	reg, err := sv.store.Nick(nick)
+	if err != nil || reg == nil || strings.EqualFold(account, reg.Nick) {
+		return
+	}
+
+	sv.notice(NickServ, client, "This nickname is registered. Please identify via /msg NickServ IDENTIFY <password>")
+	if sv.enforceDelay <= 0 {
+		return
+	}
+	sv.notice(NickServ, client, fmt.Sprintf("If you do not identify within %s, your nickname will be changed", sv.enforceDelay))
+
+	sv.mu.Lock()
+	sv.pending[client.ID] = time.AfterFunc(sv.enforceDelay, func() {
+		sv.enforce(client, nick)
+	})
+	sv.mu.Unlock()
+}
+
+// cancelEnforcement stops a pending enforcement timer for the client
+func (sv *Services) cancelEnforcement(client *Client) {
+	if sv == nil {
+		return
+	}
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+	if timer, ok := sv.pending[client.ID]; ok {
+		timer.Stop()
+		delete(sv.pending, client.ID)
+	}
+}
+
+// enforce renames a client still using nick without having identified for it
+func (sv *Services) enforce(client *Client, nick string) {
+	sv.mu.Lock()
+	delete(sv.pending, client.ID)
+	sv.mu.Unlock()
+
+	client.mu.RLock()
+	current, account := client.Nickname, client.Account
+	client.mu.RUnlock()
+	if sv.server.GetClient(current) != client || current != nick || strings.EqualFold(account, nick) {
+		return
+	}
+
+	guest := sv.guestNick()
+	sv.notice(NickServ, client, "You failed to identify in time, your nickname is now "+guest)
+	client.changeNick(guest)
+}
+
+// guestNick returns an unused Guest nickname
+func (sv *Services) guestNick() string {
+	for {
+		nick := fmt.Sprintf("Guest%05d", rand.Intn(100000))
+		if sv.server.GetClient(nick) == nil {
+			return nick
+		}
+	}
+}
+
+// handleChanServ handles ChanServ commands
+func (sv *Services) handleChanServ(client *Client, command string, args []string) {
+	client.mu.RLock()
+	account := client.Account
+	client.mu.RUnlock()
+
+	switch command {
+	case "REGISTER":
+		if len(args) < 1 {
+			sv.notice(ChanServ, client, "Syntax: REGISTER <#channel>")
+			return
+		}
+		name := args[0]
+		if account == "" {
+			sv.notice(ChanServ, client, "You must identify to NickServ first")
+			return
+		}
+		channel := sv.server.GetChannel(name)
+		if channel == nil || !channel.IsOperator(client) {
+			sv.notice(ChanServ, client, "You must be a channel operator in "+name)
+			return
+		}
+		if reg, err := sv.store.Channel(name); err != nil || reg != nil {
+			sv.notice(ChanServ, client, name+" is already registered")
+			return
+		}
+		reg := &ChannelRegistration{
+			Channel:      channel.Name,
+			Founder:      account,
+			RegisteredAt: sv.server.Now(),
+		}
+		if err := sv.store.SaveChannel(reg); err != nil {
+			sv.notice(ChanServ, client, "Failed to register "+name)
+			return
+		}
+		sv.notice(ChanServ, client, fmt.Sprintf("%s is now registered to %s", channel.Name, account))
+
+	case "DROP":
+		if len(args) < 1 {
+			sv.notice(ChanServ, client, "Syntax: DROP <#channel>")
+			return
+		}
+		name := args[0]
+		reg, err := sv.store.Channel(name)
+		if err != nil || reg == nil {
+			sv.notice(ChanServ, client, name+" is not registered")
+			return
+		}
+		if account == "" || !strings.EqualFold(reg.Founder, account) {
+			sv.notice(ChanServ, client, "Only the founder of "+reg.Channel+" can drop it")
+			return
+		}
+		if err := sv.store.DeleteChannel(name); err != nil {
+			sv.notice(ChanServ, client, "Failed to drop "+reg.Channel)
+			return
+		}
+		sv.notice(ChanServ, client, reg.Channel+" has been dropped")
+
+	case "INFO":
+		if len(args) < 1 {
+			sv.notice(ChanServ, client, "Syntax: INFO <#channel>")
+			return
+		}
+		reg, err := sv.store.Channel(args[0])
+		if err != nil || reg == nil {
+			sv.notice(ChanServ, client, args[0]+" is not registered")
+			return
+		}
+		sv.notice(ChanServ, client, fmt.Sprintf("%s is registered to %s since %s", reg.Channel, reg.Founder, reg.RegisteredAt.UTC().Format(time.RFC1123)))
+
+	case "HELP":
+		sv.notice(ChanServ, client, "ChanServ commands: REGISTER <#channel>, DROP <#channel>, INFO <#channel>")
+
+	default:
+		sv.notice(ChanServ, client, "Unknown command "+command+". Use HELP for a list of commands")
+	}
+}
+
+// onJoin gives the founder of a registered channel operator status on join
+func (sv *Services) onJoin(client *Client, channel *Channel) {
+	if sv == nil {
+		return
+	}
+
+	client.mu.RLock()
+	account := client.Account
+	client.mu.RUnlock()
+	if account == "" {
+		return
+	}
+
+	reg, err := sv.store.Channel(channel.Name)
+	if err != nil || reg == nil || !strings.EqualFold(reg.Founder, account) {
+		return
+	}
+
+	channel.mu.Lock()
+	opped := channel.Operators[channel.key(client.Nickname)]
+	channel.Operators[channel.key(client.Nickname)] = true
+	channel.mu.Unlock()
+	if opped {
+		return
+	}
+
+	prefix := fmt.Sprintf("%s!services@%s", ChanServ, sv.server.GetConfig().Server.Name)
+	channel.SendToAll(fmt.Sprintf(":%s MODE %s +o %s", prefix, channel.Name, client.Nickname), nil)
+}
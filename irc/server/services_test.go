@@ -0,0 +1,184 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServicesServer creates a test server with services enabled
+func newServicesServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.Services.Enabled = true
+	return newTestServer(t, cfg, opts...)
+}
+
+func TestNickServRegisterAndIdentify(t *testing.T) {
+	srv := newServicesServer(t)
+
+	alice := srv.register(t, "alice")
+	alice.send("PRIVMSG NickServ :REGISTER hunter2 alice@example.com")
+	assert.Contains(t, alice.expect(" 900 "), "You are now logged in as alice")
+	assert.Equal(t, ":NickServ!services@test.irc.local NOTICE alice :alice is now registered to you", alice.expect("NOTICE"))
+
+	alice.send("PRIVMSG NickServ :REGISTER other")
+	assert.Contains(t, alice.expect("NOTICE"), "alice is already registered")
+	alice.send("QUIT")
+
+	// A later connection is warned until it identifies
+	again := srv.register(t, "alice")
+	assert.Contains(t, again.expect("NickServ"), "This nickname is registered")
+	again.send("PRIVMSG NickServ :IDENTIFY wrong")
+	assert.Contains(t, again.expect("NickServ"), "Invalid password")
+	again.send("PRIVMSG nickserv :IDENTIFY hunter2")
+	again.expect(" 900 ")
+	assert.Contains(t, again.expect("NickServ"), "You are now identified for alice")
+	assert.Equal(t, "alice", srv.GetClient("alice").Account)
+
+	// Service names are reserved
+	again.send("NICK ChanServ")
+	again.expect(" 432 ")
+}
+
+func TestNickServOperatorNames(t *testing.T) {
+	srv := newServicesServer(t)
+	srv.operators.Store("Admin", &Operator{Username: "Admin", Password: "secret"})
+
+	// A nickname matching an operator username cannot be registered
+	admin := srv.register(t, "admin")
+	admin.send("PRIVMSG NickServ :REGISTER hunter2")
+	assert.Contains(t, admin.expect("NOTICE"), "admin is reserved and cannot be registered")
+	reg, err := srv.services.store.Nick("admin")
+	assert.NoError(t, err)
+	assert.Nil(t, reg)
+
+	// A registration made before the operator was configured cannot log in
+	bob := srv.register(t, "bob")
+	bob.send("PRIVMSG NickServ :REGISTER hunter2")
+	bob.expect(" 900 ")
+	srv.operators.Store("bob", &Operator{Username: "bob", Password: "secret"})
+	admin.send("PRIVMSG NickServ :IDENTIFY bob hunter2")
+	assert.Contains(t, admin.expect("NOTICE"), "Invalid password for bob")
+}
+
+func TestNickServIdentifyThrottle(t *testing.T) {
+	clock := newFakeClock()
+	srv := newServicesServer(t, WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	alice.send("PRIVMSG NickServ :REGISTER hunter2")
+	alice.expect(" 900 ")
+
+	// After the free failures, even the right password is refused until the
+	// delay passes
+	bob := srv.register(t, "bob")
+	for i := 0; i < authFreeFailures; i++ {
+		bob.send("PRIVMSG NickServ :IDENTIFY alice wrong")
+		assert.Contains(t, bob.expect("NOTICE"), "Invalid password for alice")
+	}
+	bob.send("PRIVMSG NickServ :IDENTIFY alice hunter2")
+	assert.Contains(t, bob.expect("NOTICE"), "Too many failed attempts")
+
+	// Another client is not affected
+	carol := srv.register(t, "carol")
+	carol.send("PRIVMSG NickServ :IDENTIFY alice hunter2")
+	carol.expect(" 900 ")
+
+	clock.Advance(authFailureDelay)
+	bob.send("PRIVMSG NickServ :IDENTIFY alice wrong")
+	assert.Contains(t, bob.expect("NOTICE"), "Invalid password for alice")
+	bob.send("PRIVMSG NickServ :IDENTIFY alice hunter2")
+	assert.Contains(t, bob.expect("NOTICE"), "Too many failed attempts")
+
+	clock.Advance(authFailureDelay)
+	bob.send("PRIVMSG NickServ :IDENTIFY alice hunter2")
+	bob.expect(" 900 ")
+	assert.Contains(t, bob.expect("NOTICE"), "You are now identified for alice")
+}
+
+func TestNickServEnforcement(t *testing.T) {
+	srv := newServicesServer(t)
+	srv.services.enforceDelay = 50 * time.Millisecond
+
+	owner := srv.register(t, "alice")
+	owner.send("PRIVMSG NickServ :REGISTER hunter2")
+	owner.expect(" 900 ")
+	owner.send("NICK alice_")
+	owner.drain()
+
+	intruder := srv.register(t, "bob")
+	intruder.send("NICK alice")
+	intruder.expect("If you do not identify")
+	assert.Contains(t, intruder.expect("failed to identify"), "your nickname is now Guest")
+	assert.Regexp(t, `^:alice!bob@ NICK Guest\d{5}$`, intruder.expect(" NICK "))
+	assert.Nil(t, srv.GetClient("alice"))
+
+	// The owner is not challenged when taking the nickname back
+	owner.send("NICK alice")
+	owner.drain()
+	time.Sleep(100 * time.Millisecond)
+	assert.NotNil(t, srv.GetClient("alice"))
+}
+
+func TestChanServFounderAutoOp(t *testing.T) {
+	srv := newServicesServer(t)
+
+	alice := srv.register(t, "alice")
+	alice.send("PRIVMSG ChanServ :REGISTER #test")
+	assert.Contains(t, alice.expect("ChanServ"), "You must identify to NickServ first")
+
+	alice.send("PRIVMSG NickServ :REGISTER hunter2")
+	alice.expect(" 900 ")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+	alice.send("PRIVMSG ChanServ :REGISTER #test")
+	assert.Contains(t, alice.expect("ChanServ"), "#test is now registered to alice")
+
+	// bob takes over the channel operator slot while alice is away, once the
+	// emptied channel is gone
+	bob := srv.register(t, "bob")
+	alice.send("PART #test")
+	alice.expect(" PART ")
+	require.Eventually(t, func() bool { return srv.GetChannel("#test") == nil }, time.Second, 5*time.Millisecond)
+	bob.send("JOIN #test")
+	bob.expect(" 366 bob #test ")
+	require.True(t, srv.GetChannel("#test").IsOperator(srv.GetClient("bob")))
+
+	bob.send("PRIVMSG ChanServ :DROP #test")
+	assert.Contains(t, bob.expect("ChanServ"), "Only the founder of #test can drop it")
+
+	// The registration is in the store, so ChanServ ops alice on rejoin
+	reg, err := srv.services.store.Channel("#test")
+	require.NoError(t, err)
+	require.NotNil(t, reg)
+	alice.send("JOIN #test")
+	assert.Equal(t, ":ChanServ!services@test.irc.local MODE #test +o alice", bob.expect(" MODE #test +o alice"))
+	assert.True(t, srv.GetChannel("#test").IsOperator(srv.GetClient("alice")))
+}
+
+func TestFileServiceStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+
+	store, err := NewFileServiceStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.SaveNick(&NickRegistration{Nick: "Alice", PasswordHash: "hash"}))
+	require.NoError(t, store.SaveChannel(&ChannelRegistration{Channel: "#Test", Founder: "Alice"}))
+	require.NoError(t, store.SaveNick(&NickRegistration{Nick: "bob"}))
+	require.NoError(t, store.DeleteNick("BOB"))
+
+	reopened, err := NewFileServiceStore(path)
+	require.NoError(t, err)
+	nick, err := reopened.Nick("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", nick.PasswordHash)
+	channel, err := reopened.Channel("#test")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", channel.Founder)
+	nick, err = reopened.Nick("bob")
+	require.NoError(t, err)
+	assert.Nil(t, nick)
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NickRegistration is a nickname registered with NickServ
+type NickRegistration struct {
+	Nick         string    `json:"nick"`
+	PasswordHash string    `json:"password_hash"`
+	Email        string    `json:"email,omitempty"`
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// ChannelRegistration is a channel registered with ChanServ
+type ChannelRegistration struct {
+	Channel      string    `json:"channel"`
+	Founder      string    `json:"founder"` // Account of the founder
+	RegisteredAt time.Time `json:"registered_at"`
+}
+
+// ServiceStore persists NickServ and ChanServ registrations. Lookups of
+// unregistered names return nil without an error. Names are matched
+// case-insensitively.
+type ServiceStore interface {
+	Nick(name string) (*NickRegistration, error)
+	SaveNick(reg *NickRegistration) error
+	DeleteNick(name string) error
+	Channel(name string) (*ChannelRegistration, error)
+	SaveChannel(reg *ChannelRegistration) error
+	DeleteChannel(name string) error
+}
+
+// serviceKey normalizes a nickname or channel name for lookups
+func serviceKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// MemoryServiceStore keeps registrations in memory
+type MemoryServiceStore struct {
+	Nicks    map[string]*NickRegistration    `json:"nicks"`
+	Channels map[string]*ChannelRegistration `json:"channels"`
+	mu       sync.RWMutex
+}
+
+// NewMemoryServiceStore creates an empty in-memory store
+func NewMemoryServiceStore() *MemoryServiceStore {
+	return &MemoryServiceStore{
+		Nicks:    make(map[string]*NickRegistration),
+		Channels: make(map[string]*ChannelRegistration),
+	}
+}
+
+// Nick returns the registration for a nickname
+func (m *MemoryServiceStore) Nick(name string) (*NickRegistration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Nicks[serviceKey(name)], nil
+}
+
+// SaveNick stores a nickname registration
+func (m *MemoryServiceStore) SaveNick(reg *NickRegistration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Nicks[serviceKey(reg.Nick)] = reg
+	return nil
+}
+
+// DeleteNick removes a nickname registration
+func (m *MemoryServiceStore) DeleteNick(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Nicks, serviceKey(name))
+	return nil
+}
+
+// Channel returns the registration for a channel
+func (m *MemoryServiceStore) Channel(name string) (*ChannelRegistration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Channels[serviceKey(name)], nil
+}
+
+// SaveChannel stores a channel registration
+func (m *MemoryServiceStore) SaveChannel(reg *ChannelRegistration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Channels[serviceKey(reg.Channel)] = reg
+	return nil
+}
+
+// DeleteChannel removes a channel registration
+func (m *MemoryServiceStore) DeleteChannel(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Channels, serviceKey(name))
+	return nil
+}
+
+// FileServiceStore keeps registrations in memory and writes them to a JSON
+// file after every change
+type FileServiceStore struct {
+	*MemoryServiceStore
+	path string
+	mu   sync.Mutex // Serializes writes to the file
+}
+
+// NewFileServiceStore creates a store backed by the JSON file at path,
+// loading any registrations it already contains
+func NewFileServiceStore(path string) (*FileServiceStore, error) {
+	store := &FileServiceStore{
+		MemoryServiceStore: NewMemoryServiceStore(),
+		path:               path,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read services store: %v", err)
+	}
+	if err := json.Unmarshal(data, store.MemoryServiceStore); err != nil {
+		return nil, fmt.Errorf("failed to parse services store: %v", err)
+	}
+	if store.Nicks == nil {
+		store.Nicks = make(map[string]*NickRegistration)
+	}
+	if store.Channels == nil {
+		store.Channels = make(map[string]*ChannelRegistration)
+	}
+	return store, nil
+}
+
+// SaveNick stores a nickname registration and persists the store
+func (f *FileServiceStore) SaveNick(reg *NickRegistration) error {
+	f.MemoryServiceStore.SaveNick(reg)
+	return f.flush()
+}
+
+// DeleteNick removes a nickname registration and persists the store
+func (f *FileServiceStore) DeleteNick(name string) error {
+	f.MemoryServiceStore.DeleteNick(name)
+	return f.flush()
+}
+
+// SaveChannel stores a channel registration and persists the store
+func (f *FileServiceStore) SaveChannel(reg *ChannelRegistration) error {
+	f.MemoryServiceStore.SaveChannel(reg)
+	return f.flush()
+}
+
+// DeleteChannel removes a channel registration and persists the store
+func (f *FileServiceStore) DeleteChannel(name string) error {
+	f.MemoryServiceStore.DeleteChannel(name)
+	return f.flush()
+}
+
+// flush atomically rewrites the JSON file with the current registrations
+func (f *FileServiceStore) flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.MemoryServiceStore.mu.RLock()
+	data, err := json.MarshalIndent(f.MemoryServiceStore, "", "  ")
+	f.MemoryServiceStore.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode services store: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write services store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write services store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write services store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to write services store: %v", err)
+	}
+	return nil
+}
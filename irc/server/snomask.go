@@ -0,0 +1,199 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// Server notice mask (snomask) categories
+const (
+	SnomaskConnect = 'c' // Client connections
+	SnomaskFlood   = 'f' // Flood notices
+	SnomaskGlobops = 'g' // Global operator notices
+	SnomaskKill    = 'k' // KILLs and server bans
+	SnomaskLocops  = 'l' // Local operator notices
+	SnomaskOper    = 'o' // Operator logins
+	SnomaskLinks   = 'L' // STATS and LINKS requests
+)
+
+// DefaultOperSnomask is the snomask granted to clients when they become an operator
+const DefaultOperSnomask = "cfgklo"
+
+// snomaskNames maps each category to the tag used in its notices
+var snomaskNames = map[rune]string{
+	SnomaskConnect: "CONNECT",
+	SnomaskFlood:   "FLOOD",
+	SnomaskGlobops: "GLOBOPS",
+	SnomaskKill:    "KILL",
+	SnomaskLocops:  "LOCOPS",
+	SnomaskOper:    "OPER",
+	SnomaskLinks:   "LINKS",
+}
+
+// SetSnomask applies a snomask change such as "+ck-o" and returns the resulting
+// snomask string. Letters without a leading sign are added. Unknown categories
+// are ignored.
+func (c *Client) SetSnomask(changes string) string {
+	c.mu.Lock()
+	if c.snomask == nil {
+		c.snomask = make(map[rune]bool)
+	}
+	add := true
+	for _, category := range changes {
+		switch category {
+		case '+':
+			add = true
+		case '-':
+			add = false
+		default:
+			if _, known := snomaskNames[category]; !known {
+				continue
+			}
+			if add {
+				c.snomask[category] = true
+			} else {
+				delete(c.snomask, category)
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	return c.GetSnomaskString()
+}
+
+// ClearSnomask removes every snomask category from the client
+func (c *Client) ClearSnomask() {
+	c.mu.Lock()
+	c.snomask = nil
+	c.mu.Unlock()
+}
+
+// HasSnomask checks if the client is subscribed to a snomask category
+func (c *Client) HasSnomask(category rune) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snomask[category]
+}
+
+// GetSnomaskString returns the client's snomask, e.g. "+ckL"
+func (c *Client) GetSnomaskString() string {
+	c.mu.RLock()
+	categories := make([]string, 0, len(c.snomask))
+	for category := range c.snomask {
+		categories = append(categories, string(category))
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(categories)
+	return "+" + strings.Join(categories, "")
+}
+
+// SendSnomask reports the client's current snomask with RPL_SNOMASKIS
+func (c *Client) SendSnomask() {
+	c.SendReply(irc.RPL_SNOMASKIS, c.GetSnomaskString(), "Server notice mask")
+}
+
+// SendServerNotice sends a notice to every operator with +s subscribed to the category
+func (s *Server) SendServerNotice(category rune, text string) {
+	name, known := snomaskNames[category]
+	if !known {
+		return
+	}
+	serverName := s.GetConfig().Server.Name
+	notice := fmt.Sprintf("*** %s: %s", name, text)
+
+	s.clients.Range(func(_, value interface{}) bool {
+		c := value.(*Client)
+		c.mu.RLock()
+		subscribed := c.Registered && c.IsOper && c.snomask[category]
+		nickname := c.Nickname
+		c.mu.RUnlock()
+		if subscribed && c.Modes.HasMode('s') {
+			c.SendMessage(serverName, "NOTICE", nickname, notice)
+		}
+		return true
+	})
+}
+
+// SendGlobopsNotice sends a notice to operators subscribed to global operator notices
+func (s *Server) SendGlobopsNotice(text string) {
+	s.SendServerNotice(SnomaskGlobops, text)
+}
+
+// SendLocopsNotice sends a notice to operators subscribed to local operator notices
+func (s *Server) SendLocopsNotice(text string) {
+	s.SendServerNotice(SnomaskLocops, text)
+}
+
+// SendStatsLinksNotice sends a notice to operators subscribed to STATS/LINKS requests
+func (s *Server) SendStatsLinksNotice(text string) {
+	s.SendServerNotice(SnomaskLinks, text)
+}
+
+// SendWallops sends a WALLOPS message from source to every user with +w
+func (s *Server) SendWallops(source, text string) {
+	s.clients.Range(func(_, value interface{}) bool {
+		c := value.(*Client)
+		if c.Registered && c.Modes.Wallops {
+			c.SendMessage(source, "WALLOPS", text)
+		}
+		return true
+	})
+}
+
+// handleWallops handles the WALLOPS command
+func handleWallops(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	// Check if the client is an operator
+	if !client.IsOper {
+		client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+		return nil
+	}
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "WALLOPS", "Not enough parameters")
+		return nil
+	}
+
+	source := fmt.Sprintf("%s!%s@%s", client.Nickname, client.Username, client.Hostname)
+	client.Server.SendWallops(source, message.Params[len(message.Params)-1])
+
+	return nil
+}
+
+// handleGlobops handles the GLOBOPS command
+func handleGlobops(params *HookParams) error {
+	return handleOperNotice(params, SnomaskGlobops)
+}
+
+// handleLocops handles the LOCOPS command
+func handleLocops(params *HookParams) error {
+	return handleOperNotice(params, SnomaskLocops)
+}
+
+// handleOperNotice handles GLOBOPS/LOCOPS :<message>
+func handleOperNotice(params *HookParams, category rune) error {
+	client := params.Client
+	message := params.Message
+
+	// Check if the client is an operator
+	if !client.IsOper {
+		client.SendNumeric(irc.ERR_NOPRIVILEGES, "Permission Denied- You're not an IRC operator")
+		return nil
+	}
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, message.Command, "Not enough parameters")
+		return nil
+	}
+
+	text := fmt.Sprintf("from %s: %s", client.Nickname, message.Params[len(message.Params)-1])
+	client.Server.SendServerNotice(category, text)
+
+	return nil
+}
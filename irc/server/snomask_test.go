@@ -0,0 +1,91 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// noticesUntilPong syncs with the server and returns the server notices received meanwhile
+func noticesUntilPong(tc *testClient) []string {
+	tc.send("PING sync")
+	var notices []string
+	for _, line := range tc.collect(" PONG ") {
+		if strings.Contains(line, "NOTICE") && strings.Contains(line, "*** ") {
+			notices = append(notices, line)
+		}
+	}
+	return notices
+}
+
+func TestOperReceivesConnectNotice(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+	assert.Equal(t, ":test.irc.local 008 alice +cfgklo :Server notice mask", alice.expect(" 008 "))
+	alice.expect("*** OPER: alice")
+	assert.True(t, srv.GetClient("alice").Modes.HasMode('s'))
+
+	srv.register(t, "bob")
+	line := alice.expect("*** CONNECT: ")
+	assert.Contains(t, line, ":test.irc.local NOTICE alice :*** CONNECT: Client connecting: bob (bob@")
+}
+
+func TestUnsubscribedOperMissesNotice(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+
+	carol := srv.register(t, "carol")
+	srv.oper(t, carol, "carol")
+	carol.expect(" 008 ")
+	carol.send("MODE carol +s -c")
+	assert.Equal(t, ":test.irc.local 008 carol +fgklo :Server notice mask", carol.expect(" 008 "))
+	carol.drain()
+
+	// Non-operators cannot subscribe to server notices
+	bob := srv.register(t, "bob")
+	bob.send("MODE bob +s c")
+	assert.False(t, srv.GetClient("bob").Modes.HasMode('s'))
+
+	srv.register(t, "dave")
+	alice.expect("*** CONNECT: Client connecting: dave")
+	assert.Empty(t, noticesUntilPong(carol))
+	assert.Empty(t, noticesUntilPong(bob))
+
+	// Removing +s drops every subscription
+	alice.send("MODE alice -s")
+	alice.expect("MODE alice -s")
+	srv.register(t, "erin")
+	assert.Empty(t, noticesUntilPong(alice))
+	assert.Equal(t, "+", srv.GetClient("alice").GetSnomaskString())
+}
+
+func TestOperNoticeRouting(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+	alice.send("MODE alice +s +L")
+	alice.expect(" 008 alice +Lcfgklo ")
+
+	carol := srv.register(t, "carol")
+	srv.oper(t, carol, "carol")
+	carol.send("MODE carol +s -g")
+	carol.expect(" 008 carol +cfklo ")
+	carol.drain()
+
+	carol.send("STATS u")
+	alice.expect("*** LINKS: STATS u requested by carol")
+
+	carol.send("GLOBOPS :maintenance at noon")
+	alice.expect("*** GLOBOPS: from carol: maintenance at noon")
+	assert.Empty(t, noticesUntilPong(carol))
+
+	carol.send("LOCOPS :local only")
+	alice.expect("*** LOCOPS: from carol: local only")
+	carol.expect("*** LOCOPS: from carol: local only")
+}
@@ -0,0 +1,333 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// ChannelState is the channel topic, modes and lists kept across restarts
+type ChannelState struct {
+	Name                string         `gorm:"primaryKey" json:"name"` // Casemapped channel name
+	DisplayName         string         `json:"display_name"`
+	Topic               string         `json:"topic"`
+	TopicSetBy          string         `json:"topic_set_by"`
+	TopicSetAt          time.Time      `json:"topic_set_at"`
+	Modes               ChannelModes   `gorm:"serializer:json" json:"modes"`
+	BanList             []*ChannelMask `gorm:"serializer:json" json:"bans"`
+	ExceptionList       []*ChannelMask `gorm:"serializer:json" json:"exceptions"`
+	InviteExceptionList []*ChannelMask `gorm:"serializer:json" json:"invite_exceptions"`
+}
+
+// TableName sets the table channel state is stored in
+func (ChannelState) TableName() string { return "ircd_channels" }
+
+// StateStore persists server bans, operators added at runtime and channel
+// state so that they survive restarts. Lookups of unknown names return nil
+// without an error.
+type StateStore interface {
+	Bans() ([]*ServerBan, error)
+	SaveBan(ban *ServerBan) error
+	DeleteBan(banType rune, mask string) error
+	Operators() ([]*Operator, error)
+	SaveOperator(op *Operator) error
+	DeleteOperator(username string) error
+	Channel(name string) (*ChannelState, error)
+	SaveChannel(state *ChannelState) error
+	DeleteChannel(name string) error
+}
+
+// WithStateStore persists server state to store, regardless of the
+// persistence settings in the configuration
+func WithStateStore(store StateStore) Option {
+	return func(s *Server) {
+		s.state = store
+	}
+}
+
+// newStateStore opens the persistence backend selected in the configuration
+func newStateStore(s *Server) (StateStore, error) {
+	cfg := s.GetConfig().Persistence
+	switch strings.ToLower(cfg.Backend) {
+	case "", "sqlite":
+		return OpenSQLiteStateStore(cfg.Path)
+	case "postgres":
+		return OpenPostgresStateStore(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown persistence backend %q", cfg.Backend)
+	}
+}
+
+// banRecord is the stored form of a ServerBan
+type banRecord struct {
+	Type      string `gorm:"primaryKey"`
+	Mask      string `gorm:"primaryKey"` // Lowercased mask
+	Display   string // Mask as set
+	SetBy     string
+	Reason    string
+	SetAt     time.Time
+	ExpiresAt time.Time
+}
+
+// TableName sets the table bans are stored in
+func (banRecord) TableName() string { return "ircd_bans" }
+
+// operatorRecord is the stored form of an Operator
+type operatorRecord struct {
+	Username string `gorm:"primaryKey"`
+	Password string
+	Email    string
+	Mask     string
+	CertFP   string
+}
+
+// TableName sets the table operators are stored in
+func (operatorRecord) TableName() string { return "ircd_operators" }
+
+// SQLStateStore keeps server state in a SQL database through GORM
+type SQLStateStore struct {
+	db *gorm.DB
+}
+
+// NewSQLStateStore creates a store in db, migrating its tables if needed
+func NewSQLStateStore(db *gorm.DB) (*SQLStateStore, error) {
+	if err := db.AutoMigrate(&banRecord{}, &operatorRecord{}, &ChannelState{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate state tables: %v", err)
+	}
+	return &SQLStateStore{db: db}, nil
+}
+
+// OpenSQLiteStateStore creates a store backed by the SQLite database at path
+func OpenSQLiteStateStore(path string) (*SQLStateStore, error) {
+	if path == "" {
+		return nil, errors.New("persistence.path is required for the sqlite backend")
+	}
+	return openStateStore(sqlite.Open(path))
+}
+
+// OpenPostgresStateStore creates a store backed by the PostgreSQL database at dsn
+func OpenPostgresStateStore(dsn string) (*SQLStateStore, error) {
+	if dsn == "" {
+		return nil, errors.New("persistence.dsn is required for the postgres backend")
+	}
+	return openStateStore(postgres.Open(dsn))
+}
+
+// openStateStore opens a database and creates a store in it
+func openStateStore(dialector gorm.Dialector) (*SQLStateStore, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state database: %v", err)
+	}
+	return NewSQLStateStore(db)
+}
+
+// Bans returns all stored bans
+func (s *SQLStateStore) Bans() ([]*ServerBan, error) {
+	var records []banRecord
+	if err := s.db.Order("set_at").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	bans := make([]*ServerBan, 0, len(records))
+	for _, r := range records {
+		bans = append(bans, &ServerBan{
+			Type:      []rune(r.Type)[0],
+			Mask:      r.Display,
+			SetBy:     r.SetBy,
+			Reason:    r.Reason,
+			SetAt:     r.SetAt,
+			ExpiresAt: r.ExpiresAt,
+		})
+	}
+	return bans, nil
+}
+
+// SaveBan adds or replaces a ban
+func (s *SQLStateStore) SaveBan(ban *ServerBan) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&banRecord{
+		Type:      string(ban.Type),
+		Mask:      strings.ToLower(ban.Mask),
+		Display:   ban.Mask,
+		SetBy:     ban.SetBy,
+		Reason:    ban.Reason,
+		SetAt:     ban.SetAt,
+		ExpiresAt: ban.ExpiresAt,
+	}).Error
+}
+
+// DeleteBan removes a ban
+func (s *SQLStateStore) DeleteBan(banType rune, mask string) error {
+	return s.db.Where("type = ? AND mask = ?", string(banType), strings.ToLower(mask)).Delete(&banRecord{}).Error
+}
+
+// Operators returns all stored operators
+func (s *SQLStateStore) Operators() ([]*Operator, error) {
+	var records []operatorRecord
+	if err := s.db.Order("username").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	operators := make([]*Operator, 0, len(records))
+	for _, r := range records {
+		operators = append(operators, &Operator{
+			Username: r.Username,
+			Password: r.Password,
+			Email:    r.Email,
+			Mask:     r.Mask,
+			CertFP:   r.CertFP,
+		})
+	}
+	return operators, nil
+}
+
+// SaveOperator adds or replaces an operator
+func (s *SQLStateStore) SaveOperator(op *Operator) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&operatorRecord{
+		Username: op.Username,
+		Password: op.Password,
+		Email:    op.Email,
+		Mask:     op.Mask,
+		CertFP:   op.CertFP,
+	}).Error
+}
+
+// DeleteOperator removes an operator
+func (s *SQLStateStore) DeleteOperator(username string) error {
+	return s.db.Where("username = ?", username).Delete(&operatorRecord{}).Error
+}
+
+// Channel returns the stored state of a channel
+func (s *SQLStateStore) Channel(name string) (*ChannelState, error) {
+	var states []*ChannelState
+	if err := s.db.Where("name = ?", name).Limit(1).Find(&states).Error; err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return states[0], nil
+}
+
+// SaveChannel adds or replaces the state of a channel
+func (s *SQLStateStore) SaveChannel(state *ChannelState) error {
+	return s.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(state).Error
+}
+
+// DeleteChannel removes the state of a channel
+func (s *SQLStateStore) DeleteChannel(name string) error {
+	return s.db.Where("name = ?", name).Delete(&ChannelState{}).Error
+}
+
+// restoreState loads the stored bans and operators. Operators defined in the
+// configuration are loaded afterwards and take precedence.
+func (s *Server) restoreState() error {
+	if s.state == nil {
+		return nil
+	}
+
+	bans, err := s.state.Bans()
+	if err != nil {
+		return fmt.Errorf("failed to load bans: %v", err)
+	}
+	now := s.Now()
+	for _, ban := range bans {
+		if ban.Expired(now) {
+			s.state.DeleteBan(ban.Type, ban.Mask)
+			continue
+		}
+		s.bans.Store(banKey(ban.Type, ban.Mask), ban)
+	}
+
+	return s.restoreOperators()
+}
+
+// restoreOperators loads the operators added at runtime
+func (s *Server) restoreOperators() error {
+	if s.state == nil {
+		return nil
+	}
+	operators, err := s.state.Operators()
+	if err != nil {
+		return fmt.Errorf("failed to load operators: %v", err)
+	}
+	for _, op := range operators {
+		s.operators.Store(op.Username, op)
+	}
+	return nil
+}
+
+// persist reports a failed write to the state store
+func (s *Server) persist(what string, err error) {
+	if err != nil {
+		s.Logger(LogStorage).Error("Failed to persist state", "what", what, "error", err)
+	}
+}
+
+// stopping reports whether the server is shutting down
+func (s *Server) stopping() bool {
+	select {
+	case <-s.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// saveChannel persists the topic, modes and lists of a channel
+func (s *Server) saveChannel(channel *Channel) {
+	if s == nil || s.state == nil {
+		return
+	}
+
+	channel.mu.RLock()
+	modes := channel.Modes
+	modes.ModeParams = make(map[string]string, len(channel.Modes.ModeParams))
+	for k, v := range channel.Modes.ModeParams {
+		modes.ModeParams[k] = v
+	}
+	state := &ChannelState{
+		Name:                s.Fold(channel.Name),
+		DisplayName:         channel.Name,
+		Topic:               channel.Topic,
+		TopicSetBy:          channel.TopicSetBy,
+		TopicSetAt:          channel.TopicSetAt,
+		Modes:               modes,
+		BanList:             append([]*ChannelMask(nil), channel.BanList...),
+		ExceptionList:       append([]*ChannelMask(nil), channel.ExceptionList...),
+		InviteExceptionList: append([]*ChannelMask(nil), channel.InviteExceptionList...),
+	}
+	channel.mu.RUnlock()
+
+	s.persist("channel "+channel.Name, s.state.SaveChannel(state))
+}
+
+// restoreChannel applies the stored state of a newly created channel
+func (s *Server) restoreChannel(channel *Channel) {
+	if s.state == nil {
+		return
+	}
+	state, err := s.state.Channel(s.Fold(channel.Name))
+	if err != nil || state == nil {
+		s.persist("channel "+channel.Name, err)
+		return
+	}
+
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+	channel.Name = state.DisplayName
+	channel.Topic = state.Topic
+	channel.TopicSetBy = state.TopicSetBy
+	channel.TopicSetAt = state.TopicSetAt
+	channel.Modes = state.Modes
+	if channel.Modes.ModeParams == nil {
+		channel.Modes.ModeParams = make(map[string]string)
+	}
+	channel.BanList = state.BanList
+	channel.ExceptionList = state.ExceptionList
+	channel.InviteExceptionList = state.InviteExceptionList
+}
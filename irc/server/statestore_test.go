@@ -0,0 +1,81 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPersistentServer creates a test server persisting its state to path
+func newPersistentServer(t *testing.T, path string) *Server {
+	t.Helper()
+	cfg := newTestConfig()
+	cfg.Persistence.Enabled = true
+	cfg.Persistence.Path = path
+	return newTestServer(t, cfg)
+}
+
+func TestStatePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	srv := newPersistentServer(t, path)
+
+	srv.AddBan(BanTypeKLine, "mallory@*", "alice", "Go away", 0)
+	srv.AddBan(BanTypeGLine, "eve@*", "alice", "Expired", time.Nanosecond)
+	srv.AddBan(BanTypeGLine, "trent@*", "alice", "Removed", 0)
+	srv.RemoveBan(BanTypeGLine, "TRENT@*")
+	require.NoError(t, srv.AddOperator(&Operator{Username: "bob", Password: "secret"}))
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #Test")
+	alice.expect(" 366 ")
+	alice.send("MODE #test -t+k sesame")
+	alice.expect(" +k ")
+	alice.send("MODE #test +b carol")
+	alice.expect(" +b ")
+	alice.send("TOPIC #test :Persistent topic")
+	alice.expect(" TOPIC ")
+
+	// Channels forget their state when they empty, but not on shutdown
+	alice.send("JOIN #gone")
+	alice.expect(" 366 ")
+	alice.send("MODE #gone -t")
+	alice.expect(" MODE ")
+	alice.send("TOPIC #gone :Forgotten")
+	alice.expect(" TOPIC ")
+	alice.send("PART #gone")
+	alice.expect(" PART ")
+	require.Eventually(t, func() bool { return srv.GetChannel("#gone") == nil }, time.Second, 5*time.Millisecond)
+	require.NoError(t, srv.Stop())
+
+	srv = newPersistentServer(t, path)
+	bans := srv.GetBans(BanTypeKLine)
+	require.Len(t, bans, 1)
+	assert.Equal(t, "mallory@*", bans[0].Mask)
+	assert.Equal(t, "Go away", bans[0].Reason)
+	assert.Empty(t, srv.GetBans(BanTypeGLine))
+	assert.NotNil(t, srv.GetOperator("bob"))
+
+	carol := srv.register(t, "carol")
+	carol.send("JOIN #test sesame")
+	carol.expect(" 474 ")
+	dave := srv.register(t, "dave")
+	dave.send("JOIN #test")
+	dave.expect(" 475 ")
+	srv.GetChannel("#test").Modes.Key = ""
+	dave.send("JOIN #test")
+	assert.Equal(t, ":test.irc.local 332 dave #Test :Persistent topic", dave.expect(" 332 "))
+
+	dave.send("JOIN #gone")
+	dave.expect(" 366 dave #gone ")
+	gone := srv.GetChannel("#gone")
+	require.NotNil(t, gone)
+	topic, _, _ := gone.GetTopic()
+	assert.Empty(t, topic)
+
+	require.NoError(t, srv.RemoveOperator("bob"))
+	srv = newPersistentServer(t, path)
+	assert.Nil(t, srv.GetOperator("bob"))
+}
@@ -0,0 +1,22 @@
+//go:build !windows && !plan9
+
+package server
+
+import (
+	"io"
+	"log/syslog"
+	"net/url"
+)
+
+// dialSyslog connects to the syslog daemon at address, such as
+// "udp://logs.example.com:514", or to the local daemon when address is empty
+func dialSyslog(address string) (io.WriteCloser, error) {
+	if address == "" {
+		return syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, "ircd")
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	return syslog.Dial(u.Scheme, u.Host, syslog.LOG_INFO|syslog.LOG_DAEMON, "ircd")
+}
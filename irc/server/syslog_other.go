@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package server
+
+import (
+	"errors"
+	"io"
+)
+
+// dialSyslog reports that syslog is not available on this platform
+func dialSyslog(address string) (io.WriteCloser, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
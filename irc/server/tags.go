@@ -0,0 +1,112 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// serverTimeFormat is the IRCv3 server-time timestamp layout
+const serverTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// FormatServerTime formats t as a server-time tag value
+func FormatServerTime(t time.Time) string {
+	return t.UTC().Format(serverTimeFormat)
+}
+
+// SendTagged sends a message to the client, prefixed with the given client tags
+// if the client enabled message-tags and with a time tag if it enabled
+// server-time. Tags the client did not negotiate are dropped.
+func (c *Client) SendTagged(tags map[string]string, message string) {
+	c.sendTaggedAt(c.Server.Now(), tags, message)
+}
+
+// sendTaggedAt is SendTagged with the server-time stamp taken from t
+func (c *Client) sendTaggedAt(t time.Time, tags map[string]string, message string) {
+	out := c.negotiatedTags(tags, t)
+	if len(out) > 0 {
+		message = "@" + irc.FormatTags(out) + " " + message
+	}
+	c.SendRaw(message)
+}
+
+// negotiatedTags returns the tags the client should receive for a message
+// carrying tags and sent at t
+func (c *Client) negotiatedTags(tags map[string]string, t time.Time) map[string]string {
+	out := make(map[string]string, len(tags)+1)
+	if c.HasCap(CapMessageTags) {
+		for key, value := range tags {
+			out[key] = value
+		}
+	}
+	if c.HasCap(CapServerTime) {
+		out["time"] = FormatServerTime(t)
+	}
+	return out
+}
+
+// SendTaggedToAll sends a tagged message to all members of the channel
+func (c *Channel) SendTaggedToAll(tags map[string]string, message string, except *Client) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, member := range c.Members {
+		if except != nil && member.ID == except.ID {
+			continue
+		}
+		member.SendTagged(tags, message)
+	}
+}
+
+// handleTagmsg handles the TAGMSG command, which carries only client tags and
+// is delivered only to clients that enabled message-tags
+func handleTagmsg(params *HookParams) error {
+	client := params.Client
+	message := params.Message
+
+	if len(message.Params) < 1 {
+		client.SendError(irc.ERR_NEEDMOREPARAMS, "TAGMSG", "Not enough parameters")
+		return nil
+	}
+
+	target := message.Params[0]
+	tags := message.ClientTags()
+	if len(tags) == 0 {
+		return nil
+	}
+	line := fmt.Sprintf(":%s!%s@%s TAGMSG %s", client.Nickname, client.Username, client.Hostname, target)
+
+	if strings.HasPrefix(target, "#") {
+		channel := client.Server.GetChannel(target)
+		if channel == nil {
+			client.SendError(irc.ERR_NOSUCHNICK, target, "No such nick/channel")
+			return nil
+		}
+		if !channel.CanSendToChannel(client) {
+			client.SendError(irc.ERR_CANNOTSENDTOCHAN, target, "Cannot send to channel")
+			return nil
+		}
+
+		channel.mu.RLock()
+		defer channel.mu.RUnlock()
+		for _, member := range channel.Members {
+			if member.ID != client.ID && member.HasCap(CapMessageTags) {
+				member.SendTagged(tags, line)
+			}
+		}
+		return nil
+	}
+
+	targetClient := client.Server.GetClient(target)
+	if targetClient == nil {
+		client.SendError(irc.ERR_NOSUCHNICK, target, "No such nick/channel")
+		return nil
+	}
+	if targetClient.HasCap(CapMessageTags) {
+		targetClient.SendTagged(tags, line)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagmsgRelaysClientTags(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.registerWithCaps(t, "alice", CapMessageTags)
+	bob := srv.registerWithCaps(t, "bob", CapMessageTags)
+	carol := srv.register(t, "carol")
+	for _, tc := range []*testClient{alice, bob, carol} {
+		tc.send("JOIN #test")
+		tc.expect(" 366 ")
+	}
+	bob.drain()
+	carol.drain()
+
+	// Server tags are stripped, client tags are relayed to capable members only
+	alice.send("@+draft/react=👍;+draft/reply=msg1;time=forged TAGMSG #test")
+	assert.Equal(t, "@+draft/react=👍;+draft/reply=msg1 :alice!alice@ TAGMSG #test", bob.expect(" TAGMSG "))
+
+	// PRIVMSG keeps its tags for capable members and drops them for others
+	alice.send("@+draft/reply=msg1 PRIVMSG #test :agreed")
+	assert.Equal(t, "@+draft/reply=msg1 :alice!alice@ PRIVMSG #test :agreed", bob.expect(" PRIVMSG "))
+	lines := carol.collect(" PRIVMSG ")
+	assert.Equal(t, ":alice!alice@ PRIVMSG #test :agreed", lines[len(lines)-1])
+	for _, line := range lines {
+		assert.NotContains(t, line, "TAGMSG", "carol did not enable message-tags")
+	}
+
+	// Private TAGMSG follows the same rules
+	alice.send("@+typing=active TAGMSG bob")
+	assert.Equal(t, "@+typing=active :alice!alice@ TAGMSG bob", bob.expect(" TAGMSG "))
+	alice.send("@+typing=active TAGMSG carol")
+	alice.send("@+draft/reply=msg2 PRIVMSG carol :hi")
+	lines = carol.collect(" PRIVMSG ")
+	assert.Equal(t, []string{":alice!alice@ PRIVMSG carol :hi"}, lines)
+}
+
+func TestServerTime(t *testing.T) {
+	clock := newFakeClock()
+	srv := newTestServer(t, nil, WithClock(clock))
+
+	alice := srv.register(t, "alice")
+	bob := srv.registerWithCaps(t, "bob", CapServerTime)
+	carol := srv.registerWithCaps(t, "carol", CapServerTime, CapMessageTags)
+
+	// Only negotiated tags are emitted, stamped with the server clock
+	alice.send("@+draft/reply=msg1 PRIVMSG bob :hi")
+	assert.Equal(t, "@time=2024-01-01T12:00:00.000Z :alice!alice@ PRIVMSG bob :hi", bob.expect(" PRIVMSG "))
+
+	clock.Advance(1500 * time.Millisecond)
+	alice.send("@+draft/reply=msg1 NOTICE carol :hello")
+	assert.Equal(t, "@+draft/reply=msg1;time=2024-01-01T12:00:01.500Z :alice!alice@ NOTICE carol :hello", carol.expect(" NOTICE "))
+
+	// Clients without server-time receive untagged messages
+	bob.send("NOTICE alice :back")
+	assert.Equal(t, ":bob!bob@ NOTICE alice :back", alice.expect(" NOTICE alice "))
+}
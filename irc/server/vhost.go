@@ -0,0 +1,49 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/presbrey/pkg/irc"
+)
+
+// GetVHost returns the virtual host configured for an account, if any
+func (s *Server) GetVHost(account string) string {
+	if account == "" {
+		return ""
+	}
+	return s.GetConfig().VHosts[account]
+}
+
+// applyVHost sets the client's displayed host to the vhost configured for its account
+func (s *Server) applyVHost(client *Client) {
+	client.mu.RLock()
+	account := client.Account
+	client.mu.RUnlock()
+
+	vhost := s.GetVHost(account)
+	if vhost == "" || vhost == client.Hostname {
+		return
+	}
+
+	client.SetHost(vhost)
+	client.SendReply(irc.RPL_HOSTHIDDEN, vhost, "is now your displayed host")
+}
+
+// SetHost changes the client's displayed host and announces the change with
+// CHGHOST to the client and its channel members that enabled the chghost capability
+func (c *Client) SetHost(host string) {
+	c.mu.Lock()
+	oldHost := c.Hostname
+	c.Hostname = host
+	if c.RealHostname == "" {
+		c.RealHostname = oldHost
+	}
+	c.mu.Unlock()
+
+	if !c.Registered || host == oldHost {
+		return
+	}
+
+	line := fmt.Sprintf(":%s!%s@%s CHGHOST %s %s", c.Nickname, c.Username, oldHost, c.Username, host)
+	c.notifyCommonChannels(CapChghost, line, true)
+}
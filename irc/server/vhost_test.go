@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOperVHost(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.VHosts = map[string]string{"alice": "staff.testnet"}
+	srv := newTestServer(t, cfg)
+
+	// bob negotiates chghost before registering
+	bob := srv.connect(t)
+	bob.send("CAP LS 302")
+	assert.Equal(t, ":test.irc.local CAP * LS :chghost message-tags sasl=PLAIN,EXTERNAL server-time batch draft/chathistory away-notify account-notify extended-join", bob.expect(" CAP "))
+	bob.send("NICK bob")
+	bob.send("USER bob 0 * :Test bob")
+	bob.send("CAP REQ :chghost")
+	assert.Equal(t, ":test.irc.local CAP bob ACK chghost", bob.expect(" CAP "))
+	assert.False(t, srv.GetClient("bob").Registered, "registration must wait for CAP END")
+	bob.send("CAP END")
+	bob.expect(" 376 ")
+
+	alice := srv.register(t, "alice")
+	carol := srv.register(t, "carol")
+	for _, tc := range []*testClient{alice, bob, carol} {
+		tc.send("JOIN #test")
+		tc.expect(" 366 ")
+	}
+	bob.drain()
+	carol.drain()
+
+	srv.oper(t, alice, "alice")
+	assert.Equal(t, ":test.irc.local 396 alice staff.testnet :is now your displayed host", alice.expect(" 396 "))
+	assert.Equal(t, ":alice!alice@ CHGHOST alice staff.testnet", bob.expect(" CHGHOST "))
+
+	// The vhost is used in message prefixes
+	alice.send("PRIVMSG #test :hello")
+	assert.Equal(t, ":alice!alice@staff.testnet PRIVMSG #test :hello", bob.expect(" PRIVMSG "))
+	for _, line := range carol.collect(" PRIVMSG ") {
+		assert.NotContains(t, line, "CHGHOST", "carol did not enable chghost")
+	}
+
+	// WHOIS shows the vhost instead of the real host
+	alice.send("PRIVMSG bob :hi")
+	assert.Equal(t, ":alice!alice@staff.testnet PRIVMSG bob :hi", bob.expect(" PRIVMSG "))
+	carol.send("WHOIS alice")
+	assert.Equal(t, ":test.irc.local 311 carol alice alice staff.testnet * :Test alice", carol.expect(" 311 "))
+}
+
+func TestCapNak(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	tc := srv.connect(t)
+	tc.send("CAP REQ :chghost unknown-cap")
+	assert.Equal(t, ":test.irc.local CAP * NAK :chghost unknown-cap", tc.expect(" CAP "))
+	tc.send("CAP LIST")
+	assert.Equal(t, ":test.irc.local CAP * LIST :", tc.expect(" CAP "))
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultPageLimit is the page size of list APIs when limit is not given
+	defaultPageLimit = 50
+
+	// maxPageLimit bounds the page size of list APIs
+	maxPageLimit = 500
+)
+
+// Fields the list APIs can be sorted by
+var (
+	channelSortKeys = []string{"name", "users"}
+	clientSortKeys  = []string{"nickname", "username", "hostname", "ip", "channels"}
+)
+
+// listQuery holds the filtering, sorting and pagination parameters of a list
+// API request: q, sort, order, offset and limit
+type listQuery struct {
+	Search string
+	Sort   string
+	Desc   bool
+	Offset int
+	Limit  int
+}
+
+// parseListQuery reads the list parameters of a request, sorting by
+// defaultSort unless one of sortKeys is requested
+func parseListQuery(c echo.Context, defaultSort string, sortKeys []string) (listQuery, error) {
+	query := listQuery{
+		Search: strings.ToLower(c.QueryParam("q")),
+		Sort:   defaultSort,
+		Limit:  defaultPageLimit,
+	}
+
+	if key := c.QueryParam("sort"); key != "" {
+		valid := false
+		for _, allowed := range sortKeys {
+			if key == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return query, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid sort, expected one of %s", strings.Join(sortKeys, ", ")))
+		}
+		query.Sort = key
+	}
+
+	switch strings.ToLower(c.QueryParam("order")) {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid order, expected asc or desc")
+	}
+
+	if value := c.QueryParam("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid offset")
+		}
+		query.Offset = offset
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return query, echo.NewHTTPError(http.StatusBadRequest, "Invalid limit")
+		}
+		query.Limit = limit
+	}
+	if query.Limit > maxPageLimit {
+		query.Limit = maxPageLimit
+	}
+
+	return query, nil
+}
+
+// matches reports whether any of the fields contains the search text,
+// ignoring case
+func (q listQuery) matches(fields ...string) bool {
+	if q.Search == "" {
+		return true
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), q.Search) {
+			return true
+		}
+	}
+	return false
+}
+
+// page sorts the items and returns the requested page. The number of items
+// before pagination is reported in the X-Total-Count header.
+func (q listQuery) page(c echo.Context, items []map[string]interface{}) []map[string]interface{} {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i][q.Sort], items[j][q.Sort]
+		var less bool
+		switch av := a.(type) {
+		case int:
+			bv, _ := b.(int)
+			if av == bv {
+				return false
+			}
+			less = av < bv
+		default:
+			as, bs := strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b))
+			if as == bs {
+				return false
+			}
+			less = as < bs
+		}
+		return less != q.Desc
+	})
+
+	c.Response().Header().Set("X-Total-Count", strconv.Itoa(len(items)))
+
+	start := q.Offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + q.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end]
+}
+
+// channelList returns the channels accepted by filter, or every channel
+// when filter is nil
+func (w *WebPortal) channelList(filter func(*Channel) bool) []map[string]interface{} {
+	channels := make([]map[string]interface{}, 0)
+	w.server.channels.Range(func(_, value interface{}) bool {
+		channel := value.(*Channel)
+		if filter != nil && !filter(channel) {
+			return true
+		}
+		topic, _, _ := channel.GetTopic()
+		channels = append(channels, map[string]interface{}{
+			"name":  channel.Name,
+			"topic": topic,
+			"users": channel.MemberCount(),
+			"modes": channel.GetModeString(),
+		})
+		return true
+	})
+	return channels
+}
+
+// clientList returns the clients accepted by filter, or every client when
+// filter is nil
+func (w *WebPortal) clientList(filter func(*Client) bool) []map[string]interface{} {
+	users := make([]map[string]interface{}, 0)
+	w.server.clients.Range(func(_, value interface{}) bool {
+		client := value.(*Client)
+		if filter != nil && !filter(client) {
+			return true
+		}
+		users = append(users, map[string]interface{}{
+			"nickname":  client.Nickname,
+			"username":  client.Username,
+			"hostname":  client.Hostname,
+			"ip":        client.IP,
+			"modes":     client.Modes.GetModeString(),
+			"channels":  len(client.Channels),
+			"oper":      client.IsOper,
+			"connected": w.server.Since(client.lastActive()).String(),
+		})
+		return true
+	})
+	return users
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// eventStatsInterval is the shortest interval between two stats updates
+	// on an event stream
+	eventStatsInterval = time.Second
+
+	// eventKeepalive is the longest interval without any stats update, so
+	// that the uptime stays current and idle proxies keep the stream open
+	eventKeepalive = 15 * time.Second
+)
+
+// stats returns the server statistics shown on the dashboard
+func (w *WebPortal) stats() map[string]interface{} {
+	stats := map[string]interface{}{
+		"server":   w.server.GetConfig().Server.Name,
+		"network":  w.server.GetConfig().Server.Network,
+		"uptime":   w.server.GetUptime().String(),
+		"clients":  w.server.ClientCount(),
+		"channels": w.server.ChannelCount(),
+	}
+	if w.server.screener != nil {
+		stats["dnsbl"] = w.server.screener.Stats()
+	}
+	return stats
+}
+
+// writeEvent writes one server-sent event and flushes it to the browser
+func writeEvent(c echo.Context, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(c.Response(), "event: %s\ndata: %s\n\n", eventType, payload); err != nil {
+		return err
+	}
+	c.Response().Flush()
+	return nil
+}
+
+// handleAPIEvents streams live updates to the dashboard as server-sent
+// events. The stream starts with a "snapshot" of the stats, channels and
+// clients, followed by server events (connect, quit, nick, join, part, topic
+// and ban) and "stats" updates after changes. The stream ends if the client
+// falls behind, and the browser reconnects to get a new snapshot.
+func (w *WebPortal) handleAPIEvents(c echo.Context) error {
+	// Check if the user is logged in
+	session, _ := w.getSession(c.Request())
+	if session == nil {
+		return echo.ErrUnauthorized
+	}
+
+	// Subscribe before taking the snapshot so that no change is missed
+	events, unsubscribe := w.server.SubscribeEvents()
+	defer unsubscribe()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	c.Response().WriteHeader(http.StatusOK)
+
+	err := writeEvent(c, "snapshot", map[string]interface{}{
+		"stats":    w.stats(),
+		"channels": w.channelList(nil),
+		"clients":  w.clientList(nil),
+	})
+	if err != nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(eventStatsInterval)
+	defer ticker.Stop()
+	changed, lastStats := false, time.Now()
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeEvent(c, event.Type, event); err != nil {
+				return nil
+			}
+			changed = true
+
+		case <-ticker.C:
+			if !changed && time.Since(lastStats) < eventKeepalive {
+				continue
+			}
+			if err := writeEvent(c, "stats", w.stats()); err != nil {
+				return nil
+			}
+			changed, lastStats = false, time.Now()
+
+		case <-c.Request().Context().Done():
+			return nil
+		case <-w.quit:
+			return nil
+		case <-w.server.quit:
+			return nil
+		}
+	}
+}
@@ -1,12 +1,13 @@
 package server
 
 import (
+	"context"
 	"crypto/subtle"
 	"fmt"
 	"html/template"
 	"io"
-	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,6 +24,7 @@ type WebPortal struct {
 	config   *config.Config
 	echo     *echo.Echo
 	sessions map[string]*WebSession
+	quit     chan struct{} // Closed by Stop to end event streams
 }
 
 // WebSession represents a web session
@@ -64,6 +66,7 @@ func NewWebPortal(server *Server, cfg *config.Config) (*WebPortal, error) {
 		config:   cfg,
 		echo:     e,
 		sessions: make(map[string]*WebSession),
+		quit:     make(chan struct{}),
 	}
 
 	// Setup routes
@@ -75,6 +78,7 @@ func NewWebPortal(server *Server, cfg *config.Config) (*WebPortal, error) {
 // Start starts the web portal
 func (w *WebPortal) Start() error {
 	// Start the Echo server
+	w.server.Logger(LogHTTP).Info("Starting web portal", "address", w.config.GetWebListenAddress())
 	if w.config.WebPortal.TLS {
 		return w.echo.StartTLS(w.config.GetWebListenAddress(), w.config.ListenTLS.Cert, w.config.ListenTLS.Key)
 	}
@@ -83,8 +87,9 @@ func (w *WebPortal) Start() error {
 
 // Stop stops the web portal
 func (w *WebPortal) Stop() error {
-	log.Println("Stopping web portal")
-	return w.echo.Shutdown(nil)
+	w.server.Logger(LogHTTP).Info("Stopping web portal")
+	close(w.quit)
+	return w.echo.Shutdown(context.Background())
 }
 
 // setupRoutes sets up the Echo routes
@@ -106,11 +111,20 @@ func (w *WebPortal) setupRoutes() {
 	api.POST("/login", w.handleAPILogin)
 	api.GET("/token", w.handleAPIToken)
 	api.GET("/stats", w.handleAPIStats)
+	api.GET("/events", w.handleAPIEvents)
 	api.GET("/channels", w.handleAPIChannels)
 	api.GET("/users", w.handleAPIUsers)
+	api.GET("/clients", w.handleAPIUsers)
 	api.POST("/kick", w.handleAPIKick)
 	api.POST("/kill", w.handleAPIKill)
 	api.POST("/mode", w.handleAPIMode)
+	api.POST("/topic", w.handleAPITopic)
+	api.POST("/wallops", w.handleAPIWallops)
+	api.GET("/bans", w.handleAPIBans)
+	api.POST("/kline", w.handleAPIAddBan(BanTypeKLine))
+	api.POST("/unkline", w.handleAPIRemoveBan(BanTypeKLine))
+	api.POST("/gline", w.handleAPIAddBan(BanTypeGLine))
+	api.POST("/ungline", w.handleAPIRemoveBan(BanTypeGLine))
 	api.POST("/rehash", w.handleAPIRehash)
 }
 
@@ -199,14 +213,10 @@ func (w *WebPortal) handleDashboard(c echo.Context) error {
 	}
 
 	// Get stats
-	stats := map[string]interface{}{
-		"server":   w.server.GetConfig().Server.Name,
-		"network":  w.server.GetConfig().Server.Network,
-		"uptime":   w.server.GetUptime().String(),
-		"clients":  w.server.ClientCount(),
-		"channels": w.server.ChannelCount(),
-		"username": session.Username,
-	}
+	stats := w.stats()
+	stats["username"] = session.Username
+	stats["klines"] = w.server.GetBans(BanTypeKLine)
+	stats["glines"] = w.server.GetBans(BanTypeGLine)
 
 	// Show the dashboard
 	return c.Render(http.StatusOK, "dashboard.html", stats)
@@ -221,20 +231,7 @@ func (w *WebPortal) handleChannels(c echo.Context) error {
 	}
 
 	// Get channels
-	w.server.mu.RLock()
-	channels := make([]map[string]interface{}, 0)
-	w.server.channels.Range(func(key, value interface{}) bool {
-		name := key.(string)
-		channel := value.(*Channel)
-		channels = append(channels, map[string]interface{}{
-			"name":  name,
-			"topic": channel.Topic,
-			"users": channel.MemberCount(),
-			"modes": channel.GetModeString(),
-		})
-		return true
-	})
-	w.server.mu.RUnlock()
+	channels := w.channelList(nil)
 
 	// Show the channels page
 	return c.Render(http.StatusOK, "channels.html", map[string]interface{}{
@@ -252,22 +249,7 @@ func (w *WebPortal) handleUsers(c echo.Context) error {
 	}
 
 	// Get users
-	w.server.mu.RLock()
-	users := make([]map[string]interface{}, 0)
-	w.server.clients.Range(func(_, value interface{}) bool {
-		client := value.(*Client)
-		users = append(users, map[string]interface{}{
-			"nickname":  client.Nickname,
-			"username":  client.Username,
-			"hostname":  client.Hostname,
-			"ip":        client.IP,
-			"modes":     client.Modes.GetModeString(),
-			"channels":  len(client.Channels),
-			"connected": time.Since(client.LastPing).String(),
-		})
-		return true
-	})
-	w.server.mu.RUnlock()
+	users := w.clientList(nil)
 
 	// Show the users page
 	return c.Render(http.StatusOK, "users.html", map[string]interface{}{
@@ -404,17 +386,8 @@ func (w *WebPortal) handleAPIStats(c echo.Context) error {
 		return echo.ErrUnauthorized
 	}
 
-	// Get stats
-	stats := map[string]interface{}{
-		"server":   w.server.GetConfig().Server.Name,
-		"network":  w.server.GetConfig().Server.Network,
-		"uptime":   w.server.GetUptime().String(),
-		"clients":  w.server.ClientCount(),
-		"channels": w.server.ChannelCount(),
-	}
-
 	// Return the stats
-	return c.JSON(http.StatusOK, stats)
+	return c.JSON(http.StatusOK, w.stats())
 }
 
 // handleAPIChannels handles the channels API
@@ -425,27 +398,28 @@ func (w *WebPortal) handleAPIChannels(c echo.Context) error {
 		return echo.ErrUnauthorized
 	}
 
-	// Get channels
-	w.server.mu.RLock()
-	channels := make([]map[string]interface{}, 0)
-	w.server.channels.Range(func(key, value interface{}) bool {
-		name := key.(string)
-		channel := value.(*Channel)
-		channels = append(channels, map[string]interface{}{
-			"name":  name,
-			"topic": channel.Topic,
-			"users": channel.MemberCount(),
-			"modes": channel.GetModeString(),
-		})
-		return true
+	query, err := parseListQuery(c, "name", channelSortKeys)
+	if err != nil {
+		return err
+	}
+
+	minUsers := 0
+	if value := c.QueryParam("min_users"); value != "" {
+		if minUsers, err = strconv.Atoi(value); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid min_users")
+		}
+	}
+
+	// Get the matching channels
+	channels := w.channelList(func(channel *Channel) bool {
+		return query.matches(channel.Name) && channel.MemberCount() >= minUsers
 	})
-	w.server.mu.RUnlock()
 
-	// Return the channels
-	return c.JSON(http.StatusOK, channels)
+	// Return one page of channels
+	return c.JSON(http.StatusOK, query.page(c, channels))
 }
 
-// handleAPIUsers handles the users API
+// handleAPIUsers handles the users API, also served as /api/clients
 func (w *WebPortal) handleAPIUsers(c echo.Context) error {
 	// Check if the user is logged in
 	session, _ := w.getSession(c.Request())
@@ -453,26 +427,32 @@ func (w *WebPortal) handleAPIUsers(c echo.Context) error {
 		return echo.ErrUnauthorized
 	}
 
-	// Get users
-	w.server.mu.RLock()
-	users := make([]map[string]interface{}, 0)
-	w.server.clients.Range(func(_, value interface{}) bool {
-		client := value.(*Client)
-		users = append(users, map[string]interface{}{
-			"nickname":  client.Nickname,
-			"username":  client.Username,
-			"hostname":  client.Hostname,
-			"ip":        client.IP,
-			"modes":     client.Modes.GetModeString(),
-			"channels":  len(client.Channels),
-			"connected": time.Since(client.LastPing).String(),
-		})
-		return true
+	query, err := parseListQuery(c, "nickname", clientSortKeys)
+	if err != nil {
+		return err
+	}
+
+	// Get the matching clients
+	var channel *Channel
+	channelName := c.QueryParam("channel")
+	if channelName != "" {
+		if channel = w.server.GetChannel(channelName); channel == nil {
+			return echo.NewHTTPError(http.StatusNotFound, "Channel not found")
+		}
+	}
+	opersOnly := c.QueryParam("oper") == "true"
+	users := w.clientList(func(client *Client) bool {
+		if opersOnly && !client.IsOper {
+			return false
+		}
+		if channel != nil && !channel.IsMember(client) {
+			return false
+		}
+		return query.matches(client.Nickname, client.Username, client.Hostname, client.IP)
 	})
-	w.server.mu.RUnlock()
 
-	// Return the users
-	return c.JSON(http.StatusOK, users)
+	// Return one page of clients
+	return c.JSON(http.StatusOK, query.page(c, users))
 }
 
 // handleAPIKick handles the kick API
@@ -561,8 +541,12 @@ func (w *WebPortal) handleAPIKill(c echo.Context) error {
 		return echo.ErrNotFound
 	}
 
+	w.server.SendServerNotice(SnomaskKill, fmt.Sprintf("Received KILL message for %s!%s@%s from %s (web): %s", targetClient.Nickname, targetClient.Username, targetClient.Hostname, session.Username, reason))
+
 	// Kill the client
-	targetClient.Quit(fmt.Sprintf("Killed by %s: %s", session.Username, reason))
+	killMessage := fmt.Sprintf("Killed by %s: %s", session.Username, reason)
+	targetClient.SendMessage(w.server.GetConfig().Server.Name, "KILL", targetClient.Nickname, killMessage)
+	targetClient.Quit(killMessage)
 
 	// Return success
 	return c.JSON(http.StatusOK, map[string]interface{}{
@@ -653,6 +637,160 @@ func (w *WebPortal) handleAPIMode(c echo.Context) error {
 	})
 }
 
+// handleAPITopic handles the topic API
+func (w *WebPortal) handleAPITopic(c echo.Context) error {
+	// Check if the user is logged in
+	session, _ := w.getSession(c.Request())
+	if session == nil {
+		return echo.ErrUnauthorized
+	}
+
+	name := c.FormValue("channel")
+	topic := c.FormValue("topic")
+
+	// Get the channel
+	channel := w.server.GetChannel(name)
+	if channel == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Channel not found")
+	}
+
+	// Set the topic and notify all members
+	channel.SetTopic(topic, session.Username)
+	channel.SendToAll(fmt.Sprintf(":%s!oper@%s TOPIC %s :%s", session.Username, w.server.GetConfig().Server.Name, channel.Name, topic), nil)
+
+	// Return success
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Set topic of %s", channel.Name),
+	})
+}
+
+// handleAPIWallops handles the wallops API
+func (w *WebPortal) handleAPIWallops(c echo.Context) error {
+	// Check if the user is logged in
+	session, _ := w.getSession(c.Request())
+	if session == nil {
+		return echo.ErrUnauthorized
+	}
+
+	message := c.FormValue("message")
+	if message == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "Message is required")
+	}
+
+	// Broadcast to every user with +w
+	w.server.SendWallops(fmt.Sprintf("%s!oper@%s", session.Username, w.server.GetConfig().Server.Name), message)
+
+	// Return success
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "WALLOPS sent",
+	})
+}
+
+// handleAPIBans handles the bans API
+func (w *WebPortal) handleAPIBans(c echo.Context) error {
+	// Check if the user is logged in
+	session, _ := w.getSession(c.Request())
+	if session == nil {
+		return echo.ErrUnauthorized
+	}
+
+	bans := make([]map[string]interface{}, 0)
+	for _, banType := range []rune{BanTypeKLine, BanTypeGLine} {
+		for _, ban := range w.server.GetBans(banType) {
+			entry := map[string]interface{}{
+				"type":   string(ban.Type),
+				"mask":   ban.Mask,
+				"set_by": ban.SetBy,
+				"reason": ban.Reason,
+				"set_at": ban.SetAt,
+			}
+			if !ban.ExpiresAt.IsZero() {
+				entry["expires_at"] = ban.ExpiresAt
+			}
+			bans = append(bans, entry)
+		}
+	}
+
+	// Return the bans
+	return c.JSON(http.StatusOK, bans)
+}
+
+// handleAPIAddBan returns a handler adding a K-line or G-line
+func (w *WebPortal) handleAPIAddBan(banType rune) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Check if the user is logged in
+		session, _ := w.getSession(c.Request())
+		if session == nil {
+			return echo.ErrUnauthorized
+		}
+
+		mask := c.FormValue("mask")
+		reason := c.FormValue("reason")
+		if mask == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Mask is required")
+		}
+		if !strings.Contains(mask, "@") {
+			mask = "*@" + mask
+		}
+		if reason == "" {
+			reason = "Banned by operator"
+		}
+
+		var duration time.Duration
+		if value := c.FormValue("duration"); value != "" {
+			var err error
+			duration, err = parseBanDuration(value)
+			if err != nil || duration < 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, "Invalid duration")
+			}
+		}
+
+		// Add the ban and disconnect matching clients
+		ban := w.server.AddBan(banType, mask, session.Username, reason, duration)
+		expiry := "permanently"
+		if duration > 0 {
+			expiry = fmt.Sprintf("for %s", duration)
+		}
+		w.server.SendServerNotice(SnomaskKill, fmt.Sprintf("%s (web) added %c-Line for %s %s: %s", session.Username, banType, mask, expiry, reason))
+		w.server.enforceBan(ban, nil)
+
+		// Return success
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": fmt.Sprintf("Added %c-Line for %s %s: %s", banType, mask, expiry, reason),
+		})
+	}
+}
+
+// handleAPIRemoveBan returns a handler removing a K-line or G-line
+func (w *WebPortal) handleAPIRemoveBan(banType rune) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Check if the user is logged in
+		session, _ := w.getSession(c.Request())
+		if session == nil {
+			return echo.ErrUnauthorized
+		}
+
+		mask := c.FormValue("mask")
+		if !strings.Contains(mask, "@") {
+			mask = "*@" + mask
+		}
+
+		if !w.server.RemoveBan(banType, mask) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("No %c-Line for %s", banType, mask))
+		}
+		w.server.SendServerNotice(SnomaskKill, fmt.Sprintf("%s (web) removed %c-Line for %s", session.Username, banType, mask))
+
+		// Return success
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": fmt.Sprintf("Removed %c-Line for %s", banType, mask),
+		})
+	}
+}
+
 // handleAPIRehash handles the rehash API
 func (w *WebPortal) handleAPIRehash(c echo.Context) error {
 	// Only allow POST
@@ -0,0 +1,301 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPortal creates a web portal for srv with a logged in session for
+// the operator "admin"
+func newTestPortal(t *testing.T, srv *Server) (*WebPortal, *http.Cookie) {
+	t.Helper()
+	portal, err := NewWebPortal(srv, srv.GetConfig())
+	require.NoError(t, err)
+	portal.sessions["test-session"] = &WebSession{Username: "admin", ExpiresAt: time.Now().Add(time.Hour)}
+	return portal, &http.Cookie{Name: "session", Value: "test-session"}
+}
+
+// post sends a form to the portal and returns the response
+func post(portal *WebPortal, cookie *http.Cookie, path string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	portal.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebPortalModeration(t *testing.T) {
+	srv := newTestServer(t, nil)
+	portal, cookie := newTestPortal(t, srv)
+
+	alice := srv.register(t, "alice")
+	alice.send("MODE alice +w")
+	alice.drain()
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+
+	// Requests without a session are rejected
+	rec := post(portal, nil, "/api/wallops", url.Values{"message": {"hello"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = post(portal, cookie, "/api/wallops", url.Values{"message": {"Maintenance at noon"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ":admin!oper@test.irc.local WALLOPS :Maintenance at noon", alice.expect("WALLOPS"))
+
+	rec = post(portal, cookie, "/api/topic", url.Values{"channel": {"#test"}, "topic": {"Welcome back"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ":admin!oper@test.irc.local TOPIC #test :Welcome back", alice.expect(" TOPIC "))
+	topic, setBy, _ := srv.GetChannel("#test").GetTopic()
+	assert.Equal(t, "Welcome back", topic)
+	assert.Equal(t, "admin", setBy)
+
+	rec = post(portal, cookie, "/api/topic", url.Values{"channel": {"#missing"}, "topic": {"x"}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWebPortalBans(t *testing.T) {
+	srv := newTestServer(t, nil)
+	portal, cookie := newTestPortal(t, srv)
+
+	bob := srv.register(t, "bob")
+	rec := post(portal, cookie, "/api/kline", url.Values{"mask": {"bob@*"}, "duration": {"1h"}, "reason": {"Spam"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Added K-Line for bob@* for 1h0m0s: Spam")
+	bob.expect(" 465 ")
+	assert.Contains(t, bob.expect("ERROR"), "K-Lined: Spam")
+
+	rec = post(portal, cookie, "/api/gline", url.Values{"mask": {"192.0.2.1"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bans", nil)
+	req.AddCookie(cookie)
+	list := httptest.NewRecorder()
+	portal.echo.ServeHTTP(list, req)
+	assert.Contains(t, list.Body.String(), `"mask":"bob@*"`)
+	assert.Contains(t, list.Body.String(), `"mask":"*@192.0.2.1"`)
+
+	// The dashboard lists the bans with controls to remove them
+	req = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.AddCookie(cookie)
+	page := httptest.NewRecorder()
+	portal.echo.ServeHTTP(page, req)
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Contains(t, page.Body.String(), `action="/api/ungline"><input type="hidden" name="mask" value="*@192.0.2.1">`)
+
+	rec = post(portal, cookie, "/api/unkline", url.Values{"mask": {"bob@*"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, srv.GetBans(BanTypeKLine))
+	rec = post(portal, cookie, "/api/unkline", url.Values{"mask": {"bob@*"}})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = post(portal, cookie, "/api/gline", url.Values{"mask": {"x@y"}, "duration": {"soon"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestWebPortalKill(t *testing.T) {
+	srv := newTestServer(t, nil)
+	portal, cookie := newTestPortal(t, srv)
+
+	carol := srv.register(t, "carol")
+	rec := post(portal, cookie, "/api/kill", url.Values{"nickname": {"carol"}, "reason": {"Bye"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ":test.irc.local KILL carol :Killed by admin: Bye", carol.expect(" KILL "))
+}
+
+// get sends a GET request to the portal and returns the response
+func get(portal *WebPortal, cookie *http.Cookie, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	portal.echo.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebPortalListAPI(t *testing.T) {
+	srv := newTestServer(t, nil)
+	portal, cookie := newTestPortal(t, srv)
+
+	alice := srv.register(t, "alice")
+	bob := srv.register(t, "bob")
+	srv.register(t, "carol")
+	alice.send("JOIN #big")
+	alice.expect(" 366 ")
+	bob.send("JOIN #big")
+	bob.expect(" 366 ")
+	bob.send("JOIN #small")
+	bob.expect(" 366 ")
+	srv.oper(t, bob, "bob")
+
+	var channels []map[string]interface{}
+	rec := get(portal, cookie, "/api/channels?sort=users&order=desc")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &channels))
+	require.Len(t, channels, 2)
+	assert.Equal(t, "#big", channels[0]["name"])
+	assert.Equal(t, "2", rec.Header().Get("X-Total-Count"))
+
+	rec = get(portal, cookie, "/api/channels?min_users=2")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &channels))
+	require.Len(t, channels, 1)
+
+	var clients []map[string]interface{}
+	rec = get(portal, cookie, "/api/clients?limit=2&offset=1")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &clients))
+	require.Len(t, clients, 2)
+	assert.Equal(t, "bob", clients[0]["nickname"])
+	assert.Equal(t, "carol", clients[1]["nickname"])
+	assert.Equal(t, "3", rec.Header().Get("X-Total-Count"))
+
+	rec = get(portal, cookie, "/api/clients?channel=%23big&q=AL")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &clients))
+	require.Len(t, clients, 1)
+	assert.Equal(t, "alice", clients[0]["nickname"])
+
+	rec = get(portal, cookie, "/api/clients?oper=true")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &clients))
+	require.Len(t, clients, 1)
+	assert.Equal(t, "bob", clients[0]["nickname"])
+
+	assert.Equal(t, http.StatusBadRequest, get(portal, cookie, "/api/clients?sort=password").Code)
+	assert.Equal(t, http.StatusBadRequest, get(portal, cookie, "/api/channels?limit=-1").Code)
+	assert.Equal(t, http.StatusNotFound, get(portal, cookie, "/api/clients?channel=%23none").Code)
+}
+
+// sseEvent is a server-sent event read from a stream
+type sseEvent struct {
+	Type string
+	Data map[string]interface{}
+}
+
+// readEvents decodes the server-sent events of a response body
+func readEvents(t *testing.T, resp *http.Response) <-chan sseEvent {
+	events := make(chan sseEvent, 64)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.Type = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			case line == "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent returns the next event of the given type, skipping others
+func nextEvent(t *testing.T, events <-chan sseEvent, eventType string) map[string]interface{} {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "stream closed while waiting for %s", eventType)
+			if event.Type == eventType {
+				return event.Data
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s event", eventType)
+		}
+	}
+}
+
+func TestWebPortalEvents(t *testing.T) {
+	srv := newTestServer(t, nil)
+	portal, cookie := newTestPortal(t, srv)
+	web := httptest.NewServer(portal.echo)
+	defer web.Close()
+
+	alice := srv.register(t, "alice")
+	alice.send("JOIN #test")
+	alice.expect(" 366 ")
+
+	resp, err := http.Get(web.URL + "/api/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, web.URL+"/api/events", nil)
+	require.NoError(t, err)
+	req.AddCookie(cookie)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	events := readEvents(t, resp)
+
+	snapshot := nextEvent(t, events, "snapshot")
+	assert.EqualValues(t, 1, snapshot["stats"].(map[string]interface{})["channels"])
+	assert.Len(t, snapshot["clients"], 1)
+
+	// Joins, parts and quits are pushed as they happen
+	bob := srv.register(t, "bob")
+	assert.Equal(t, "bob", nextEvent(t, events, EventConnect)["data"].(map[string]interface{})["nickname"])
+	bob.send("JOIN #test")
+	join := nextEvent(t, events, EventJoin)["data"].(map[string]interface{})
+	assert.Equal(t, "#test", join["channel"])
+	assert.EqualValues(t, 2, join["users"])
+
+	bob.send("QUIT :Gone fishing")
+	part := nextEvent(t, events, EventPart)["data"].(map[string]interface{})
+	assert.Equal(t, "bob", part["nickname"])
+	assert.EqualValues(t, 1, part["users"])
+	quit := nextEvent(t, events, EventQuit)["data"].(map[string]interface{})
+	assert.Equal(t, "bob", quit["nickname"])
+	assert.Contains(t, quit["reason"], "Gone fishing")
+
+	// Counters follow the changes, possibly after a tick taken mid-way
+	stats := nextEvent(t, events, "stats")
+	if stats["clients"] != float64(1) {
+		stats = nextEvent(t, events, "stats")
+	}
+	assert.EqualValues(t, 1, stats["clients"])
+
+	// Stopping the portal ends the stream
+	portal.Stop()
+	for range events {
+	}
+}
+
+func TestEventsLagged(t *testing.T) {
+	srv := newTestServer(t, nil)
+	slow, unsubscribeSlow := srv.SubscribeEvents()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := srv.SubscribeEvents()
+	defer unsubscribeFast()
+
+	// A subscriber that falls behind gets the queued events and is then cut off
+	for i := 0; i <= eventBuffer; i++ {
+		srv.publish(EventTopic, map[string]interface{}{"n": i})
+		<-fast
+	}
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, eventBuffer, received)
+	assert.EqualValues(t, 1, srv.events.lagged.Load())
+
+	// Subscribers keeping up still get events
+	srv.publish(EventTopic, nil)
+	assert.Equal(t, EventTopic, (<-fast).Type)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhoisActualHostForOperators(t *testing.T) {
+	srv := newTestServer(t, nil)
+
+	alice := srv.register(t, "alice")
+	srv.oper(t, alice, "alice")
+	carol := srv.register(t, "carol")
+	bobConn := srv.register(t, "bob")
+
+	// Simulate a cloaked host once registration has finished
+	bobConn.send("PING sync")
+	bobConn.expect("PONG")
+	bob := srv.GetClient("bob")
+	bob.Hostname = "cloak-1a2b3c.users.test"
+	bob.RealHostname = "dsl-42.isp.example.net"
+	bob.IP = "203.0.113.7"
+
+	alice.drain()
+	alice.send("WHOIS bob")
+	lines := alice.collect(" 318 ")
+	assert.Contains(t, lines, ":test.irc.local 311 alice bob bob cloak-1a2b3c.users.test * :Test bob")
+	assert.Contains(t, lines, ":test.irc.local 338 alice bob bob@dsl-42.isp.example.net 203.0.113.7 :Actually using host")
+
+	carol.send("WHOIS bob")
+	for _, line := range carol.collect(" 318 ") {
+		assert.False(t, strings.Contains(line, " 338 "), "non-operator received %q", line)
+		assert.NotContains(t, line, "dsl-42.isp.example.net")
+	}
+}
@@ -0,0 +1,95 @@
+package irc
+
+import (
+	"sort"
+	"strings"
+)
+
+// tagEscapes maps characters to their IRCv3 message tag escape sequences
+var tagEscapes = strings.NewReplacer(
+	"\\", "\\\\",
+	";", "\\:",
+	" ", "\\s",
+	"\r", "\\r",
+	"\n", "\\n",
+)
+
+// ParseTags parses the tag section of a message, without the leading '@'
+func ParseTags(raw string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(raw, ";") {
+		if tag == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(tag, "=")
+		tags[key] = unescapeTagValue(value)
+	}
+	return tags
+}
+
+// FormatTags formats tags for the wire, without the leading '@'. Keys are sorted
+// so the output is stable.
+func FormatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if value := tags[key]; value != "" {
+			parts = append(parts, key+"="+tagEscapes.Replace(value))
+		} else {
+			parts = append(parts, key)
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// ClientTags returns the client-only tags of the message, those prefixed with '+'
+func (m *Message) ClientTags() map[string]string {
+	var tags map[string]string
+	for key, value := range m.Tags {
+		if !strings.HasPrefix(key, "+") {
+			continue
+		}
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// unescapeTagValue reverses the IRCv3 message tag value escaping
+func unescapeTagValue(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			builder.WriteByte(value[i])
+			continue
+		}
+		i++
+		if i == len(value) {
+			break // A trailing backslash is dropped
+		}
+		switch value[i] {
+		case ':':
+			builder.WriteByte(';')
+		case 's':
+			builder.WriteByte(' ')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'n':
+			builder.WriteByte('\n')
+		default:
+			builder.WriteByte(value[i])
+		}
+	}
+	return builder.String()
+}
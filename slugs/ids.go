@@ -0,0 +1,134 @@
+package slugs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidID is returned by DecodeID for slugs that don't end in an encoded ID
+var ErrInvalidID = errors.New("slugs: slug does not end in a valid ID")
+
+const (
+	// idAlphabet holds the characters of encoded IDs, shuffled by the salt
+	idAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	// idLength is the number of characters needed for any uint64 in base 36
+	idLength = 13
+	// idMultiplier is an odd constant, so multiplying by it modulo 2^64 is
+	// reversible and spreads consecutive IDs apart
+	idMultiplier uint64 = 0x9E3779B97F4A7C15
+)
+
+// idMultiplierInverse is the inverse of idMultiplier modulo 2^64
+var idMultiplierInverse = func() uint64 {
+	// Newton's method doubles the number of correct low bits each step
+	inv := idMultiplier
+	for i := 0; i < 5; i++ {
+		inv *= 2 - idMultiplier*inv
+	}
+	return inv
+}()
+
+// idCodec obfuscates numeric IDs with keys and an alphabet derived from a salt
+type idCodec struct {
+	alphabet string
+	values   [256]int8
+	xorIn    uint64
+	xorOut   uint64
+}
+
+// newIDCodec derives an ID codec from a salt
+func newIDCodec(salt string) *idCodec {
+	seed := sha256.Sum256([]byte(salt))
+	c := &idCodec{
+		xorIn:  binary.BigEndian.Uint64(seed[0:8]),
+		xorOut: binary.BigEndian.Uint64(seed[8:16]),
+	}
+
+	// Shuffle the alphabet with a stream of hashes of the seed
+	alphabet := []byte(idAlphabet)
+	stream := seed
+	for i := len(alphabet) - 1; i > 0; i-- {
+		stream = sha256.Sum256(stream[:])
+		j := int(binary.BigEndian.Uint64(stream[:8]) % uint64(i+1))
+		alphabet[i], alphabet[j] = alphabet[j], alphabet[i]
+	}
+	c.alphabet = string(alphabet)
+
+	for i := range c.values {
+		c.values[i] = -1
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c.values[alphabet[i]] = int8(i)
+	}
+	return c
+}
+
+// encode returns the fixed-length obfuscated form of id
+func (c *idCodec) encode(id uint64) string {
+	n := (id^c.xorIn)*idMultiplier ^ c.xorOut
+
+	encoded := make([]byte, idLength)
+	base := uint64(len(c.alphabet))
+	for i := idLength - 1; i >= 0; i-- {
+		encoded[i] = c.alphabet[n%base]
+		n /= base
+	}
+	return string(encoded)
+}
+
+// decode reverses encode
+func (c *idCodec) decode(encoded string) (uint64, error) {
+	if len(encoded) != idLength {
+		return 0, ErrInvalidID
+	}
+
+	var n uint64
+	base := uint64(len(c.alphabet))
+	for i := 0; i < len(encoded); i++ {
+		v := c.values[encoded[i]]
+		if v < 0 {
+			return 0, ErrInvalidID
+		}
+		// Reject values that overflow 64 bits
+		if n > (^uint64(0)-uint64(v))/base {
+			return 0, ErrInvalidID
+		}
+		n = n*base + uint64(v)
+	}
+
+	return ((n ^ c.xorOut) * idMultiplierInverse) ^ c.xorIn, nil
+}
+
+// IDSalt sets the salt that obfuscates IDs embedded by EncodeID. Slugs encoded
+// with one salt can only be decoded with the same salt.
+func (sg *SlugGenerator) IDSalt(salt string) *SlugGenerator {
+	sg.ids = newIDCodec(salt)
+	return sg
+}
+
+// EncodeID creates a text slug that ends in an obfuscated form of id, so the
+// ID can be recovered from the slug with DecodeID. The prefix is applied but
+// not the suffix, which would hide the ID. The obfuscation hides the sequence
+// of IDs but is not encryption.
+func (sg *SlugGenerator) EncodeID(id uint64, text string) string {
+	result := sg.ids.encode(id)
+	if slug := sg.generateTextSlug(text); slug != "" {
+		result = slug + sg.delimiter + result
+	}
+	if sg.prefix != "" {
+		result = sg.prefix + sg.delimiter + result
+	}
+	return result
+}
+
+// DecodeID returns the ID embedded in a slug created by EncodeID. The text
+// part of the slug is ignored, so slugs still decode after titles change.
+func (sg *SlugGenerator) DecodeID(slug string) (uint64, error) {
+	encoded := slug
+	if i := strings.LastIndex(slug, sg.delimiter); i >= 0 && sg.delimiter != "" {
+		encoded = slug[i+len(sg.delimiter):]
+	}
+	return sg.ids.decode(encoded)
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/presbrey/pkg/slugs"
 )
@@ -20,15 +22,19 @@ func main() {
 
 	uuidv4Mode := flag.Bool("u4", false, "generate UUID v4-based slug")
 	uuidv7Mode := flag.Bool("u7", false, "generate UUID v7-based slug")
+	ulidMode := flag.Bool("ulid", false, "generate time-sortable ULID slug")
+	ksuidMode := flag.Bool("ksuid", false, "generate time-sortable KSUID slug")
 	nanoLength := flag.Int("n", 0, "length of NanoID slugs")
 	randomLength := flag.Int("r", 0, "length of random slugs")
 	count := flag.Int("c", 1, "number of slugs to generate")
+	batch := flag.Bool("b", false, "read input text from stdin and generate one slug per line")
+	template := flag.String("t", "", "output template, e.g. \"{{prefix}}/{{date}}/{{slug}}\"")
 
 	flag.Parse()
 
 	// Check if any text was provided
 	args := flag.Args()
-	if len(args) == 0 && !*uuidv4Mode && !*uuidv7Mode && *nanoLength == 0 && *randomLength == 0 {
+	if len(args) == 0 && !*batch && !*uuidv4Mode && !*uuidv7Mode && !*ulidMode && !*ksuidMode && *nanoLength == 0 && *randomLength == 0 {
 		fmt.Println("Error: No input text provided and no random slug mode selected")
 		fmt.Println("Usage: slug [options] text")
 		flag.PrintDefaults()
@@ -48,11 +54,11 @@ func main() {
 		sg.AddStopWords(words...)
 	}
 
-	// Add prefix and suffix if provided
-	if *prefix != "" {
+	// Add prefix and suffix if provided, unless the template places them
+	if *prefix != "" && *template == "" {
 		sg.WithPrefix(*prefix)
 	}
-	if *suffix != "" {
+	if *suffix != "" && *template == "" {
 		sg.WithSuffix(*suffix)
 	}
 
@@ -61,12 +67,57 @@ func main() {
 		sg.UUIDv4()
 	} else if *uuidv7Mode {
 		sg.UUIDv7()
+	} else if *ulidMode {
+		sg.ULID()
+	} else if *ksuidMode {
+		sg.KSUID()
 	} else if *nanoLength > 0 {
 		sg.NanoID().RandomLength(*nanoLength)
 	} else if *randomLength > 0 {
 		sg.Random().RandomLength(*randomLength)
 	}
 
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	render := func(slug, text string) string {
+		if *template == "" {
+			return slug
+		}
+		now := time.Now()
+		return strings.NewReplacer(
+			"{{slug}}", slug,
+			"{{text}}", text,
+			"{{prefix}}", *prefix,
+			"{{suffix}}", *suffix,
+			"{{date}}", now.Format("2006-01-02"),
+			"{{year}}", now.Format("2006"),
+			"{{month}}", now.Format("01"),
+			"{{day}}", now.Format("02"),
+		).Replace(*template)
+	}
+
+	// In batch mode, generate one slug per input line, keeping blank lines so
+	// the output lines up with the input
+	if *batch {
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				fmt.Fprintln(out)
+				continue
+			}
+			fmt.Fprintln(out, render(sg.Generate(text), text))
+		}
+		if err := scanner.Err(); err != nil {
+			out.Flush()
+			fmt.Fprintln(os.Stderr, "Error: reading stdin:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Generate the slugs
 	var text string
 	if len(args) > 0 {
@@ -81,6 +132,6 @@ func main() {
 	// Generate and output the requested number of slugs
 	for i := 0; i < *count; i++ {
 		slug := sg.Generate(text)
-		fmt.Println(slug)
+		fmt.Fprintln(out, render(slug, text))
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"encoding/binary"
 	"math/big"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -25,6 +26,8 @@ type SlugGenerator struct {
 	randomLength    int
 	safePattern     *regexp.Regexp
 	multiPattern    *regexp.Regexp
+	isUnused        func(slug string) bool
+	ids             *idCodec
 }
 
 type slugType int
@@ -35,6 +38,22 @@ const (
 	uuidV7Slug
 	nanoidSlug
 	randomSlug
+	ulidSlug
+	ksuidSlug
+)
+
+// ksuidEpoch is the KSUID timestamp epoch (2014-05-13T16:53:20Z) in Unix seconds
+const ksuidEpoch = 1400000000
+
+const (
+	// maxNumericSuffix is the highest numeric suffix tried for text slugs
+	// before falling back to random suffixes
+	maxNumericSuffix = 100
+	// maxRandomAttempts is the number of random suffixes, or regenerated
+	// random slugs, tried before giving up
+	maxRandomAttempts = 10
+	// randomSuffixLength is the length of random collision suffixes
+	randomSuffixLength = 4
 )
 
 // New creates a new SlugGenerator with default settings.
@@ -47,6 +66,7 @@ func New() *SlugGenerator {
 		stopWords:       defaultStopWords(),
 		slugType:        textSlug,
 		randomLength:    8,
+		ids:             newIDCodec(""),
 	}
 	sg.compileRegex()
 	return sg
@@ -124,6 +144,20 @@ func (sg *SlugGenerator) NanoID() *SlugGenerator {
 	return sg
 }
 
+// ULID sets the generator to create ULID slugs: 26 Crockford base32 characters
+// encoding a millisecond timestamp and 80 random bits, which sort by creation time.
+func (sg *SlugGenerator) ULID() *SlugGenerator {
+	sg.slugType = ulidSlug
+	return sg
+}
+
+// KSUID sets the generator to create KSUID slugs: 27 base62 characters
+// encoding a second timestamp and 128 random bits, which sort by creation time.
+func (sg *SlugGenerator) KSUID() *SlugGenerator {
+	sg.slugType = ksuidSlug
+	return sg
+}
+
 // Random sets the generator to create random string slugs.
 func (sg *SlugGenerator) Random() *SlugGenerator {
 	sg.slugType = randomSlug
@@ -136,8 +170,53 @@ func (sg *SlugGenerator) RandomLength(length int) *SlugGenerator {
 	return sg
 }
 
+// SetUniquenessChecker sets a function reporting whether a slug is unused,
+// such as a database lookup. Generate then appends -2, -3 and so on to text
+// slugs until the checker accepts one, falling back to a short random suffix,
+// and regenerates other slugs. If no candidate is accepted, Generate returns
+// an empty string. A nil checker disables the check.
+func (sg *SlugGenerator) SetUniquenessChecker(checker func(slug string) bool) *SlugGenerator {
+	sg.isUnused = checker
+	return sg
+}
+
 // Generate creates a slug from the given text based on the configured options.
 func (sg *SlugGenerator) Generate(text string) string {
+	result := sg.generate(text)
+	if sg.isUnused == nil || result == "" || sg.isUnused(result) {
+		return result
+	}
+
+	// Regenerate random slugs
+	if sg.slugType != textSlug {
+		for i := 0; i < maxRandomAttempts; i++ {
+			if candidate := sg.generate(text); sg.isUnused(candidate) {
+				return candidate
+			}
+		}
+		return ""
+	}
+
+	// Number text slugs, then add random suffixes
+	for n := 2; n <= maxNumericSuffix; n++ {
+		if candidate := result + sg.delimiter + strconv.Itoa(n); sg.isUnused(candidate) {
+			return candidate
+		}
+	}
+	for i := 0; i < maxRandomAttempts; i++ {
+		suffix := randomString("abcdefghijklmnopqrstuvwxyz0123456789", randomSuffixLength)
+		if suffix == "" {
+			break
+		}
+		if candidate := result + sg.delimiter + suffix; sg.isUnused(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// generate creates a single slug candidate
+func (sg *SlugGenerator) generate(text string) string {
 	var result string
 
 	switch sg.slugType {
@@ -151,6 +230,10 @@ func (sg *SlugGenerator) Generate(text string) string {
 		result = sg.generateNanoID()
 	case randomSlug:
 		result = sg.generateRandomSlug()
+	case ulidSlug:
+		result = sg.generateULID()
+	case ksuidSlug:
+		result = sg.generateKSUID()
 	}
 
 	// Apply prefix and suffix
@@ -312,6 +395,82 @@ func (sg *SlugGenerator) generateRandomSlug() string {
 	return string(bytes)
 }
 
+func (sg *SlugGenerator) generateULID() string {
+	// Crockford's base32 alphabet sorts the same in either case
+	alphabet := "0123456789abcdefghjkmnpqrstvwxyz"
+	if !sg.lowercase {
+		alphabet = strings.ToUpper(alphabet)
+	}
+
+	// 48-bit millisecond timestamp followed by 80 random bits
+	var b [16]byte
+	timestamp := uint64(time.Now().UnixMilli())
+	for i := 0; i < 6; i++ {
+		b[i] = byte(timestamp >> (40 - 8*i))
+	}
+	if _, err := rand.Read(b[6:]); err != nil {
+		return "error-generating-ulid"
+	}
+
+	// Encode the 128 bits as 26 characters of 5 bits, most significant first,
+	// with the first character holding only the top 3 bits
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	ulid := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		ulid[i] = alphabet[lo&0x1F]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return sg.truncate(string(ulid))
+}
+
+func (sg *SlugGenerator) generateKSUID() string {
+	const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	// 32-bit second timestamp from the KSUID epoch followed by 128 random bits
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint32(b[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	if _, err := rand.Read(b[4:]); err != nil {
+		return "error-generating-ksuid"
+	}
+
+	// Encode in base62, zero-padded to 27 characters so slugs sort by time
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	mod := new(big.Int)
+	ksuid := make([]byte, 27)
+	for i := len(ksuid) - 1; i >= 0; i-- {
+		n.DivMod(n, base, mod)
+		ksuid[i] = alphabet[mod.Int64()]
+	}
+
+	return sg.truncate(string(ksuid))
+}
+
+// truncate shortens a generated ID to the maximum length
+func (sg *SlugGenerator) truncate(id string) string {
+	if len(id) > sg.maxLength {
+		return id[:sg.maxLength]
+	}
+	return id
+}
+
+// randomString returns length random characters from alphabet, or an empty
+// string if the system's random source fails
+func randomString(alphabet string, length int) string {
+	bytes := make([]byte, length)
+	for i := range bytes {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return ""
+		}
+		bytes[i] = alphabet[num.Int64()]
+	}
+	return string(bytes)
+}
+
 // compileRegex compiles regex patterns based on the current delimiter.
 func (sg *SlugGenerator) compileRegex() {
 	d := regexp.QuoteMeta(sg.delimiter)
@@ -321,10 +480,9 @@ func (sg *SlugGenerator) compileRegex() {
 
 // Common English stop words that can be removed from slugs
 func defaultStopWords() map[string]bool {
-	return map[string]bool{
-		"a": true, "an": true, "the": true, "and": true, "or": true,
-		"but": true, "if": true, "then": true, "else": true, "when": true,
-		"at": true, "from": true, "by": true, "for": true, "with": true,
-		"about": true, "to": true, "in": true, "on": true, "of": true,
+	stopWords := make(map[string]bool, len(stopWordLists["en"]))
+	for _, word := range stopWordLists["en"] {
+		stopWords[word] = true
 	}
+	return stopWords
 }
@@ -278,6 +278,218 @@ func TestReusability(t *testing.T) {
 	}
 }
 
+func TestSortableSlugs(t *testing.T) {
+	t.Run("ULID", func(t *testing.T) {
+		generator := New().ULID()
+		pattern := regexp.MustCompile(`^[0-9abcdefghjkmnpqrstvwxyz]{26}$`)
+
+		first := generator.Generate("")
+		if !pattern.MatchString(first) {
+			t.Errorf("Invalid ULID slug: %q", first)
+		}
+		if first[0] > '7' {
+			t.Errorf("ULID first character must encode 3 bits, got %q", first)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+		second := generator.Generate("")
+		if second <= first {
+			t.Errorf("ULID slugs not sortable by time: %q <= %q", second, first)
+		}
+
+		upper := New().Lowercase(false).ULID().Generate("")
+		if upper != strings.ToUpper(upper) {
+			t.Errorf("Expected uppercase ULID, got %q", upper)
+		}
+	})
+
+	t.Run("ULID timestamp", func(t *testing.T) {
+		before := time.Now().UnixMilli()
+		slug := New().ULID().Generate("")
+
+		var timestamp int64
+		for _, c := range slug[:10] {
+			timestamp = timestamp<<5 | int64(strings.IndexRune("0123456789abcdefghjkmnpqrstvwxyz", c))
+		}
+		if timestamp < before || timestamp > time.Now().UnixMilli() {
+			t.Errorf("ULID timestamp %d out of range", timestamp)
+		}
+	})
+
+	t.Run("KSUID", func(t *testing.T) {
+		generator := New().KSUID()
+		pattern := regexp.MustCompile(`^[0-9A-Za-z]{27}$`)
+
+		first := generator.Generate("")
+		if !pattern.MatchString(first) {
+			t.Errorf("Invalid KSUID slug: %q", first)
+		}
+
+		time.Sleep(1100 * time.Millisecond)
+		second := generator.Generate("")
+		if second <= first {
+			t.Errorf("KSUID slugs not sortable by time: %q <= %q", second, first)
+		}
+	})
+
+	t.Run("Max length", func(t *testing.T) {
+		if slug := New().MaxLength(10).KSUID().Generate(""); len(slug) != 10 {
+			t.Errorf("Expected 10 characters, got %q", slug)
+		}
+	})
+}
+
+func TestEncodeID(t *testing.T) {
+	generator := New().IDSalt("secret")
+
+	ids := []uint64{0, 1, 2, 42, 1 << 32, ^uint64(0)}
+	for _, id := range ids {
+		slug := generator.EncodeID(id, "Hello World")
+		if !strings.HasPrefix(slug, "hello-world-") {
+			t.Errorf("Expected text slug prefix, got %q", slug)
+		}
+
+		decoded, err := generator.DecodeID(slug)
+		if err != nil || decoded != id {
+			t.Errorf("DecodeID(%q) = %d, %v; want %d", slug, decoded, err, id)
+		}
+	}
+
+	// Consecutive IDs don't look consecutive
+	first := generator.EncodeID(1, "")
+	second := generator.EncodeID(2, "")
+	if first[:6] == second[:6] {
+		t.Errorf("Consecutive IDs share a prefix: %q, %q", first, second)
+	}
+
+	// The text part is ignored when decoding
+	renamed := "a-new-title-" + first
+	if id, err := generator.DecodeID(renamed); err != nil || id != 1 {
+		t.Errorf("DecodeID(%q) = %d, %v; want 1", renamed, id, err)
+	}
+
+	// Prefixes are applied but suffixes aren't
+	prefixed := New().IDSalt("secret").WithPrefix("post").WithSuffix("x").EncodeID(7, "Title")
+	if !strings.HasPrefix(prefixed, "post-title-") {
+		t.Errorf("Expected prefixed slug, got %q", prefixed)
+	}
+	if id, err := generator.DecodeID(prefixed); err != nil || id != 7 {
+		t.Errorf("DecodeID(%q) = %d, %v; want 7", prefixed, id, err)
+	}
+
+	// Salts produce different slugs
+	if New().IDSalt("other").EncodeID(1, "") == first {
+		t.Errorf("Different salts produced the same encoding")
+	}
+}
+
+func TestDecodeIDInvalid(t *testing.T) {
+	generator := New()
+	invalid := []string{
+		"",
+		"hello-world",
+		"hello-" + strings.Repeat("a", idLength+1),
+		"hello-" + strings.Repeat("A", idLength),
+		"hello-" + strings.Repeat(string(generator.ids.alphabet[35]), idLength),
+	}
+	for _, slug := range invalid {
+		if _, err := generator.DecodeID(slug); err != ErrInvalidID {
+			t.Errorf("DecodeID(%q) error = %v, want ErrInvalidID", slug, err)
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		codes    []string
+		text     string
+		expected string
+	}{
+		{"German", []string{"de"}, "Der Hund und die Katze", "hund-katze"},
+		{"Spanish", []string{"es"}, "El perro y el gato", "perro-gato"},
+		{"French", []string{"fr"}, "Le chien et le chat", "chien-chat"},
+		{"Portuguese", []string{"pt-BR"}, "O cachorro e o gato", "cachorro-gato"},
+		{"Replaces English", []string{"de"}, "The dog and the cat", "the-dog-and-the-cat"},
+		{"Multiple languages", []string{"en", "de"}, "The Hund and die Katze", "hund-katze"},
+		{"Unknown language", []string{"xx"}, "The dog", "the-dog"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			slug := New().Language(tc.codes...).RemoveStopWords(true).Generate(tc.text)
+			if slug != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, slug)
+			}
+		})
+	}
+
+	// Custom stop words can be added to a language's list
+	slug := New().Language("de").AddStopWords("hund").RemoveStopWords(true).Generate("Der Hund und die Katze")
+	if slug != "katze" {
+		t.Errorf("Expected 'katze', got %q", slug)
+	}
+
+	for _, code := range Languages() {
+		if len(stopWordLists[code]) == 0 {
+			t.Errorf("No stop words for language %q", code)
+		}
+	}
+}
+
+func TestUniquenessChecker(t *testing.T) {
+	t.Run("Numeric suffixes", func(t *testing.T) {
+		taken := map[string]bool{"hello-world": true, "hello-world-2": true}
+		generator := New().SetUniquenessChecker(func(slug string) bool {
+			return !taken[slug]
+		})
+
+		if slug := generator.Generate("Hello World"); slug != "hello-world-3" {
+			t.Errorf("Expected 'hello-world-3', got %q", slug)
+		}
+		if slug := generator.Generate("Goodbye World"); slug != "goodbye-world" {
+			t.Errorf("Expected 'goodbye-world', got %q", slug)
+		}
+	})
+
+	t.Run("Random suffix fallback", func(t *testing.T) {
+		generator := New().SetUniquenessChecker(func(slug string) bool {
+			return regexp.MustCompile(`^hello-[a-z0-9]{4}$`).MatchString(slug) &&
+				!regexp.MustCompile(`^hello-[0-9]+$`).MatchString(slug)
+		})
+
+		slug := generator.Generate("Hello")
+		if !regexp.MustCompile(`^hello-[a-z0-9]{4}$`).MatchString(slug) {
+			t.Errorf("Expected random suffix, got %q", slug)
+		}
+	})
+
+	t.Run("Regenerates random slugs", func(t *testing.T) {
+		calls := 0
+		generator := New().Random().SetUniquenessChecker(func(slug string) bool {
+			calls++
+			return calls > 2
+		})
+
+		if slug := generator.Generate(""); len(slug) != 8 {
+			t.Errorf("Expected 8 character slug, got %q", slug)
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 checks, got %d", calls)
+		}
+	})
+
+	t.Run("Gives up", func(t *testing.T) {
+		generator := New().SetUniquenessChecker(func(slug string) bool {
+			return false
+		})
+
+		if slug := generator.Generate("Hello"); slug != "" {
+			t.Errorf("Expected empty slug, got %q", slug)
+		}
+	})
+}
+
 func BenchmarkSlugGeneration(b *testing.B) {
 	generator := New()
 	text := "This is a benchmark test for the slug generation package"
@@ -0,0 +1,53 @@
+package slugs
+
+import "strings"
+
+// stopWordLists holds the built-in stop words by ISO 639-1 language code
+var stopWordLists = map[string][]string{
+	"en": {
+		"a", "an", "the", "and", "or", "but", "if", "then", "else", "when",
+		"at", "from", "by", "for", "with", "about", "to", "in", "on", "of",
+	},
+	"es": {
+		"el", "la", "los", "las", "un", "una", "unos", "unas", "y", "e",
+		"o", "u", "pero", "si", "de", "del", "al", "a", "en", "con",
+		"por", "para", "sin", "sobre", "entre", "que", "se", "su", "sus", "lo",
+	},
+	"de": {
+		"der", "die", "das", "den", "dem", "des", "ein", "eine", "einen", "einem",
+		"einer", "eines", "und", "oder", "aber", "wenn", "dann", "als", "an", "auf",
+		"aus", "bei", "mit", "nach", "von", "vor", "zu", "zum", "zur", "im",
+		"in", "am", "um", "für", "über", "unter", "ist", "sind",
+	},
+	"fr": {
+		"le", "la", "les", "l", "un", "une", "des", "du", "de", "d",
+		"et", "ou", "mais", "si", "à", "au", "aux", "en", "dans", "sur",
+		"sous", "par", "pour", "avec", "sans", "que", "qui", "ce", "cette", "est",
+	},
+	"pt": {
+		"o", "a", "os", "as", "um", "uma", "uns", "umas", "e", "ou",
+		"mas", "se", "de", "do", "da", "dos", "das", "em", "no", "na",
+		"nos", "nas", "por", "para", "com", "sem", "sobre", "que", "ao", "à",
+	},
+}
+
+// Languages returns the codes of the languages with built-in stop word lists
+func Languages() []string {
+	return []string{"de", "en", "es", "fr", "pt"}
+}
+
+// Language replaces the stop words with the built-in lists for the given
+// languages, such as "de" or "pt-BR". Unknown languages are ignored, and
+// custom stop words can be added afterwards with AddStopWords. Stop words are
+// only removed when RemoveStopWords is enabled.
+func (sg *SlugGenerator) Language(codes ...string) *SlugGenerator {
+	sg.stopWords = make(map[string]bool)
+	for _, code := range codes {
+		// Regional variants share their language's list
+		code, _, _ = strings.Cut(strings.ToLower(code), "-")
+		for _, word := range stopWordLists[code] {
+			sg.stopWords[word] = true
+		}
+	}
+	return sg
+}
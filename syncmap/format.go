@@ -0,0 +1,80 @@
+package syncmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the document format of the remote data
+type Format int
+
+// Supported formats
+const (
+	AutoFormat Format = iota // Chosen by the source, defaulting to JSON
+	JSON
+	YAML
+	TOML
+)
+
+// String returns the name of the format
+func (f Format) String() string {
+	switch f {
+	case JSON:
+		return "JSON"
+	case YAML:
+		return "YAML"
+	case TOML:
+		return "TOML"
+	default:
+		return "auto"
+	}
+}
+
+// WithFormat sets the format of the remote data. By default the format is chosen
+// from the response's Content-Type for URLs, or the extension for files, and is
+// JSON unless it names YAML or TOML.
+func (rm *RemoteMap) WithFormat(format Format) *RemoteMap {
+	rm.format = format
+	return rm
+}
+
+// formatFromContentType returns the format named by a Content-Type header
+func formatFromContentType(contentType string) Format {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return YAML
+	case "application/toml", "text/toml", "text/x-toml":
+		return TOML
+	default:
+		return JSON
+	}
+}
+
+// toJSON converts a YAML or TOML document to JSON, so every format is decoded
+// into the same value types
+func toJSON(format Format, body []byte) ([]byte, error) {
+	var data map[string]interface{}
+	switch format {
+	case YAML:
+		if err := yaml.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+		}
+	case TOML:
+		if err := toml.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal TOML: %w", err)
+		}
+	default:
+		return body, nil
+	}
+
+	converted, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s to JSON: %w", format, err)
+	}
+	return converted, nil
+}
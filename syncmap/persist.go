@@ -0,0 +1,67 @@
+package syncmap
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WithPersistence keeps a copy of the last successfully fetched data at path.
+// Start loads it before the first fetch, so the map starts with the last known
+// good data even when the source is unavailable.
+func (rm *RemoteMap) WithPersistence(path string) *RemoteMap {
+	rm.persistPath = path
+	return rm
+}
+
+// persist atomically writes the fetched document, already converted to JSON, to
+// the persistence path
+func (rm *RemoteMap) persist(body []byte) error {
+	if rm.persistPath == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(rm.persistPath), filepath.Base(rm.persistPath)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to persist data: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to persist data: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to persist data: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), rm.persistPath); err != nil {
+		return fmt.Errorf("failed to persist data: %w", err)
+	}
+
+	return nil
+}
+
+// loadPersisted fills the map from the persistence path, if there is a file there
+func (rm *RemoteMap) loadPersisted() error {
+	if rm.persistPath == "" {
+		return nil
+	}
+
+	body, err := os.ReadFile(rm.persistPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load persisted data: %w", err)
+	}
+
+	data, err := rm.decode(body)
+	if err != nil {
+		return fmt.Errorf("failed to load persisted data: %w", err)
+	}
+
+	rm.apply(data)
+	return nil
+}
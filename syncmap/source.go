@@ -0,0 +1,179 @@
+package syncmap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotModified is returned by a Source when the document hasn't changed since
+// the version passed to Fetch
+var ErrNotModified = errors.New("not modified")
+
+// Document is a version of the data fetched from a Source
+type Document struct {
+	Body    []byte
+	Format  Format // Format of the body, or AutoFormat for JSON
+	Version string // Identifies this version, such as an ETag, or empty if unknown
+}
+
+// Source fetches the document a RemoteMap synchronizes with
+type Source interface {
+	// Fetch returns the current document. version is the Version of the last
+	// document successfully stored in the map, or empty before the first one;
+	// Fetch returns ErrNotModified if the document still has that version.
+	Fetch(ctx context.Context, version string) (*Document, error)
+}
+
+// NewRemoteMapFromSource creates a new RemoteMap that synchronizes with the
+// provided source, such as a FileSource or FSSource
+func NewRemoteMapFromSource(source Source) *RemoteMap {
+	return NewRemoteMap("").WithSource(source)
+}
+
+// WithSource sets the source the map synchronizes with, replacing its URL
+func (rm *RemoteMap) WithSource(source Source) *RemoteMap {
+	rm.source = source
+	rm.versionMu.Lock()
+	rm.version = ""
+	rm.versionMu.Unlock()
+	return rm
+}
+
+// httpSource fetches a RemoteMap's URL using its HTTP settings, making
+// conditional requests with the ETag and Last-Modified of the last version
+type httpSource struct {
+	rm *RemoteMap
+}
+
+// Fetch requests the URL, returning ErrNotModified on a 304 response
+func (s *httpSource) Fetch(ctx context.Context, version string) (*Document, error) {
+	rm := s.rm
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rm.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Add headers
+	for key, value := range rm.headers {
+		req.Header.Add(key, value)
+	}
+
+	// Make the request conditional on the data having changed since the last fetch
+	etag, lastModified, _ := strings.Cut(version, "\n")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	resp, err := rm.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, ErrNotModified
+	}
+
+	if !rm.isAcceptableStatus(resp.StatusCode) {
+		return nil, fmt.Errorf("received non-OK response: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	doc := &Document{
+		Body:   body,
+		Format: formatFromContentType(resp.Header.Get("Content-Type")),
+	}
+	if etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"); etag != "" || lastModified != "" {
+		doc.Version = etag + "\n" + lastModified
+	}
+	return doc, nil
+}
+
+// formatFromName returns the format named by a file's extension
+func formatFromName(name string) Format {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return YAML
+	case ".toml":
+		return TOML
+	default:
+		return JSON
+	}
+}
+
+// fileSource reads a local file
+type fileSource struct {
+	path string
+}
+
+// FileSource returns a Source that reads a local file, with its format chosen
+// by its extension. The file is only read again once its modification time or
+// size changes.
+func FileSource(path string) Source {
+	return &fileSource{path: path}
+}
+
+// Fetch reads the file unless its modification time and size are unchanged
+func (s *fileSource) Fetch(ctx context.Context, version string) (*Document, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	current := info.ModTime().UTC().Format(time.RFC3339Nano) + " " + strconv.FormatInt(info.Size(), 10)
+	if current == version {
+		return nil, ErrNotModified
+	}
+
+	body, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return &Document{Body: body, Format: formatFromName(s.path), Version: current}, nil
+}
+
+// fsSource reads a file from an fs.FS
+type fsSource struct {
+	fsys fs.FS
+	name string
+}
+
+// FSSource returns a Source that reads the named file from fsys, such as an
+// embed.FS, with its format chosen by its extension. Changes are detected by
+// the file's content, as file systems like embed.FS have no modification times.
+func FSSource(fsys fs.FS, name string) Source {
+	return &fsSource{fsys: fsys, name: name}
+}
+
+// Fetch reads the file, reporting ErrNotModified if its content is unchanged
+func (s *fsSource) Fetch(ctx context.Context, version string) (*Document, error) {
+	body, err := fs.ReadFile(s.fsys, s.name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	current := hex.EncodeToString(sum[:])
+	if current == version {
+		return nil, ErrNotModified
+	}
+	return &Document{Body: body, Format: formatFromName(s.name), Version: current}, nil
+}
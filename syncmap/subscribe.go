@@ -0,0 +1,85 @@
+package syncmap
+
+import "reflect"
+
+// subscription is a callback registered for changes to a key
+type subscription struct {
+	fn func(old, new interface{})
+}
+
+// Subscribe calls fn whenever the value of key changes, whether by a refresh
+// or a write-through Put or Remove. old is nil when the key is added and new
+// is nil when it is deleted. Callbacks run synchronously, after the map has
+// been updated. The returned function cancels the subscription.
+func (rm *RemoteMap) Subscribe(key string, fn func(old, new interface{})) (unsubscribe func()) {
+	sub := &subscription{fn: fn}
+
+	rm.subsMu.Lock()
+	if rm.subs == nil {
+		rm.subs = make(map[string][]*subscription)
+	}
+	rm.subs[key] = append(rm.subs[key], sub)
+	rm.subsMu.Unlock()
+
+	return func() {
+		rm.subsMu.Lock()
+		defer rm.subsMu.Unlock()
+
+		subs := rm.subs[key]
+		for i, s := range subs {
+			if s == sub {
+				rm.subs[key] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(rm.subs[key]) == 0 {
+			delete(rm.subs, key)
+		}
+	}
+}
+
+// watchedValue is the value of a subscribed key before a change
+type watchedValue struct {
+	value interface{}
+	ok    bool
+}
+
+// snapshotSubscribed returns the current values of the keys with subscribers
+func (rm *RemoteMap) snapshotSubscribed() map[string]watchedValue {
+	rm.subsMu.Lock()
+	keys := make([]string, 0, len(rm.subs))
+	for key := range rm.subs {
+		keys = append(keys, key)
+	}
+	rm.subsMu.Unlock()
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	snapshot := make(map[string]watchedValue, len(keys))
+	for _, key := range keys {
+		value, ok := rm.Map.Load(key)
+		snapshot[key] = watchedValue{value: value, ok: ok}
+	}
+	return snapshot
+}
+
+// notifySubscribed calls the subscribers of every key in the snapshot whose
+// value has changed since
+func (rm *RemoteMap) notifySubscribed(snapshot map[string]watchedValue) {
+	for key, old := range snapshot {
+		value, ok := rm.Map.Load(key)
+		if ok == old.ok && reflect.DeepEqual(value, old.value) {
+			continue
+		}
+
+		rm.subsMu.Lock()
+		subs := rm.subs[key]
+		rm.subsMu.Unlock()
+
+		for _, sub := range subs {
+			sub.fn(old.value, value)
+		}
+	}
+}
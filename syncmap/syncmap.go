@@ -4,8 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"reflect"
 	"sync"
@@ -18,7 +18,7 @@ const DefaultRefreshPeriod = 5 * time.Minute
 // DefaultTimeout is the default timeout for HTTP requests
 const DefaultTimeout = 30 * time.Second
 
-// RemoteMap extends sync.Map to synchronize with a remote JSON endpoint
+// RemoteMap extends sync.Map to synchronize with a remote JSON, YAML or TOML endpoint
 type RemoteMap struct {
 	sync.Map
 	url             string
@@ -31,6 +31,19 @@ type RemoteMap struct {
 	deleteCallback  func([]string)
 	refreshCallback func()
 	transformFunc   func(map[string]interface{}) map[string]interface{}
+	decode          func([]byte) (map[string]interface{}, error)
+	format          Format
+	write           *WriteConfig
+	subs            map[string][]*subscription
+	subsMu          sync.Mutex
+	acceptStatus    map[int]bool
+	deleteGrace     time.Duration
+	missingSince    map[string]time.Time
+	graceMu         sync.Mutex
+	source          Source
+	persistPath     string
+	version         string
+	versionMu       sync.Mutex
 	httpClient      *http.Client
 	cancel          context.CancelFunc
 	wg              sync.WaitGroup
@@ -46,7 +59,9 @@ func NewRemoteMap(url string) *RemoteMap {
 		timeout:         DefaultTimeout,
 		ignoreTLSVerify: false,
 		headers:         make(map[string]string),
+		decode:          decodeJSON,
 	}
+	rm.source = &httpSource{rm: rm}
 
 	// Initialize HTTP client with default settings
 	rm.initHTTPClient()
@@ -131,6 +146,29 @@ func (rm *RemoteMap) WithTransformFunc(transform func(map[string]interface{}) ma
 	return rm
 }
 
+// WithAcceptableStatus sets the HTTP status codes treated as a successful fetch.
+// By default any 2xx status is accepted.
+func (rm *RemoteMap) WithAcceptableStatus(codes ...int) *RemoteMap {
+	if len(codes) == 0 {
+		rm.acceptStatus = nil
+		return rm
+	}
+	rm.acceptStatus = make(map[int]bool, len(codes))
+	for _, code := range codes {
+		rm.acceptStatus[code] = true
+	}
+	return rm
+}
+
+// WithDeleteGrace sets how long a key must be missing from the remote data before
+// it is deleted from the map and reported to the delete callback
+func (rm *RemoteMap) WithDeleteGrace(grace time.Duration) *RemoteMap {
+	if grace >= 0 {
+		rm.deleteGrace = grace
+	}
+	return rm
+}
+
 // Start begins the periodic refresh of the map from the remote URL and returns the RemoteMap for chaining
 func (rm *RemoteMap) Start() *RemoteMap {
 	rm.mu.Lock()
@@ -141,6 +179,11 @@ func (rm *RemoteMap) Start() *RemoteMap {
 		return rm
 	}
 	
+	// Load the last known good data before the first fetch
+	if err := rm.loadPersisted(); err != nil && rm.errorHandler != nil {
+		rm.errorHandler(err)
+	}
+
 	// Immediately fetch data once
 	if err := rm.Refresh(); err != nil && rm.errorHandler != nil {
 		rm.errorHandler(err)
@@ -198,19 +241,31 @@ func (rm *RemoteMap) Started() bool {
 	return rm.started
 }
 
-// Refresh immediately updates the map from the remote URL and returns any error
+// Refresh immediately updates the map from the remote URL and returns any error.
+// When the server reports the data hasn't changed since the last fetch, the map
+// is left as is and no callbacks are called.
 func (rm *RemoteMap) Refresh() error {
 	data, err := rm.fetchData()
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
 
+	rm.apply(data)
+	return nil
+}
+
+// apply stores freshly decoded data in the map and calls the callbacks
+func (rm *RemoteMap) apply(data map[string]interface{}) {
 	// Apply transform function if provided
 	if rm.transformFunc != nil {
 		data = rm.transformFunc(data)
 	}
 
 	// Update the map with the new data and track changes
+	snapshot := rm.snapshotSubscribed()
 	_, updated, deleted := rm.updateMap(data)
 
 	// Call the update callback if set and if there are changes
@@ -223,44 +278,58 @@ func (rm *RemoteMap) Refresh() error {
 		rm.deleteCallback(deleted)
 	}
 
+	// Call the subscribers of keys that changed
+	rm.notifySubscribed(snapshot)
+
 	// Call the refresh callback if set
 	if rm.refreshCallback != nil {
 		rm.refreshCallback()
 	}
-
-	return nil
 }
 
-// fetchData retrieves the JSON data from the remote URL
+// fetchData retrieves the data from the source and decodes it
 func (rm *RemoteMap) fetchData() (map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), rm.timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rm.url, nil)
+	rm.versionMu.Lock()
+	version := rm.version
+	rm.versionMu.Unlock()
+
+	doc, err := rm.source.Fetch(ctx, version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	// Add headers
-	for key, value := range rm.headers {
-		req.Header.Add(key, value)
+	format := rm.format
+	if format == AutoFormat {
+		format = doc.Format
 	}
-
-	resp, err := rm.httpClient.Do(req)
+	body, err := toJSON(format, doc.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received non-OK response: %s", resp.Status)
+	data, err := rm.decode(body)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	// Record the version only once the data is known to be usable
+	rm.versionMu.Lock()
+	rm.version = doc.Version
+	rm.versionMu.Unlock()
+
+	// Keep a copy on disk for the next start
+	if err := rm.persist(body); err != nil && rm.errorHandler != nil {
+		rm.errorHandler(err)
 	}
 
+	return data, nil
+}
+
+// decodeJSON decodes a JSON object into a map of generic values
+func decodeJSON(body []byte) (map[string]interface{}, error) {
 	var data map[string]interface{}
 	if err := json.Unmarshal(body, &data); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
@@ -269,6 +338,14 @@ func (rm *RemoteMap) fetchData() (map[string]interface{}, error) {
 	return data, nil
 }
 
+// isAcceptableStatus reports whether a response status should be treated as success
+func (rm *RemoteMap) isAcceptableStatus(code int) bool {
+	if rm.acceptStatus == nil {
+		return code >= 200 && code < 300
+	}
+	return rm.acceptStatus[code]
+}
+
 // updateMap updates the internal sync.Map with the fetched data
 // Returns slices of added, updated, and deleted keys
 func (rm *RemoteMap) updateMap(data map[string]interface{}) ([]string, []string, []string) {
@@ -303,19 +380,51 @@ func (rm *RemoteMap) updateMap(data map[string]interface{}) ([]string, []string,
 			delete(existingKeys, key)
 		}
 		// Store the value
-		rm.Store(key, value)
+		rm.Map.Store(key, value)
 	}
 
 	// Any keys left in existingKeys are no longer in the data (deleted)
-	deleted := make([]string, 0, len(existingKeys))
-	for key := range existingKeys {
-		deleted = append(deleted, key)
-		rm.Delete(key)
+	// Keys within their delete grace period are kept
+	deleted := rm.expiredMissing(existingKeys)
+	for _, key := range deleted {
+		rm.Map.Delete(key)
 	}
 
 	return added, updated, deleted
 }
 
+// expiredMissing returns the missing keys whose delete grace period has elapsed.
+// It tracks when each key was first found missing, so keys that reappear start over.
+func (rm *RemoteMap) expiredMissing(missing map[string]interface{}) []string {
+	expired := make([]string, 0, len(missing))
+	if rm.deleteGrace <= 0 {
+		for key := range missing {
+			expired = append(expired, key)
+		}
+		return expired
+	}
+
+	rm.graceMu.Lock()
+	defer rm.graceMu.Unlock()
+
+	now := time.Now()
+	pending := make(map[string]time.Time, len(missing))
+	for key := range missing {
+		since, ok := rm.missingSince[key]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) >= rm.deleteGrace {
+			expired = append(expired, key)
+		} else {
+			pending[key] = since
+		}
+	}
+	rm.missingSince = pending
+
+	return expired
+}
+
 // Keys returns all keys in the map as a slice of strings
 func (rm *RemoteMap) Keys() []string {
 	var keys []string
@@ -396,8 +505,8 @@ func (rm *RemoteMap) LoadOrStore(key string, defaultValue interface{}) (interfac
 		return defaultValue, false
 	}
 
-	// Store the default value
-	rm.Store(key, defaultValue)
+	// Store the default value locally, without writing it through
+	rm.Map.Store(key, defaultValue)
 	return defaultValue, false
 }
 
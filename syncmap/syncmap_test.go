@@ -2,12 +2,16 @@ package syncmap
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -362,6 +366,133 @@ func TestRemoteMapErrorHandler(t *testing.T) {
 	}
 }
 
+func TestRemoteMapAcceptableStatus(t *testing.T) {
+	// Create a test server that returns JSON with a non-200 status
+	var mu sync.Mutex
+	status := http.StatusNonAuthoritativeInfo
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": status,
+		})
+	}))
+	defer server.Close()
+
+	// Any 2xx status is accepted by default
+	rm := NewRemoteMap(server.URL).WithTimeout(1 * time.Second)
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Expected 203 to be accepted by default, got %v", err)
+	}
+	if val, ok := rm.Load("status"); !ok || val != float64(http.StatusNonAuthoritativeInfo) {
+		t.Errorf("Expected status=203, got %v, ok=%v", val, ok)
+	}
+
+	// Restricting the accepted statuses rejects other 2xx codes
+	rm = NewRemoteMap(server.URL).
+		WithTimeout(1 * time.Second).
+		WithAcceptableStatus(http.StatusOK)
+	if err := rm.Refresh(); err == nil {
+		t.Error("Expected 203 to be rejected when only 200 is acceptable")
+	}
+	if _, ok := rm.Load("status"); ok {
+		t.Error("Expected no data to be stored for a rejected status")
+	}
+
+	// A custom acceptable status outside 2xx is parsed rather than error-handled
+	mu.Lock()
+	status = http.StatusTeapot
+	mu.Unlock()
+
+	rm = NewRemoteMap(server.URL).
+		WithTimeout(1 * time.Second).
+		WithAcceptableStatus(http.StatusOK, http.StatusTeapot)
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Expected 418 to be accepted, got %v", err)
+	}
+	if val, ok := rm.Load("status"); !ok || val != float64(http.StatusTeapot) {
+		t.Errorf("Expected status=418, got %v, ok=%v", val, ok)
+	}
+}
+
+func TestRemoteMapDeleteGrace(t *testing.T) {
+	// Create a test server whose data can be changed between refreshes
+	var mu sync.Mutex
+	data := map[string]interface{}{"stable": 1, "flaky": 2}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+	}))
+	defer server.Close()
+
+	setData := func(d map[string]interface{}) {
+		mu.Lock()
+		data = d
+		mu.Unlock()
+	}
+
+	var deletedKeys []string
+	grace := 100 * time.Millisecond
+	rm := NewRemoteMap(server.URL).
+		WithTimeout(1 * time.Second).
+		WithDeleteGrace(grace).
+		WithDeleteCallback(func(keys []string) {
+			deletedKeys = append(deletedKeys, keys...)
+		})
+
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Initial refresh failed: %v", err)
+	}
+
+	// The key disappears and reappears within the grace period
+	setData(map[string]interface{}{"stable": 1})
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if _, ok := rm.Load("flaky"); !ok {
+		t.Error("Expected flaky to be kept during the grace period")
+	}
+
+	setData(map[string]interface{}{"stable": 1, "flaky": 2})
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if len(deletedKeys) != 0 {
+		t.Errorf("Expected no deletions, got %v", deletedKeys)
+	}
+
+	// Reappearing resets the grace period
+	time.Sleep(grace)
+	setData(map[string]interface{}{"stable": 1})
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if _, ok := rm.Load("flaky"); !ok {
+		t.Error("Expected flaky to be kept after reappearing")
+	}
+
+	// The key stays absent past the grace period
+	time.Sleep(grace + 10*time.Millisecond)
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if _, ok := rm.Load("flaky"); ok {
+		t.Error("Expected flaky to be deleted after the grace period")
+	}
+	if !reflect.DeepEqual(deletedKeys, []string{"flaky"}) {
+		t.Errorf("Expected delete callback for flaky, got %v", deletedKeys)
+	}
+	if _, ok := rm.Load("stable"); !ok {
+		t.Error("Expected stable to be kept")
+	}
+}
+
 func TestRemoteMapManualRefresh(t *testing.T) {
 	// Create a test server with changing data
 	var mu sync.Mutex
@@ -1229,3 +1360,465 @@ func TestRemoteMapStartedState(t *testing.T) {
 	// Clean up
 	rm.Stop()
 }
+
+func TestRemoteMapAs(t *testing.T) {
+	type limit struct {
+		Rate  int    `json:"rate"`
+		Burst int    `json:"burst"`
+		Scope string `json:"scope"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"api": {"rate": 100, "burst": 20, "scope": "user"}, "login": {"rate": 5}}`))
+	}))
+	defer server.Close()
+
+	tm := NewRemoteMapAs[limit](server.URL)
+	if err := tm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	api, ok := tm.Get("api")
+	if !ok || api != (limit{Rate: 100, Burst: 20, Scope: "user"}) {
+		t.Errorf("Expected decoded api limit, got %+v (ok=%v)", api, ok)
+	}
+
+	if login := tm.GetWithDefault("login", limit{}); login.Rate != 5 {
+		t.Errorf("Expected login rate 5, got %+v", login)
+	}
+	if missing := tm.GetWithDefault("missing", limit{Rate: 1}); missing.Rate != 1 {
+		t.Errorf("Expected default for missing key, got %+v", missing)
+	}
+	if all := tm.All(); len(all) != 2 {
+		t.Errorf("Expected 2 values, got %v", all)
+	}
+
+	// Values that don't decode into T fail the refresh
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"api": "unlimited"}`))
+	}))
+	defer bad.Close()
+
+	if err := NewRemoteMapAs[limit](bad.URL).Refresh(); err == nil {
+		t.Error("Expected an error for values that don't match the type")
+	}
+}
+
+func TestRemoteStruct(t *testing.T) {
+	type config struct {
+		Name     string   `json:"name"`
+		Replicas int      `json:"replicas"`
+		Regions  []string `json:"regions"`
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"name": "api", "replicas": 3, "regions": ["iad", "ams"]}`))
+	}))
+	defer server.Close()
+
+	rs := NewRemoteStruct[config](server.URL)
+	if got := rs.Get(); got.Name != "" {
+		t.Errorf("Expected zero value before the first fetch, got %+v", got)
+	}
+
+	if err := rs.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	expected := config{Name: "api", Replicas: 3, Regions: []string{"iad", "ams"}}
+	if got := rs.Get(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// The top-level values are still available from the map
+	if name, ok := rs.GetString("name"); !ok || name != "api" {
+		t.Errorf("Expected name=api from the map, got %q", name)
+	}
+}
+
+func TestConditionalRefresh(t *testing.T) {
+	var mu sync.Mutex
+	version := "v1"
+	body := `{"key": "value1"}`
+	var notModified int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		etag := `"` + version + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	var refreshes int
+	rm := NewRemoteMap(server.URL).
+		WithAcceptableStatus(http.StatusOK).
+		WithRefreshCallback(func() { refreshes++ })
+
+	for i := 0; i < 3; i++ {
+		if err := rm.Refresh(); err != nil {
+			t.Fatalf("Refresh %d failed: %v", i, err)
+		}
+	}
+
+	if notModified != 2 {
+		t.Errorf("Expected 2 not modified responses, got %d", notModified)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected callbacks to be skipped when not modified, got %d refreshes", refreshes)
+	}
+	if value, _ := rm.GetString("key"); value != "value1" {
+		t.Errorf("Expected key=value1 to be kept, got %q", value)
+	}
+
+	// A changed document is fetched in full
+	mu.Lock()
+	version = "v2"
+	body = `{"key": "value2"}`
+	mu.Unlock()
+
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if value, _ := rm.GetString("key"); value != "value2" {
+		t.Errorf("Expected key=value2 after the change, got %q", value)
+	}
+	if refreshes != 2 {
+		t.Errorf("Expected 2 refreshes, got %d", refreshes)
+	}
+}
+
+func TestRemoteMapFormats(t *testing.T) {
+	documents := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"/config.yaml": {"application/yaml", "name: api\nreplicas: 3\nregions:\n  - iad\n  - ams\n"},
+		"/config.toml": {"text/plain", "name = \"api\"\nreplicas = 3\nregions = [\"iad\", \"ams\"]\n"},
+		"/config.json": {"application/json", `{"name": "api", "replicas": 3, "regions": ["iad", "ams"]}`},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		doc := documents[r.URL.Path]
+		w.Header().Set("Content-Type", doc.contentType)
+		w.Write([]byte(doc.body))
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		path   string
+		format Format
+	}{
+		{"/config.yaml", AutoFormat}, // Detected from the Content-Type
+		{"/config.toml", TOML},
+		{"/config.json", AutoFormat},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			rm := NewRemoteMap(server.URL + tc.path).WithFormat(tc.format)
+			if err := rm.Refresh(); err != nil {
+				t.Fatalf("Refresh failed: %v", err)
+			}
+
+			if name, _ := rm.GetString("name"); name != "api" {
+				t.Errorf("Expected name=api, got %q", name)
+			}
+			// Numbers are decoded as they are from JSON
+			if replicas, _ := rm.Load("replicas"); replicas != float64(3) {
+				t.Errorf("Expected replicas=3 as float64, got %v (type %T)", replicas, replicas)
+			}
+			if regions, _ := rm.GetStringSlice("regions"); !reflect.DeepEqual(regions, []string{"iad", "ams"}) {
+				t.Errorf("Expected regions [iad ams], got %v", regions)
+			}
+		})
+	}
+
+	// Typed maps decode other formats using json tags
+	type document struct {
+		Replicas int `json:"replicas"`
+	}
+	rs := NewRemoteStruct[document](server.URL + "/config.yaml")
+	if err := rs.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if rs.Get().Replicas != 3 {
+		t.Errorf("Expected 3 replicas, got %+v", rs.Get())
+	}
+
+	// Invalid documents fail the refresh
+	if err := NewRemoteMap(server.URL + "/config.json").WithFormat(TOML).Refresh(); err == nil {
+		t.Error("Expected an error decoding JSON as TOML")
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("name: api\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var refreshes int
+	rm := NewRemoteMapFromSource(FileSource(path)).
+		WithRefreshCallback(func() { refreshes++ })
+
+	for i := 0; i < 2; i++ {
+		if err := rm.Refresh(); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if name, _ := rm.GetString("name"); name != "api" {
+		t.Errorf("Expected name=api, got %q", name)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected an unchanged file to be skipped, got %d refreshes", refreshes)
+	}
+
+	// A new modification time is detected
+	if err := os.WriteFile(path, []byte("name: web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if name, _ := rm.GetString("name"); name != "web" {
+		t.Errorf("Expected name=web after the change, got %q", name)
+	}
+
+	if err := NewRemoteMapFromSource(FileSource(filepath.Join(t.TempDir(), "missing.json"))).Refresh(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/app.toml": {Data: []byte("replicas = 3\n")},
+	}
+
+	var refreshes int
+	rm := NewRemoteMapFromSource(FSSource(fsys, "config/app.toml")).
+		WithRefreshCallback(func() { refreshes++ })
+
+	for i := 0; i < 2; i++ {
+		if err := rm.Refresh(); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+	if replicas, _ := rm.GetInt("replicas"); replicas != 3 {
+		t.Errorf("Expected replicas=3, got %d", replicas)
+	}
+	if refreshes != 1 {
+		t.Errorf("Expected unchanged content to be skipped, got %d refreshes", refreshes)
+	}
+
+	fsys["config/app.toml"] = &fstest.MapFile{Data: []byte("replicas = 5\n")}
+	if err := rm.Refresh(); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if replicas, _ := rm.GetInt("replicas"); replicas != 5 {
+		t.Errorf("Expected replicas=5 after the change, got %d", replicas)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+
+		if r.URL.Path == "/config/forbidden" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	var handled []error
+	rm := NewRemoteMap(server.URL + "/config").
+		WithWriteThrough(WriteConfig{}).
+		WithErrorHandler(func(err error) { handled = append(handled, err) })
+
+	rm.Store("name", "api")
+	rm.Delete("old key")
+	if err := rm.Put("replicas", 3); err != nil {
+		t.Errorf("Put failed: %v", err)
+	}
+
+	// Failed writes aren't applied locally
+	rm.Store("forbidden", true)
+	if err := rm.Remove("forbidden"); err == nil {
+		t.Error("Expected an error removing a forbidden key")
+	}
+
+	expected := []string{
+		`PUT /config/name "api"`,
+		`DELETE /config/old key `,
+		`PUT /config/replicas 3`,
+		`PUT /config/forbidden true`,
+		`DELETE /config/forbidden `,
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %q, got %q", expected, requests)
+	}
+	if name, _ := rm.GetString("name"); name != "api" {
+		t.Errorf("Expected name=api stored locally, got %q", name)
+	}
+	if _, ok := rm.Load("forbidden"); ok {
+		t.Error("Expected the failed write not to be stored")
+	}
+	if len(handled) != 1 {
+		t.Errorf("Expected the failed Store to reach the error handler, got %v", handled)
+	}
+
+	// PATCH sends JSON merge patches to the map's URL
+	requests = nil
+	patch := NewRemoteMap(server.URL + "/config").
+		WithWriteThrough(WriteConfig{StoreMethod: http.MethodPatch, DeleteMethod: http.MethodPatch})
+	patch.Store("name", "web")
+	patch.Delete("name")
+
+	expected = []string{
+		`PATCH /config {"name":"web"}`,
+		`PATCH /config {"name":null}`,
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %q, got %q", expected, requests)
+	}
+
+	// Without write-through, Put fails and Store is local
+	readOnly := NewRemoteMap(server.URL)
+	if err := readOnly.Put("name", "api"); err == nil {
+		t.Error("Expected Put to fail without write-through")
+	}
+	readOnly.Store("name", "api")
+	if name, _ := readOnly.GetString("name"); name != "api" {
+		t.Errorf("Expected a local store, got %q", name)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	var mu sync.Mutex
+	body := `{"replicas": 3, "name": "api"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	type change struct{ old, new interface{} }
+	var replicas, names []change
+	rm := NewRemoteMap(server.URL).WithWriteThrough(WriteConfig{})
+	rm.Subscribe("replicas", func(old, new interface{}) {
+		replicas = append(replicas, change{old, new})
+	})
+	unsubscribe := rm.Subscribe("name", func(old, new interface{}) {
+		names = append(names, change{old, new})
+	})
+
+	refresh := func(next string) {
+		t.Helper()
+		mu.Lock()
+		body = next
+		mu.Unlock()
+		if err := rm.Refresh(); err != nil {
+			t.Fatalf("Refresh failed: %v", err)
+		}
+	}
+
+	refresh(`{"replicas": 3, "name": "api"}`)
+	refresh(`{"replicas": 3, "name": "web"}`) // Only name changes
+	unsubscribe()
+	refresh(`{"replicas": 5, "name": "db"}`)
+	refresh(`{"name": "db"}`)
+	if err := rm.Put("replicas", 1); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	expectedReplicas := []change{{nil, float64(3)}, {float64(3), float64(5)}, {float64(5), nil}, {nil, 1}}
+	if !reflect.DeepEqual(replicas, expectedReplicas) {
+		t.Errorf("Expected replicas changes %v, got %v", expectedReplicas, replicas)
+	}
+	expectedNames := []change{{nil, "api"}, {"api", "web"}}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Expected name changes %v until unsubscribed, got %v", expectedNames, names)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	var mu sync.Mutex
+	down := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name": "api", "replicas": 3}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	// The first start fetches the data and persists it
+	rm := NewRemoteMap(server.URL).WithPersistence(path).Start()
+	rm.Stop()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected persisted file: %v", err)
+	}
+
+	mu.Lock()
+	down = true
+	mu.Unlock()
+
+	// The next start boots from the persisted data while the server is down
+	var errs []error
+	rm = NewRemoteMap(server.URL).
+		WithPersistence(path).
+		WithErrorHandler(func(err error) { errs = append(errs, err) }).
+		Start()
+	defer rm.Stop()
+
+	if name, ok := rm.GetString("name"); !ok || name != "api" {
+		t.Errorf("Expected name 'api' from persisted data, got %q (ok=%v)", name, ok)
+	}
+	if replicas, ok := rm.GetInt("replicas"); !ok || replicas != 3 {
+		t.Errorf("Expected replicas 3 from persisted data, got %d (ok=%v)", replicas, ok)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected the failed fetch to be reported, got %v", errs)
+	}
+
+	// A missing file is not an error
+	errs = nil
+	empty := NewRemoteMap(server.URL).
+		WithPersistence(filepath.Join(t.TempDir(), "missing.json")).
+		WithErrorHandler(func(err error) { errs = append(errs, err) }).
+		Start()
+	defer empty.Stop()
+	if len(errs) != 1 || len(empty.Keys()) != 0 {
+		t.Errorf("Expected only the fetch error and no keys, got %v and %v", errs, empty.Keys())
+	}
+}
@@ -0,0 +1,110 @@
+package syncmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// TypedMap is a RemoteMap whose remote object is decoded into a map[string]T,
+// so every value has type T. Documents of every format are decoded using T's
+// json struct tags. It embeds the RemoteMap, which is configured and started
+// as usual.
+type TypedMap[T any] struct {
+	*RemoteMap
+}
+
+// NewRemoteMapAs creates a new TypedMap that synchronizes with the provided
+// URL, decoding each value of the remote object into T
+func NewRemoteMapAs[T any](url string) *TypedMap[T] {
+	rm := NewRemoteMap(url)
+	rm.decode = func(body []byte) (map[string]interface{}, error) {
+		var typed map[string]T
+		if err := json.Unmarshal(body, &typed); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+
+		data := make(map[string]interface{}, len(typed))
+		for key, value := range typed {
+			data[key] = value
+		}
+		return data, nil
+	}
+	return &TypedMap[T]{RemoteMap: rm}
+}
+
+// Get retrieves the value for a key. It reports false if the key is missing or
+// its value isn't a T, such as a value added by a transform function.
+func (tm *TypedMap[T]) Get(key string) (T, bool) {
+	value, ok := tm.Load(key)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// GetWithDefault retrieves the value for a key or returns a default value if not found
+func (tm *TypedMap[T]) GetWithDefault(key string, defaultValue T) T {
+	value, ok := tm.Get(key)
+	if !ok {
+		return defaultValue
+	}
+	return value
+}
+
+// All returns a copy of every value of type T in the map
+func (tm *TypedMap[T]) All() map[string]T {
+	all := make(map[string]T)
+	tm.Range(func(key, value interface{}) bool {
+		k, ok := key.(string)
+		typed, isT := value.(T)
+		if ok && isT {
+			all[k] = typed
+		}
+		return true
+	})
+	return all
+}
+
+// RemoteStruct is a RemoteMap that also decodes the whole remote document into
+// a value of type T, using its json struct tags, such as a configuration struct. The map holds the
+// document's top-level values as a RemoteMap does.
+type RemoteStruct[T any] struct {
+	*RemoteMap
+	value atomic.Pointer[T]
+}
+
+// NewRemoteStruct creates a new RemoteStruct that synchronizes with the
+// provided URL, decoding the remote document into T
+func NewRemoteStruct[T any](url string) *RemoteStruct[T] {
+	rs := &RemoteStruct[T]{RemoteMap: NewRemoteMap(url)}
+	rs.decode = func(body []byte) (map[string]interface{}, error) {
+		var value T
+		if err := json.Unmarshal(body, &value); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+
+		data, err := decodeJSON(body)
+		if err != nil {
+			return nil, err
+		}
+
+		rs.value.Store(&value)
+		return data, nil
+	}
+	return rs
+}
+
+// Get returns the most recently fetched value, or the zero value of T before
+// the first successful fetch
+func (rs *RemoteStruct[T]) Get() T {
+	value := rs.value.Load()
+	if value == nil {
+		var zero T
+		return zero
+	}
+	return *value
+}
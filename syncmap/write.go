@@ -0,0 +1,165 @@
+package syncmap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WriteConfig configures how a RemoteMap writes changes back to its URL
+type WriteConfig struct {
+	// StoreMethod is the HTTP method used to store a value (default: PUT). PATCH
+	// sends a JSON merge patch such as {"key": value} to the map's URL; other
+	// methods send the value as JSON to the key's URL.
+	StoreMethod string
+
+	// DeleteMethod is the HTTP method used to delete a key (default: DELETE).
+	// PATCH sends a JSON merge patch such as {"key": null} to the map's URL;
+	// other methods request the key's URL without a body.
+	DeleteMethod string
+
+	// KeyURL returns the URL of a key (default: the map's URL followed by a
+	// slash and the escaped key)
+	KeyURL func(key string) string
+}
+
+// WithWriteThrough makes Store and Delete push changes to the map's URL before
+// applying them locally, so the map can be used as a simple key-value store.
+// Writes that fail aren't applied locally and are reported to the error
+// handler; Put and Remove return the error instead. Other sync.Map methods and
+// LoadOrStore only change the local map.
+func (rm *RemoteMap) WithWriteThrough(config WriteConfig) *RemoteMap {
+	if config.StoreMethod == "" {
+		config.StoreMethod = http.MethodPut
+	}
+	if config.DeleteMethod == "" {
+		config.DeleteMethod = http.MethodDelete
+	}
+	if config.KeyURL == nil {
+		config.KeyURL = func(key string) string {
+			return strings.TrimSuffix(rm.url, "/") + "/" + url.PathEscape(key)
+		}
+	}
+	rm.write = &config
+	return rm
+}
+
+// Store sets the value for a key, writing it through to the remote first if
+// write-through is enabled
+func (rm *RemoteMap) Store(key, value interface{}) {
+	k, ok := key.(string)
+	if rm.write == nil || !ok {
+		rm.Map.Store(key, value)
+		return
+	}
+
+	if err := rm.Put(k, value); err != nil && rm.errorHandler != nil {
+		rm.errorHandler(err)
+	}
+}
+
+// Delete deletes the value for a key, deleting it from the remote first if
+// write-through is enabled
+func (rm *RemoteMap) Delete(key interface{}) {
+	k, ok := key.(string)
+	if rm.write == nil || !ok {
+		rm.Map.Delete(key)
+		return
+	}
+
+	if err := rm.Remove(k); err != nil && rm.errorHandler != nil {
+		rm.errorHandler(err)
+	}
+}
+
+// Put writes a value to the remote and then stores it in the map. It returns
+// an error if write-through isn't enabled or the write fails.
+func (rm *RemoteMap) Put(key string, value interface{}) error {
+	if err := rm.writeRemote(key, value, false); err != nil {
+		return err
+	}
+	snapshot := rm.snapshotSubscribed()
+	rm.Map.Store(key, value)
+	rm.notifySubscribed(snapshot)
+	return nil
+}
+
+// Remove deletes a key from the remote and then from the map. It returns an
+// error if write-through isn't enabled or the write fails.
+func (rm *RemoteMap) Remove(key string) error {
+	if err := rm.writeRemote(key, nil, true); err != nil {
+		return err
+	}
+	snapshot := rm.snapshotSubscribed()
+	rm.Map.Delete(key)
+	rm.notifySubscribed(snapshot)
+	return nil
+}
+
+// writeRemote sends a stored value or a deletion of a key to the remote
+func (rm *RemoteMap) writeRemote(key string, value interface{}, deleting bool) error {
+	if rm.write == nil {
+		return errors.New("write-through is not enabled")
+	}
+	if _, ok := rm.source.(*httpSource); !ok {
+		return errors.New("write-through requires a URL source")
+	}
+
+	method := rm.write.StoreMethod
+	if deleting {
+		method = rm.write.DeleteMethod
+	}
+
+	target := rm.write.KeyURL(key)
+	contentType := "application/json"
+	var body []byte
+	var err error
+	switch {
+	case method == http.MethodPatch:
+		// A JSON merge patch, where null deletes the key
+		target = rm.url
+		contentType = "application/merge-patch+json"
+		body, err = json.Marshal(map[string]interface{}{key: value})
+	case !deleting:
+		body, err = json.Marshal(value)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rm.timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range rm.headers {
+		req.Header.Add(k, v)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := rm.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to write %s: received non-OK response: %s", key, resp.Status)
+	}
+	return nil
+}